@@ -6,9 +6,23 @@ import (
 	"os"
 
 	"github.com/labring/devbox-pack/pkg/cli"
+	"github.com/labring/devbox-pack/pkg/utils"
+)
+
+// Set by release builds through -ldflags "-X main.version=... -X main.commit=..."
+var (
+	version string
+	commit  string
 )
 
 func main() {
+	if version != "" {
+		utils.Version = version
+	}
+	if commit != "" {
+		utils.Commit = commit
+	}
+
 	cliHandler := cli.NewCLIApp()
 	if err := cliHandler.Run(os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -0,0 +1,201 @@
+// Package backing infers the databases, caches and message brokers an
+// application connects to from its declared dependencies and environment.
+package backing
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/rules"
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Backing service kinds
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	Redis    = "redis"
+	MongoDB  = "mongodb"
+	RabbitMQ = "rabbitmq"
+)
+
+// EnvFileNames lists the dotenv files read for connection URLs, in the project root
+var EnvFileNames = []string{".env", ".env.example", ".env.sample", ".env.local"}
+
+// dependencies maps lower-case dependency names of every supported ecosystem to the
+// service they imply. Go module paths also match their major version suffixes.
+var dependencies = map[string]string{
+	// PostgreSQL
+	"pg":                   Postgres,
+	"postgres":             Postgres,
+	"pg-promise":           Postgres,
+	"psycopg":              Postgres,
+	"psycopg2":             Postgres,
+	"psycopg2-binary":      Postgres,
+	"asyncpg":              Postgres,
+	"github.com/lib/pq":    Postgres,
+	"github.com/jackc/pgx": Postgres,
+	"postgresql":           Postgres,
+	"tokio-postgres":       Postgres,
+
+	// MySQL
+	"mysql":                          MySQL,
+	"mysql2":                         MySQL,
+	"mysqlclient":                    MySQL,
+	"pymysql":                        MySQL,
+	"aiomysql":                       MySQL,
+	"github.com/go-sql-driver/mysql": MySQL,
+	"mysql-connector-java":           MySQL,
+	"mysql-connector-j":              MySQL,
+
+	// Redis
+	"redis":                          Redis,
+	"ioredis":                        Redis,
+	"django-redis":                   Redis,
+	"github.com/redis/go-redis":      Redis,
+	"github.com/go-redis/redis":      Redis,
+	"github.com/gomodule/redigo":     Redis,
+	"jedis":                          Redis,
+	"lettuce-core":                   Redis,
+	"spring-boot-starter-data-redis": Redis,
+	"predis/predis":                  Redis,
+
+	// MongoDB
+	"mongodb":                          MongoDB,
+	"mongoose":                         MongoDB,
+	"pymongo":                          MongoDB,
+	"motor":                            MongoDB,
+	"go.mongodb.org/mongo-driver":      MongoDB,
+	"mongoid":                          MongoDB,
+	"mongo":                            MongoDB,
+	"mongodb-driver-sync":              MongoDB,
+	"spring-boot-starter-data-mongodb": MongoDB,
+	"mongodb/mongodb":                  MongoDB,
+
+	// RabbitMQ
+	"amqplib":                        RabbitMQ,
+	"pika":                           RabbitMQ,
+	"github.com/rabbitmq/amqp091-go": RabbitMQ,
+	"github.com/streadway/amqp":      RabbitMQ,
+	"bunny":                          RabbitMQ,
+	"spring-boot-starter-amqp":       RabbitMQ,
+	"php-amqplib/php-amqplib":        RabbitMQ,
+}
+
+// urlSchemes maps connection URL schemes to the service they connect to
+var urlSchemes = map[string]string{
+	"postgres":    Postgres,
+	"postgresql":  Postgres,
+	"mysql":       MySQL,
+	"redis":       Redis,
+	"rediss":      Redis,
+	"mongodb":     MongoDB,
+	"mongodb+srv": MongoDB,
+	"amqp":        RabbitMQ,
+	"amqps":       RabbitMQ,
+}
+
+// Detect infers the backing services of the project in the root of fsys from the
+// dependencies of its manifests, its dotenv files and the plan environment.
+// Services are sorted by name.
+func Detect(fsys fs.FS, environment map[string]string) []types.BackingService {
+	found := make(map[string]*types.BackingService)
+	service := func(name string) *types.BackingService {
+		if found[name] == nil {
+			found[name] = &types.BackingService{Name: name}
+		}
+		return found[name]
+	}
+
+	for _, declared := range rules.Dependencies(fsys) {
+		for _, dependency := range declared {
+			if name, ok := serviceForDependency(dependency); ok {
+				backing := service(name)
+				backing.Dependencies = appendUnique(backing.Dependencies, dependency)
+			}
+		}
+	}
+
+	sources := []map[string]string{environment}
+	for _, fileName := range EnvFileNames {
+		if content, err := source.ReadText(fsys, fileName); err == nil {
+			sources = append(sources, parseEnvFile(content))
+		}
+	}
+	for _, variables := range sources {
+		for key, value := range variables {
+			if name, ok := serviceForURL(value); ok {
+				backing := service(name)
+				backing.Environment = appendUnique(backing.Environment, key)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make([]types.BackingService, len(names))
+	for i, name := range names {
+		services[i] = *found[name]
+		sort.Strings(services[i].Dependencies)
+		sort.Strings(services[i].Environment)
+	}
+	return services
+}
+
+// serviceForDependency returns the service implied by a declared dependency
+func serviceForDependency(dependency string) (string, bool) {
+	dependency = strings.ToLower(dependency)
+	if name, ok := dependencies[dependency]; ok {
+		return name, true
+	}
+	// Go modules carry their major version as a path suffix, e.g. github.com/jackc/pgx/v5
+	if index := strings.LastIndex(dependency, "/v"); index > 0 {
+		if name, ok := dependencies[dependency[:index]]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// serviceForURL returns the service a connection URL such as postgres://localhost/app points to
+func serviceForURL(value string) (string, bool) {
+	scheme, _, found := strings.Cut(strings.TrimSpace(value), "://")
+	if !found {
+		return "", false
+	}
+	name, ok := urlSchemes[strings.ToLower(scheme)]
+	return name, ok
+}
+
+// parseEnvFile parses KEY=VALUE lines of a dotenv file, ignoring comments and export prefixes
+func parseEnvFile(content string) map[string]string {
+	variables := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			continue
+		}
+		variables[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return variables
+}
+
+// appendUnique appends value unless list already contains it
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,38 @@
+package backing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestDetect(t *testing.T) {
+	fsys := source.NewMap(map[string]string{
+		"package.json": `{"dependencies": {"express": "^4.0.0", "pg": "^8.0.0", "ioredis": "^5.0.0"}}`,
+		"go.mod":       "module example.com/app\n\nrequire github.com/jackc/pgx/v5 v5.5.0\n",
+		".env.example": "# local services\nexport DATABASE_URL=\"postgres://localhost:5432/app\"\nBROKER=amqp://localhost\nAPI_URL=http://localhost:8080\n",
+	})
+
+	services := Detect(fsys, map[string]string{"CACHE_URL": "rediss://cache:6380"})
+
+	expected := []types.BackingService{
+		{Name: Postgres, Dependencies: []string{"github.com/jackc/pgx/v5", "pg"}, Environment: []string{"DATABASE_URL"}},
+		{Name: RabbitMQ, Environment: []string{"BROKER"}},
+		{Name: Redis, Dependencies: []string{"ioredis"}, Environment: []string{"CACHE_URL"}},
+	}
+	if !reflect.DeepEqual(services, expected) {
+		t.Errorf("expected %+v, got %+v", expected, services)
+	}
+}
+
+func TestDetect_NoServices(t *testing.T) {
+	fsys := source.NewMap(map[string]string{
+		"requirements.txt": "flask==2.0\n",
+		".env":             "DATABASE_URL=sqlite:///app.db\n",
+	})
+	if services := Detect(fsys, nil); len(services) != 0 {
+		t.Errorf("expected no backing services, got %+v", services)
+	}
+}
@@ -0,0 +1,294 @@
+// Package batch analyses a list of repositories with a pool of workers and
+// reports every outcome as one line of JSON, so a whole catalogue can be
+// re-analysed without one failing repository stopping the rest.
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/service"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// DefaultWorkers is the number of repositories analysed at the same time
+const DefaultWorkers = 4
+
+// Spec identifies a repository to analyse
+type Spec struct {
+	// Git repository URL, local path or local archive
+	Repository string `json:"repository"`
+	// Git branch, tag or commit (optional)
+	Ref string `json:"ref,omitempty"`
+	// Subdirectory within the repository (optional)
+	Subdir string `json:"subdir,omitempty"`
+}
+
+// Result is the outcome of one spec, written as one line of JSON
+type Result struct {
+	// Line of the spec in the input, starting at 1
+	Line int `json:"line"`
+	Spec
+	// Wall time of the analysis in milliseconds
+	DurationMs int64 `json:"durationMs"`
+	// Execution plan, set on success
+	Plan *types.ExecutionPlan `json:"plan,omitempty"`
+	// Failure, set instead of Plan
+	Error *types.DevBoxPackError `json:"error,omitempty"`
+}
+
+// Summary counts the outcomes of a batch
+type Summary struct {
+	Total       int     `json:"total"`
+	Succeeded   int     `json:"succeeded"`
+	Failed      int     `json:"failed"`
+	SuccessRate float64 `json:"successRate"`
+	// Succeeded analyses per provider
+	ProviderStats map[string]int `json:"providerStats"`
+	// Failed analyses per error code
+	ErrorStats map[string]int `json:"errorStats"`
+}
+
+// Options configures a batch
+type Options struct {
+	// Workers is the number of repositories analysed at the same time; 0 uses DefaultWorkers
+	Workers int
+	// Timeout bounds the analysis of each repository; 0 disables it
+	Timeout time.Duration
+	// Provider forces a specific provider for every repository (optional)
+	Provider string
+	// Base overrides the runtime image of every plan (optional)
+	Base string
+	// Platform is the target platform of every plan (optional)
+	Platform string
+	// NoCache recomputes plans instead of reading them from the plan cache
+	NoCache bool
+}
+
+// job is a spec read from the input, or the error of a line that is not one
+type job struct {
+	line int
+	spec Spec
+	err  *types.DevBoxPackError
+}
+
+// ParseSpec parses a line of the input: a JSON object with repository, ref and
+// subdir fields, or a repository optionally followed by a ref and a subdirectory
+func ParseSpec(line string) (Spec, error) {
+	var spec Spec
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&spec); err != nil {
+			return Spec{}, types.NewDevBoxPackError(
+				fmt.Sprintf("invalid repository spec: %s", err.Error()),
+				types.ErrorCodeInvalidInput,
+				nil,
+			)
+		}
+	} else {
+		fields := strings.Fields(line)
+		if len(fields) > 3 {
+			return Spec{}, types.NewDevBoxPackError(
+				fmt.Sprintf("invalid repository spec, expected <repository> [ref] [subdir]: %s", line),
+				types.ErrorCodeInvalidInput,
+				nil,
+			)
+		}
+		fields = append(fields, "", "", "")
+		spec = Spec{Repository: fields[0], Ref: fields[1], Subdir: fields[2]}
+	}
+
+	if spec.Repository == "" {
+		return Spec{}, types.NewDevBoxPackError(
+			"invalid repository spec: repository is missing",
+			types.ErrorCodeInvalidInput,
+			nil,
+		)
+	}
+	return spec, nil
+}
+
+// Run analyses the specs read from r, one per line, with devBoxPack and writes a
+// Result line to w as each analysis finishes, followed by a {"summary": ...} line.
+// Blank lines and lines starting with # are skipped. Failed analyses are reported
+// in their Result; only failures to read r or write w stop the batch.
+func Run(ctx context.Context, devBoxPack *service.DevBoxPack, r io.Reader, w io.Writer, options Options) (*Summary, error) {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	jobs := make(chan job)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- analyze(ctx, devBoxPack, job, options)
+			}
+		}()
+	}
+
+	// Read specs while the workers run, so long inputs stream through
+	var readErr error
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(results)
+		}()
+		scanner := bufio.NewScanner(r)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			next := job{line: line}
+			spec, err := ParseSpec(text)
+			if err != nil {
+				next.err = asDevBoxPackError(err)
+			}
+			next.spec = spec
+			select {
+			case jobs <- next:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr = scanner.Err()
+	}()
+
+	summary := &Summary{ProviderStats: make(map[string]int), ErrorStats: make(map[string]int)}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	var writeErr error
+	for result := range results {
+		summary.Total++
+		if result.Error != nil {
+			summary.Failed++
+			summary.ErrorStats[result.Error.Code]++
+		} else {
+			summary.Succeeded++
+			summary.ProviderStats[result.Plan.Provider]++
+		}
+		// Keep draining after a write failure so the workers can finish
+		if writeErr == nil {
+			writeErr = encoder.Encode(result)
+		}
+	}
+	if summary.Total > 0 {
+		summary.SuccessRate = float64(summary.Succeeded) / float64(summary.Total) * 100
+	}
+
+	if readErr != nil {
+		return summary, fmt.Errorf("failed to read repository specs: %w", readErr)
+	}
+	if writeErr != nil {
+		return summary, fmt.Errorf("failed to write results: %w", writeErr)
+	}
+	if err := types.ContextError(ctx); err != nil {
+		return summary, err
+	}
+	if err := encoder.Encode(map[string]*Summary{"summary": summary}); err != nil {
+		return summary, fmt.Errorf("failed to write results: %w", err)
+	}
+	return summary, nil
+}
+
+// analyze analyses the spec of a job, turning every failure into the result's error
+func analyze(ctx context.Context, devBoxPack *service.DevBoxPack, job job, options Options) (result Result) {
+	result = Result{Line: job.line, Spec: job.spec, Error: job.err}
+	if job.err != nil {
+		return result
+	}
+
+	start := time.Now()
+	fork := devBoxPack.Fork()
+	defer func() {
+		_ = fork.Cleanup()
+		// A panicking provider fails its repository, not the batch
+		if recovered := recover(); recovered != nil {
+			result.Plan = nil
+			result.Error = types.NewDevBoxPackError(fmt.Sprintf("analysis panicked: %v", recovered), types.ErrorCodeInternal, nil)
+		}
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	cliOptions := &types.CLIOptions{
+		Repository: job.spec.Repository,
+		Format:     string(types.OutputFormatJSON),
+		Quiet:      true,
+		NoCache:    options.NoCache,
+	}
+	if job.spec.Ref != "" {
+		cliOptions.Ref = &job.spec.Ref
+	}
+	if job.spec.Subdir != "" {
+		cliOptions.Subdir = &job.spec.Subdir
+	}
+	if options.Provider != "" {
+		cliOptions.Provider = &options.Provider
+	}
+	if options.Base != "" {
+		cliOptions.Base = &options.Base
+	}
+	if options.Platform != "" {
+		cliOptions.Platform = &options.Platform
+	}
+
+	analysis, err := fork.Analyze(ctx, job.spec.Repository, cliOptions)
+	if err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			err = ctxErr
+		}
+		result.Error = asDevBoxPackError(err)
+		return result
+	}
+	result.Plan = analysis.Plan
+	return result
+}
+
+// asDevBoxPackError returns the DevBoxPackError in err's chain, or wraps err as an internal error
+func asDevBoxPackError(err error) *types.DevBoxPackError {
+	var devBoxErr *types.DevBoxPackError
+	if errors.As(err, &devBoxErr) {
+		return devBoxErr
+	}
+	return types.NewDevBoxPackError(err.Error(), types.ErrorCodeInternal, nil)
+}
+
+// Providers returns the providers of a summary sorted by count, then name
+func (s *Summary) Providers() []string {
+	names := make([]string, 0, len(s.ProviderStats))
+	for name := range s.ProviderStats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.ProviderStats[names[i]] != s.ProviderStats[names[j]] {
+			return s.ProviderStats[names[i]] > s.ProviderStats[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
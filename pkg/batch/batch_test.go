@@ -0,0 +1,145 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/service"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Spec
+		wantErr  bool
+	}{
+		{"https://github.com/acme/shop", Spec{Repository: "https://github.com/acme/shop"}, false},
+		{"  https://github.com/acme/shop v1.2.0 web ", Spec{Repository: "https://github.com/acme/shop", Ref: "v1.2.0", Subdir: "web"}, false},
+		{`{"repository": "https://github.com/acme/shop", "subdir": "web"}`, Spec{Repository: "https://github.com/acme/shop", Subdir: "web"}, false},
+		{`{"repo": "https://github.com/acme/shop"}`, Spec{}, true},
+		{`{"ref": "main"}`, Spec{}, true},
+		{"https://github.com/acme/shop main web extra", Spec{}, true},
+	}
+	for _, tt := range tests {
+		spec, err := ParseSpec(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSpec(%q): expected error %t, got %v", tt.line, tt.wantErr, err)
+			continue
+		}
+		if spec != tt.expected {
+			t.Errorf("ParseSpec(%q): expected %+v, got %+v", tt.line, tt.expected, spec)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	projects := map[string]string{
+		"node/package.json": `{"name": "web", "scripts": {"start": "node index.js"}}`,
+		"mono/api/go.mod":   "module example.com/api\n\ngo 1.21\n",
+		"mono/api/main.go":  "package main\n\nfunc main() {}\n",
+		"empty/README.md":   "# nothing to run",
+	}
+	for name, content := range projects {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	input := strings.Join([]string{
+		"# nightly catalogue",
+		filepath.Join(root, "node"),
+		"",
+		`{"repository": "` + filepath.Join(root, "mono") + `", "subdir": "api"}`,
+		filepath.Join(root, "empty"),
+		filepath.Join(root, "missing"),
+		`{"repository": `,
+	}, "\n")
+
+	var output bytes.Buffer
+	summary, err := Run(context.Background(), service.NewDevBoxPackWithLogger(nil), strings.NewReader(input), &output, Options{Workers: 2})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := make(map[int]Result)
+	var lines []string
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 6 {
+		t.Fatalf("expected 5 results and a summary, got %d lines:\n%s", len(lines), output.String())
+	}
+	for _, line := range lines[:5] {
+		var result Result
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("result is not valid JSON: %v\n%s", err, line)
+		}
+		results[result.Line] = result
+	}
+
+	if plan := results[2].Plan; plan == nil || plan.Provider != "node" {
+		t.Errorf("line 2: expected node plan, got %+v", results[2])
+	}
+	if plan := results[4].Plan; plan == nil || plan.Provider != "go" || results[4].Subdir != "api" {
+		t.Errorf("line 4: expected go plan of subdir api, got %+v", results[4])
+	}
+	expectedCodes := map[int]string{
+		5: types.ErrorCodeUnsupported,
+		6: types.ErrorCodeLocalAccessError,
+		7: types.ErrorCodeInvalidInput,
+	}
+	for line, code := range expectedCodes {
+		if result := results[line]; result.Error == nil || result.Error.Code != code || result.Plan != nil {
+			t.Errorf("line %d: expected %s error, got %+v", line, code, result)
+		}
+	}
+
+	var last map[string]Summary
+	if err := json.Unmarshal([]byte(lines[5]), &last); err != nil {
+		t.Fatalf("summary is not valid JSON: %v\n%s", err, lines[5])
+	}
+	if last["summary"].Total != 5 || summary.Succeeded != 2 || summary.Failed != 3 {
+		t.Errorf("unexpected summary %+v", last["summary"])
+	}
+	if summary.ProviderStats["node"] != 1 || summary.ProviderStats["go"] != 1 || summary.ErrorStats[types.ErrorCodeUnsupported] != 1 {
+		t.Errorf("unexpected stats %+v", summary)
+	}
+	if providers := summary.Providers(); len(providers) != 2 || providers[0] != "go" {
+		t.Errorf("expected providers sorted by count then name, got %v", providers)
+	}
+}
+
+func TestRun_Base(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"name": "web", "scripts": {"start": "node index.js"}}`), 0644); err != nil {
+		t.Fatalf("failed to write package.json: %v", err)
+	}
+
+	var output bytes.Buffer
+	options := Options{Base: "registry.example.com/node:custom"}
+	if _, err := Run(context.Background(), service.NewDevBoxPackWithLogger(nil), strings.NewReader(root), &output, options); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var result Result
+	line, _, _ := strings.Cut(output.String(), "\n")
+	if err := json.Unmarshal([]byte(line), &result); err != nil {
+		t.Fatalf("result is not valid JSON: %v\n%s", err, line)
+	}
+	if result.Plan == nil || result.Plan.Runtime.Image != options.Base {
+		t.Errorf("expected runtime image %s, got %+v", options.Base, result)
+	}
+}
@@ -3,11 +3,28 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
-
+	"syscall"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/batch"
+	"github.com/labring/devbox-pack/pkg/formatters"
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/pack"
+	"github.com/labring/devbox-pack/pkg/plancache"
+	"github.com/labring/devbox-pack/pkg/plugins"
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/rules"
+	"github.com/labring/devbox-pack/pkg/server"
 	"github.com/labring/devbox-pack/pkg/service"
 	"github.com/labring/devbox-pack/pkg/types"
 	"github.com/labring/devbox-pack/pkg/utils"
@@ -21,17 +38,32 @@ type CLIApp struct {
 // NewCLIApp creates a new CLI application instance
 func NewCLIApp() *CLIApp {
 	return &CLIApp{
-		version: "1.0.0",
+		version: utils.Version,
 	}
 }
 
 // showHelp displays help information
 func (c *CLIApp) showHelp() {
-	fmt.Print(`
+	fmt.Print(helpText())
+}
+
+// helpText returns the help information
+func helpText() string {
+	return fmt.Sprintf(`
 DevBox Pack Execution Plan Generator
 
 Usage:
   devbox-pack <repository> [options]
+  devbox-pack providers [options]
+  devbox-pack serve [options]
+  devbox-pack batch [file] [options]
+  devbox-pack cache prune [options]
+
+Commands:
+  providers                List registered Providers, including loaded plugins and rules
+  batch                    Analyse the repositories listed in file or stdin, one JSON result per line
+  serve                    Serve plan generation over HTTP (POST /v1/plans, POST /v1/plans/upload, GET /healthz)
+  cache prune              Remove unused mirrors and plans, and plans of other versions, from the cache directory
 
 Arguments:
   repository               Git repository URL or local path
@@ -39,28 +71,96 @@ Arguments:
 Options:
   -h, --help              Show help information
   -v, --version           Show version information
-  --ref <ref>             Git branch or tag (default: main)
+  --ref <ref>             Git branch, tag or commit, read from Git objects for local repositories
   --subdir <path>         Subdirectory path
   --provider <name>       Force use of specified Provider
-  --format <format>      Output format (pretty|json, default: pretty)
+  --format <format>       Output format, one of the Output Formats below (default: pretty)
   --verbose               Show detailed information
   --offline               Offline mode, do not clone repository
   --platform <arch>       Target platform (e.g.: linux/amd64)
   --base <name>           Specify base image
+  --monorepo              Emit one plan per detected service
+  --explain               Show the scoring breakdown of every Provider and why the winner was chosen
+  --timeout <duration>    Abort analysis after duration (e.g. 90s, 2m; 0 disables, default: 30s)
+  --plugin-path <dirs>    Directories with devbox-pack-provider-* plugins (default: $DEVBOX_PACK_PLUGIN_PATH)
+  --rules-path <dirs>     Directories with YAML/JSON provider rules (default: $DEVBOX_PACK_RULES_PATH)
+  --cache-dir <dir>       Directory caching mirrors of remote repositories and plans. Caching is on by
+                          default in $DEVBOX_PACK_CACHE_DIR, else devbox-pack in the user cache directory
+                          (~/.cache/devbox-pack on Linux); --cache-dir "" disables it
+  --no-cache              Recompute the plan instead of reading it from the cache, replacing the cached plan
+  --namespace <name>      Kubernetes namespace of k8s manifests
+  --replicas <n>          Kubernetes Deployment replicas of k8s manifests (default: 1)
+  --output-dir <dir>      Write the files of the scripts and devcontainer formats into dir instead of printing them
+  --template <file>       Render the plan through a Go text/template file (selects --format template)
+  --test-command <cmd>    Test command of github-actions and gitlab-ci pipelines
+  --addr <address>        Listen address of serve (default: :8080)
+  --max-clones <n>        Repositories serve clones or uploads it reads at the same time (default: 4)
+  --workers <n>           Repositories batch analyses at the same time (default: 4)
 
 Examples:
   devbox-pack https://github.com/user/repo
   devbox-pack . --offline --verbose
   devbox-pack /path/to/project --format json
   devbox-pack https://github.com/user/repo --ref develop --subdir backend
+  devbox-pack . --offline --monorepo --format json
+  devbox-pack . --offline --explain
+  devbox-pack https://github.com/user/repo --timeout 2m
+  devbox-pack . --offline --plugin-path ~/.devbox-pack/plugins
+  devbox-pack . --offline --rules-path ./rules
+  devbox-pack providers --format json
+  devbox-pack serve --addr :8080 --timeout 2m --max-clones 8
+  devbox-pack batch repos.txt --workers 8 --timeout 2m > plans.jsonl
+  devbox-pack cache prune
+  devbox-pack https://github.com/user/repo --format k8s --namespace prod --replicas 3
+  devbox-pack . --offline --quiet --format compose > compose.yaml
+  devbox-pack . --offline --format scripts --output-dir .devbox
+  devbox-pack . --offline --format devcontainer --output-dir .
+  devbox-pack . --offline --quiet --format sarif > devbox-pack.sarif
+  devbox-pack . --offline --quiet --template deploy.tmpl
+  devbox-pack . --offline --quiet --format github-actions --test-command "npm test" > .github/workflows/ci.yml
 
 Supported Providers:
-  node, python, java, go, php, ruby, deno, rust, staticfile, shell
+  %s
 
 Output Formats:
-  pretty    - Human readable format (default)
-  json      - JSON format
-`)
+%s`, strings.Join(providers.RegisteredNames(), ", "), formatHelp(formatters.NewFormatterFactory()))
+}
+
+// formatDescriptions describe the output formats in the help, formats
+// registered without one are listed by name
+var formatDescriptions = map[string]string{
+	"pretty":         "Human readable format (default)",
+	"json":           "JSON format",
+	"yaml":           "YAML format, fields in the same order as JSON",
+	"toml":           "TOML format, fields in the same order as JSON",
+	"markdown":       "Markdown tables for pull request comments",
+	"sarif":          "SARIF log of plan warnings for code scanning",
+	"dockerfile":     "Dockerfile built from the plan",
+	"k8s":            "Kubernetes Deployment and Service manifests",
+	"compose":        "Docker Compose file with detected backing services",
+	"scripts":        "setup.sh, build.sh, dev.sh and run.sh shell scripts",
+	"github-actions": "GitHub Actions workflow running setup, test and build",
+	"gitlab-ci":      "GitLab CI pipeline running setup, test and build",
+	"devcontainer":   ".devcontainer/devcontainer.json for VS Code and Codespaces",
+	"railpack":       "Railpack build plan (railpack-plan.json)",
+	"template":       "Rendered through the --template file",
+}
+
+// formatHelp lists the formats of factory, and the template format --template adds, one per line
+func formatHelp(factory *formatters.FormatterFactory) string {
+	formats := factory.GetSupportedFormats()
+	if _, err := factory.GetFormatter(string(types.OutputFormatTemplate)); err != nil {
+		formats = append(formats, string(types.OutputFormatTemplate))
+	}
+	var help strings.Builder
+	for _, format := range formats {
+		line := "  " + format
+		if description, ok := formatDescriptions[format]; ok {
+			line = fmt.Sprintf("  %-15s - %s", format, description)
+		}
+		help.WriteString(line + "\n")
+	}
+	return help.String()
 }
 
 // showVersion displays version information
@@ -93,7 +193,7 @@ func (c *CLIApp) parseArgs(args []string) (string, map[string]interface{}, error
 		if strings.HasPrefix(arg, "--") {
 			key := strings.TrimPrefix(arg, "--")
 
-			if key == "verbose" || key == "offline" || key == "quiet" {
+			if key == "verbose" || key == "offline" || key == "quiet" || key == "monorepo" || key == "explain" || key == "no-cache" {
 				options[key] = true
 			} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
 				options[key] = args[i+1]
@@ -107,6 +207,11 @@ func (c *CLIApp) parseArgs(args []string) (string, map[string]interface{}, error
 			}
 		} else if repo == "" {
 			repo = arg
+		} else if repo == "batch" && options["input"] == nil {
+			// The batch command reads its specs from a file
+			options["input"] = arg
+		} else if repo == "cache" && options["action"] == nil {
+			options["action"] = arg
 		} else {
 			return "", nil, types.NewDevBoxPackError(
 				fmt.Sprintf("unknown argument: %s", arg),
@@ -119,12 +224,40 @@ func (c *CLIApp) parseArgs(args []string) (string, map[string]interface{}, error
 	return repo, options, nil
 }
 
+// newFormatterFactory returns the formatters available with options, including the
+// template format when --template is given
+func newFormatterFactory(options *types.CLIOptions) (*formatters.FormatterFactory, error) {
+	factory := formatters.NewFormatterFactory()
+	if options.Template == nil {
+		return factory, nil
+	}
+
+	formatter, err := formatters.NewTemplateFormatterFromFile(*options.Template)
+	if err != nil {
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("invalid template %s: %v", *options.Template, err),
+			types.ErrorCodeInvalidArgument,
+			map[string]interface{}{"template": *options.Template},
+		)
+	}
+	factory.RegisterFormatter(string(types.OutputFormatTemplate), formatter)
+	return factory, nil
+}
+
 // validateOptions validates and converts CLI options
 func (c *CLIApp) validateOptions(rawOptions map[string]interface{}) (*types.CLIOptions, error) {
 	options := &types.CLIOptions{
-		Format:  "pretty",
-		Verbose: false,
-		Offline: false,
+		Format:      "pretty",
+		Verbose:     false,
+		Offline:     false,
+		Timeout:     time.Duration(utils.CLIDefaults.Timeout) * time.Millisecond,
+		PluginPaths: splitPathList(os.Getenv(plugins.PathEnv)),
+		RulesPaths:  splitPathList(os.Getenv(rules.PathEnv)),
+	}
+	// Caching is on by default; without a user cache directory, or with
+	// --cache-dir "", repositories are cloned and analysed afresh
+	if cacheDir, err := git.DefaultCacheDir(); err == nil {
+		options.CacheDir = cacheDir
 	}
 
 	// Set option values
@@ -149,43 +282,119 @@ func (c *CLIApp) validateOptions(rawOptions map[string]interface{}) (*types.CLIO
 	if quiet, ok := rawOptions["quiet"].(bool); ok {
 		options.Quiet = quiet
 	}
+	if monorepo, ok := rawOptions["monorepo"].(bool); ok {
+		options.Monorepo = monorepo
+	}
+	if explain, ok := rawOptions["explain"].(bool); ok {
+		options.Explain = explain
+	}
+	if noCache, ok := rawOptions["no-cache"].(bool); ok {
+		options.NoCache = noCache
+	}
 	if platform, ok := rawOptions["platform"].(string); ok {
 		options.Platform = &platform
 	}
 	if base, ok := rawOptions["base"].(string); ok {
 		options.Base = &base
 	}
+	if timeout, ok := rawOptions["timeout"].(string); ok {
+		duration, err := parseTimeout(timeout)
+		if err != nil {
+			return nil, err
+		}
+		options.Timeout = duration
+	}
+	if namespace, ok := rawOptions["namespace"].(string); ok {
+		options.Namespace = &namespace
+	}
+	if replicas, ok := rawOptions["replicas"].(string); ok {
+		count, err := strconv.Atoi(replicas)
+		if err != nil || count < 0 {
+			return nil, types.NewDevBoxPackError(
+				fmt.Sprintf("invalid replicas: %s", replicas),
+				types.ErrorCodeInvalidArgument,
+				map[string]interface{}{"replicas": replicas},
+			)
+		}
+		options.Replicas = &count
+	}
+	if outputDir, ok := rawOptions["output-dir"].(string); ok {
+		options.OutputDir = &outputDir
+	}
+	if template, ok := rawOptions["template"].(string); ok {
+		options.Template = &template
+		// --template alone selects the template format
+		if _, ok := rawOptions["format"]; !ok {
+			options.Format = string(types.OutputFormatTemplate)
+		}
+	}
+	if testCommand, ok := rawOptions["test-command"].(string); ok {
+		options.TestCommand = &testCommand
+	}
+	if pluginPath, ok := rawOptions["plugin-path"].(string); ok {
+		options.PluginPaths = splitPathList(pluginPath)
+	}
+	if rulesPath, ok := rawOptions["rules-path"].(string); ok {
+		options.RulesPaths = splitPathList(rulesPath)
+		// Missing directories of the environment hold no rules, given ones are mistakes
+		for _, dir := range options.RulesPaths {
+			if _, err := os.Stat(dir); err != nil {
+				return nil, types.NewDevBoxPackError(
+					fmt.Sprintf("cannot read rules directory: %s", err.Error()),
+					types.ErrorCodeInvalidArgument,
+					map[string]interface{}{"path": dir},
+				)
+			}
+		}
+	}
+	if cacheDir, ok := rawOptions["cache-dir"].(string); ok {
+		options.CacheDir = cacheDir
+	}
 
 	// Validate output format
-	if options.Format != string(types.OutputFormatJSON) && options.Format != string(types.OutputFormatPretty) {
+	isTemplate := options.Format == string(types.OutputFormatTemplate)
+	if isTemplate != (options.Template != nil) {
+		return nil, types.NewDevBoxPackError(
+			"--template and --format template must be used together",
+			types.ErrorCodeInvalidArgument,
+			map[string]interface{}{"format": options.Format},
+		)
+	}
+	factory, err := newFormatterFactory(options)
+	if err != nil {
+		return nil, err
+	}
+	formatter, err := factory.GetFormatter(options.Format)
+	if err != nil {
 		return nil, types.NewDevBoxPackError(
 			fmt.Sprintf("unsupported output format: %s", options.Format),
 			types.ErrorCodeInvalidFormat,
 			map[string]interface{}{
 				"format":    options.Format,
-				"supported": []string{"json", "pretty"},
+				"supported": factory.GetSupportedFormats(),
 			},
 		)
 	}
+	if _, bundle := formatter.(formatters.BundleFormatter); options.OutputDir != nil && !bundle {
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("--output-dir is not supported by output format: %s", options.Format),
+			types.ErrorCodeInvalidArgument,
+			map[string]interface{}{"format": options.Format},
+		)
+	}
 
 	// Validate Provider
 	if options.Provider != nil {
-		supportedProviders := []string{
-			"node", "python", "java", "go", "php", "ruby",
-			"deno", "rust", "staticfile", "shell",
-		}
-		found := false
-		for _, p := range supportedProviders {
-			if *options.Provider == p {
-				found = true
-				break
-			}
-		}
-		if !found {
+		_, found := providers.Lookup(*options.Provider)
+		// Plugin and rule names are only known once they are loaded, the detection engine checks those
+		if !found && len(options.PluginPaths) == 0 && len(options.RulesPaths) == 0 {
 			return nil, types.NewDevBoxPackError(
 				fmt.Sprintf("unsupported Provider: %s", *options.Provider),
 				types.ErrorCodeInvalidProvider,
-				nil,
+				map[string]interface{}{
+					"provider":  *options.Provider,
+					"supported": providers.RegisteredNames(),
+				},
 			)
 		}
 	}
@@ -215,6 +424,33 @@ func (c *CLIApp) validateOptions(rawOptions map[string]interface{}) (*types.CLIO
 	return options, nil
 }
 
+// splitPathList splits a list of directories separated like PATH, dropping empty entries
+func splitPathList(list string) []string {
+	var paths []string
+	for _, dir := range filepath.SplitList(list) {
+		if dir != "" {
+			paths = append(paths, dir)
+		}
+	}
+	return paths
+}
+
+// parseTimeout parses a Go duration such as "90s" or a plain number of seconds
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, types.NewDevBoxPackError(
+			fmt.Sprintf("invalid timeout: %s", value),
+			types.ErrorCodeInvalidArgument,
+			map[string]interface{}{"timeout": value},
+		)
+	}
+	return duration, nil
+}
+
 // parseGitRepository parses Git repository information
 func (c *CLIApp) parseGitRepository(repo string, options *types.CLIOptions) (*types.GitRepository, error) {
 	// Check if it's a local path
@@ -252,6 +488,7 @@ func (c *CLIApp) handleAnalyze(repo string, rawOptions map[string]interface{}) e
 	if err != nil {
 		return err
 	}
+	options.Repository = repo
 
 	if options.Verbose {
 		fmt.Println(utils.Blue("🔍 DevBox Pack Execution Plan Generator"))
@@ -288,14 +525,328 @@ func (c *CLIApp) handleAnalyze(repo string, rawOptions map[string]interface{}) e
 		)
 	}
 
-	// Create DevBoxPack instance and run
-	devBoxPack := service.NewDevBoxPack()
-	return devBoxPack.Run(gitRepo.URL, options)
+	// Run the analysis through the library API and print the result
+	packOptions := pack.Options{
+		Monorepo:    options.Monorepo,
+		Explain:     options.Explain,
+		PluginPaths: options.PluginPaths,
+		RulesPaths:  options.RulesPaths,
+		CacheDir:    options.CacheDir,
+		NoCache:     options.NoCache,
+		Logger:      service.NewConsoleLogger(options),
+	}
+	if options.Provider != nil {
+		packOptions.Provider = *options.Provider
+	}
+	if options.Base != nil {
+		packOptions.Base = *options.Base
+	}
+	if options.Platform != nil {
+		packOptions.Platform = *options.Platform
+	}
+	source := pack.Source{Repository: gitRepo.URL}
+	if gitRepo.Ref != nil {
+		source.Ref = *gitRepo.Ref
+	}
+	if gitRepo.Subdir != nil {
+		source.Subdir = *gitRepo.Subdir
+	}
+
+	// Interrupting cancels the analysis, which then removes its clone
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	result, err := pack.Analyze(ctx, source, packOptions)
+	if err != nil {
+		return err
+	}
+
+	factory, err := newFormatterFactory(options)
+	if err != nil {
+		return err
+	}
+	outputUtils := formatters.NewOutputUtilsWithFactory(factory)
+	if options.Monorepo {
+		plans := make(map[string]*types.ExecutionPlan, len(result.Services))
+		detections := make(map[string][]*types.DetectResult, len(result.Services))
+		var explanations map[string]*types.Explanation
+		if options.Explain {
+			explanations = make(map[string]*types.Explanation, len(result.Services))
+		}
+		for path, serviceResult := range result.Services {
+			plans[path] = serviceResult.Plan
+			detections[path] = serviceResult.Detections
+			if explanations != nil {
+				explanations[path] = serviceResult.Explanation
+			}
+		}
+		return outputUtils.OutputDetectedPlans(plans, detections, explanations, options)
+	}
+	return outputUtils.OutputDetectedPlan(result.Plan, result.Detections, result.Explanation, options)
+}
+
+// handleProviders handles the providers command
+func (c *CLIApp) handleProviders(rawOptions map[string]interface{}) error {
+	options, err := c.validateOptions(rawOptions)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	list, err := pack.Providers(ctx, pack.Options{
+		PluginPaths: options.PluginPaths,
+		RulesPaths:  options.RulesPaths,
+		Logger:      service.NewConsoleLogger(options),
+	})
+	if err != nil {
+		return err
+	}
+
+	if options.Format == string(types.OutputFormatJSON) {
+		output, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Println(utils.Blue("📦 Registered Providers"))
+	for _, info := range list {
+		fmt.Println()
+		fmt.Printf("%s %s\n", utils.Green(info.Name), utils.Gray(fmt.Sprintf("(%s, priority %d, %s)", info.Language, info.Priority, info.Source)))
+		if len(info.Frameworks) > 0 {
+			fmt.Println(utils.Gray("  Frameworks: " + strings.Join(info.Frameworks, ", ")))
+		}
+		if len(info.KeyFiles) > 0 {
+			fmt.Println(utils.Gray("  Key files: " + strings.Join(info.KeyFiles, ", ")))
+		}
+		if len(info.PackageManagers) > 0 {
+			fmt.Println(utils.Gray("  Package managers: " + strings.Join(info.PackageManagers, ", ")))
+		}
+	}
+	return nil
+}
+
+// handleServe handles the serve command, serving until interrupted
+func (c *CLIApp) handleServe(rawOptions map[string]interface{}) error {
+	options, err := c.validateOptions(rawOptions)
+	if err != nil {
+		return err
+	}
+
+	serverOptions := server.Options{
+		CacheDir:    options.CacheDir,
+		PluginPaths: options.PluginPaths,
+		RulesPaths:  options.RulesPaths,
+	}
+	// The analysis default is too short for cloning, requests use the server default
+	if _, ok := rawOptions["timeout"]; ok {
+		serverOptions.Timeout = options.Timeout
+	}
+	if maxClones, ok := rawOptions["max-clones"].(string); ok {
+		count, err := strconv.Atoi(maxClones)
+		if err != nil || count < 1 {
+			return types.NewDevBoxPackError(
+				fmt.Sprintf("invalid max-clones: %s", maxClones),
+				types.ErrorCodeInvalidArgument,
+				map[string]interface{}{"max-clones": maxClones},
+			)
+		}
+		serverOptions.MaxClones = count
+	}
+	addr := ":8080"
+	if value, ok := rawOptions["addr"].(string); ok {
+		addr = value
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	api, err := server.New(ctx, serverOptions)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintln(os.Stderr, utils.Blue(fmt.Sprintf("🚀 Serving execution plans on %s", addr)))
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	// Let running analyses finish, their clones are removed when they return
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// handleBatch handles the batch command, writing JSON Lines results to stdout
+func (c *CLIApp) handleBatch(rawOptions map[string]interface{}) error {
+	options, err := c.validateOptions(rawOptions)
+	if err != nil {
+		return err
+	}
+
+	batchOptions := batch.Options{Timeout: options.Timeout, NoCache: options.NoCache}
+	if options.Provider != nil {
+		batchOptions.Provider = *options.Provider
+	}
+	if options.Base != nil {
+		batchOptions.Base = *options.Base
+	}
+	if options.Platform != nil {
+		batchOptions.Platform = *options.Platform
+	}
+	if workers, ok := rawOptions["workers"].(string); ok {
+		count, err := strconv.Atoi(workers)
+		if err != nil || count < 1 {
+			return types.NewDevBoxPackError(
+				fmt.Sprintf("invalid workers: %s", workers),
+				types.ErrorCodeInvalidArgument,
+				map[string]interface{}{"workers": workers},
+			)
+		}
+		batchOptions.Workers = count
+	}
+
+	input := os.Stdin
+	if name, ok := rawOptions["input"].(string); ok && name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return types.NewDevBoxPackError(
+				fmt.Sprintf("cannot read repository list: %s", err.Error()),
+				types.ErrorCodeFileReadError,
+				map[string]interface{}{"path": name},
+			)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Progress of concurrent analyses would interleave, so it is discarded
+	devBoxPack := service.NewDevBoxPackWithLogger(nil)
+	if options.CacheDir != "" {
+		devBoxPack.SetCacheDir(options.CacheDir)
+	}
+	if len(options.RulesPaths) > 0 {
+		if err := devBoxPack.LoadRules(options.RulesPaths); err != nil {
+			return err
+		}
+	}
+	if len(options.PluginPaths) > 0 {
+		if err := devBoxPack.LoadPlugins(ctx, options.PluginPaths); err != nil {
+			return err
+		}
+	}
+
+	summary, err := batch.Run(ctx, devBoxPack, input, os.Stdout, batchOptions)
+	if err != nil {
+		return err
+	}
+	if !options.Quiet {
+		counts := make([]string, 0, len(summary.ProviderStats))
+		for _, provider := range summary.Providers() {
+			counts = append(counts, fmt.Sprintf("%s %d", provider, summary.ProviderStats[provider]))
+		}
+		fmt.Fprintln(os.Stderr, utils.Blue(fmt.Sprintf("📦 Analysed %d repositories: %d succeeded, %d failed", summary.Total, summary.Succeeded, summary.Failed)))
+		if len(counts) > 0 {
+			fmt.Fprintln(os.Stderr, utils.Gray("  Providers: "+strings.Join(counts, ", ")))
+		}
+	}
+	return nil
+}
+
+// handleCache handles the cache command
+func (c *CLIApp) handleCache(rawOptions map[string]interface{}) error {
+	options, err := c.validateOptions(rawOptions)
+	if err != nil {
+		return err
+	}
+	if action, _ := rawOptions["action"].(string); action != "prune" {
+		return types.NewDevBoxPackError(
+			fmt.Sprintf("unknown cache command: %s, expected prune", action),
+			types.ErrorCodeInvalidArgument,
+			map[string]interface{}{"command": action},
+		)
+	}
+	if options.CacheDir == "" {
+		return types.NewDevBoxPackError(
+			"no cache directory, set --cache-dir or $"+git.CacheDirEnv,
+			types.ErrorCodeInvalidArgument,
+			nil,
+		)
+	}
+
+	clones, err := git.NewCloneCache(options.CacheDir).Prune()
+	if err != nil {
+		return err
+	}
+	plans, err := plancache.New(options.CacheDir, utils.BuildVersion()).Prune()
+	if err != nil {
+		return err
+	}
+
+	if options.Format == string(types.OutputFormatJSON) {
+		output, err := json.MarshalIndent(map[string]interface{}{
+			"cacheDir": options.CacheDir,
+			"clones":   clones,
+			"plans":    plans,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Println(utils.Blue(fmt.Sprintf("🧹 Pruned %s", options.CacheDir)))
+	fmt.Println(utils.Gray(fmt.Sprintf("  Mirrors: removed %d (%s), kept %d (%s)", clones.Removed, formatSize(clones.Freed), clones.Kept, formatSize(clones.Size))))
+	fmt.Println(utils.Gray(fmt.Sprintf("  Plans: removed %d (%s), kept %d (%s)", plans.Removed, formatSize(plans.Freed), plans.Kept, formatSize(plans.Size))))
+	return nil
+}
+
+// formatSize formats a number of bytes for display
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size, exponent := float64(bytes)/unit, 0
+	for size >= unit && exponent < 3 {
+		size /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", size, "KMGT"[exponent])
 }
 
 // handleError handles errors
 func (c *CLIApp) handleError(err error) {
-	if devBoxErr, ok := err.(*types.DevBoxPackError); ok {
+	var devBoxErr *types.DevBoxPackError
+	if errors.As(err, &devBoxErr) {
 		fmt.Fprintf(os.Stderr, "%s\n", utils.Red(fmt.Sprintf("❌ Error [%s]: %s", devBoxErr.Code, devBoxErr.Message)))
 		if devBoxErr.Details != nil {
 			detailsJSON, _ := json.MarshalIndent(devBoxErr.Details, "", "  ")
@@ -321,7 +872,17 @@ func (c *CLIApp) Run(args []string) error {
 		return fmt.Errorf("missing repository argument")
 	}
 
-	err = c.handleAnalyze(repo, options)
+	if repo == "providers" {
+		err = c.handleProviders(options)
+	} else if repo == "serve" {
+		err = c.handleServe(options)
+	} else if repo == "batch" {
+		err = c.handleBatch(options)
+	} else if repo == "cache" {
+		err = c.handleCache(options)
+	} else {
+		err = c.handleAnalyze(repo, options)
+	}
 	if err != nil {
 		c.handleError(err)
 		return err
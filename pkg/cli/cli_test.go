@@ -1,7 +1,16 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/formatters"
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/plugins"
+	"github.com/labring/devbox-pack/pkg/rules"
 )
 
 func TestNewCLIApp(t *testing.T) {
@@ -28,6 +37,22 @@ func TestParseArgs_Help(t *testing.T) {
 	}
 }
 
+func TestHelpText_Formats(t *testing.T) {
+	help := helpText()
+	for _, format := range formatters.NewFormatterFactory().GetSupportedFormats() {
+		if !strings.Contains(help, "\n  "+format+" ") {
+			t.Errorf("expected format %s in help", format)
+		}
+	}
+
+	// Formats registered by library users are listed without a description
+	factory := formatters.NewFormatterFactory()
+	factory.RegisterFormatter("custom", formatters.NewJSONFormatter())
+	if !strings.Contains(formatHelp(factory), "\n  custom\n") {
+		t.Errorf("expected custom format in:\n%s", formatHelp(factory))
+	}
+}
+
 func TestParseArgs_Version(t *testing.T) {
 	// Test --version flag
 	args := []string{"devbox-pack", "--version"}
@@ -119,6 +144,7 @@ func TestParseArgs_WithOptions(t *testing.T) {
 		"--offline",
 		"--platform", "linux/amd64",
 		"--base", "node:18-alpine",
+		"--monorepo",
 	}
 
 	repo, options, err := app.parseArgs(args)
@@ -139,6 +165,7 @@ func TestParseArgs_WithOptions(t *testing.T) {
 		"offline":  true,
 		"platform": "linux/amd64",
 		"base":     "node:18-alpine",
+		"monorepo": true,
 	}
 
 	for key, expected := range expectedOptions {
@@ -160,6 +187,18 @@ func TestValidateOptions_ValidFormat(t *testing.T) {
 	}{
 		{"json format", "json", false},
 		{"pretty format", "pretty", false},
+		{"dockerfile format", "dockerfile", false},
+		{"k8s format", "k8s", false},
+		{"compose format", "compose", false},
+		{"yaml format", "yaml", false},
+		{"toml format", "toml", false},
+		{"scripts format", "scripts", false},
+		{"github-actions format", "github-actions", false},
+		{"gitlab-ci format", "gitlab-ci", false},
+		{"devcontainer format", "devcontainer", false},
+		{"railpack format", "railpack", false},
+		{"markdown format", "markdown", false},
+		{"sarif format", "sarif", false},
 		{"invalid format", "xml", true},
 		{"empty format", "", false}, // should default to pretty
 	}
@@ -305,6 +344,118 @@ func TestValidateOptions_ValidPlatform(t *testing.T) {
 	}
 }
 
+func TestValidateOptions_Timeout(t *testing.T) {
+	app := NewCLIApp()
+
+	tests := []struct {
+		name    string
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"default", "", 30 * time.Second, false},
+		{"duration", "2m", 2 * time.Minute, false},
+		{"seconds", "90", 90 * time.Second, false},
+		{"disabled", "0", 0, false},
+		{"negative", "-5s", 0, true},
+		{"invalid", "soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawOptions := map[string]interface{}{}
+			if tt.timeout != "" {
+				rawOptions["timeout"] = tt.timeout
+			}
+
+			options, err := app.validateOptions(rawOptions)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if options.Timeout != tt.want {
+				t.Errorf("expected timeout %s, got %s", tt.want, options.Timeout)
+			}
+		})
+	}
+}
+
+func TestValidateOptions_Replicas(t *testing.T) {
+	app := NewCLIApp()
+
+	options, err := app.validateOptions(map[string]interface{}{"replicas": "3", "namespace": "prod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.Replicas == nil || *options.Replicas != 3 || options.Namespace == nil || *options.Namespace != "prod" {
+		t.Errorf("expected 3 replicas in prod, got %v in %v", options.Replicas, options.Namespace)
+	}
+
+	for _, replicas := range []string{"-1", "many"} {
+		if _, err := app.validateOptions(map[string]interface{}{"replicas": replicas}); err == nil {
+			t.Errorf("expected error for replicas %q", replicas)
+		}
+	}
+}
+
+func TestValidateOptions_OutputDir(t *testing.T) {
+	app := NewCLIApp()
+
+	options, err := app.validateOptions(map[string]interface{}{"format": "scripts", "output-dir": "out"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.OutputDir == nil || *options.OutputDir != "out" {
+		t.Errorf("expected output dir out, got %v", options.OutputDir)
+	}
+
+	if _, err := app.validateOptions(map[string]interface{}{"format": "json", "output-dir": "out"}); err == nil {
+		t.Error("expected error for --output-dir with a format that is not a bundle")
+	}
+}
+
+func TestValidateOptions_Template(t *testing.T) {
+	app := NewCLIApp()
+	dir := t.TempDir()
+	template := filepath.Join(dir, "plan.tmpl")
+	if err := os.WriteFile(template, []byte("{{ .Plan.Provider }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(broken, []byte("{{ .Plan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := app.validateOptions(map[string]interface{}{"template": template})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.Format != "template" || options.Template == nil || *options.Template != template {
+		t.Errorf("expected the template format with %s, got %s with %v", template, options.Format, options.Template)
+	}
+
+	invalid := []map[string]interface{}{
+		{"format": "template"},
+		{"format": "json", "template": template},
+		{"template": broken},
+		{"template": filepath.Join(dir, "missing.tmpl")},
+	}
+	for _, rawOptions := range invalid {
+		if _, err := app.validateOptions(rawOptions); err == nil {
+			t.Errorf("expected error for %v", rawOptions)
+		}
+	}
+}
+
 func TestValidateOptions_AllOptions(t *testing.T) {
 	app := NewCLIApp()
 
@@ -318,6 +469,7 @@ func TestValidateOptions_AllOptions(t *testing.T) {
 		"quiet":    false,
 		"platform": "linux/amd64",
 		"base":     "python:3.11-slim",
+		"monorepo": true,
 	}
 
 	options, err := app.validateOptions(rawOptions)
@@ -357,6 +509,9 @@ func TestValidateOptions_AllOptions(t *testing.T) {
 	if options.Base == nil || *options.Base != "python:3.11-slim" {
 		t.Error("base not set correctly")
 	}
+	if !options.Monorepo {
+		t.Error("monorepo not set correctly")
+	}
 }
 
 func TestRun_InvalidArguments(t *testing.T) {
@@ -379,6 +534,24 @@ func TestRun_InvalidArguments(t *testing.T) {
 	if err == nil {
 		t.Error("expected error for invalid format")
 	}
+
+	// Test invalid batch worker count
+	err = app.Run([]string{"devbox-pack", "batch", "repos.txt", "--workers", "none"})
+	if err == nil {
+		t.Error("expected error for invalid workers")
+	}
+
+	// Test invalid serve clone limit
+	err = app.Run([]string{"devbox-pack", "serve", "--max-clones", "0"})
+	if err == nil {
+		t.Error("expected error for invalid max-clones")
+	}
+
+	// Test unknown cache command
+	err = app.Run([]string{"devbox-pack", "cache", "clear", "--cache-dir", t.TempDir()})
+	if err == nil {
+		t.Error("expected error for unknown cache command")
+	}
 }
 
 func TestParseArgs_EdgeCases(t *testing.T) {
@@ -404,6 +577,21 @@ func TestParseArgs_EdgeCases(t *testing.T) {
 			args:    []string{"devbox-pack", "repo1", "repo2"},
 			wantErr: true,
 		},
+		{
+			name:    "batch input file",
+			args:    []string{"devbox-pack", "batch", "repos.txt"},
+			wantErr: false,
+		},
+		{
+			name:    "batch with two input files",
+			args:    []string{"devbox-pack", "batch", "repos.txt", "more.txt"},
+			wantErr: true,
+		},
+		{
+			name:    "cache command",
+			args:    []string{"devbox-pack", "cache", "prune", "--no-cache"},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -419,3 +607,70 @@ func TestParseArgs_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateOptions_ExtensionPaths(t *testing.T) {
+	app := NewCLIApp()
+	t.Setenv(plugins.PathEnv, "")
+	t.Setenv(rules.PathEnv, "")
+
+	// Unknown providers are rejected without plugins
+	if _, err := app.validateOptions(map[string]interface{}{"provider": "acme"}); err == nil {
+		t.Error("expected error for unknown provider without plugins")
+	}
+
+	pluginPath := strings.Join([]string{"/opt/plugins", "", "/usr/local/plugins"}, string(os.PathListSeparator))
+	options, err := app.validateOptions(map[string]interface{}{
+		"provider":    "acme",
+		"plugin-path": pluginPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(options.PluginPaths) != 2 || options.PluginPaths[0] != "/opt/plugins" || options.PluginPaths[1] != "/usr/local/plugins" {
+		t.Errorf("unexpected plugin paths: %v", options.PluginPaths)
+	}
+
+	rulesPath := t.TempDir()
+	options, err = app.validateOptions(map[string]interface{}{
+		"provider":   "acme",
+		"rules-path": rulesPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(options.RulesPaths) != 1 || options.RulesPaths[0] != rulesPath {
+		t.Errorf("unexpected rules paths: %v", options.RulesPaths)
+	}
+
+	// A missing rules directory is an error only when given on the command line
+	missing := filepath.Join(rulesPath, "missing")
+	if _, err := app.validateOptions(map[string]interface{}{"rules-path": missing}); err == nil {
+		t.Error("expected error for missing --rules-path directory")
+	}
+	t.Setenv(rules.PathEnv, missing)
+	if options, err := app.validateOptions(map[string]interface{}{}); err != nil || len(options.RulesPaths) != 1 {
+		t.Errorf("expected missing environment rules directory to be accepted, got %v, %v", options, err)
+	}
+}
+
+func TestValidateOptions_CacheDir(t *testing.T) {
+	app := NewCLIApp()
+	t.Setenv(git.CacheDirEnv, "/var/cache/devbox-pack")
+
+	options, err := app.validateOptions(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.CacheDir != "/var/cache/devbox-pack" {
+		t.Errorf("expected cache directory from environment, got %q", options.CacheDir)
+	}
+
+	// An empty directory disables the clone and plan caches
+	options, err = app.validateOptions(map[string]interface{}{"cache-dir": "", "no-cache": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.CacheDir != "" || !options.NoCache {
+		t.Errorf("expected disabled cache, got %q, no cache %t", options.CacheDir, options.NoCache)
+	}
+}
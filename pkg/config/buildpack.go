@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+)
+
+// BuildpackFileNames lists the Cloud Native Buildpacks project descriptors looked up in the project root
+var BuildpackFileNames = []string{"project.toml"}
+
+// buildpackEnvironmentPrefixes are the prefixes of build variables the Paketo
+// environment-variables buildpack sets at launch without the prefix
+var buildpackEnvironmentPrefixes = []string{"BPE_DEFAULT_", "BPE_OVERRIDE_", "BPE_"}
+
+// buildpackDescriptor holds the project.toml properties that override a plan,
+// from the io.buildpacks table of schema 0.2 or the build table of schema 0.1
+type buildpackDescriptor struct {
+	IO struct {
+		Buildpacks buildpackTable `json:"buildpacks"`
+	} `json:"io"`
+	Build buildpackTable `json:"build"`
+}
+
+// buildpackTable lists the buildpacks and build variables of a descriptor
+type buildpackTable struct {
+	// Schema 0.2 buildpacks and build variables
+	Group []buildpackReference `json:"group"`
+	Build struct {
+		Env []buildpackVariable `json:"env"`
+	} `json:"build"`
+	// Schema 0.1 buildpacks and build variables
+	Buildpacks []buildpackReference `json:"buildpacks"`
+	Env        []buildpackVariable  `json:"env"`
+}
+
+// buildpackReference names a buildpack by ID, e.g. "paketo-buildpacks/nodejs", or URI
+type buildpackReference struct {
+	ID  string `json:"id"`
+	URI string `json:"uri"`
+}
+
+// buildpackVariable is a build-time environment variable
+type buildpackVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ParseBuildpack parses a project.toml project descriptor into an override. The first
+// language buildpack pins the provider, or GOOGLE_RUNTIME with Google's buildpacks.
+// Launch variables of the Paketo environment-variables buildpack become the environment
+// and GOOGLE_ENTRYPOINT the run command. Other build variables configure buildpacks and are ignored.
+func ParseBuildpack(fileName string, content []byte) (*Override, error) {
+	table, err := markup.ParseTOML(string(content))
+	if err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+	data, err := json.Marshal(table)
+	if err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+	var descriptor buildpackDescriptor
+	if err := json.Unmarshal(data, &descriptor); err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+
+	override := &Override{File: fileName}
+	var references []buildpackReference
+	var variables []buildpackVariable
+	for _, table := range []buildpackTable{descriptor.IO.Buildpacks, descriptor.Build} {
+		references = append(append(references, table.Group...), table.Buildpacks...)
+		variables = append(append(variables, table.Build.Env...), table.Env...)
+	}
+
+	for _, reference := range references {
+		if override.Provider = builderProvider(buildpackName(reference)); override.Provider != nil {
+			break
+		}
+	}
+
+	for _, variable := range variables {
+		switch variable.Name {
+		case "GOOGLE_RUNTIME":
+			if provider := builderProvider(variable.Value); provider != nil {
+				override.Provider = provider
+			}
+			continue
+		case "GOOGLE_ENTRYPOINT":
+			override.Commands.Run = &[]string{variable.Value}
+			continue
+		}
+		for _, prefix := range buildpackEnvironmentPrefixes {
+			name, found := strings.CutPrefix(variable.Name, prefix)
+			if !found {
+				continue
+			}
+			// Appending, prepending and delimiter variables modify a value instead of setting it
+			if name != "" && !strings.HasPrefix(name, "APPEND_") && !strings.HasPrefix(name, "PREPEND_") && !strings.HasPrefix(name, "DELIM_") {
+				if override.Environment == nil {
+					override.Environment = make(map[string]string)
+				}
+				override.Environment[name] = variable.Value
+			}
+			break
+		}
+	}
+	return override, nil
+}
+
+// buildpackName returns the name of a buildpack reference without its registry, namespace and version,
+// e.g. "nodejs" for "docker://gcr.io/paketo-buildpacks/nodejs:1.2" or "heroku/nodejs@3.0"
+func buildpackName(reference buildpackReference) string {
+	name := reference.ID
+	if name == "" {
+		name = reference.URI
+	}
+	name = path.Base(name)
+	name, _, _ = strings.Cut(name, "@")
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+)
+
+// DevcontainerFileNames lists the Dev Container files looked up in the project root, in order of precedence
+var DevcontainerFileNames = []string{
+	".devcontainer/devcontainer.json",
+	".devcontainer.json",
+}
+
+// devcontainer holds the devcontainer.json properties that seed a plan
+type devcontainer struct {
+	Image             string            `json:"image"`
+	ForwardPorts      []json.RawMessage `json:"forwardPorts"`
+	AppPort           json.RawMessage   `json:"appPort"`
+	ContainerEnv      map[string]string `json:"containerEnv"`
+	RemoteEnv         map[string]string `json:"remoteEnv"`
+	PostCreateCommand json.RawMessage   `json:"postCreateCommand"`
+}
+
+// LoadDevcontainer loads the first Dev Container file found in fsys as an override.
+// It returns nil without error when the project has no Dev Container file.
+func LoadDevcontainer(fsys fs.FS) (*Override, error) {
+	return loadFirst(fsys, DevcontainerFileNames, ParseDevcontainer)
+}
+
+// ParseDevcontainer parses devcontainer.json content, which may contain comments,
+// into an override carrying its image, port, environment and post-create commands
+func ParseDevcontainer(fileName string, content []byte) (*Override, error) {
+	var container devcontainer
+	if err := json.Unmarshal(markup.StripJSONC(content), &container); err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+
+	override := &Override{File: fileName}
+	if container.Image != "" {
+		image := container.Image
+		override.Runtime.Image = &image
+	}
+
+	ports := container.ForwardPorts
+	if len(container.AppPort) > 0 {
+		// appPort is a single port or a list of them
+		var appPorts []json.RawMessage
+		if err := json.Unmarshal(container.AppPort, &appPorts); err != nil {
+			appPorts = []json.RawMessage{container.AppPort}
+		}
+		ports = append(ports, appPorts...)
+	}
+	for _, raw := range ports {
+		if port, ok := devcontainerPort(raw); ok {
+			override.Port = &port
+			break
+		}
+	}
+
+	for key, value := range container.RemoteEnv {
+		// Values referring to the local machine, e.g. ${localEnv:HOME}, only resolve in an editor
+		if strings.Contains(value, "${") {
+			continue
+		}
+		if override.Environment == nil {
+			override.Environment = make(map[string]string)
+		}
+		override.Environment[key] = value
+	}
+	for key, value := range container.ContainerEnv {
+		if override.Environment == nil {
+			override.Environment = make(map[string]string)
+		}
+		override.Environment[key] = value
+	}
+
+	if len(container.PostCreateCommand) > 0 {
+		commands, err := devcontainerCommands(container.PostCreateCommand)
+		if err != nil {
+			return nil, overrideParseError(fileName, fmt.Errorf("postCreateCommand: %w", err))
+		}
+		if len(commands) > 0 {
+			override.Commands.Setup = &commands
+		}
+	}
+	return override, nil
+}
+
+// devcontainerPort reads a forwarded port given as a number, "port" or "host:port".
+// Ports forwarded from other containers, e.g. "db:5432", are not the application's.
+func devcontainerPort(raw json.RawMessage) (int, bool) {
+	var port int
+	if err := json.Unmarshal(raw, &port); err == nil {
+		return port, port > 0
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, false
+	}
+	host, portText, found := strings.Cut(value, ":")
+	if !found {
+		portText = host
+	} else if host != "localhost" && host != "127.0.0.1" && host != "0.0.0.0" {
+		if _, err := strconv.Atoi(host); err != nil {
+			return 0, false
+		}
+	}
+	port, err := strconv.Atoi(portText)
+	return port, err == nil && port > 0
+}
+
+// devcontainerCommands converts a lifecycle command into plan commands.
+// A string is a shell command, an array is one command's arguments and
+// an object holds named commands, taken in name order.
+func devcontainerCommands(raw json.RawMessage) ([]string, error) {
+	var command string
+	if err := json.Unmarshal(raw, &command); err == nil {
+		if command == "" {
+			return nil, nil
+		}
+		return []string{command}, nil
+	}
+
+	var args []string
+	if err := json.Unmarshal(raw, &args); err == nil {
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return []string{joinArguments(args)}, nil
+	}
+
+	var named map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &named); err != nil {
+		return nil, fmt.Errorf("expected a string, an array or an object")
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var commands []string
+	for _, name := range names {
+		if err := json.Unmarshal(named[name], &command); err == nil {
+			if command != "" {
+				commands = append(commands, command)
+			}
+			continue
+		}
+		if err := json.Unmarshal(named[name], &args); err != nil {
+			return nil, fmt.Errorf("command %q must be a string or an array", name)
+		}
+		if len(args) > 0 {
+			commands = append(commands, joinArguments(args))
+		}
+	}
+	return commands, nil
+}
+
+// joinArguments joins arguments into a shell command, quoting those the shell would split or expand
+func joinArguments(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@+,%") == "" {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestLoadDevcontainer(t *testing.T) {
+	dir := t.TempDir()
+	content := `{
+	// Created by the editor
+	"name": "app",
+	"image": "mcr.microsoft.com/devcontainers/javascript-node:20",
+	"forwardPorts": ["db:5432", 3000],
+	"containerEnv": {"NODE_ENV": "development"},
+	"remoteEnv": {"PATH": "${containerEnv:PATH}:/extra", "API_URL": "http://localhost:4000"},
+	"postCreateCommand": {"install": "npm ci", "cache": ["npm", "config", "set", "cache", "/tmp/npm cache"]},
+}`
+	if err := os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0755); err != nil {
+		t.Fatalf("failed to create .devcontainer: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".devcontainer", "devcontainer.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write devcontainer.json: %v", err)
+	}
+
+	override, err := LoadDevcontainer(source.Dir(dir))
+	if err != nil {
+		t.Fatalf("LoadDevcontainer failed: %v", err)
+	}
+	if override == nil || override.File != ".devcontainer/devcontainer.json" {
+		t.Fatalf("expected override from .devcontainer/devcontainer.json, got %+v", override)
+	}
+	if override.Runtime.Image == nil || *override.Runtime.Image != "mcr.microsoft.com/devcontainers/javascript-node:20" {
+		t.Errorf("expected the container image, got %v", override.Runtime.Image)
+	}
+	if override.Port == nil || *override.Port != 3000 {
+		t.Errorf("expected port 3000, got %v", override.Port)
+	}
+	expectedEnv := map[string]string{"NODE_ENV": "development", "API_URL": "http://localhost:4000"}
+	if !reflect.DeepEqual(override.Environment, expectedEnv) {
+		t.Errorf("expected environment %v, got %v", expectedEnv, override.Environment)
+	}
+	expectedSetup := []string{"npm config set cache '/tmp/npm cache'", "npm ci"}
+	if override.Commands.Setup == nil || !reflect.DeepEqual(*override.Commands.Setup, expectedSetup) {
+		t.Errorf("expected setup %q, got %v", expectedSetup, override.Commands.Setup)
+	}
+	if override.Provider != nil || override.Commands.Build != nil {
+		t.Errorf("expected only devcontainer fields to be set, got %+v", override)
+	}
+}
+
+func TestParseDevcontainer(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		port    int
+		setup   []string
+	}{
+		{"string command and published app port", `{"appPort": "8080:80", "postCreateCommand": "pip install -r requirements.txt"}`, 80, []string{"pip install -r requirements.txt"}},
+		{"app port list", `{"appPort": [5000], "postCreateCommand": ""}`, 5000, nil},
+		{"no ports", `{"forwardPorts": ["redis:6379"]}`, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override, err := ParseDevcontainer(".devcontainer.json", []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseDevcontainer failed: %v", err)
+			}
+			port := 0
+			if override.Port != nil {
+				port = *override.Port
+			}
+			if port != tt.port {
+				t.Errorf("expected port %d, got %d", tt.port, port)
+			}
+			var setup []string
+			if override.Commands.Setup != nil {
+				setup = *override.Commands.Setup
+			}
+			if !reflect.DeepEqual(setup, tt.setup) {
+				t.Errorf("expected setup %q, got %q", tt.setup, setup)
+			}
+		})
+	}
+
+	_, err := ParseDevcontainer(".devcontainer.json", []byte(`{"postCreateCommand": 1}`))
+	devBoxErr, ok := err.(*types.DevBoxPackError)
+	if !ok || devBoxErr.Code != types.ErrorCodeInvalidOverride {
+		t.Errorf("expected %s error, got %v", types.ErrorCodeInvalidOverride, err)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"io/fs"
+)
+
+// importer reads the configuration file of another tool as an override
+type importer struct {
+	fileNames []string
+	parse     func(fileName string, content []byte) (*Override, error)
+}
+
+// importers lists the imported tools in the order their overrides apply, later ones winning.
+// Builder configurations describe how the project is built, a Dev Container the environment it is developed in.
+var importers = []importer{
+	{BuildpackFileNames, ParseBuildpack},
+	{NixpacksFileNames, ParseNixpacks},
+	{RailpackFileNames, ParseRailpack},
+	{DevcontainerFileNames, ParseDevcontainer},
+}
+
+// builderProviders maps the provider names of other builders to DevBox Pack Providers
+var builderProviders = map[string]string{
+	"node":       "node",
+	"nodejs":     "node",
+	"python":     "python",
+	"go":         "go",
+	"golang":     "go",
+	"java":       "java",
+	"php":        "php",
+	"ruby":       "ruby",
+	"deno":       "deno",
+	"rust":       "rust",
+	"staticfile": "staticfile",
+	"shell":      "shell",
+}
+
+// LoadImports loads the Cloud Native Buildpacks, Nixpacks, Railpack and Dev Container
+// configuration found in the root of fsys, in the order the overrides apply
+func LoadImports(fsys fs.FS) ([]*Override, error) {
+	var overrides []*Override
+	for _, importer := range importers {
+		override, err := loadFirst(fsys, importer.fileNames, importer.parse)
+		if err != nil {
+			return nil, err
+		}
+		if override != nil {
+			overrides = append(overrides, override)
+		}
+	}
+	return overrides, nil
+}
+
+// builderProvider maps the provider of another builder, returning nil for providers DevBox Pack lacks
+func builderProvider(name string) *string {
+	provider, ok := builderProviders[name]
+	if !ok {
+		return nil
+	}
+	return &provider
+}
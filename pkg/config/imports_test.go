@@ -0,0 +1,243 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// stringsOf dereferences an optional list, returning nil when unset
+func stringsOf(values *[]string) []string {
+	if values == nil {
+		return nil
+	}
+	return *values
+}
+
+func TestParseRailpack(t *testing.T) {
+	content := `{
+  "$schema": "https://schema.railpack.com",
+  "provider": "golang",
+  "buildAptPackages": ["libvips-dev"],
+  "steps": {
+    "install": {"commands": [{"path": "/root/go/bin"}, {"cmd": "go mod download"}], "variables": {"CGO_ENABLED": "1"}},
+    "build": {"commands": ["...", "go build -o out ./cmd"]}
+  },
+  "deploy": {
+    "startCommand": "./out",
+    "aptPackages": ["ca-certificates"],
+    "variables": {"GIN_MODE": "release", "CGO_ENABLED": "0"}
+  }
+}`
+	override, err := ParseRailpack("railpack.json", []byte(content))
+	if err != nil {
+		t.Fatalf("ParseRailpack failed: %v", err)
+	}
+
+	if override.Provider == nil || *override.Provider != "go" {
+		t.Errorf("expected provider go, got %v", override.Provider)
+	}
+	expected := map[string][]string{
+		"setup": {"go mod download"},
+		"build": {"...", "go build -o out ./cmd"},
+		"run":   {"./out"},
+		"apt":   {"libvips-dev", "ca-certificates"},
+	}
+	actual := map[string][]string{
+		"setup": stringsOf(override.Commands.Setup),
+		"build": stringsOf(override.Commands.Build),
+		"run":   stringsOf(override.Commands.Run),
+		"apt":   stringsOf(override.Apt),
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if !reflect.DeepEqual(override.Environment, map[string]string{"GIN_MODE": "release", "CGO_ENABLED": "0"}) {
+		t.Errorf("expected deploy variables to win, got %v", override.Environment)
+	}
+
+	if _, err := ParseRailpack("railpack.json", []byte(`{"steps": {"build": {"commands": [1]}}}`)); err == nil {
+		t.Error("expected error for invalid command")
+	}
+}
+
+func TestParseNixpacks(t *testing.T) {
+	content := `
+providers = ["...", "python"]
+
+[variables]
+PYTHONUNBUFFERED = "1"
+
+[phases.setup]
+nixPkgs = ["...", "ffmpeg"]
+aptPkgs = ["...", "libpq-dev"]
+cmds = ["pip install --upgrade pip"]
+
+[phases.build]
+cmds = ["python manage.py collectstatic --noinput"]
+
+[start]
+cmd = "gunicorn app.wsgi"
+`
+	override, err := ParseNixpacks("nixpacks.toml", []byte(content))
+	if err != nil {
+		t.Fatalf("ParseNixpacks failed: %v", err)
+	}
+
+	if override.Provider == nil || *override.Provider != "python" {
+		t.Errorf("expected provider python, got %v", override.Provider)
+	}
+	expected := map[string][]string{
+		// Without an install phase the detected install commands follow the setup commands
+		"setup": {"pip install --upgrade pip", "..."},
+		"build": {"python manage.py collectstatic --noinput"},
+		"run":   {"gunicorn app.wsgi"},
+		"apt":   {"...", "libpq-dev"},
+	}
+	actual := map[string][]string{
+		"setup": stringsOf(override.Commands.Setup),
+		"build": stringsOf(override.Commands.Build),
+		"run":   stringsOf(override.Commands.Run),
+		"apt":   stringsOf(override.Apt),
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if override.Environment["PYTHONUNBUFFERED"] != "1" {
+		t.Errorf("expected variables as environment, got %v", override.Environment)
+	}
+
+	override, err = ParseNixpacks("nixpacks.json", []byte(`{"providers": ["elixir"], "phases": {"install": {"cmds": ["mix deps.get"]}}}`))
+	if err != nil {
+		t.Fatalf("ParseNixpacks failed: %v", err)
+	}
+	if override.Provider != nil {
+		t.Errorf("expected no provider for elixir, got %s", *override.Provider)
+	}
+	if !reflect.DeepEqual(stringsOf(override.Commands.Setup), []string{"mix deps.get"}) {
+		t.Errorf("expected install commands as setup, got %v", override.Commands.Setup)
+	}
+}
+
+func TestParseBuildpack(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		provider    string
+		environment map[string]string
+		run         []string
+	}{
+		{
+			name: "schema 0.2",
+			content: `
+[_]
+schema-version = "0.2"
+
+[[io.buildpacks.group]]
+uri = "docker://gcr.io/paketo-buildpacks/ca-certificates:3.6"
+
+[[io.buildpacks.group]]
+id = "paketo-buildpacks/nodejs"
+
+[[io.buildpacks.build.env]]
+name = "BP_NODE_VERSION"
+value = "20.*"
+
+[[io.buildpacks.build.env]]
+name = "BPE_DEFAULT_NODE_ENV"
+value = "production"
+
+[[io.buildpacks.build.env]]
+name = "BPE_APPEND_PATH"
+value = "/app/bin"
+`,
+			provider:    "node",
+			environment: map[string]string{"NODE_ENV": "production"},
+		},
+		{
+			name: "schema 0.1 with Google buildpacks",
+			content: `
+[build]
+builder = "gcr.io/buildpacks/builder"
+
+[[build.env]]
+name = "GOOGLE_RUNTIME"
+value = "python"
+
+[[build.env]]
+name = "GOOGLE_ENTRYPOINT"
+value = "gunicorn -b :$PORT main:app"
+
+[[build.env]]
+name = "BPE_LOG_LEVEL"
+value = "debug"
+`,
+			provider:    "python",
+			environment: map[string]string{"LOG_LEVEL": "debug"},
+			run:         []string{"gunicorn -b :$PORT main:app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			override, err := ParseBuildpack("project.toml", []byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseBuildpack failed: %v", err)
+			}
+			if override.Provider == nil || *override.Provider != tt.provider {
+				t.Errorf("expected provider %s, got %v", tt.provider, override.Provider)
+			}
+			if !reflect.DeepEqual(override.Environment, tt.environment) {
+				t.Errorf("expected environment %v, got %v", tt.environment, override.Environment)
+			}
+			if !reflect.DeepEqual(stringsOf(override.Commands.Run), tt.run) {
+				t.Errorf("expected run %q, got %v", tt.run, override.Commands.Run)
+			}
+		})
+	}
+}
+
+func TestLoadImports(t *testing.T) {
+	fsys := source.NewMap(map[string]string{
+		"project.toml":       "[[io.buildpacks.group]]\nid = \"paketo-buildpacks/nodejs\"\n",
+		"railpack.json":      `{"deploy": {"startCommand": "node dist/server.js"}}`,
+		".devcontainer.json": `{"image": "node:20"}`,
+	})
+	overrides, err := LoadImports(fsys)
+	if err != nil {
+		t.Fatalf("LoadImports failed: %v", err)
+	}
+	var files []string
+	for _, override := range overrides {
+		files = append(files, override.File)
+	}
+	if !reflect.DeepEqual(files, []string{"project.toml", "railpack.json", ".devcontainer.json"}) {
+		t.Errorf("expected imports in precedence order, got %v", files)
+	}
+
+	_, err = LoadImports(source.NewMap(map[string]string{"nixpacks.toml": "[start\n"}))
+	devBoxErr, ok := err.(*types.DevBoxPackError)
+	if !ok || devBoxErr.Code != types.ErrorCodeInvalidOverride {
+		t.Errorf("expected %s error, got %v", types.ErrorCodeInvalidOverride, err)
+	}
+}
+
+func TestOverride_ApplyPlaceholder(t *testing.T) {
+	build := []string{"...", "npm run lint"}
+	apt := []string{"ffmpeg", "..."}
+	override := &Override{File: "railpack.json", Commands: CommandsOverride{Build: &build}, Apt: &apt}
+	plan := &types.ExecutionPlan{
+		Apt:      []string{"python3"},
+		Commands: types.Commands{Build: []string{"npm run build"}},
+	}
+
+	override.Apply(plan)
+
+	if !reflect.DeepEqual(plan.Commands.Build, []string{"npm run build", "npm run lint"}) {
+		t.Errorf("expected detected build commands first, got %v", plan.Commands.Build)
+	}
+	if !reflect.DeepEqual(plan.Apt, []string{"ffmpeg", "python3"}) {
+		t.Errorf("expected detected packages last, got %v", plan.Apt)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+)
+
+// NixpacksFileNames lists the Nixpacks configuration files looked up in the project root, in order of precedence
+var NixpacksFileNames = []string{"nixpacks.toml", "nixpacks.json"}
+
+// nixpacksConfig holds the nixpacks.toml properties that override a plan
+type nixpacksConfig struct {
+	Providers []string                 `json:"providers"`
+	Variables map[string]string        `json:"variables"`
+	Phases    map[string]nixpacksPhase `json:"phases"`
+	Start     struct {
+		Cmd *string `json:"cmd"`
+	} `json:"start"`
+}
+
+// nixpacksPhase is a build phase
+type nixpacksPhase struct {
+	Cmds    *[]string `json:"cmds"`
+	AptPkgs *[]string `json:"aptPkgs"`
+}
+
+// ParseNixpacks parses nixpacks.toml or nixpacks.json content into an override. The setup
+// and install phases become the setup commands, the build phase the build commands and
+// the start command the run command. Nix packages have no equivalent in plans and are ignored.
+func ParseNixpacks(fileName string, content []byte) (*Override, error) {
+	data := content
+	if strings.HasSuffix(fileName, ".toml") {
+		table, err := markup.ParseTOML(string(content))
+		if err != nil {
+			return nil, overrideParseError(fileName, err)
+		}
+		data, err = json.Marshal(table)
+		if err != nil {
+			return nil, overrideParseError(fileName, err)
+		}
+	}
+
+	var nixpacks nixpacksConfig
+	if err := json.Unmarshal(data, &nixpacks); err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+
+	override := &Override{File: fileName, Environment: nixpacks.Variables}
+	for _, name := range nixpacks.Providers {
+		if name == DetectedPlaceholder {
+			continue
+		}
+		if override.Provider = builderProvider(name); override.Provider != nil {
+			break
+		}
+	}
+
+	// Setup phase commands prepare the system before the install phase installs dependencies
+	setup, install := nixpacks.Phases["setup"], nixpacks.Phases["install"]
+	if setup.Cmds != nil || install.Cmds != nil {
+		commands := []string{}
+		if setup.Cmds != nil {
+			commands = append(commands, *setup.Cmds...)
+		}
+		if install.Cmds != nil {
+			commands = append(commands, *install.Cmds...)
+		} else {
+			commands = append(commands, DetectedPlaceholder)
+		}
+		override.Commands.Setup = &commands
+	}
+	override.Commands.Build = nixpacks.Phases["build"].Cmds
+	override.Apt = setup.AptPkgs
+	if nixpacks.Start.Cmd != nil {
+		override.Commands.Run = &[]string{*nixpacks.Start.Cmd}
+	}
+	return override, nil
+}
@@ -0,0 +1,199 @@
+// Package config loads repository level configuration that refines
+// the execution plan produced by detection.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// OverrideFileNames lists the override files looked up in the project root, in order of precedence
+var OverrideFileNames = []string{
+	"devbox-pack.toml",
+	".devbox-pack.toml",
+	"devbox-pack.json",
+	".devbox-pack.json",
+}
+
+// Override represents a checked-in override file.
+// Pointer and nil-able fields distinguish "not set" from "set to empty".
+type Override struct {
+	// File the override was loaded from, relative to the project root
+	File string `json:"-"`
+
+	Provider    *string           `json:"provider,omitempty"`
+	Runtime     RuntimeOverride   `json:"runtime,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Apt         *[]string         `json:"apt,omitempty"`
+	Commands    CommandsOverride  `json:"commands,omitempty"`
+	Port        *int              `json:"port,omitempty"`
+	HealthCheck *string           `json:"healthCheck,omitempty"`
+}
+
+// RuntimeOverride overrides runtime configuration
+type RuntimeOverride struct {
+	Image     *string `json:"image,omitempty"`
+	Framework *string `json:"framework,omitempty"`
+}
+
+// CommandsOverride overrides individual command phases
+type CommandsOverride struct {
+	Setup *[]string `json:"setup,omitempty"`
+	Dev   *[]string `json:"dev,omitempty"`
+	Build *[]string `json:"build,omitempty"`
+	Run   *[]string `json:"run,omitempty"`
+}
+
+// DetectedPlaceholder stands for the detected values in an overridden command phase or package list,
+// e.g. ["...", "npm run lint"] runs the detected commands first
+const DetectedPlaceholder = "..."
+
+// LoadOverride loads the first override file found in the root of fsys.
+// It returns nil without error when the project has no override file.
+func LoadOverride(fsys fs.FS) (*Override, error) {
+	return loadFirst(fsys, OverrideFileNames, ParseOverride)
+}
+
+// loadFirst parses the first of fileNames found in fsys, returning nil when there is none
+func loadFirst(fsys fs.FS, fileNames []string, parse func(fileName string, content []byte) (*Override, error)) (*Override, error) {
+	for _, name := range fileNames {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			continue
+		}
+		return parse(name, content)
+	}
+	return nil, nil
+}
+
+// ParseOverride parses override file content, choosing the format from the file extension
+func ParseOverride(fileName string, content []byte) (*Override, error) {
+	data := content
+	if strings.HasSuffix(fileName, ".toml") {
+		table, err := markup.ParseTOML(string(content))
+		if err != nil {
+			return nil, overrideParseError(fileName, err)
+		}
+		// Round-trip through JSON so both formats share one schema
+		data, err = json.Marshal(table)
+		if err != nil {
+			return nil, overrideParseError(fileName, err)
+		}
+	}
+
+	override := &Override{}
+	if err := json.Unmarshal(data, override); err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+	override.File = fileName
+	return override, nil
+}
+
+// overrideParseError wraps a parse failure into a DevBoxPackError
+func overrideParseError(fileName string, err error) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("failed to parse override file %s: %s", fileName, err.Error()),
+		types.ErrorCodeInvalidOverride,
+		map[string]interface{}{
+			"path":  fileName,
+			"error": err.Error(),
+		},
+	)
+}
+
+// Apply deep-merges the override over the plan and records the overridden fields in the plan evidence.
+// Environment keys are merged individually; every other field replaces the detected value,
+// with DetectedPlaceholder in commands and apt expanding to the detected values.
+func (o *Override) Apply(plan *types.ExecutionPlan) {
+	if o == nil || plan == nil {
+		return
+	}
+
+	var fields []string
+
+	if o.Provider != nil {
+		plan.Provider = *o.Provider
+		fields = append(fields, "provider")
+	}
+	if o.Runtime.Image != nil {
+		plan.Runtime.Image = *o.Runtime.Image
+		fields = append(fields, "runtime.image")
+	}
+	if o.Runtime.Framework != nil {
+		framework := *o.Runtime.Framework
+		plan.Runtime.Framework = &framework
+		fields = append(fields, "runtime.framework")
+	}
+
+	if len(o.Environment) > 0 {
+		if plan.Environment == nil {
+			plan.Environment = make(map[string]string)
+		}
+		keys := make([]string, 0, len(o.Environment))
+		for key := range o.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			plan.Environment[key] = o.Environment[key]
+			fields = append(fields, "environment."+key)
+		}
+	}
+
+	if o.Apt != nil {
+		plan.Apt = expandPlaceholder(*o.Apt, plan.Apt)
+		fields = append(fields, "apt")
+	}
+
+	phases := []struct {
+		name     string
+		override *[]string
+		target   *[]string
+	}{
+		{"setup", o.Commands.Setup, &plan.Commands.Setup},
+		{"dev", o.Commands.Dev, &plan.Commands.Dev},
+		{"build", o.Commands.Build, &plan.Commands.Build},
+		{"run", o.Commands.Run, &plan.Commands.Run},
+	}
+	for _, phase := range phases {
+		if phase.override != nil {
+			*phase.target = expandPlaceholder(*phase.override, *phase.target)
+			fields = append(fields, "commands."+phase.name)
+		}
+	}
+
+	if o.Port != nil {
+		plan.Port = *o.Port
+		fields = append(fields, "port")
+	}
+	if o.HealthCheck != nil {
+		plan.HealthCheck = *o.HealthCheck
+		fields = append(fields, "healthCheck")
+	}
+
+	if len(fields) > 0 {
+		plan.Evidence.Overrides = append(plan.Evidence.Overrides, types.OverrideEvidence{
+			File:   o.File,
+			Fields: fields,
+		})
+	}
+}
+
+// expandPlaceholder copies values, replacing DetectedPlaceholder with the detected values
+func expandPlaceholder(values, detected []string) []string {
+	expanded := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == DetectedPlaceholder {
+			expanded = append(expanded, detected...)
+			continue
+		}
+		expanded = append(expanded, value)
+	}
+	return expanded
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestLoadOverride_NoFile(t *testing.T) {
+	override, err := LoadOverride(source.Dir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("LoadOverride failed: %v", err)
+	}
+	if override != nil {
+		t.Errorf("expected nil override, got %+v", override)
+	}
+}
+
+func TestLoadOverride_TOML(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+provider = "node"
+port = 4000
+
+[runtime]
+image = "node:18-alpine"
+
+[environment]
+API_URL = "http://localhost"
+
+[commands]
+build = ["npm run build:prod"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "devbox-pack.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write override: %v", err)
+	}
+
+	override, err := LoadOverride(source.Dir(dir))
+	if err != nil {
+		t.Fatalf("LoadOverride failed: %v", err)
+	}
+	if override == nil {
+		t.Fatal("expected override to be loaded")
+	}
+	if override.File != "devbox-pack.toml" {
+		t.Errorf("expected file 'devbox-pack.toml', got '%s'", override.File)
+	}
+	if override.Provider == nil || *override.Provider != "node" {
+		t.Errorf("expected provider 'node', got %v", override.Provider)
+	}
+	if override.Port == nil || *override.Port != 4000 {
+		t.Errorf("expected port 4000, got %v", override.Port)
+	}
+	if override.Commands.Build == nil || len(*override.Commands.Build) != 1 {
+		t.Errorf("expected one build command, got %v", override.Commands.Build)
+	}
+	if override.Commands.Run != nil {
+		t.Errorf("expected run commands to be unset, got %v", *override.Commands.Run)
+	}
+}
+
+func TestLoadOverride_JSON(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"commands": {"run": []}, "apt": ["libpq-dev"]}`
+	if err := os.WriteFile(filepath.Join(dir, ".devbox-pack.json"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write override: %v", err)
+	}
+
+	override, err := LoadOverride(source.Dir(dir))
+	if err != nil {
+		t.Fatalf("LoadOverride failed: %v", err)
+	}
+	if override == nil || override.File != ".devbox-pack.json" {
+		t.Fatalf("expected override from .devbox-pack.json, got %+v", override)
+	}
+	if override.Commands.Run == nil || len(*override.Commands.Run) != 0 {
+		t.Errorf("expected explicitly empty run commands, got %v", override.Commands.Run)
+	}
+}
+
+func TestLoadOverride_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "devbox-pack.json"), []byte(`{"port": "abc"}`), 0644); err != nil {
+		t.Fatalf("failed to write override: %v", err)
+	}
+
+	_, err := LoadOverride(source.Dir(dir))
+	if err == nil {
+		t.Fatal("expected error for invalid override file")
+	}
+	devBoxErr, ok := err.(*types.DevBoxPackError)
+	if !ok || devBoxErr.Code != types.ErrorCodeInvalidOverride {
+		t.Errorf("expected %s error, got %v", types.ErrorCodeInvalidOverride, err)
+	}
+}
+
+func TestOverride_Apply(t *testing.T) {
+	image := "custom:latest"
+	build := []string{"make build"}
+	port := 9000
+	healthCheck := "/healthz"
+	override := &Override{
+		File:        "devbox-pack.toml",
+		Runtime:     RuntimeOverride{Image: &image},
+		Environment: map[string]string{"PORT": "9000", "EXTRA": "1"},
+		Commands:    CommandsOverride{Build: &build},
+		Port:        &port,
+		HealthCheck: &healthCheck,
+	}
+
+	plan := &types.ExecutionPlan{
+		Provider:    "go",
+		Runtime:     types.RuntimeConfig{Image: "golang:1.21-alpine"},
+		Environment: map[string]string{"PORT": "8080", "GO_ENV": "production"},
+		Commands: types.Commands{
+			Build: []string{"go build"},
+			Run:   []string{"./app"},
+		},
+		Port: 8080,
+	}
+
+	override.Apply(plan)
+
+	if plan.Runtime.Image != image {
+		t.Errorf("expected image '%s', got '%s'", image, plan.Runtime.Image)
+	}
+	if plan.Environment["PORT"] != "9000" || plan.Environment["GO_ENV"] != "production" || plan.Environment["EXTRA"] != "1" {
+		t.Errorf("environment not deep-merged: %v", plan.Environment)
+	}
+	if len(plan.Commands.Build) != 1 || plan.Commands.Build[0] != "make build" {
+		t.Errorf("expected build override, got %v", plan.Commands.Build)
+	}
+	if len(plan.Commands.Run) != 1 || plan.Commands.Run[0] != "./app" {
+		t.Errorf("expected run commands to be kept, got %v", plan.Commands.Run)
+	}
+	if plan.Port != 9000 {
+		t.Errorf("expected port 9000, got %d", plan.Port)
+	}
+	if plan.HealthCheck != "/healthz" {
+		t.Errorf("expected health check /healthz, got %q", plan.HealthCheck)
+	}
+
+	if len(plan.Evidence.Overrides) != 1 {
+		t.Fatalf("expected one override evidence entry, got %d", len(plan.Evidence.Overrides))
+	}
+	expectedFields := []string{"runtime.image", "environment.EXTRA", "environment.PORT", "commands.build", "port", "healthCheck"}
+	fields := plan.Evidence.Overrides[0].Fields
+	if len(fields) != len(expectedFields) {
+		t.Fatalf("expected fields %v, got %v", expectedFields, fields)
+	}
+	for i, field := range expectedFields {
+		if fields[i] != field {
+			t.Errorf("expected field %s at %d, got %s", field, i, fields[i])
+		}
+	}
+}
+
+func TestOverride_ApplyNil(t *testing.T) {
+	var override *Override
+	plan := &types.ExecutionPlan{Provider: "node"}
+	override.Apply(plan)
+	if len(plan.Evidence.Overrides) != 0 {
+		t.Error("nil override should not record evidence")
+	}
+}
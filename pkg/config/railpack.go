@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RailpackFileNames lists the Railpack configuration files looked up in the project root, in order of precedence
+var RailpackFileNames = []string{"railpack.json"}
+
+// railpackConfig holds the railpack.json properties that override a plan
+type railpackConfig struct {
+	Provider         string                  `json:"provider"`
+	BuildAptPackages []string                `json:"buildAptPackages"`
+	Steps            map[string]railpackStep `json:"steps"`
+	Deploy           struct {
+		StartCommand *string           `json:"startCommand"`
+		AptPackages  []string          `json:"aptPackages"`
+		Variables    map[string]string `json:"variables"`
+		Base         struct {
+			Image string `json:"image"`
+		} `json:"base"`
+	} `json:"deploy"`
+}
+
+// railpackStep is a build step, whose commands are strings or command objects
+type railpackStep struct {
+	Commands  *[]json.RawMessage `json:"commands"`
+	Variables map[string]string  `json:"variables"`
+}
+
+// ParseRailpack parses railpack.json content into an override. The install and build
+// steps become the setup and build commands and the deploy start command the run command.
+func ParseRailpack(fileName string, content []byte) (*Override, error) {
+	var railpack railpackConfig
+	if err := json.Unmarshal(content, &railpack); err != nil {
+		return nil, overrideParseError(fileName, err)
+	}
+
+	override := &Override{File: fileName}
+	override.Provider = builderProvider(railpack.Provider)
+	if railpack.Deploy.Base.Image != "" {
+		image := railpack.Deploy.Base.Image
+		override.Runtime.Image = &image
+	}
+
+	phases := []struct {
+		step   string
+		target **[]string
+	}{
+		{"install", &override.Commands.Setup},
+		{"build", &override.Commands.Build},
+	}
+	for _, phase := range phases {
+		step, ok := railpack.Steps[phase.step]
+		if !ok || step.Commands == nil {
+			continue
+		}
+		commands, err := railpackCommands(*step.Commands)
+		if err != nil {
+			return nil, overrideParseError(fileName, fmt.Errorf("steps.%s.commands: %w", phase.step, err))
+		}
+		*phase.target = &commands
+	}
+	if railpack.Deploy.StartCommand != nil {
+		override.Commands.Run = &[]string{*railpack.Deploy.StartCommand}
+	}
+
+	if railpack.BuildAptPackages != nil || railpack.Deploy.AptPackages != nil {
+		apt := append(append([]string(nil), railpack.BuildAptPackages...), railpack.Deploy.AptPackages...)
+		override.Apt = &apt
+	}
+
+	// Build step variables first, the deploy variables the application runs with win
+	for _, name := range []string{"install", "build"} {
+		for key, value := range railpack.Steps[name].Variables {
+			if override.Environment == nil {
+				override.Environment = make(map[string]string)
+			}
+			override.Environment[key] = value
+		}
+	}
+	for key, value := range railpack.Deploy.Variables {
+		if override.Environment == nil {
+			override.Environment = make(map[string]string)
+		}
+		override.Environment[key] = value
+	}
+	return override, nil
+}
+
+// railpackCommands reads the shell commands of a step. Besides shell command strings
+// Railpack steps copy files and extend PATH, which plans leave to the image.
+func railpackCommands(raw []json.RawMessage) ([]string, error) {
+	commands := []string{}
+	for _, entry := range raw {
+		var command string
+		if err := json.Unmarshal(entry, &command); err == nil {
+			commands = append(commands, command)
+			continue
+		}
+		var object struct {
+			Cmd string `json:"cmd"`
+		}
+		if err := json.Unmarshal(entry, &object); err != nil {
+			return nil, fmt.Errorf("expected a string or a command object")
+		}
+		if object.Cmd != "" {
+			commands = append(commands, object.Cmd)
+		}
+	}
+	return commands, nil
+}
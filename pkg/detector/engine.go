@@ -5,8 +5,13 @@
 package detector
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"runtime"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/labring/devbox-pack/pkg/providers"
 	"github.com/labring/devbox-pack/pkg/types"
@@ -15,38 +20,41 @@ import (
 // DetectionEngine detection engine
 // Responsible for coordinating all Providers for project detection
 type DetectionEngine struct {
-	providers map[string]Provider
+	providers   map[string]Provider
+	concurrency int
 }
 
 // NewDetectionEngine creates a new detection engine
 func NewDetectionEngine() *DetectionEngine {
 	engine := &DetectionEngine{
-		providers: make(map[string]Provider),
+		providers:   make(map[string]Provider),
+		concurrency: runtime.GOMAXPROCS(0),
 	}
 	engine.initializeProviders()
 	return engine
 }
 
-// initializeProviders initializes all Providers
+// initializeProviders initializes every registered built-in Provider
 func (e *DetectionEngine) initializeProviders() {
-	providerList := []Provider{
-		providers.NewStaticFileProvider(), // Move StaticFileProvider to last, lower priority
-		providers.NewNodeProvider(),
-		providers.NewPythonProvider(),
-		providers.NewJavaProvider(),
-		providers.NewGoProvider(),
-		providers.NewPHPProvider(),
-		providers.NewRubyProvider(),
-		providers.NewDenoProvider(),
-		providers.NewRustProvider(),
-		providers.NewShellProvider(),
-	}
-
-	for _, provider := range providerList {
+	for _, provider := range providers.NewRegistered() {
 		e.providers[provider.GetName()] = provider
 	}
 }
 
+// RegisterProvider adds a Provider to the engine, replacing any Provider of the same name
+func (e *DetectionEngine) RegisterProvider(provider Provider) {
+	e.providers[provider.GetName()] = provider
+}
+
+// SetConcurrency sets the maximum number of Providers run at the same time.
+// Values below 1 run Providers sequentially.
+func (e *DetectionEngine) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	e.concurrency = concurrency
+}
+
 // GetAvailableProviders gets all available Providers
 func (e *DetectionEngine) GetAvailableProviders() []string {
 	var names []string
@@ -63,77 +71,229 @@ func (e *DetectionEngine) GetProvider(name string) (Provider, bool) {
 	return provider, exists
 }
 
+// DetectionReport collects every detection result together with the
+// diagnostics raised by providers along the way
+type DetectionReport struct {
+	// Matched and valid detection results
+	Results []*types.DetectResult `json:"results"`
+	// Provider failures and other non-fatal problems
+	Diagnostics []types.Diagnostic `json:"diagnostics,omitempty"`
+	// Every Provider run in priority order, including non-matching ones
+	Providers []ProviderRun `json:"providers,omitempty"`
+	// Scoring breakdown of every Provider run, only recorded with CLIOptions.Explain
+	Explanations []types.ProviderExplanation `json:"explanations,omitempty"`
+}
+
+// ProviderRun records how a single Provider fared during detection
+type ProviderRun struct {
+	// Provider name
+	Name string `json:"name"`
+	// Wall-clock time spent in Detect
+	Duration time.Duration `json:"duration"`
+	// Whether the Provider matched the project
+	Matched bool `json:"matched"`
+	// Failure message, empty on success
+	Error string `json:"error,omitempty"`
+}
+
 // DetectProject detects project language and framework
 func (e *DetectionEngine) DetectProject(
-	projectPath string,
+	ctx context.Context,
+	fsys fs.FS,
 	files []types.FileInfo,
-	gitHandler interface{},
 	options *types.CLIOptions,
 ) ([]*types.DetectResult, error) {
-	var results []*types.DetectResult
+	report, err := e.Detect(ctx, fsys, files, options)
+	if err != nil {
+		return nil, err
+	}
+	return report.Results, nil
+}
+
+// Detect detects project language and framework and reports provider diagnostics
+// instead of printing them. Detection stops with the context error once ctx is done.
+func (e *DetectionEngine) Detect(
+	ctx context.Context,
+	fsys fs.FS,
+	files []types.FileInfo,
+	options *types.CLIOptions,
+) (*DetectionReport, error) {
+	report := &DetectionReport{}
+	explain := options != nil && options.Explain
 
 	if options != nil && options.Provider != nil && *options.Provider != "" {
 		// Use specified Provider
 		provider, exists := e.providers[*options.Provider]
 		if !exists {
-			return nil, fmt.Errorf("unknown Provider: %s, available Providers: %v",
-				*options.Provider, e.GetAvailableProviders())
+			return nil, types.NewDevBoxPackError(
+				fmt.Sprintf("unknown Provider: %s, available Providers: %v", *options.Provider, e.GetAvailableProviders()),
+				types.ErrorCodeInvalidProvider,
+				map[string]interface{}{"provider": *options.Provider},
+			)
 		}
 
-		result, err := e.runProvider(provider, projectPath, files, gitHandler)
-		if err != nil {
-			return nil, err
+		outcome := e.runProvider(ctx, provider, fsys, files)
+		report.Providers = append(report.Providers, outcome.run)
+		report.record(provider, outcome, explain)
+		if outcome.err != nil {
+			return nil, outcome.err
 		}
-		if result != nil {
-			results = append(results, result)
+		if outcome.result != nil {
+			report.Results = append(report.Results, outcome.result)
+		} else {
+			report.Diagnostics = append(report.Diagnostics, invalidResultDiagnostic(provider))
 		}
-	} else {
-		// Run all Providers
-		providers := e.getProvidersByPriority()
-
-		for _, provider := range providers {
-			result, err := e.runProvider(provider, projectPath, files, gitHandler)
-			if err != nil {
-				fmt.Printf("Provider %s detection failed: %v\n", provider.GetName(), err)
-				// Continue with other Providers
-				continue
-			}
-			if result != nil && result.Matched {
-				results = append(results, result)
-			}
+		return report, nil
+	}
+
+	// Run all Providers concurrently, then collect in priority order
+	providers := e.getProvidersByPriority()
+	outcomes := e.runProviders(ctx, providers, fsys, files)
+	if err := types.ContextError(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, provider := range providers {
+		outcome := outcomes[i]
+		report.Providers = append(report.Providers, outcome.run)
+		report.record(provider, outcome, explain)
+		if outcome.err != nil {
+			// Record the failure and continue with other Providers
+			report.Diagnostics = append(report.Diagnostics, types.Diagnostic{
+				Severity: types.SeverityWarning,
+				Source:   provider.GetName(),
+				Message:  fmt.Sprintf("Provider %s detection failed: %v", provider.GetName(), outcome.err),
+			})
+			continue
+		}
+		if outcome.result == nil {
+			report.Diagnostics = append(report.Diagnostics, invalidResultDiagnostic(provider))
+			continue
+		}
+		if outcome.result.Matched {
+			report.Results = append(report.Results, outcome.result)
 		}
 	}
 
-	// Filter valid results
-	var validResults []*types.DetectResult
-	for _, result := range results {
-		if e.isValidDetectResult(result) {
-			validResults = append(validResults, result)
+	return report, nil
+}
+
+// record adds the scoring breakdown of a Provider run when explaining.
+// Otherwise the scoring is dropped so results stay as compact as before.
+func (r *DetectionReport) record(provider Provider, outcome providerOutcome, explain bool) {
+	if !explain {
+		if outcome.result != nil {
+			outcome.result.Scoring = nil
 		}
+		return
+	}
+
+	explanation := types.ProviderExplanation{
+		Provider: provider.GetName(),
+		Priority: provider.GetPriority(),
+		Matched:  outcome.run.Matched,
+		Error:    outcome.run.Error,
+	}
+	if outcome.result != nil {
+		explanation.Confidence = outcome.result.Confidence
+		explanation.Scoring = outcome.result.Scoring
+	}
+	r.Explanations = append(r.Explanations, explanation)
+}
+
+// providerOutcome is the result of running a single Provider
+type providerOutcome struct {
+	result *types.DetectResult
+	err    error
+	run    ProviderRun
+}
+
+// runProviders runs Providers on a bounded worker pool.
+// Outcomes are returned in the same order as providers.
+func (e *DetectionEngine) runProviders(
+	ctx context.Context,
+	providers []Provider,
+	fsys fs.FS,
+	files []types.FileInfo,
+) []providerOutcome {
+	outcomes := make([]providerOutcome, len(providers))
+
+	workers := e.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(providers) {
+		workers = len(providers)
 	}
 
-	return validResults, nil
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				outcomes[i] = e.runProvider(ctx, providers[i], fsys, files)
+			}
+		}()
+	}
+	for i := range providers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return outcomes
 }
 
-// runProvider runs a single Provider
+// invalidResultDiagnostic reports a provider returning an unusable result
+func invalidResultDiagnostic(provider Provider) types.Diagnostic {
+	return types.Diagnostic{
+		Severity: types.SeverityWarning,
+		Source:   provider.GetName(),
+		Message:  fmt.Sprintf("Provider %s returned invalid detection result", provider.GetName()),
+	}
+}
+
+// runProvider runs a single Provider and times it.
+// The outcome result is nil when the Provider fails or returns an invalid result.
 func (e *DetectionEngine) runProvider(
+	ctx context.Context,
 	provider Provider,
-	projectPath string,
+	fsys fs.FS,
 	files []types.FileInfo,
-	gitHandler interface{},
-) (*types.DetectResult, error) {
-	result, err := provider.Detect(projectPath, files, interface{}(gitHandler))
+) providerOutcome {
+	outcome := providerOutcome{run: ProviderRun{Name: provider.GetName()}}
+	if err := types.ContextError(ctx); err != nil {
+		outcome.err = err
+		outcome.run.Error = err.Error()
+		return outcome
+	}
+
+	start := time.Now()
+	result, err := provider.Detect(ctx, fsys, files)
+	outcome.run.Duration = time.Since(start)
 	if err != nil {
-		return nil, fmt.Errorf("Provider %s execution failed: %w", provider.GetName(), err)
+		outcome.err = fmt.Errorf("Provider %s execution failed: %w", provider.GetName(), err)
+		outcome.run.Error = outcome.err.Error()
+		return outcome
 	}
 
 	// Validate result
 	if !e.isValidDetectResult(result) {
-		fmt.Printf("Provider %s returned invalid detection result\n", provider.GetName())
-		return nil, nil
+		outcome.run.Error = "invalid detection result"
+		return outcome
 	}
 
-	return result, nil
+	// Record which Provider produced the result so plans are generated by the same Provider
+	if result.Provider == nil {
+		name := provider.GetName()
+		result.Provider = &name
+	}
+
+	outcome.result = result
+	outcome.run.Matched = result.Matched
+	return outcome
 }
 
 // getProvidersByPriority gets Provider list sorted by priority
@@ -143,9 +303,12 @@ func (e *DetectionEngine) getProvidersByPriority() []Provider {
 		providers = append(providers, provider)
 	}
 
-	// Sort by priority (smaller number means higher priority)
+	// Sort by priority (smaller number means higher priority), then by name
 	sort.Slice(providers, func(i, j int) bool {
-		return providers[i].GetPriority() < providers[j].GetPriority()
+		if providers[i].GetPriority() != providers[j].GetPriority() {
+			return providers[i].GetPriority() < providers[j].GetPriority()
+		}
+		return providers[i].GetName() < providers[j].GetName()
 	})
 
 	return providers
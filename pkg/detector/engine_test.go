@@ -1,10 +1,15 @@
 package detector
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -58,8 +63,7 @@ func TestDetectProject_NodeExpress(t *testing.T) {
 		Verbose: true,
 	}
 
-	gitHandler := git.NewGitHandler()
-	results, err := engine.DetectProject(projectPath, files, gitHandler, options)
+	results, err := engine.DetectProject(context.Background(), source.Dir(projectPath), files, options)
 	if err != nil {
 		t.Fatalf("DetectProject failed: %v", err)
 	}
@@ -109,8 +113,7 @@ func TestDetectProject_Python(t *testing.T) {
 		Verbose: true,
 	}
 
-	gitHandler := git.NewGitHandler()
-	results, err := engine.DetectProject(projectPath, files, gitHandler, options)
+	results, err := engine.DetectProject(context.Background(), source.Dir(projectPath), files, options)
 	if err != nil {
 		t.Fatalf("DetectProject failed: %v", err)
 	}
@@ -160,8 +163,7 @@ func TestDetectProject_Static(t *testing.T) {
 		Verbose: true,
 	}
 
-	gitHandler := git.NewGitHandler()
-	results, err := engine.DetectProject(projectPath, files, gitHandler, options)
+	results, err := engine.DetectProject(context.Background(), source.Dir(projectPath), files, options)
 	if err != nil {
 		t.Fatalf("DetectProject failed: %v", err)
 	}
@@ -183,6 +185,85 @@ func TestDetectProject_Static(t *testing.T) {
 	}
 }
 
+// stubProvider is a Provider with canned detection behaviour
+type stubProvider struct {
+	name     string
+	priority int
+	matched  bool
+	delay    time.Duration
+	err      error
+}
+
+func (p *stubProvider) GetName() string     { return p.name }
+func (p *stubProvider) GetLanguage() string { return p.name }
+func (p *stubProvider) GetPriority() int    { return p.priority }
+
+func (p *stubProvider) Detect(_ context.Context, _ fs.FS, _ []types.FileInfo) (*types.DetectResult, error) {
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &types.DetectResult{Matched: p.matched, Language: p.name, Confidence: 0.5}, nil
+}
+
+func (p *stubProvider) GenerateCommands(*types.DetectResult, types.CLIOptions) types.Commands {
+	return types.Commands{}
+}
+
+func (p *stubProvider) GenerateEnvironment(*types.DetectResult) map[string]string {
+	return map[string]string{}
+}
+
+func (p *stubProvider) NeedsNativeCompilation(*types.DetectResult) bool { return false }
+
+func TestDetect_ConcurrentOrderingAndTimings(t *testing.T) {
+	stubs := []*stubProvider{
+		{name: "slow", priority: 1, matched: true, delay: 20 * time.Millisecond},
+		{name: "beta", priority: 2, matched: true},
+		{name: "alpha", priority: 2, matched: true, delay: 10 * time.Millisecond},
+		{name: "broken", priority: 3, err: errors.New("boom")},
+		{name: "miss", priority: 4},
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		engine := &DetectionEngine{providers: make(map[string]Provider)}
+		engine.SetConcurrency(concurrency)
+		for _, stub := range stubs {
+			engine.providers[stub.name] = stub
+		}
+
+		report, err := engine.Detect(context.Background(), source.NewMap(nil), nil, nil)
+		if err != nil {
+			t.Fatalf("Detect failed with concurrency %d: %v", concurrency, err)
+		}
+
+		var matched []string
+		for _, result := range report.Results {
+			matched = append(matched, result.Language)
+		}
+		if want := []string{"slow", "alpha", "beta"}; !reflect.DeepEqual(matched, want) {
+			t.Errorf("concurrency %d: expected results %v, got %v", concurrency, want, matched)
+		}
+
+		var runs []string
+		for _, run := range report.Providers {
+			runs = append(runs, run.Name)
+		}
+		if want := []string{"slow", "alpha", "beta", "broken", "miss"}; !reflect.DeepEqual(runs, want) {
+			t.Errorf("concurrency %d: expected provider runs %v, got %v", concurrency, want, runs)
+		}
+		if report.Providers[0].Duration < 20*time.Millisecond {
+			t.Errorf("concurrency %d: expected slow provider timing >= 20ms, got %s", concurrency, report.Providers[0].Duration)
+		}
+		if report.Providers[3].Error == "" || report.Providers[3].Matched {
+			t.Errorf("concurrency %d: expected broken provider failure, got %+v", concurrency, report.Providers[3])
+		}
+		if len(report.Diagnostics) != 1 || report.Diagnostics[0].Source != "broken" {
+			t.Errorf("concurrency %d: expected one diagnostic for broken, got %+v", concurrency, report.Diagnostics)
+		}
+	}
+}
+
 func TestGetBestResult(t *testing.T) {
 	engine := NewDetectionEngine()
 
@@ -295,3 +376,57 @@ func TestGetDetectionStats(t *testing.T) {
 		t.Errorf("expected 2 frameworks, got %d", len(stats.Frameworks))
 	}
 }
+
+func TestDetect_Explain(t *testing.T) {
+	engine := NewDetectionEngine()
+	fsys := source.NewMap(map[string]string{
+		"package.json": `{"dependencies": {"express": "^4.18.0"}}`,
+		"index.html":   "<html></html>",
+	})
+	scanned, err := source.Scan(context.Background(), fsys, nil)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	files := make([]types.FileInfo, len(scanned))
+	for i, file := range scanned {
+		files[i] = *file
+	}
+
+	report, err := engine.Detect(context.Background(), fsys, files, &types.CLIOptions{Explain: true})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if len(report.Explanations) != len(engine.GetAvailableProviders()) {
+		t.Fatalf("expected one explanation per provider, got %d", len(report.Explanations))
+	}
+
+	explanations := make(map[string]types.ProviderExplanation)
+	for _, explanation := range report.Explanations {
+		explanations[explanation.Provider] = explanation
+	}
+	node := explanations["node"]
+	if !node.Matched || node.Scoring == nil || len(node.Scoring.Indicators) == 0 || node.Scoring.Indicators[0].Description != "package.json" {
+		t.Errorf("unexpected node explanation: %+v", node)
+	}
+	static := explanations["staticfile"]
+	if static.Matched || static.Scoring == nil || len(static.Scoring.Adjustments) != 1 {
+		t.Fatalf("expected penalised staticfile explanation, got %+v", static)
+	}
+	if static.Scoring.Confidence != static.Scoring.RawConfidence*static.Scoring.Adjustments[0].Factor {
+		t.Errorf("expected adjusted confidence, got %+v", static.Scoring)
+	}
+
+	// Without explain the scoring is not kept
+	report, err = engine.Detect(context.Background(), fsys, files, &types.CLIOptions{})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if len(report.Explanations) != 0 {
+		t.Errorf("expected no explanations, got %d", len(report.Explanations))
+	}
+	for _, result := range report.Results {
+		if result.Scoring != nil {
+			t.Errorf("expected no scoring on %s result", result.Language)
+		}
+	}
+}
@@ -3,16 +3,8 @@
 package detector
 
 import (
-	"github.com/labring/devbox-pack/pkg/types"
+	"github.com/labring/devbox-pack/pkg/providers"
 )
 
-// Provider interface definition
-type Provider interface {
-	GetName() string
-	GetLanguage() string
-	GetPriority() int
-	Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error)
-	GenerateCommands(result *types.DetectResult, options types.CLIOptions) types.Commands
-	GenerateEnvironment(result *types.DetectResult) map[string]string
-	NeedsNativeCompilation(result *types.DetectResult) bool
-}
+// Provider interface definition, see providers.Provider
+type Provider = providers.Provider
@@ -0,0 +1,328 @@
+/**
+ * DevBox Pack Execution Plan Generator - Monorepo Service Discovery
+ */
+
+package detector
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// RootPath is the service path used for the project root itself
+const RootPath = "."
+
+// projectManifests are files that mark an independent project root
+var projectManifests = map[string]bool{
+	"package.json":     true,
+	"go.mod":           true,
+	"pyproject.toml":   true,
+	"requirements.txt": true,
+	"Pipfile":          true,
+	"setup.py":         true,
+	"Cargo.toml":       true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"composer.json":    true,
+	"Gemfile":          true,
+	"deno.json":        true,
+	"deno.jsonc":       true,
+}
+
+// workspaceMarkers are files that declare the members of a workspace rooted in their directory
+var workspaceMarkers = []string{
+	"pnpm-workspace.yaml",
+	"lerna.json",
+	"go.work",
+	"settings.gradle",
+	"settings.gradle.kts",
+}
+
+var (
+	mavenModulePattern   = regexp.MustCompile(`<module>\s*([^<]+?)\s*</module>`)
+	gradleIncludePattern = regexp.MustCompile(`(?m)^\s*include\b[\s(]*(.*)$`)
+	quotedPattern        = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+// workspace is a workspace root and the globs of its member directories
+type workspace struct {
+	root string
+	// Slash-separated globs relative to root; those starting with ! exclude directories
+	members []string
+}
+
+// FindServiceRoots identifies independent project roots in fsys.
+// Members of a workspace (npm/pnpm/lerna workspaces, go.work, Cargo and
+// Maven/Gradle multi-module builds) are folded into its root so a workspace is
+// reported as one service; other projects below a workspace root stay services
+// of their own. Paths are slash-separated, relative to the root of fsys and
+// sorted; the root itself is reported as RootPath.
+func FindServiceRoots(fsys fs.FS, files []types.FileInfo) []string {
+	candidates := make(map[string]bool)
+	for _, file := range files {
+		if file.IsDirectory {
+			continue
+		}
+		if projectManifests[file.Name] || isWorkspaceMarker(file.Name) {
+			candidates[path.Dir(file.Path)] = true
+		}
+	}
+
+	dirs := make([]string, 0, len(candidates))
+	for dir := range candidates {
+		dirs = append(dirs, dir)
+	}
+	// Parents sort before children so workspace roots are seen first
+	sort.Slice(dirs, func(i, j int) bool {
+		di, dj := pathDepth(dirs[i]), pathDepth(dirs[j])
+		if di != dj {
+			return di < dj
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	fileSet := make(map[string]bool, len(files))
+	for _, file := range files {
+		fileSet[file.Path] = true
+	}
+
+	var workspaces []workspace
+	var roots []string
+	for _, dir := range dirs {
+		if memberOfAny(dir, workspaces) {
+			continue
+		}
+		roots = append(roots, dir)
+		if members := workspaceMembers(fsys, dir, fileSet); len(members) > 0 {
+			workspaces = append(workspaces, workspace{root: dir, members: members})
+		}
+	}
+
+	sort.Strings(roots)
+	return roots
+}
+
+// workspaceMembers returns the member globs that the manifests of dir declare,
+// nil when dir is not a workspace root
+func workspaceMembers(fsys fs.FS, dir string, fileSet map[string]bool) []string {
+	read := func(name string) (string, bool) {
+		if !fileSet[path.Join(dir, name)] {
+			return "", false
+		}
+		content, err := source.ReadText(fsys, path.Join(dir, name))
+		return content, err == nil
+	}
+	var members []string
+
+	if content, ok := read("package.json"); ok {
+		var packageJSON struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal([]byte(content), &packageJSON) == nil && packageJSON.Workspaces != nil {
+			// Yarn also accepts {"packages": [...]}
+			var globs []string
+			var object struct {
+				Packages []string `json:"packages"`
+			}
+			if json.Unmarshal(packageJSON.Workspaces, &globs) != nil && json.Unmarshal(packageJSON.Workspaces, &object) == nil {
+				globs = object.Packages
+			}
+			members = append(members, globs...)
+		}
+	}
+
+	if content, ok := read("pnpm-workspace.yaml"); ok {
+		if document, err := markup.ParseYAML(content); err == nil {
+			members = append(members, stringList(document["packages"])...)
+		}
+	}
+
+	if content, ok := read("lerna.json"); ok {
+		var lerna struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal([]byte(content), &lerna) == nil {
+			if lerna.Packages == nil {
+				lerna.Packages = []string{"packages/*"}
+			}
+			members = append(members, lerna.Packages...)
+		}
+	}
+
+	if content, ok := read("go.work"); ok {
+		members = append(members, goWorkUses(content)...)
+	}
+
+	if content, ok := read("Cargo.toml"); ok {
+		if document, err := markup.ParseTOML(content); err == nil {
+			if cargoWorkspace, ok := document["workspace"].(map[string]interface{}); ok {
+				members = append(members, stringList(cargoWorkspace["members"])...)
+				for _, excluded := range stringList(cargoWorkspace["exclude"]) {
+					members = append(members, "!"+excluded)
+				}
+			}
+		}
+	}
+
+	if content, ok := read("pom.xml"); ok {
+		for _, match := range mavenModulePattern.FindAllStringSubmatch(content, -1) {
+			members = append(members, match[1])
+		}
+	}
+
+	for _, settings := range []string{"settings.gradle", "settings.gradle.kts"} {
+		if content, ok := read(settings); ok {
+			for _, include := range gradleIncludePattern.FindAllStringSubmatch(content, -1) {
+				for _, project := range quotedPattern.FindAllStringSubmatch(include[1], -1) {
+					// Project paths such as :services:api name directories
+					members = append(members, strings.ReplaceAll(strings.TrimPrefix(project[1], ":"), ":", "/"))
+				}
+			}
+		}
+	}
+
+	return members
+}
+
+// goWorkUses returns the module directories of the use directives of a go.work file
+func goWorkUses(content string) []string {
+	var uses []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if index := strings.Index(line, "//"); index >= 0 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case inBlock && len(fields) == 1 && fields[0] == ")":
+			inBlock = false
+		case inBlock && len(fields) > 0:
+			uses = append(uses, strings.Trim(fields[0], `"`))
+		case len(fields) == 2 && fields[0] == "use" && fields[1] == "(":
+			inBlock = true
+		case len(fields) == 2 && fields[0] == "use":
+			uses = append(uses, strings.Trim(fields[1], `"`))
+		}
+	}
+	return uses
+}
+
+// stringList returns the strings of a parsed YAML or TOML array
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var list []string
+	for _, item := range items {
+		if text, ok := item.(string); ok {
+			list = append(list, text)
+		}
+	}
+	return list
+}
+
+// memberOfAny checks whether dir is, or is below, a member of any of the workspaces
+func memberOfAny(dir string, workspaces []workspace) bool {
+	for _, w := range workspaces {
+		if !insideAny(dir, []string{w.root}) {
+			continue
+		}
+		rel := dir
+		if w.root != RootPath {
+			rel = strings.TrimPrefix(dir, w.root+"/")
+		}
+		// A directory below a member belongs to that member; the closest
+		// matching directory decides, so exclusions apply below broader globs
+		segments := strings.Split(rel, "/")
+		for i := len(segments); i > 0; i-- {
+			if member, decided := isMember(strings.Join(segments[:i], "/"), w.members); decided {
+				if member {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+// isMember checks rel against the member globs: decided is false when no glob
+// matches, member is false when an exclusion does
+func isMember(rel string, members []string) (member, decided bool) {
+	for _, glob := range members {
+		excluded := strings.HasPrefix(glob, "!")
+		pattern := path.Clean(strings.TrimPrefix(glob, "!"))
+		if !matchGlob(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			continue
+		}
+		if excluded {
+			return false, true
+		}
+		member, decided = true, true
+	}
+	return member, decided
+}
+
+// matchGlob matches path segments against glob segments, where ** matches any
+// number of segments
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
+
+// isWorkspaceMarker checks if the file name is a workspace marker
+func isWorkspaceMarker(name string) bool {
+	for _, marker := range workspaceMarkers {
+		if name == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// insideAny checks if dir is strictly below any of the given roots
+func insideAny(dir string, roots []string) bool {
+	for _, root := range roots {
+		if root == RootPath {
+			if dir != RootPath {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(dir, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// pathDepth returns the number of path elements in a relative directory
+func pathDepth(dir string) int {
+	if dir == RootPath {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
@@ -0,0 +1,142 @@
+package detector
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// writeProject builds an in-memory project and returns it with its scanned file list
+func writeProject(t *testing.T, files map[string]string) (fs.FS, []types.FileInfo) {
+	t.Helper()
+	fsys := source.NewMap(files)
+
+	scanned, err := source.Scan(context.Background(), fsys, &types.ScanOptions{Depth: 4, MaxFiles: 1000})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	fileInfos := make([]types.FileInfo, len(scanned))
+	for i, file := range scanned {
+		fileInfos[i] = *file
+	}
+	return fsys, fileInfos
+}
+
+func TestFindServiceRoots_IndependentServices(t *testing.T) {
+	fsys, files := writeProject(t, map[string]string{
+		"README.md":               "# monorepo",
+		"frontend/package.json":   `{"name": "frontend"}`,
+		"backend/go.mod":          "module example.com/backend\n\ngo 1.21\n",
+		"worker/pyproject.toml":   "[project]\nname = \"worker\"\n",
+		"worker/requirements.txt": "celery\n",
+	})
+
+	roots := FindServiceRoots(fsys, files)
+	expected := []string{"backend", "frontend", "worker"}
+	if !reflect.DeepEqual(roots, expected) {
+		t.Errorf("expected roots %v, got %v", expected, roots)
+	}
+}
+
+func TestFindServiceRoots_Workspaces(t *testing.T) {
+	fsys, files := writeProject(t, map[string]string{
+		"web/package.json":             `{"name": "web", "workspaces": ["packages/*"]}`,
+		"web/packages/ui/package.json": `{"name": "ui"}`,
+		"tools/go.work":                "go 1.21\n\nuse ./a\n",
+		"tools/a/go.mod":               "module example.com/a\n",
+		"engine/Cargo.toml":            "[workspace]\nmembers = [\"core\"]\n",
+		"engine/core/Cargo.toml":       "[package]\nname = \"core\"\n",
+		"api/package.json":             `{"name": "api"}`,
+	})
+
+	roots := FindServiceRoots(fsys, files)
+	expected := []string{"api", "engine", "tools", "web"}
+	if !reflect.DeepEqual(roots, expected) {
+		t.Errorf("expected roots %v, got %v", expected, roots)
+	}
+}
+
+func TestFindServiceRoots_RootWorkspace(t *testing.T) {
+	fsys, files := writeProject(t, map[string]string{
+		"package.json":             `{"name": "root", "private": true}`,
+		"pnpm-workspace.yaml":      "packages:\n  - apps/*\n",
+		"apps/site/package.json":   `{"name": "site"}`,
+		"apps/server/package.json": `{"name": "server"}`,
+	})
+
+	roots := FindServiceRoots(fsys, files)
+	expected := []string{RootPath}
+	if !reflect.DeepEqual(roots, expected) {
+		t.Errorf("expected roots %v, got %v", expected, roots)
+	}
+}
+
+func TestFindServiceRoots_WorkspaceWithOtherServices(t *testing.T) {
+	fsys, files := writeProject(t, map[string]string{
+		"package.json":             `{"name": "root", "private": true, "workspaces": ["packages/*"]}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+		"frontend/package.json":    `{"name": "frontend"}`,
+		"backend/go.mod":           "module example.com/backend\n\ngo 1.21\n",
+		"worker/requirements.txt":  "celery\n",
+	})
+
+	// Only the declared members are folded into the root workspace
+	roots := FindServiceRoots(fsys, files)
+	expected := []string{RootPath, "backend", "frontend", "worker"}
+	if !reflect.DeepEqual(roots, expected) {
+		t.Errorf("expected roots %v, got %v", expected, roots)
+	}
+}
+
+func TestFindServiceRoots_WorkspaceMembers(t *testing.T) {
+	fsys, files := writeProject(t, map[string]string{
+		"js/lerna.json":                        `{"version": "1.0.0"}`,
+		"js/packages/a/package.json":           `{"name": "a"}`,
+		"js/site/package.json":                 `{"name": "site"}`,
+		"gomod/go.work":                        "go 1.21\n\nuse (\n\t./api // service\n\t./lib\n)\n",
+		"gomod/api/go.mod":                     "module example.com/api\n",
+		"gomod/lib/go.mod":                     "module example.com/lib\n",
+		"gomod/tool/go.mod":                    "module example.com/tool\n",
+		"rust/Cargo.toml":                      "[workspace]\nmembers = [\n  \"crates/*\",\n]\nexclude = [\"crates/bench\"]\n",
+		"rust/crates/core/Cargo.toml":          "[package]\nname = \"core\"\n",
+		"rust/crates/bench/Cargo.toml":         "[package]\nname = \"bench\"\n",
+		"java/pom.xml":                         "<project><modules><module>core</module></modules></project>",
+		"java/core/pom.xml":                    "<project/>",
+		"java/docs/package.json":               `{"name": "docs"}`,
+		"kotlin/settings.gradle.kts":           "rootProject.name = \"app\"\ninclude(\":services:api\", \"web\")\n",
+		"kotlin/services/api/build.gradle.kts": "",
+		"kotlin/web/build.gradle.kts":          "",
+		"kotlin/scripts/package.json":          `{"name": "scripts"}`,
+		"pnpm/pnpm-workspace.yaml":             "packages:\n  - 'apps/**'\n  - '!apps/legacy'\n",
+		"pnpm/apps/web/site/package.json":      `{"name": "site"}`,
+		"pnpm/apps/legacy/package.json":        `{"name": "legacy"}`,
+	})
+
+	roots := FindServiceRoots(fsys, files)
+	expected := []string{
+		"gomod", "gomod/tool",
+		"java", "java/docs",
+		"js", "js/site",
+		"kotlin", "kotlin/scripts",
+		"pnpm", "pnpm/apps/legacy",
+		"rust", "rust/crates/bench",
+	}
+	if !reflect.DeepEqual(roots, expected) {
+		t.Errorf("expected roots %v, got %v", expected, roots)
+	}
+}
+
+func TestFindServiceRoots_NoManifests(t *testing.T) {
+	fsys, files := writeProject(t, map[string]string{
+		"docs/index.md": "# docs",
+	})
+
+	roots := FindServiceRoots(fsys, files)
+	if len(roots) != 0 {
+		t.Errorf("expected no roots, got %v", roots)
+	}
+}
@@ -0,0 +1,455 @@
+/**
+ * DevBox Pack Execution Plan Generator - CI Pipeline Formatters
+ */
+
+package formatters
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// ciRuntime describes how GitHub Actions installs a language runtime
+type ciRuntime struct {
+	// Display name of the setup step
+	name string
+	// Setup action and the input receiving Runtime.Version
+	action       string
+	versionInput string
+	// Further inputs of the setup action
+	inputs map[string]string
+	// Input enabling the setup action's dependency cache, "" when it has none
+	cacheInput string
+	// Input receiving the cache key files, "" when the action finds them itself
+	dependencyInput string
+	// Input receiving the project directory, "" when the action does not need it
+	directoryInput string
+}
+
+// ciRuntimes are the runtimes GitHub Actions sets up, keyed by language. Other
+// languages run with the tools of the runner image.
+var ciRuntimes = map[string]ciRuntime{
+	"node":   {name: "Node.js", action: "actions/setup-node@v4", versionInput: "node-version", cacheInput: "cache", dependencyInput: "cache-dependency-path"},
+	"python": {name: "Python", action: "actions/setup-python@v5", versionInput: "python-version", cacheInput: "cache", dependencyInput: "cache-dependency-path"},
+	"go":     {name: "Go", action: "actions/setup-go@v5", versionInput: "go-version", cacheInput: "cache", dependencyInput: "cache-dependency-path"},
+	"java": {
+		name: "Java", action: "actions/setup-java@v4", versionInput: "java-version",
+		inputs: map[string]string{"distribution": "temurin"}, cacheInput: "cache", dependencyInput: "cache-dependency-path",
+	},
+	"ruby": {name: "Ruby", action: "ruby/setup-ruby@v1", versionInput: "ruby-version", cacheInput: "bundler-cache", directoryInput: "working-directory"},
+	"php":  {name: "PHP", action: "shivammathur/setup-php@v2", versionInput: "php-version"},
+	"deno": {name: "Deno", action: "denoland/setup-deno@v1", versionInput: "deno-version"},
+	"rust": {name: "Rust", action: "dtolnay/rust-toolchain@master", versionInput: "toolchain"},
+}
+
+// ciPackageManager describes the dependency cache of a package manager in CI pipelines
+type ciPackageManager struct {
+	// Commands installing the package manager itself
+	bootstrap []string
+	// Value of the setup action's cache input, "" caches paths with actions/cache
+	setupCache string
+	// Home directories actions/cache keeps on GitHub
+	paths []string
+	// Files whose content keys the cache, only those the plan's evidence lists are used
+	keyFiles []string
+	// Variable and directory moving the cache into the project on GitLab, which only
+	// caches paths inside it
+	variable  string
+	directory string
+	// Value of variable when it is not the directory itself, %s receives the directory
+	value string
+	// GitLab CI image carrying the package manager, %s receives Runtime.Version;
+	// "" uses Runtime.Image
+	image string
+}
+
+// ciPackageManagers are the package managers pipelines cache, keyed by name
+var ciPackageManagers = map[string]ciPackageManager{
+	"npm":      {setupCache: "npm", keyFiles: []string{"package-lock.json"}, variable: "npm_config_cache", directory: ".npm"},
+	"yarn":     {setupCache: "yarn", keyFiles: []string{"yarn.lock"}, variable: "YARN_CACHE_FOLDER", directory: ".yarn-cache"},
+	"pnpm":     {bootstrap: []string{"corepack enable"}, setupCache: "pnpm", keyFiles: []string{"pnpm-lock.yaml"}, variable: "npm_config_store_dir", directory: ".pnpm-store"},
+	"bun":      {bootstrap: []string{"npm install -g bun"}, paths: []string{"~/.bun/install/cache"}, keyFiles: []string{"bun.lockb"}, variable: "BUN_INSTALL_CACHE_DIR", directory: ".bun-cache"},
+	"pip":      {setupCache: "pip", keyFiles: []string{"requirements.txt", "pyproject.toml"}, variable: "PIP_CACHE_DIR", directory: ".cache/pip"},
+	"poetry":   {bootstrap: []string{"pip install poetry"}, paths: []string{"~/.cache/pypoetry"}, keyFiles: []string{"poetry.lock"}, variable: "POETRY_CACHE_DIR", directory: ".cache/pypoetry"},
+	"pdm":      {bootstrap: []string{"pip install pdm"}, paths: []string{"~/.cache/pdm"}, keyFiles: []string{"pdm.lock"}, variable: "PDM_CACHE_DIR", directory: ".cache/pdm"},
+	"pipenv":   {bootstrap: []string{"pip install pipenv"}, setupCache: "pipenv", keyFiles: []string{"Pipfile.lock"}, variable: "PIPENV_CACHE_DIR", directory: ".cache/pipenv"},
+	"go":       {setupCache: "true", keyFiles: []string{"go.sum"}, variable: "GOMODCACHE", directory: ".go/pkg/mod"},
+	"maven":    {setupCache: "maven", keyFiles: []string{"pom.xml"}, variable: "MAVEN_OPTS", directory: ".m2/repository", value: "-Dmaven.repo.local=%s", image: "maven:3-eclipse-temurin-%s"},
+	"gradle":   {setupCache: "gradle", keyFiles: []string{"build.gradle", "build.gradle.kts"}, variable: "GRADLE_USER_HOME", directory: ".gradle", image: "gradle:jdk%s"},
+	"bundler":  {setupCache: "true", keyFiles: []string{"Gemfile.lock"}, variable: "BUNDLE_PATH", directory: "vendor/bundle"},
+	"composer": {paths: []string{"~/.cache/composer"}, keyFiles: []string{"composer.lock"}, variable: "COMPOSER_CACHE_DIR", directory: ".composer-cache"},
+	"cargo":    {paths: []string{"~/.cargo/registry", "~/.cargo/git", "target"}, keyFiles: []string{"Cargo.lock"}, variable: "CARGO_HOME", directory: ".cargo"},
+	"deno":     {paths: []string{"~/.cache/deno"}, keyFiles: []string{"deno.lock"}, variable: "DENO_DIR", directory: ".deno"},
+}
+
+// ciCommandManagers maps the programs commands start with to their package manager
+var ciCommandManagers = map[string]string{
+	"npm":       "npm",
+	"npx":       "npm",
+	"yarn":      "yarn",
+	"pnpm":      "pnpm",
+	"bun":       "bun",
+	"pip":       "pip",
+	"pip3":      "pip",
+	"poetry":    "poetry",
+	"pdm":       "pdm",
+	"pipenv":    "pipenv",
+	"go":        "go",
+	"mvn":       "maven",
+	"./mvnw":    "maven",
+	"gradle":    "gradle",
+	"./gradlew": "gradle",
+	"bundle":    "bundler",
+	"rails":     "bundler",
+	"composer":  "composer",
+	"cargo":     "cargo",
+	"deno":      "deno",
+}
+
+// ciPipeline is the part of a plan CI pipelines run, shared by GitHub Actions and GitLab CI
+type ciPipeline struct {
+	plan *types.ExecutionPlan
+	// Project directory relative to the repository root, "" for the root
+	subdir string
+	// Language the runtime is set up for
+	language string
+	// Package managers the commands use, in order of first use
+	managers []string
+	// Commands run by the pipeline, in order
+	bootstrap []string
+	setup     []string
+	test      string
+	build     []string
+}
+
+// newCIPipeline collects what CI pipelines run for plan
+func newCIPipeline(plan *types.ExecutionPlan, options *types.CLIOptions) *ciPipeline {
+	pipeline := &ciPipeline{
+		plan:     plan,
+		language: plan.Provider,
+		setup:    plan.Commands.Setup,
+		build:    plan.Commands.Build,
+	}
+	if registration, ok := providers.Lookup(plan.Provider); ok {
+		pipeline.language = registration.Language
+	}
+	if options != nil {
+		if options.Subdir != nil {
+			if subdir := path.Clean(strings.Trim(*options.Subdir, "/")); subdir != "." {
+				pipeline.subdir = subdir
+			}
+		}
+		if options.TestCommand != nil {
+			pipeline.test = *options.TestCommand
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, command := range append(append([]string(nil), plan.Commands.Setup...), plan.Commands.Build...) {
+		for _, part := range strings.Split(command, "&&") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 {
+				continue
+			}
+			if manager, ok := ciCommandManagers[fields[0]]; ok && !seen[manager] {
+				seen[manager] = true
+				pipeline.managers = append(pipeline.managers, manager)
+				pipeline.bootstrap = append(pipeline.bootstrap, ciPackageManagers[manager].bootstrap...)
+			}
+		}
+	}
+	return pipeline
+}
+
+// keyFiles returns the cache key files of a package manager the plan's evidence lists,
+// relative to the repository root
+func (p *ciPipeline) keyFiles(manager ciPackageManager) []string {
+	var files []string
+	for _, file := range manager.keyFiles {
+		for _, evidence := range p.plan.Evidence.Files {
+			if evidence == file {
+				files = append(files, p.repositoryPath(file))
+				break
+			}
+		}
+	}
+	return files
+}
+
+// repositoryPath returns the path of a project file relative to the repository root
+func (p *ciPipeline) repositoryPath(file string) string {
+	if p.subdir == "" {
+		return file
+	}
+	return path.Join(p.subdir, file)
+}
+
+// ciBlock renders "key: |" followed by lines as a YAML literal block at indent
+func ciBlock(key string, lines []string, indent string) []string {
+	if len(lines) == 1 && !strings.Contains(lines[0], "\n") {
+		return []string{indent + key + ": " + markup.YAMLString(lines[0])}
+	}
+	block := []string{indent + key + ": |"}
+	for _, line := range lines {
+		for _, part := range strings.Split(line, "\n") {
+			block = append(block, indent+"  "+part)
+		}
+	}
+	return block
+}
+
+// GitHubActionsFormatter renders an execution plan as a GitHub Actions workflow
+// setting up the runtime, then running the setup, test and build commands
+type GitHubActionsFormatter struct{}
+
+// NewGitHubActionsFormatter creates a new GitHub Actions workflow formatter
+func NewGitHubActionsFormatter() *GitHubActionsFormatter {
+	return &GitHubActionsFormatter{}
+}
+
+// Format formats execution plan as a GitHub Actions workflow
+func (f *GitHubActionsFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	pipeline := newCIPipeline(plan, options)
+
+	lines := []string{
+		fmt.Sprintf("# Generated by DevBox Pack from the %s execution plan", plan.Provider),
+		"name: CI",
+		"on:",
+		"  push:",
+		"  pull_request:",
+		"jobs:",
+		"  build:",
+		"    runs-on: ubuntu-latest",
+	}
+	if pipeline.subdir != "" {
+		lines = append(lines,
+			"    defaults:",
+			"      run:",
+			"        working-directory: "+markup.YAMLString(pipeline.subdir),
+		)
+	}
+	if len(plan.Environment) > 0 {
+		lines = append(lines, "    env:")
+		lines = append(lines, yamlMapping(plan.Environment, "      ")...)
+	}
+	lines = append(lines, "    steps:", "      - uses: actions/checkout@v4")
+
+	// Runtime, caching the dependencies of the first package manager the setup action
+	// supports; the others are cached with actions/cache. Package managers are only
+	// cached when the plan's evidence lists their key files.
+	runtime, hasRuntime := ciRuntimes[pipeline.language]
+	inputs := make(map[string]string, len(runtime.inputs))
+	for key, value := range runtime.inputs {
+		inputs[key] = value
+	}
+	if plan.Runtime.Version != "" {
+		inputs[runtime.versionInput] = plan.Runtime.Version
+	}
+	if runtime.directoryInput != "" && pipeline.subdir != "" {
+		inputs[runtime.directoryInput] = pipeline.subdir
+	}
+
+	var cacheNames, cachePaths, cacheKeyFiles []string
+	setupCached := false
+	for _, name := range pipeline.managers {
+		manager := ciPackageManagers[name]
+		keyFiles := pipeline.keyFiles(manager)
+		if len(keyFiles) == 0 {
+			continue
+		}
+		if hasRuntime && runtime.cacheInput != "" && manager.setupCache != "" && !setupCached {
+			setupCached = true
+			inputs[runtime.cacheInput] = manager.setupCache
+			if runtime.dependencyInput != "" {
+				inputs[runtime.dependencyInput] = strings.Join(keyFiles, "\n")
+			}
+			continue
+		}
+		if len(manager.paths) > 0 {
+			cacheNames = append(cacheNames, name)
+			cachePaths = append(cachePaths, manager.paths...)
+			cacheKeyFiles = append(cacheKeyFiles, keyFiles...)
+		}
+	}
+	if hasRuntime {
+		lines = append(lines, "      - name: Set up "+runtime.name, "        uses: "+runtime.action)
+		if len(inputs) > 0 {
+			lines = append(lines, "        with:")
+			lines = append(lines, ciInputs(inputs, "          ")...)
+		}
+	}
+	if len(cachePaths) > 0 {
+		for i, path := range cachePaths {
+			if !strings.HasPrefix(path, "~") {
+				cachePaths[i] = pipeline.repositoryPath(path)
+			}
+		}
+		hashes := make([]string, len(cacheKeyFiles))
+		for i, file := range cacheKeyFiles {
+			hashes[i] = "'" + file + "'"
+		}
+		lines = append(lines,
+			"      - name: Cache "+strings.Join(cacheNames, " and ")+" dependencies",
+			"        uses: actions/cache@v4",
+			"        with:",
+		)
+		lines = append(lines, ciBlock("path", cachePaths, "          ")...)
+		lines = append(lines, "          key: "+markup.YAMLString(fmt.Sprintf("${{ runner.os }}-%s-${{ hashFiles(%s) }}", strings.Join(cacheNames, "-"), strings.Join(hashes, ", "))))
+	}
+
+	// Commands
+	var system []string
+	if len(plan.Apt) > 0 {
+		system = []string{fmt.Sprintf("sudo apt-get update && sudo apt-get install -y --no-install-recommends %s", strings.Join(aptTool.packages(plan.Apt), " "))}
+	}
+	steps := []struct {
+		name     string
+		commands []string
+	}{
+		{"Install system packages", system},
+		{"Install package managers", pipeline.bootstrap},
+		{"Set up", pipeline.setup},
+		{"Test", nonEmpty(pipeline.test)},
+		{"Build", pipeline.build},
+	}
+	for _, step := range steps {
+		if len(step.commands) == 0 {
+			continue
+		}
+		lines = append(lines, "      - name: "+step.name)
+		lines = append(lines, ciBlock("run", step.commands, "        ")...)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// GitLabCIFormatter renders an execution plan as a .gitlab-ci.yml pipeline running
+// the setup, test and build commands in the plan's runtime image
+type GitLabCIFormatter struct{}
+
+// NewGitLabCIFormatter creates a new GitLab CI pipeline formatter
+func NewGitLabCIFormatter() *GitLabCIFormatter {
+	return &GitLabCIFormatter{}
+}
+
+// Format formats execution plan as a GitLab CI pipeline
+func (f *GitLabCIFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	pipeline := newCIPipeline(plan, options)
+
+	// The runtime image, or the image of the first package manager that brings its own
+	image := plan.Runtime.Image
+	for _, name := range pipeline.managers {
+		if manager := ciPackageManagers[name]; manager.image != "" && plan.Runtime.Version != "" {
+			image = fmt.Sprintf(manager.image, plan.Runtime.Version)
+			break
+		}
+	}
+	if image == "" {
+		return "", fmt.Errorf("execution plan has no runtime image, set one with --base")
+	}
+
+	// Caches moved into the project directory
+	variables := make(map[string]string, len(plan.Environment))
+	for key, value := range plan.Environment {
+		variables[key] = value
+	}
+	var cachePaths, cacheKeyFiles []string
+	for _, name := range pipeline.managers {
+		manager := ciPackageManagers[name]
+		directory := "$CI_PROJECT_DIR/" + pipeline.repositoryPath(manager.directory)
+		if manager.value != "" {
+			variables[manager.variable] = fmt.Sprintf(manager.value, directory)
+		} else {
+			variables[manager.variable] = directory
+		}
+		cachePaths = append(cachePaths, pipeline.repositoryPath(manager.directory)+"/")
+		cacheKeyFiles = append(cacheKeyFiles, pipeline.keyFiles(manager)...)
+	}
+
+	lines := []string{
+		fmt.Sprintf("# Generated by DevBox Pack from the %s execution plan", plan.Provider),
+		"image: " + markup.YAMLString(image),
+	}
+	if len(variables) > 0 {
+		lines = append(lines, "variables:")
+		lines = append(lines, yamlMapping(variables, "  ")...)
+	}
+	if len(cachePaths) > 0 {
+		lines = append(lines, "cache:")
+		// GitLab keys caches by at most two files
+		if len(cacheKeyFiles) > 2 {
+			cacheKeyFiles = cacheKeyFiles[:2]
+		}
+		if len(cacheKeyFiles) > 0 {
+			lines = append(lines, "  key:", "    files:")
+			for _, file := range cacheKeyFiles {
+				lines = append(lines, "      - "+markup.YAMLString(file))
+			}
+		}
+		lines = append(lines, "  paths:")
+		for _, path := range cachePaths {
+			lines = append(lines, "    - "+markup.YAMLString(path))
+		}
+	}
+
+	var script []string
+	if pipeline.subdir != "" {
+		script = append(script, "cd "+shellQuote(pipeline.subdir))
+	}
+	tool := toolFor(image)
+	if packages := tool.packages(plan.Apt); len(packages) > 0 {
+		script = append(script, fmt.Sprintf(tool.install, strings.Join(packages, " ")))
+	}
+	script = append(script, pipeline.bootstrap...)
+	script = append(script, pipeline.setup...)
+	script = append(script, nonEmpty(pipeline.test)...)
+	script = append(script, pipeline.build...)
+	if len(script) == 0 {
+		script = []string{"echo \"The plan has no setup, test or build commands\""}
+	}
+
+	lines = append(lines, "build:", "  script:")
+	for _, command := range script {
+		lines = append(lines, "    - "+markup.YAMLString(command))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ciInputs renders action inputs as sorted YAML mapping lines, multi-line values as blocks
+func ciInputs(inputs map[string]string, indent string) []string {
+	keys := make([]string, 0, len(inputs))
+	for key := range inputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, key := range keys {
+		if strings.Contains(inputs[key], "\n") {
+			lines = append(lines, ciBlock(key, strings.Split(inputs[key], "\n"), indent)...)
+		} else {
+			lines = append(lines, indent+key+": "+yamlQuoted(inputs[key]))
+		}
+	}
+	return lines
+}
+
+// nonEmpty returns command as a list, empty when command is ""
+func nonEmpty(command string) []string {
+	if command == "" {
+		return nil
+	}
+	return []string{command}
+}
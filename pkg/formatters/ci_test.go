@@ -0,0 +1,187 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func ciTestPlan() *types.ExecutionPlan {
+	return &types.ExecutionPlan{
+		Provider:    "node",
+		Runtime:     types.RuntimeConfig{Image: "node:18-alpine", Version: "18"},
+		Environment: map[string]string{"NODE_ENV": "development"},
+		Apt:         []string{"build-essential"},
+		Commands: types.Commands{
+			Setup: []string{"pnpm install"},
+			Build: []string{"pnpm run check", "pnpm run build"},
+		},
+		Evidence: types.Evidence{Files: []string{"package.json", "pnpm-lock.yaml"}},
+	}
+}
+
+func TestGitHubActionsFormatter_Format(t *testing.T) {
+	subdir := "web"
+	test := "pnpm test"
+	output, err := NewGitHubActionsFormatter().Format(ciTestPlan(), &types.CLIOptions{Subdir: &subdir, TestCommand: &test})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	document, err := markup.ParseYAML(output)
+	if err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, output)
+	}
+
+	expected := map[string]interface{}{
+		"jobs.build.runs-on":                            "ubuntu-latest",
+		"jobs.build.defaults.run.working-directory":     "web",
+		"jobs.build.env.NODE_ENV":                       "development",
+		"jobs.build.steps.0.uses":                       "actions/checkout@v4",
+		"jobs.build.steps.1.uses":                       "actions/setup-node@v4",
+		"jobs.build.steps.1.with.node-version":          "18",
+		"jobs.build.steps.1.with.cache":                 "pnpm",
+		"jobs.build.steps.1.with.cache-dependency-path": "web/pnpm-lock.yaml",
+		"jobs.build.steps.2.run":                        "sudo apt-get update && sudo apt-get install -y --no-install-recommends build-essential",
+		"jobs.build.steps.3.run":                        "corepack enable",
+		"jobs.build.steps.4.run":                        "pnpm install",
+		"jobs.build.steps.5.name":                       "Test",
+		"jobs.build.steps.5.run":                        "pnpm test",
+		"jobs.build.steps.6.run":                        "pnpm run check\npnpm run build\n",
+	}
+	for fieldPath, value := range expected {
+		if got := field(t, document, fieldPath); got != value {
+			t.Errorf("%s: expected %q, got %v", fieldPath, value, got)
+		}
+	}
+}
+
+func TestGitHubActionsFormatter_Caches(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     *types.ExecutionPlan
+		expected map[string]interface{}
+	}{
+		{
+			name: "actions/cache without setup action cache",
+			plan: &types.ExecutionPlan{
+				Provider: "rust",
+				Runtime:  types.RuntimeConfig{Version: "1.70"},
+				Commands: types.Commands{Build: []string{"cargo build --release"}},
+				Evidence: types.Evidence{Files: []string{"Cargo.toml", "Cargo.lock"}},
+			},
+			expected: map[string]interface{}{
+				"jobs.build.steps.1.with.toolchain": "1.70",
+				"jobs.build.steps.2.uses":           "actions/cache@v4",
+				"jobs.build.steps.2.with.path":      "~/.cargo/registry\n~/.cargo/git\ntarget\n",
+				"jobs.build.steps.2.with.key":       "${{ runner.os }}-cargo-${{ hashFiles('Cargo.lock') }}",
+			},
+		},
+		{
+			name: "no cache without lock file",
+			plan: &types.ExecutionPlan{
+				Provider: "python",
+				Runtime:  types.RuntimeConfig{Version: "3.12"},
+				Commands: types.Commands{Setup: []string{"pip install ."}},
+				Evidence: types.Evidence{Files: []string{"setup.py"}},
+			},
+			expected: map[string]interface{}{
+				"jobs.build.steps.1.with.python-version": "3.12",
+				"jobs.build.steps.1.with.cache":          nil,
+				"jobs.build.steps.2.run":                 "pip install .",
+			},
+		},
+		{
+			name: "no setup action for other languages",
+			plan: &types.ExecutionPlan{
+				Provider: "shell",
+				Commands: types.Commands{Build: []string{"make"}},
+			},
+			expected: map[string]interface{}{
+				"jobs.build.steps.1.run": "make",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := NewGitHubActionsFormatter().Format(tt.plan, nil)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			document, err := markup.ParseYAML(output)
+			if err != nil {
+				t.Fatalf("output is not valid YAML: %v\n%s", err, output)
+			}
+			for fieldPath, value := range tt.expected {
+				if got := field(t, document, fieldPath); got != value {
+					t.Errorf("%s: expected %q, got %v\n%s", fieldPath, value, got, output)
+				}
+			}
+		})
+	}
+}
+
+func TestGitLabCIFormatter_Format(t *testing.T) {
+	subdir := "web"
+	test := "pnpm test"
+	output, err := NewGitLabCIFormatter().Format(ciTestPlan(), &types.CLIOptions{Subdir: &subdir, TestCommand: &test})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	document, err := markup.ParseYAML(output)
+	if err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, output)
+	}
+
+	expected := map[string]interface{}{
+		"image":                          "node:18-alpine",
+		"variables.NODE_ENV":             "development",
+		"variables.npm_config_store_dir": "$CI_PROJECT_DIR/web/.pnpm-store",
+		"cache.key.files.0":              "web/pnpm-lock.yaml",
+		"cache.paths.0":                  "web/.pnpm-store/",
+	}
+	for fieldPath, value := range expected {
+		if got := field(t, document, fieldPath); got != value {
+			t.Errorf("%s: expected %q, got %v", fieldPath, value, got)
+		}
+	}
+
+	script := field(t, document, "build.script").([]interface{})
+	commands := make([]string, len(script))
+	for i, command := range script {
+		commands[i] = command.(string)
+	}
+	want := []string{"cd 'web'", "apk add --no-cache build-base", "corepack enable", "pnpm install", "pnpm test", "pnpm run check", "pnpm run build"}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected script %q, got %q", want, commands)
+	}
+}
+
+func TestGitLabCIFormatter_Image(t *testing.T) {
+	plan := &types.ExecutionPlan{
+		Provider: "java",
+		Runtime:  types.RuntimeConfig{Image: "openjdk:17-jre-alpine", Version: "17"},
+		Commands: types.Commands{Build: []string{"mvn clean package"}},
+		Evidence: types.Evidence{Files: []string{"pom.xml"}},
+	}
+	output, err := NewGitLabCIFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	document, err := markup.ParseYAML(output)
+	if err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, output)
+	}
+	// Maven needs a JDK image carrying it, not the runtime image
+	if image := field(t, document, "image"); image != "maven:3-eclipse-temurin-17" {
+		t.Errorf("expected the maven image, got %v", image)
+	}
+	if value := field(t, document, "variables.MAVEN_OPTS"); value != "-Dmaven.repo.local=$CI_PROJECT_DIR/.m2/repository" {
+		t.Errorf("expected the maven repository in the project, got %v", value)
+	}
+
+	if _, err := NewGitLabCIFormatter().Format(&types.ExecutionPlan{Provider: "shell"}, nil); err == nil {
+		t.Error("expected error for plan without runtime image")
+	}
+}
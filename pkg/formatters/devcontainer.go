@@ -0,0 +1,119 @@
+/**
+ * DevBox Pack Execution Plan Generator - Dev Container Formatter
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// DevcontainerFile is the file the Dev Container configuration is written to
+const DevcontainerFile = ".devcontainer/devcontainer.json"
+
+// devcontainerExtensions lists the VS Code extensions recommended per language
+var devcontainerExtensions = map[string][]string{
+	"node":   {"dbaeumer.vscode-eslint", "esbenp.prettier-vscode"},
+	"python": {"ms-python.python", "ms-python.vscode-pylance"},
+	"go":     {"golang.go"},
+	"java":   {"vscjava.vscode-java-pack"},
+	"php":    {"bmewburn.vscode-intelephense-client"},
+	"ruby":   {"Shopify.ruby-lsp"},
+	"deno":   {"denoland.vscode-deno"},
+	"rust":   {"rust-lang.rust-analyzer"},
+	"shell":  {"timonwong.shellcheck"},
+}
+
+// DevcontainerFormatter renders an execution plan as a Dev Container configuration
+type DevcontainerFormatter struct{}
+
+// NewDevcontainerFormatter creates a new Dev Container formatter
+func NewDevcontainerFormatter() *DevcontainerFormatter {
+	return &DevcontainerFormatter{}
+}
+
+// devcontainerConfig is the devcontainer.json document, fields in the order they are written
+type devcontainerConfig struct {
+	Name              string                  `json:"name,omitempty"`
+	Image             string                  `json:"image"`
+	WorkspaceFolder   string                  `json:"workspaceFolder,omitempty"`
+	ContainerEnv      map[string]string       `json:"containerEnv,omitempty"`
+	ForwardPorts      []int                   `json:"forwardPorts,omitempty"`
+	PostCreateCommand string                  `json:"postCreateCommand,omitempty"`
+	Customizations    *devcontainerCustomized `json:"customizations,omitempty"`
+}
+
+// devcontainerCustomized holds the editor specific settings
+type devcontainerCustomized struct {
+	VSCode struct {
+		Extensions []string `json:"extensions"`
+	} `json:"vscode"`
+}
+
+// Format formats execution plan as devcontainer.json
+func (f *DevcontainerFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	files, err := f.FormatFiles(plan, options)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(files[DevcontainerFile], "\n"), nil
+}
+
+// FormatFiles formats execution plan as .devcontainer/devcontainer.json
+func (f *DevcontainerFormatter) FormatFiles(plan *types.ExecutionPlan, options *types.CLIOptions) (map[string]string, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("execution plan cannot be nil")
+	}
+	if plan.Runtime.Image == "" {
+		return nil, fmt.Errorf("execution plan has no runtime image, set one with --base")
+	}
+
+	config := devcontainerConfig{
+		Image:        plan.Runtime.Image,
+		ContainerEnv: plan.Environment,
+	}
+	if options != nil && options.Repository != "" {
+		config.Name = git.RepoName(options.Repository)
+	}
+	if options != nil && options.Subdir != nil {
+		// The repository is mounted below /workspaces, open the service's directory
+		if subdir := path.Clean(strings.Trim(*options.Subdir, "/")); subdir != "." {
+			config.WorkspaceFolder = "/workspaces/${localWorkspaceFolderBasename}/" + subdir
+			if config.Name != "" {
+				config.Name += "/" + subdir
+			}
+		}
+	}
+	if plan.Port > 0 {
+		config.ForwardPorts = []int{plan.Port}
+	}
+
+	// Images run as root unless they set a user, so packages install without sudo
+	var commands []string
+	if packages := toolFor(plan.Runtime.Image).packages(plan.Apt); len(packages) > 0 {
+		commands = append(commands, fmt.Sprintf(toolFor(plan.Runtime.Image).install, strings.Join(packages, " ")))
+	}
+	commands = append(commands, plan.Commands.Setup...)
+	config.PostCreateCommand = strings.Join(commands, " && ")
+
+	if extensions := devcontainerExtensions[plan.Provider]; len(extensions) > 0 {
+		config.Customizations = &devcontainerCustomized{}
+		config.Customizations.VSCode.Extensions = extensions
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, fmt.Errorf("failed to encode devcontainer.json: %w", err)
+	}
+	return map[string]string{DevcontainerFile: buffer.String()}, nil
+}
@@ -0,0 +1,79 @@
+package formatters
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/config"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestDevcontainerFormatter_Format(t *testing.T) {
+	subdir := "web"
+	options := &types.CLIOptions{Repository: "https://github.com/acme/shop.git", Subdir: &subdir}
+	plan := ciTestPlan()
+	plan.Port = 3000
+
+	output, err := NewDevcontainerFormatter().Format(plan, options)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	expected := map[string]interface{}{
+		"name":              "shop/web",
+		"workspaceFolder":   "/workspaces/${localWorkspaceFolderBasename}/web",
+		"postCreateCommand": "apk add --no-cache build-base && pnpm install",
+	}
+	for key, value := range expected {
+		if document[key] != value {
+			t.Errorf("%s: expected %q, got %v", key, value, document[key])
+		}
+	}
+	extensions := field(t, document, "customizations.vscode.extensions")
+	if !reflect.DeepEqual(extensions, []interface{}{"dbaeumer.vscode-eslint", "esbenp.prettier-vscode"}) {
+		t.Errorf("expected node extensions, got %v", extensions)
+	}
+
+	// Importing the exported file gives back the plan's values
+	override, err := config.ParseDevcontainer(DevcontainerFile, []byte(output))
+	if err != nil {
+		t.Fatalf("ParseDevcontainer failed: %v", err)
+	}
+	if override.Runtime.Image == nil || *override.Runtime.Image != plan.Runtime.Image {
+		t.Errorf("expected image %s, got %v", plan.Runtime.Image, override.Runtime.Image)
+	}
+	if override.Port == nil || *override.Port != 3000 {
+		t.Errorf("expected port 3000, got %v", override.Port)
+	}
+	if !reflect.DeepEqual(override.Environment, plan.Environment) {
+		t.Errorf("expected environment %v, got %v", plan.Environment, override.Environment)
+	}
+}
+
+func TestDevcontainerFormatter_FormatFiles(t *testing.T) {
+	plan := &types.ExecutionPlan{
+		Provider: "shell",
+		Runtime:  types.RuntimeConfig{Image: "ubuntu:22.04"},
+	}
+	files, err := NewDevcontainerFormatter().FormatFiles(plan, nil)
+	if err != nil {
+		t.Fatalf("FormatFiles failed: %v", err)
+	}
+	content, ok := files[DevcontainerFile]
+	if !ok || len(files) != 1 {
+		t.Fatalf("expected only %s, got %v", DevcontainerFile, files)
+	}
+	expected := "{\n  \"image\": \"ubuntu:22.04\",\n  \"customizations\": {\n    \"vscode\": {\n      \"extensions\": [\n        \"timonwong.shellcheck\"\n      ]\n    }\n  }\n}\n"
+	if content != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	if _, err := NewDevcontainerFormatter().FormatFiles(&types.ExecutionPlan{Provider: "shell"}, nil); err == nil {
+		t.Error("expected error for plan without runtime image")
+	}
+}
@@ -0,0 +1,242 @@
+/**
+ * DevBox Pack Execution Plan Generator - Dockerfile Formatter
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// DockerfileWorkdir is the directory the project is copied to inside the image
+const DockerfileWorkdir = "/app"
+
+// DockerfileFormatter renders an execution plan as a multi-stage Dockerfile
+type DockerfileFormatter struct{}
+
+// NewDockerfileFormatter creates a new Dockerfile formatter
+func NewDockerfileFormatter() *DockerfileFormatter {
+	return &DockerfileFormatter{}
+}
+
+// packageTool installs system packages on one family of base image distributions
+type packageTool struct {
+	// Shell command installing the packages, %s receives the package list
+	install string
+	// Package names replacing the Debian names used in plans, missing names are kept
+	names map[string][]string
+}
+
+var (
+	aptTool = packageTool{
+		install: "apt-get update && apt-get install -y --no-install-recommends %s && rm -rf /var/lib/apt/lists/*",
+	}
+	apkTool = packageTool{
+		install: "apk add --no-cache %s",
+		names: map[string][]string{
+			"build-essential": {"build-base"},
+			"libssl-dev":      {"openssl-dev"},
+			"libsqlite3-dev":  {"sqlite-dev"},
+			"node-gyp":        {"python3"},
+		},
+	}
+	dnfTool = packageTool{
+		install: "dnf install -y %s && dnf clean all",
+		names: map[string][]string{
+			"build-essential": {"gcc", "gcc-c++", "make"},
+			"python3-dev":     {"python3-devel"},
+			"libffi-dev":      {"libffi-devel"},
+			"libssl-dev":      {"openssl-devel"},
+			"ruby-dev":        {"ruby-devel"},
+			"libsqlite3-dev":  {"sqlite-devel"},
+			"php-dev":         {"php-devel"},
+			"node-gyp":        {"python3"},
+		},
+	}
+)
+
+// manifestInstalls are setup commands that install dependencies from the manifests
+// alone. Installs that build the project itself (pip install ., poetry install) or
+// run its scripts (composer install) need the whole source.
+var manifestInstalls = map[string]bool{
+	"npm install":                     true,
+	"npm ci":                          true,
+	"yarn install":                    true,
+	"pnpm install":                    true,
+	"bun install":                     true,
+	"deno install":                    true,
+	"pip install -r requirements.txt": true,
+	"pipenv install":                  true,
+	"bundle install":                  true,
+	"go mod download":                 true,
+}
+
+// dnfDistributions are image names of distributions installing packages with dnf
+var dnfDistributions = []string{"fedora", "centos", "rockylinux", "almalinux", "amazonlinux", "oraclelinux", "ubi"}
+
+// Format formats execution plan as a Dockerfile
+func (f *DockerfileFormatter) Format(plan *types.ExecutionPlan, _ *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	if plan.Runtime.Image == "" {
+		return "", fmt.Errorf("execution plan has no runtime image, set one with --base")
+	}
+
+	var lines []string
+	lines = append(lines, "# syntax=docker/dockerfile:1")
+	lines = append(lines, fmt.Sprintf("# Generated by DevBox Pack from the %s execution plan", plan.Provider))
+	lines = append(lines, "")
+
+	// Base stage: system packages shared by the later stages
+	lines = append(lines, fmt.Sprintf("FROM %s AS base", plan.Runtime.Image))
+	lines = append(lines, fmt.Sprintf("WORKDIR %s", DockerfileWorkdir))
+	tool := toolFor(plan.Runtime.Image)
+	if packages := tool.packages(plan.Apt); len(packages) > 0 {
+		lines = append(lines, "RUN "+fmt.Sprintf(tool.install, strings.Join(packages, " ")))
+	}
+	lines = append(lines, "")
+
+	// Build stage: dependencies installed from the manifests alone come first, so
+	// their layer is cached until a manifest changes
+	lines = append(lines, "FROM base AS build")
+	setup := plan.Commands.Setup
+	manifests := manifestFiles(plan)
+	installs := 0
+	for installs < len(setup) && manifestInstalls[strings.TrimSpace(setup[installs])] {
+		installs++
+	}
+	if len(manifests) > 0 && installs > 0 {
+		lines = append(lines, fmt.Sprintf("COPY %s ./", strings.Join(manifests, " ")))
+		lines = append(lines, runLines(setup[:installs])...)
+		setup = setup[installs:]
+	}
+	lines = append(lines, "COPY . .")
+	lines = append(lines, runLines(setup)...)
+	lines = append(lines, runLines(plan.Commands.Build)...)
+
+	// The build stage is also the image that runs: several languages install
+	// dependencies outside the working directory (site-packages, bundler), so
+	// there are no artifacts a slimmer stage could copy on their own
+	if len(plan.Environment) > 0 {
+		keys := make([]string, 0, len(plan.Environment))
+		for key := range plan.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("ENV %s=%s", key, quoteEnvValue(plan.Environment[key])))
+		}
+	}
+	if plan.Port > 0 {
+		lines = append(lines, fmt.Sprintf("EXPOSE %d", plan.Port))
+	}
+	if len(plan.Commands.Run) > 0 {
+		// Exec form through sh keeps $VARIABLE expansion and multiple commands
+		command, err := encodeJSON([]string{"sh", "-c", strings.Join(plan.Commands.Run, " && ")})
+		if err != nil {
+			return "", fmt.Errorf("failed to encode run command: %w", err)
+		}
+		lines = append(lines, "CMD "+command)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// toolFor chooses the package tool from the base image name
+func toolFor(image string) packageTool {
+	name := strings.ToLower(image)
+	if strings.Contains(name, "alpine") {
+		return apkTool
+	}
+	// Only the repository name identifies the distribution, not the registry or tag
+	repository, _, _ := strings.Cut(path.Base(name), ":")
+	for _, distribution := range dnfDistributions {
+		if strings.HasPrefix(repository, distribution) {
+			return dnfTool
+		}
+	}
+	return aptTool
+}
+
+// packages maps plan packages to the tool's names, removing duplicates and sorting
+func (t packageTool) packages(apt []string) []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, name := range apt {
+		mapped, ok := t.names[name]
+		if !ok {
+			mapped = []string{name}
+		}
+		for _, pkg := range mapped {
+			if !seen[pkg] {
+				seen[pkg] = true
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// manifestFiles returns the root-level evidence files that the plan's provider
+// registers as key files, such as package.json or requirements.txt
+func manifestFiles(plan *types.ExecutionPlan) []string {
+	registration, ok := providers.Lookup(plan.Provider)
+	if !ok {
+		return nil
+	}
+	keyFiles := make(map[string]bool, len(registration.KeyFiles))
+	for _, file := range registration.KeyFiles {
+		keyFiles[file] = true
+	}
+
+	var manifests []string
+	for _, file := range plan.Evidence.Files {
+		if keyFiles[file] && !strings.ContainsAny(file, "/*") {
+			manifests = append(manifests, file)
+		}
+	}
+	sort.Strings(manifests)
+	return manifests
+}
+
+// runLines renders one RUN instruction per command
+func runLines(commands []string) []string {
+	lines := make([]string, len(commands))
+	for i, command := range commands {
+		lines[i] = "RUN " + command
+	}
+	return lines
+}
+
+// envEscaper escapes the characters Dockerfile double quotes give a meaning,
+// so $VARIABLE is kept literally instead of being expanded by the builder
+var envEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+// quoteEnvValue quotes ENV values that contain whitespace, quotes or variables
+func quoteEnvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\$") {
+		return value
+	}
+	return `"` + envEscaper.Replace(value) + `"`
+}
+
+// encodeJSON encodes value as compact JSON without escaping shell characters such as &
+func encodeJSON(value interface{}) (string, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
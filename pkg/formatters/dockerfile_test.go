@@ -0,0 +1,154 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// dockerfilePlan returns a node plan built on image
+func dockerfilePlan(image string) *types.ExecutionPlan {
+	return &types.ExecutionPlan{
+		Provider: "node",
+		Runtime:  types.RuntimeConfig{Image: image},
+		Environment: map[string]string{
+			"PORT":     "3000",
+			"NODE_ENV": "production",
+			"GREETING": "hello world",
+			"PROMPT":   `say "$USER\n"`,
+		},
+		Apt: []string{"build-essential", "libssl-dev", "build-essential"},
+		Commands: types.Commands{
+			Setup: []string{"npm ci"},
+			Build: []string{"npm run build"},
+			Run:   []string{"npm run migrate", "npm start"},
+		},
+		Port: 3000,
+		Evidence: types.Evidence{
+			Files: []string{"package.json", "package-lock.json", "src/index.js"},
+		},
+	}
+}
+
+func TestDockerfileFormatter_Format(t *testing.T) {
+	output, err := NewDockerfileFormatter().Format(dockerfilePlan("node:20-alpine"), nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	expected := `# syntax=docker/dockerfile:1
+# Generated by DevBox Pack from the node execution plan
+
+FROM node:20-alpine AS base
+WORKDIR /app
+RUN apk add --no-cache build-base openssl-dev
+
+FROM base AS build
+COPY package-lock.json package.json ./
+RUN npm ci
+COPY . .
+RUN npm run build
+ENV GREETING="hello world"
+ENV NODE_ENV=production
+ENV PORT=3000
+ENV PROMPT="say \"\$USER\\n\""
+EXPOSE 3000
+CMD ["sh","-c","npm run migrate && npm start"]`
+	if output != expected {
+		t.Errorf("unexpected Dockerfile:\n%s", output)
+	}
+
+	// Map iteration must not leak into the output
+	for i := 0; i < 10; i++ {
+		again, _ := NewDockerfileFormatter().Format(dockerfilePlan("node:20-alpine"), nil)
+		if again != output {
+			t.Fatal("expected deterministic output")
+		}
+	}
+}
+
+func TestDockerfileFormatter_PackageTools(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"python:3.11-slim", "RUN apt-get update && apt-get install -y --no-install-recommends build-essential libssl-dev && rm -rf /var/lib/apt/lists/*"},
+		{"registry.example.com/library/ubuntu:22.04", "RUN apt-get update && apt-get install -y --no-install-recommends build-essential libssl-dev"},
+		{"fedora:40", "RUN dnf install -y gcc gcc-c++ make openssl-devel && dnf clean all"},
+		{"registry.access.redhat.com/ubi9/ubi:latest", "RUN dnf install -y gcc gcc-c++ make openssl-devel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			output, err := NewDockerfileFormatter().Format(dockerfilePlan(tt.image), nil)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if !strings.Contains(output, tt.expected) {
+				t.Errorf("expected %q in:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestDockerfileFormatter_WithoutManifests(t *testing.T) {
+	plan := dockerfilePlan("node:20-alpine")
+	plan.Provider = "custom"
+	plan.Apt = nil
+	plan.Environment = nil
+	plan.Port = 0
+
+	output, err := NewDockerfileFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if !strings.Contains(output, "FROM base AS build\nCOPY . .\nRUN npm ci\n") {
+		t.Errorf("expected the whole project to be copied before setup:\n%s", output)
+	}
+	for _, unexpected := range []string{"RUN apk", "ENV ", "EXPOSE"} {
+		if strings.Contains(output, unexpected) {
+			t.Errorf("unexpected %q in:\n%s", unexpected, output)
+		}
+	}
+}
+
+func TestDockerfileFormatter_SetupNeedingSource(t *testing.T) {
+	plan := dockerfilePlan("php:8.2-cli")
+	plan.Provider = "php"
+	plan.Commands.Setup = []string{"composer install", "php artisan key:generate", "php artisan config:cache"}
+	plan.Evidence.Files = []string{"artisan", "composer.json"}
+
+	output, err := NewDockerfileFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	expected := "FROM base AS build\nCOPY . .\nRUN composer install\nRUN php artisan key:generate\nRUN php artisan config:cache\n"
+	if !strings.Contains(output, expected) || strings.Contains(output, "COPY artisan") {
+		t.Errorf("expected the whole project to be copied before composer install:\n%s", output)
+	}
+
+	// Commands after the install still run against the whole project
+	plan = dockerfilePlan("ruby:3.2")
+	plan.Provider = "ruby"
+	plan.Commands.Setup = []string{"bundle install", "rails db:prepare"}
+	plan.Evidence.Files = []string{"Gemfile", "Gemfile.lock", "app/models/user.rb"}
+	output, err = NewDockerfileFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	expected = "COPY Gemfile Gemfile.lock ./\nRUN bundle install\nCOPY . .\nRUN rails db:prepare\n"
+	if !strings.Contains(output, expected) {
+		t.Errorf("expected %q in:\n%s", expected, output)
+	}
+}
+
+func TestDockerfileFormatter_FormatInvalidPlan(t *testing.T) {
+	formatter := NewDockerfileFormatter()
+	if _, err := formatter.Format(nil, nil); err == nil {
+		t.Error("expected error for nil plan")
+	}
+	if _, err := formatter.Format(dockerfilePlan(""), nil); err == nil {
+		t.Error("expected error for plan without runtime image")
+	}
+}
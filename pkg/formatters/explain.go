@@ -0,0 +1,283 @@
+/**
+ * DevBox Pack Execution Plan Generator - Explanation Formatter
+ */
+
+package formatters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// explainedPlan is the JSON shape of a plan printed with --explain
+type explainedPlan struct {
+	Plan        json.RawMessage    `json:"plan"`
+	Explanation *types.Explanation `json:"explanation,omitempty"`
+}
+
+// FormatExplanation formats an explanation as human-readable text
+func FormatExplanation(explanation *types.Explanation) string {
+	if explanation == nil {
+		return ""
+	}
+
+	var lines []string
+	lines = append(lines, "🧮 Detection Explanation")
+	lines = append(lines, strings.Repeat("─", 20))
+
+	for _, provider := range explanation.Providers {
+		status := "not matched"
+		if provider.Matched {
+			status = "matched"
+		}
+		header := fmt.Sprintf("%s (priority %d): %s, confidence %.2f", provider.Provider, provider.Priority, status, provider.Confidence)
+		if provider.Scoring != nil {
+			comparison := ">"
+			if provider.Scoring.ThresholdInclusive {
+				comparison = ">="
+			}
+			header += fmt.Sprintf(" (threshold %s %.2f)", comparison, provider.Scoring.Threshold)
+		}
+		lines = append(lines, header)
+
+		if provider.Error != "" {
+			lines = append(lines, fmt.Sprintf("  Error: %s", provider.Error))
+		}
+		if provider.Scoring == nil {
+			continue
+		}
+		for _, indicator := range provider.Scoring.Indicators {
+			mark := "✗"
+			if indicator.Satisfied {
+				mark = "✓"
+			}
+			description := indicator.Description
+			if description == "" {
+				description = "(no description)"
+			}
+			lines = append(lines, fmt.Sprintf("  %s %s (weight %d)", mark, description, indicator.Weight))
+		}
+		if len(provider.Scoring.Adjustments) > 0 {
+			lines = append(lines, fmt.Sprintf("  Raw confidence: %.2f", provider.Scoring.RawConfidence))
+			for _, adjustment := range provider.Scoring.Adjustments {
+				lines = append(lines, fmt.Sprintf("  × %.2f: %s", adjustment.Factor, adjustment.Reason))
+			}
+		}
+	}
+	lines = append(lines, "")
+
+	if selection := explanation.Selection; selection != nil {
+		lines = append(lines, "🏆 Provider Selection")
+		lines = append(lines, strings.Repeat("─", 20))
+		lines = append(lines, fmt.Sprintf("Selected: %s (%s)", selection.Provider, selection.Rule))
+		lines = append(lines, fmt.Sprintf("Reason: %s", selection.Reason))
+		if len(selection.Candidates) > 0 {
+			lines = append(lines, "Candidates:")
+			for _, candidate := range selection.Candidates {
+				kind := "frontend"
+				if candidate.Backend {
+					kind = "backend"
+				}
+				detail := candidate.Language
+				if candidate.Framework != "" {
+					detail += ", " + candidate.Framework
+				}
+				lines = append(lines, fmt.Sprintf("  • %s (%s) confidence %.2f, %s", candidate.Provider, detail, candidate.Confidence, kind))
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// OutputDetectedPlan outputs an execution plan, handing the detection results to
+// a DetectionsFormatter and adding the explanation when it is not nil
+func (u *OutputUtils) OutputDetectedPlan(plan *types.ExecutionPlan, detections []*types.DetectResult, explanation *types.Explanation, options *types.CLIOptions) error {
+	if formatter, ok := u.detectionsFormatter(options); ok {
+		output, err := formatter.FormatDetections(plan, detections, explanation, options)
+		if err != nil {
+			return fmt.Errorf("failed to format plan: %w", err)
+		}
+		fmt.Println(output)
+		return nil
+	}
+	if explanation != nil {
+		return u.OutputExplainedPlan(plan, explanation, options)
+	}
+	return u.OutputPlan(plan, options)
+}
+
+// OutputDetectedPlans outputs one execution plan per service path like OutputDetectedPlan,
+// explaining them when explanations is not nil
+func (u *OutputUtils) OutputDetectedPlans(plans map[string]*types.ExecutionPlan, detections map[string][]*types.DetectResult, explanations map[string]*types.Explanation, options *types.CLIOptions) error {
+	formatter, ok := u.detectionsFormatter(options)
+	if !ok {
+		if explanations != nil {
+			return u.OutputExplainedPlans(plans, explanations, options)
+		}
+		return u.OutputPlans(plans, options)
+	}
+
+	paths := make([]string, 0, len(plans))
+	for path := range plans {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		output, err := formatter.FormatDetections(plans[path], detections[path], explanations[path], serviceOptions(options, path))
+		if err != nil {
+			return fmt.Errorf("failed to format plan for %s: %w", path, err)
+		}
+		fmt.Printf("📁 %s\n\n%s\n", path, output)
+	}
+	return nil
+}
+
+// detectionsFormatter returns the formatter of options.Format when it renders detection results
+func (u *OutputUtils) detectionsFormatter(options *types.CLIOptions) (DetectionsFormatter, bool) {
+	formatter, err := u.factory.GetFormatter(options.Format)
+	if err != nil {
+		return nil, false
+	}
+	detections, ok := formatter.(DetectionsFormatter)
+	return detections, ok
+}
+
+// OutputExplainedPlan outputs an execution plan together with its explanation.
+// JSON, YAML and TOML output is a single document with plan and explanation fields.
+func (u *OutputUtils) OutputExplainedPlan(plan *types.ExecutionPlan, explanation *types.Explanation, options *types.CLIOptions) error {
+	written, err := u.writeBundle(plan, options, ".")
+	if err != nil {
+		return err
+	}
+	if written {
+		fmt.Println(FormatExplanation(explanation))
+		return nil
+	}
+	output, err := u.formatExplainedPlan(plan, explanation, options)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// OutputExplainedPlans outputs one explained execution plan per service path.
+// JSON, YAML and TOML output is a single document keyed by path. A PlansFormatter
+// prints its document of every plan without the explanations.
+func (u *OutputUtils) OutputExplainedPlans(plans map[string]*types.ExecutionPlan, explanations map[string]*types.Explanation, options *types.CLIOptions) error {
+	if handled, err := u.outputDocument(plans, options); handled {
+		return err
+	}
+	paths := make([]string, 0, len(plans))
+	for path := range plans {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if options.Format == string(types.OutputFormatJSON) {
+		formatted := make(map[string]json.RawMessage, len(plans))
+		for _, path := range paths {
+			output, err := u.formatExplainedPlan(plans[path], explanations[path], serviceOptions(options, path))
+			if err != nil {
+				return fmt.Errorf("failed to format plan for %s: %w", path, err)
+			}
+			formatted[path] = json.RawMessage(output)
+		}
+		data, err := json.Marshal(formatted)
+		if err != nil {
+			return fmt.Errorf("failed to marshal plans to JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if encode, ok := documentEncoders[options.Format]; ok {
+		documents := make(map[string]explainedPlan, len(plans))
+		for _, path := range paths {
+			document, err := newExplainedPlan(plans[path], explanations[path])
+			if err != nil {
+				return fmt.Errorf("failed to format plan for %s: %w", path, err)
+			}
+			documents[path] = document
+		}
+		output, err := encode(documents)
+		if err != nil {
+			return fmt.Errorf("failed to marshal plans to %s: %w", options.Format, err)
+		}
+		fmt.Print(output)
+		return nil
+	}
+
+	for _, path := range paths {
+		written, err := u.writeBundle(plans[path], serviceOptions(options, path), path)
+		if err != nil {
+			return fmt.Errorf("failed to format plan for %s: %w", path, err)
+		}
+		if written {
+			fmt.Printf("📁 %s\n\n%s\n", path, FormatExplanation(explanations[path]))
+			continue
+		}
+		output, err := u.formatExplainedPlan(plans[path], explanations[path], serviceOptions(options, path))
+		if err != nil {
+			return fmt.Errorf("failed to format plan for %s: %w", path, err)
+		}
+		fmt.Printf("📁 %s\n\n%s\n", path, output)
+	}
+	return nil
+}
+
+// newExplainedPlan pairs a plan with its explanation for the structured formats
+func newExplainedPlan(plan *types.ExecutionPlan, explanation *types.Explanation) (explainedPlan, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return explainedPlan{}, fmt.Errorf("failed to marshal plan to JSON: %w", err)
+	}
+	return explainedPlan{Plan: json.RawMessage(data), Explanation: explanation}, nil
+}
+
+// formatExplainedPlan formats a plan followed by its explanation
+func (u *OutputUtils) formatExplainedPlan(plan *types.ExecutionPlan, explanation *types.Explanation, options *types.CLIOptions) (string, error) {
+	if encode, ok := documentEncoders[options.Format]; ok {
+		document, err := newExplainedPlan(plan, explanation)
+		if err != nil {
+			return "", err
+		}
+		output, err := encode(document)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal explanation to %s: %w", options.Format, err)
+		}
+		return strings.TrimSuffix(output, "\n"), nil
+	}
+
+	formatter, err := u.factory.GetFormatter(options.Format)
+	if err != nil {
+		return "", err
+	}
+	if explained, ok := formatter.(ExplainedFormatter); ok {
+		output, err := explained.FormatExplained(plan, explanation, options)
+		if err != nil {
+			return "", fmt.Errorf("failed to format plan: %w", err)
+		}
+		return output, nil
+	}
+
+	output, err := formatter.Format(plan, options)
+	if err != nil {
+		return "", fmt.Errorf("failed to format plan: %w", err)
+	}
+
+	if options.Format == string(types.OutputFormatJSON) {
+		data, err := json.Marshal(explainedPlan{Plan: json.RawMessage(output), Explanation: explanation})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal explanation to JSON: %w", err)
+		}
+		return string(data), nil
+	}
+
+	return output + "\n" + FormatExplanation(explanation), nil
+}
@@ -0,0 +1,88 @@
+package formatters
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func testExplanation() *types.Explanation {
+	return &types.Explanation{
+		Providers: []types.ProviderExplanation{
+			{
+				Provider:   "staticfile",
+				Priority:   90,
+				Confidence: 0.15,
+				Scoring: &types.Scoring{
+					Indicators: []types.ConfidenceIndicator{
+						{Description: "index.html", Weight: 25, Satisfied: true},
+						{Description: "Staticfile", Weight: 15},
+					},
+					RawConfidence: 0.5,
+					Adjustments:   []types.ConfidenceAdjustment{{Reason: "project files of another language present", Factor: 0.3}},
+					Confidence:    0.15,
+					Threshold:     0.2,
+				},
+			},
+			{Provider: "broken", Priority: 95, Error: "boom"},
+		},
+		Selection: &types.SelectionExplanation{
+			Provider: "python",
+			Rule:     types.SelectionRuleBackendFirst,
+			Reason:   "backend results win over frontend results",
+			Candidates: []types.SelectionCandidate{
+				{Provider: "python", Language: "python", Framework: "Flask", Confidence: 0.5, Backend: true},
+			},
+		},
+	}
+}
+
+func TestFormatExplanation(t *testing.T) {
+	output := FormatExplanation(testExplanation())
+
+	expected := []string{
+		"staticfile (priority 90): not matched, confidence 0.15 (threshold > 0.20)",
+		"✓ index.html (weight 25)",
+		"✗ Staticfile (weight 15)",
+		"Raw confidence: 0.50",
+		"× 0.30: project files of another language present",
+		"Error: boom",
+		"Selected: python (backend-first)",
+		"• python (python, Flask) confidence 0.50, backend",
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if FormatExplanation(nil) != "" {
+		t.Error("expected empty output for nil explanation")
+	}
+}
+
+func TestFormatExplainedPlan_JSON(t *testing.T) {
+	utils := NewOutputUtils()
+	plan := &types.ExecutionPlan{Provider: "python", Runtime: types.RuntimeConfig{Image: "python:3.11"}}
+
+	output, err := utils.formatExplainedPlan(plan, testExplanation(), &types.CLIOptions{Format: "json"})
+	if err != nil {
+		t.Fatalf("formatExplainedPlan failed: %v", err)
+	}
+
+	var decoded struct {
+		Plan        types.ExecutionPlan `json:"plan"`
+		Explanation types.Explanation   `json:"explanation"`
+	}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if decoded.Plan.Provider != "python" || decoded.Explanation.Selection.Rule != types.SelectionRuleBackendFirst {
+		t.Errorf("unexpected output: %s", output)
+	}
+	if len(decoded.Explanation.Providers[0].Scoring.Indicators) != 2 {
+		t.Errorf("expected indicators in JSON output, got %s", output)
+	}
+}
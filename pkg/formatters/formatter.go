@@ -7,6 +7,10 @@ package formatters
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/labring/devbox-pack/pkg/types"
@@ -17,6 +21,35 @@ type Formatter interface {
 	Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error)
 }
 
+// BundleFormatter formatter rendering a plan as several files, written to --output-dir
+type BundleFormatter interface {
+	Formatter
+	// FormatFiles returns file contents keyed by path relative to the output directory
+	FormatFiles(plan *types.ExecutionPlan, options *types.CLIOptions) (map[string]string, error)
+}
+
+// ExplainedFormatter formatter rendering the --explain explanation itself instead of
+// having the text explanation appended to its output
+type ExplainedFormatter interface {
+	Formatter
+	FormatExplained(plan *types.ExecutionPlan, explanation *types.Explanation, options *types.CLIOptions) (string, error)
+}
+
+// DetectionsFormatter formatter rendering the detection results a plan was generated
+// from, and the --explain explanation when there is one, along with the plan
+type DetectionsFormatter interface {
+	ExplainedFormatter
+	FormatDetections(plan *types.ExecutionPlan, detections []*types.DetectResult, explanation *types.Explanation, options *types.CLIOptions) (string, error)
+}
+
+// PlansFormatter formatter rendering the plans of every --monorepo service as one document
+// instead of one output per service. The document does not include explanations.
+type PlansFormatter interface {
+	Formatter
+	// FormatPlans formats plans keyed by service path relative to the repository root
+	FormatPlans(plans map[string]*types.ExecutionPlan, options *types.CLIOptions) (string, error)
+}
+
 // JSONFormatter JSON formatter
 type JSONFormatter struct{}
 
@@ -78,11 +111,16 @@ func (f *PrettyFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOp
 		lines = append(lines, fmt.Sprintf("Framework: %s", *plan.Runtime.Framework))
 	}
 
-	// Environment variables are now at the top level
+	// Environment variables are now at the top level, sorted so output is stable
 	if len(plan.Environment) > 0 {
 		lines = append(lines, "Environment Variables:")
-		for key, value := range plan.Environment {
-			lines = append(lines, fmt.Sprintf("  %s=%s", key, value))
+		keys := make([]string, 0, len(plan.Environment))
+		for key := range plan.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("  %s=%s", key, plan.Environment[key]))
 		}
 	}
 	lines = append(lines, "")
@@ -136,8 +174,19 @@ func (f *PrettyFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOp
 		lines = append(lines, "")
 	}
 
+	// Backing services
+	if len(plan.BackingServices) > 0 {
+		lines = append(lines, "🗄️  Backing Services")
+		lines = append(lines, strings.Repeat("─", 20))
+		for _, service := range plan.BackingServices {
+			sources := append(append([]string(nil), service.Dependencies...), service.Environment...)
+			lines = append(lines, fmt.Sprintf("  • %s (%s)", service.Name, strings.Join(sources, ", ")))
+		}
+		lines = append(lines, "")
+	}
+
 	// Detection evidence
-	if len(plan.Evidence.Files) > 0 || plan.Evidence.Reason != "" {
+	if len(plan.Evidence.Files) > 0 || plan.Evidence.Reason != "" || len(plan.Evidence.Overrides) > 0 {
 		lines = append(lines, "🔍 Detection Evidence")
 		lines = append(lines, strings.Repeat("─", 20))
 
@@ -154,6 +203,10 @@ func (f *PrettyFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOp
 			}
 			lines = append(lines, fmt.Sprintf("Reason: %s", plan.Evidence.Reason))
 		}
+
+		for _, override := range plan.Evidence.Overrides {
+			lines = append(lines, fmt.Sprintf("Overridden by %s: %s", override.File, strings.Join(override.Fields, ", ")))
+		}
 		lines = append(lines, "")
 	}
 
@@ -174,6 +227,18 @@ func NewFormatterFactory() *FormatterFactory {
 	// Register default formatters
 	factory.formatters["json"] = NewJSONFormatter()
 	factory.formatters["pretty"] = NewPrettyFormatter()
+	factory.formatters["dockerfile"] = NewDockerfileFormatter()
+	factory.formatters["k8s"] = NewKubernetesFormatter()
+	factory.formatters["compose"] = NewComposeFormatter()
+	factory.formatters["yaml"] = NewYAMLFormatter()
+	factory.formatters["toml"] = NewTOMLFormatter()
+	factory.formatters["scripts"] = NewScriptsFormatter()
+	factory.formatters["github-actions"] = NewGitHubActionsFormatter()
+	factory.formatters["gitlab-ci"] = NewGitLabCIFormatter()
+	factory.formatters["devcontainer"] = NewDevcontainerFormatter()
+	factory.formatters["railpack"] = NewRailpackFormatter()
+	factory.formatters["markdown"] = NewMarkdownFormatter()
+	factory.formatters["sarif"] = NewSARIFFormatter()
 
 	return factory
 }
@@ -197,12 +262,13 @@ func (f *FormatterFactory) Format(plan *types.ExecutionPlan, format string) (str
 	return formatter.Format(plan, nil)
 }
 
-// GetSupportedFormats gets supported format list
+// GetSupportedFormats gets supported format list in alphabetical order
 func (f *FormatterFactory) GetSupportedFormats() []string {
 	formats := make([]string, 0, len(f.formatters))
 	for format := range f.formatters {
 		formats = append(formats, format)
 	}
+	sort.Strings(formats)
 	return formats
 }
 
@@ -218,14 +284,96 @@ type OutputUtils struct {
 
 // NewOutputUtils creates new output utility
 func NewOutputUtils() *OutputUtils {
+	return NewOutputUtilsWithFactory(NewFormatterFactory())
+}
+
+// NewOutputUtilsWithFactory creates new output utility formatting with the formatters
+// of factory, including custom ones registered with RegisterFormatter
+func NewOutputUtilsWithFactory(factory *FormatterFactory) *OutputUtils {
 	return &OutputUtils{
-		factory: NewFormatterFactory(),
+		factory: factory,
 	}
 }
 
-// OutputPlan outputs execution plan
+// format formats execution plan with the formatter selected by options.Format
+func (u *OutputUtils) format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	formatter, err := u.factory.GetFormatter(options.Format)
+	if err != nil {
+		return "", err
+	}
+	return formatter.Format(plan, options)
+}
+
+// serviceOptions returns options for formatting the plan of one service path,
+// with the service path appended to the subdirectory
+func serviceOptions(options *types.CLIOptions, servicePath string) *types.CLIOptions {
+	if servicePath == "." {
+		return options
+	}
+	copied := *options
+	subdir := servicePath
+	if options.Subdir != nil {
+		subdir = path.Join(*options.Subdir, servicePath)
+	}
+	copied.Subdir = &subdir
+	return &copied
+}
+
+// writeBundle writes the files of a bundle format into the service path below
+// options.OutputDir, reporting whether the plan was written. Plans are printed
+// instead when no output directory is set or the format is not a bundle.
+func (u *OutputUtils) writeBundle(plan *types.ExecutionPlan, options *types.CLIOptions, servicePath string) (bool, error) {
+	if options.OutputDir == nil {
+		return false, nil
+	}
+	formatter, err := u.factory.GetFormatter(options.Format)
+	if err != nil {
+		return false, err
+	}
+	bundle, ok := formatter.(BundleFormatter)
+	if !ok {
+		return false, nil
+	}
+
+	files, err := bundle.FormatFiles(plan, options)
+	if err != nil {
+		return false, fmt.Errorf("failed to format plan: %w", err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dir := filepath.Join(*options.OutputDir, filepath.FromSlash(servicePath))
+	for _, name := range names {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return false, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		// Scripts are written executable
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0755
+		}
+		if err := os.WriteFile(target, []byte(files[name]), mode); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		// WriteFile keeps the mode of existing files
+		if err := os.Chmod(target, mode); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		u.OutputSuccess(fmt.Sprintf("Wrote %s", target), options)
+	}
+	return true, nil
+}
+
+// OutputPlan outputs execution plan, writing bundle formats to options.OutputDir when set
 func (u *OutputUtils) OutputPlan(plan *types.ExecutionPlan, options *types.CLIOptions) error {
-	output, err := u.factory.Format(plan, string(options.Format))
+	if written, err := u.writeBundle(plan, options, "."); written || err != nil {
+		return err
+	}
+	output, err := u.format(plan, options)
 	if err != nil {
 		return fmt.Errorf("failed to format plan: %w", err)
 	}
@@ -233,6 +381,82 @@ func (u *OutputUtils) OutputPlan(plan *types.ExecutionPlan, options *types.CLIOp
 	return nil
 }
 
+// OutputPlans outputs one execution plan per service path.
+// JSON, YAML and TOML output is a single document keyed by path, as is the output of a
+// PlansFormatter; bundle formats with an output directory write each plan to its service
+// path; other formats print each plan under a header.
+func (u *OutputUtils) OutputPlans(plans map[string]*types.ExecutionPlan, options *types.CLIOptions) error {
+	if handled, err := u.outputDocument(plans, options); handled {
+		return err
+	}
+
+	paths := make([]string, 0, len(plans))
+	for path := range plans {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if options.Format == string(types.OutputFormatJSON) {
+		formatted := make(map[string]json.RawMessage, len(plans))
+		for _, path := range paths {
+			output, err := u.format(plans[path], serviceOptions(options, path))
+			if err != nil {
+				return fmt.Errorf("failed to format plan for %s: %w", path, err)
+			}
+			formatted[path] = json.RawMessage(output)
+		}
+		data, err := json.Marshal(formatted)
+		if err != nil {
+			return fmt.Errorf("failed to marshal plans to JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if encode, ok := documentEncoders[options.Format]; ok {
+		output, err := encode(plans)
+		if err != nil {
+			return fmt.Errorf("failed to marshal plans to %s: %w", options.Format, err)
+		}
+		fmt.Print(output)
+		return nil
+	}
+
+	for _, path := range paths {
+		written, err := u.writeBundle(plans[path], serviceOptions(options, path), path)
+		if err != nil {
+			return fmt.Errorf("failed to format plan for %s: %w", path, err)
+		}
+		if written {
+			continue
+		}
+		output, err := u.format(plans[path], serviceOptions(options, path))
+		if err != nil {
+			return fmt.Errorf("failed to format plan for %s: %w", path, err)
+		}
+		fmt.Printf("📁 %s\n\n%s\n", path, output)
+	}
+	return nil
+}
+
+// outputDocument prints plans as one document when the format has a PlansFormatter,
+// reporting whether it did
+func (u *OutputUtils) outputDocument(plans map[string]*types.ExecutionPlan, options *types.CLIOptions) (bool, error) {
+	formatter, err := u.factory.GetFormatter(options.Format)
+	if err != nil {
+		return false, nil
+	}
+	document, ok := formatter.(PlansFormatter)
+	if !ok {
+		return false, nil
+	}
+	output, err := document.FormatPlans(plans, options)
+	if err != nil {
+		return true, fmt.Errorf("failed to format plans: %w", err)
+	}
+	fmt.Println(output)
+	return true, nil
+}
+
 // OutputError outputs error information
 func (u *OutputUtils) OutputError(err error, options *types.CLIOptions) {
 	if options != nil && options.Verbose {
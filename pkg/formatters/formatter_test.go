@@ -262,7 +262,7 @@ func TestNewFormatterFactory(t *testing.T) {
 
 	// Check that default formatters are registered
 	supportedFormats := factory.GetSupportedFormats()
-	expectedFormats := []string{"json", "pretty"}
+	expectedFormats := []string{"json", "pretty", "dockerfile", "k8s", "compose", "yaml", "toml", "scripts", "github-actions", "gitlab-ci", "devcontainer", "railpack", "markdown", "sarif"}
 
 	for _, expected := range expectedFormats {
 		found := false
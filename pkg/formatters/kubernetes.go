@@ -0,0 +1,186 @@
+/**
+ * DevBox Pack Execution Plan Generator - Kubernetes Formatter
+ */
+
+package formatters
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Kubernetes manifest defaults
+const (
+	// KubernetesDefaultName names resources when no repository name is known
+	KubernetesDefaultName = "app"
+	// KubernetesDefaultReplicas is the Deployment replica count without --replicas
+	KubernetesDefaultReplicas = 1
+	// kubernetesPortName names the container port referenced by the Service and probes
+	kubernetesPortName = "http"
+	// kubernetesNameLabel selects the Pods of a Deployment
+	kubernetesNameLabel = "app.kubernetes.io/name"
+)
+
+// invalidNameCharacters are the characters not allowed in DNS-1123 labels
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// KubernetesFormatter renders an execution plan as Kubernetes Deployment and Service manifests
+type KubernetesFormatter struct{}
+
+// NewKubernetesFormatter creates a new Kubernetes formatter
+func NewKubernetesFormatter() *KubernetesFormatter {
+	return &KubernetesFormatter{}
+}
+
+// Format formats execution plan as Kubernetes YAML manifests
+func (f *KubernetesFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	if plan.Runtime.Image == "" {
+		return "", fmt.Errorf("execution plan has no runtime image, set one with --base")
+	}
+
+	// Names such as 2048 must stay strings, labels only take string values
+	name := markup.YAMLString(KubernetesName(options))
+	replicas := KubernetesDefaultReplicas
+	var namespace string
+	if options != nil {
+		if options.Replicas != nil {
+			replicas = *options.Replicas
+		}
+		if options.Namespace != nil {
+			namespace = *options.Namespace
+		}
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("# Generated by DevBox Pack from the %s execution plan", plan.Provider))
+
+	// Deployment
+	lines = append(lines, "apiVersion: apps/v1", "kind: Deployment")
+	lines = append(lines, kubernetesMetadata(name, namespace)...)
+	lines = append(lines,
+		"spec:",
+		fmt.Sprintf("  replicas: %d", replicas),
+		"  selector:",
+		"    matchLabels:",
+		fmt.Sprintf("      %s: %s", kubernetesNameLabel, name),
+		"  template:",
+		"    metadata:",
+		"      labels:",
+		fmt.Sprintf("        %s: %s", kubernetesNameLabel, name),
+		"    spec:",
+		"      containers:",
+		fmt.Sprintf("        - name: %s", name),
+		fmt.Sprintf("          image: %s", markup.YAMLString(plan.Runtime.Image)),
+	)
+	if len(plan.Commands.Run) > 0 {
+		command := []string{"sh", "-c", strings.Join(plan.Commands.Run, " && ")}
+		lines = append(lines, "          command:")
+		for _, argument := range command {
+			lines = append(lines, "            - "+markup.YAMLString(argument))
+		}
+	}
+	if len(plan.Environment) > 0 {
+		keys := make([]string, 0, len(plan.Environment))
+		for key := range plan.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lines = append(lines, "          env:")
+		for _, key := range keys {
+			// Values are always quoted, Kubernetes rejects numbers and booleans here
+			lines = append(lines,
+				"            - name: "+markup.YAMLString(key),
+				"              value: "+yamlQuoted(plan.Environment[key]),
+			)
+		}
+	}
+	if plan.Port > 0 {
+		lines = append(lines,
+			"          ports:",
+			"            - name: "+kubernetesPortName,
+			fmt.Sprintf("              containerPort: %d", plan.Port),
+		)
+		if plan.HealthCheck != "" {
+			for _, probe := range []string{"readinessProbe", "livenessProbe"} {
+				lines = append(lines,
+					fmt.Sprintf("          %s:", probe),
+					"            httpGet:",
+					"              path: "+markup.YAMLString(plan.HealthCheck),
+					"              port: "+kubernetesPortName,
+				)
+			}
+		}
+
+		// Service
+		lines = append(lines, "---", "apiVersion: v1", "kind: Service")
+		lines = append(lines, kubernetesMetadata(name, namespace)...)
+		lines = append(lines,
+			"spec:",
+			"  selector:",
+			fmt.Sprintf("    %s: %s", kubernetesNameLabel, name),
+			"  ports:",
+			"    - name: "+kubernetesPortName,
+			fmt.Sprintf("      port: %d", plan.Port),
+			"      targetPort: "+kubernetesPortName,
+		)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// KubernetesName derives the resource name from the analysed repository, appending
+// the subdirectory so services of one repository get distinct names. The name is
+// reduced to a DNS-1123 label.
+func KubernetesName(options *types.CLIOptions) string {
+	if options == nil || options.Repository == "" {
+		return KubernetesDefaultName
+	}
+
+	name := git.RepoName(options.Repository)
+	if options.Subdir != nil {
+		if subdir := path.Clean(strings.Trim(*options.Subdir, "/")); subdir != "." {
+			name += "-" + strings.ReplaceAll(subdir, "/", "-")
+		}
+	}
+
+	name = invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	name = strings.Trim(name, "-")
+	if name == "" {
+		return KubernetesDefaultName
+	}
+	return name
+}
+
+// kubernetesMetadata renders the metadata block shared by every resource, of a
+// name already rendered as a YAML scalar
+func kubernetesMetadata(name, namespace string) []string {
+	lines := []string{"metadata:", "  name: " + name}
+	if namespace != "" {
+		lines = append(lines, "  namespace: "+markup.YAMLString(namespace))
+	}
+	return append(lines,
+		"  labels:",
+		fmt.Sprintf("    %s: %s", kubernetesNameLabel, name),
+		"    app.kubernetes.io/managed-by: devbox-pack",
+	)
+}
+
+// yamlQuoted renders a string as a double-quoted YAML scalar
+func yamlQuoted(value string) string {
+	// JSON strings are valid double-quoted YAML scalars
+	quoted, _ := encodeJSON(value)
+	return quoted
+}
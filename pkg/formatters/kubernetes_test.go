@@ -0,0 +1,179 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// kubernetesDocuments parses the YAML documents of a Kubernetes formatter output
+func kubernetesDocuments(t *testing.T, output string) []map[string]interface{} {
+	t.Helper()
+	var documents []map[string]interface{}
+	for _, content := range strings.Split(output, "\n---\n") {
+		document, err := markup.ParseYAML(content)
+		if err != nil {
+			t.Fatalf("output is not valid YAML: %v\n%s", err, content)
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+// field follows a dotted path through parsed YAML, indexing lists with numbers
+func field(t *testing.T, document interface{}, fieldPath string) interface{} {
+	t.Helper()
+	current := document
+	for _, part := range strings.Split(fieldPath, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current = node[part]
+		case []interface{}:
+			index := int(part[0] - '0')
+			if index >= len(node) {
+				t.Fatalf("%s: index %d out of range", fieldPath, index)
+			}
+			current = node[index]
+		default:
+			t.Fatalf("%s: cannot descend into %v", fieldPath, current)
+		}
+	}
+	return current
+}
+
+func TestKubernetesFormatter_Format(t *testing.T) {
+	namespace := "prod"
+	replicas := 3
+	subdir := "services/api"
+	options := &types.CLIOptions{
+		Repository: "https://github.com/acme/Shop.git",
+		Subdir:     &subdir,
+		Namespace:  &namespace,
+		Replicas:   &replicas,
+	}
+	plan := &types.ExecutionPlan{
+		Provider:    "node",
+		Runtime:     types.RuntimeConfig{Image: "node:20-alpine"},
+		Environment: map[string]string{"PORT": "3000", "DEBUG": "true"},
+		Commands:    types.Commands{Run: []string{"npm start"}},
+		Port:        3000,
+		HealthCheck: "/healthz",
+	}
+
+	output, err := NewKubernetesFormatter().Format(plan, options)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	documents := kubernetesDocuments(t, output)
+	if len(documents) != 2 {
+		t.Fatalf("expected Deployment and Service, got %d documents", len(documents))
+	}
+	deployment, service := documents[0], documents[1]
+
+	expected := map[string]interface{}{
+		"kind":                                  "Deployment",
+		"metadata.name":                         "shop-services-api",
+		"metadata.namespace":                    "prod",
+		"spec.replicas":                         int64(3),
+		"spec.template.spec.containers.0.image": "node:20-alpine",
+		"spec.template.spec.containers.0.command.2":                   "npm start",
+		"spec.template.spec.containers.0.env.0.name":                  "DEBUG",
+		"spec.template.spec.containers.0.env.0.value":                 "true",
+		"spec.template.spec.containers.0.ports.0.containerPort":       int64(3000),
+		"spec.template.spec.containers.0.readinessProbe.httpGet.path": "/healthz",
+		"spec.template.spec.containers.0.livenessProbe.httpGet.port":  "http",
+	}
+	for fieldPath, value := range expected {
+		if got := field(t, deployment, fieldPath); got != value {
+			t.Errorf("Deployment %s: expected %v (%T), got %v (%T)", fieldPath, value, value, got, got)
+		}
+	}
+	if got := field(t, service, "spec.ports.0.port"); got != int64(3000) {
+		t.Errorf("expected Service port 3000, got %v", got)
+	}
+	if got := field(t, service, "spec.selector").(map[string]interface{})["app.kubernetes.io/name"]; got != "shop-services-api" {
+		t.Errorf("expected Service selector to match the Deployment, got %v", got)
+	}
+}
+
+func TestKubernetesFormatter_FormatWithoutPort(t *testing.T) {
+	plan := &types.ExecutionPlan{
+		Provider:    "shell",
+		Runtime:     types.RuntimeConfig{Image: "alpine:latest"},
+		Commands:    types.Commands{Run: []string{"./run.sh"}},
+		HealthCheck: "/healthz",
+	}
+
+	output, err := NewKubernetesFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	documents := kubernetesDocuments(t, output)
+	if len(documents) != 1 {
+		t.Fatalf("expected only a Deployment without a port, got %d documents", len(documents))
+	}
+	if field(t, documents[0], "metadata.name") != KubernetesDefaultName || field(t, documents[0], "spec.replicas") != int64(KubernetesDefaultReplicas) {
+		t.Errorf("expected default name and replicas:\n%s", output)
+	}
+	if strings.Contains(output, "Probe") || strings.Contains(output, "namespace:") {
+		t.Errorf("unexpected probes or namespace without port and --namespace:\n%s", output)
+	}
+}
+
+func TestKubernetesFormatter_NumericName(t *testing.T) {
+	plan := &types.ExecutionPlan{
+		Provider: "node",
+		Runtime:  types.RuntimeConfig{Image: "node:20-alpine"},
+		Commands: types.Commands{Run: []string{"npm start"}},
+		Port:     3000,
+	}
+
+	for _, repository := range []string{"https://github.com/acme/2048", "https://github.com/acme/1e3", "https://github.com/acme/0x10", "https://github.com/acme/y"} {
+		name := KubernetesName(&types.CLIOptions{Repository: repository})
+		output, err := NewKubernetesFormatter().Format(plan, &types.CLIOptions{Repository: repository})
+		if err != nil {
+			t.Fatalf("Format failed: %v", err)
+		}
+		// Every name and label must read back as the string, not a number or boolean
+		documents := kubernetesDocuments(t, output)
+		if len(documents) != 2 {
+			t.Fatalf("%s: expected a Deployment and a Service, got %d documents", repository, len(documents))
+		}
+		for i, document := range documents {
+			if value := field(t, document, "metadata.name"); value != name {
+				t.Errorf("%s: expected document %d name %q, got %#v", repository, i, name, value)
+			}
+		}
+		for _, fieldPath := range []string{"0.metadata.labels", "0.spec.selector.matchLabels", "0.spec.template.metadata.labels", "1.metadata.labels", "1.spec.selector"} {
+			documentIndex, labelsPath, _ := strings.Cut(fieldPath, ".")
+			labels, _ := field(t, documents[documentIndex[0]-'0'], labelsPath).(map[string]interface{})
+			if labels[kubernetesNameLabel] != name {
+				t.Errorf("%s: expected %s label %q, got %#v", repository, fieldPath, name, labels[kubernetesNameLabel])
+			}
+		}
+		if value := field(t, documents[0], "spec.template.spec.containers.0.name"); value != name {
+			t.Errorf("%s: expected container name %q, got %#v", repository, name, value)
+		}
+	}
+}
+
+func TestKubernetesName(t *testing.T) {
+	subdir := "/apps/Web_UI/"
+	tests := []struct {
+		options  *types.CLIOptions
+		expected string
+	}{
+		{nil, KubernetesDefaultName},
+		{&types.CLIOptions{Repository: "git@github.com:acme/My.Repo.git"}, "my-repo"},
+		{&types.CLIOptions{Repository: "https://github.com/acme/shop", Subdir: &subdir}, "shop-apps-web-ui"},
+		{&types.CLIOptions{Repository: "https://github.com/acme/" + strings.Repeat("x", 70)}, strings.Repeat("x", 63)},
+	}
+
+	for _, tt := range tests {
+		if name := KubernetesName(tt.options); name != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, name)
+		}
+	}
+}
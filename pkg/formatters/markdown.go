@@ -0,0 +1,193 @@
+/**
+ * DevBox Pack Execution Plan Generator - Markdown Formatter
+ */
+
+package formatters
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/lint"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// MarkdownFormatter renders an execution plan as GitHub flavored Markdown tables,
+// suitable for a pull request comment
+type MarkdownFormatter struct{}
+
+// NewMarkdownFormatter creates a new Markdown formatter
+func NewMarkdownFormatter() *MarkdownFormatter {
+	return &MarkdownFormatter{}
+}
+
+// Format formats execution plan as Markdown
+func (f *MarkdownFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+
+	title := "## Execution plan"
+	if options != nil && options.Repository != "" {
+		name := git.RepoName(options.Repository)
+		if options.Subdir != nil {
+			if subdir := path.Clean(strings.Trim(*options.Subdir, "/")); subdir != "." {
+				name += "/" + subdir
+			}
+		}
+		title += " for " + markdownCode(name)
+	}
+	lines := []string{title, ""}
+
+	lines = append(lines, "### Runtime", "", "| Field | Value |", "| --- | --- |")
+	lines = append(lines, markdownRow("Provider", plan.Provider))
+	if plan.Runtime.Framework != nil && *plan.Runtime.Framework != "" {
+		lines = append(lines, markdownRow("Framework", *plan.Runtime.Framework))
+	}
+	lines = append(lines, markdownRow("Image", markdownCodeOrNone(plan.Runtime.Image)))
+	if plan.Runtime.Version != "" {
+		lines = append(lines, markdownRow("Version", plan.Runtime.Version))
+	}
+	if plan.Port > 0 {
+		lines = append(lines, markdownRow("Port", fmt.Sprint(plan.Port)))
+	}
+	if plan.HealthCheck != "" {
+		lines = append(lines, markdownRow("Health check", markdownCode(plan.HealthCheck)))
+	}
+	if len(plan.Apt) > 0 {
+		lines = append(lines, markdownRow("System packages", markdownCodes(plan.Apt, ", ")))
+	}
+	if len(plan.BackingServices) > 0 {
+		names := make([]string, len(plan.BackingServices))
+		for i, service := range plan.BackingServices {
+			names[i] = service.Name
+		}
+		lines = append(lines, markdownRow("Backing services", strings.Join(names, ", ")))
+	}
+
+	lines = append(lines, "", "### Commands", "", "| Phase | Commands |", "| --- | --- |")
+	phases := []struct {
+		name     string
+		commands []string
+	}{
+		{"Setup", plan.Commands.Setup},
+		{"Dev", plan.Commands.Dev},
+		{"Build", plan.Commands.Build},
+		{"Run", plan.Commands.Run},
+	}
+	for _, phase := range phases {
+		lines = append(lines, markdownRow(phase.name, markdownCodes(phase.commands, "<br>")))
+	}
+
+	if len(plan.Environment) > 0 {
+		keys := make([]string, 0, len(plan.Environment))
+		for key := range plan.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		lines = append(lines, "", "### Environment", "", "| Variable | Value |", "| --- | --- |")
+		for _, key := range keys {
+			lines = append(lines, markdownRow(markdownCode(key), markdownCodeOrNone(plan.Environment[key])))
+		}
+	}
+
+	lines = append(lines, "", "### Evidence", "")
+	if plan.Evidence.Reason != "" {
+		lines = append(lines, markdownEscape(plan.Evidence.Reason), "")
+	}
+	// Files replacing detected values list the fields they set
+	overridden := make(map[string][]string)
+	files := append([]string(nil), plan.Evidence.Files...)
+	for _, override := range plan.Evidence.Overrides {
+		if !containsString(files, override.File) {
+			files = append(files, override.File)
+		}
+		overridden[override.File] = append(overridden[override.File], override.Fields...)
+	}
+	lines = append(lines, "| File | Overridden fields |", "| --- | --- |")
+	for _, file := range files {
+		lines = append(lines, markdownRow(markdownCode(file), markdownCodes(overridden[file], ", ")))
+	}
+
+	lines = append(lines, "", "### Warnings", "")
+	diagnostics := lint.Check(plan)
+	if len(diagnostics) == 0 {
+		lines = append(lines, "No warnings.")
+	} else {
+		lines = append(lines, "| Severity | Rule | File | Message |", "| --- | --- | --- | --- |")
+		for _, diagnostic := range diagnostics {
+			file := ""
+			if diagnostic.File != "" {
+				file = markdownCode(diagnostic.File)
+			}
+			lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s |",
+				diagnostic.Severity, markdownCode(diagnostic.Rule), file, markdownEscape(diagnostic.Message)))
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// FormatExplained formats execution plan as Markdown with the explanation in a collapsed section
+func (f *MarkdownFormatter) FormatExplained(plan *types.ExecutionPlan, explanation *types.Explanation, options *types.CLIOptions) (string, error) {
+	output, err := f.Format(plan, options)
+	if err != nil || explanation == nil {
+		return output, err
+	}
+	return output + "\n\n<details>\n<summary>Detection explanation</summary>\n\n```\n" +
+		strings.TrimSuffix(FormatExplanation(explanation), "\n") + "\n```\n\n</details>", nil
+}
+
+// markdownRow renders a two-column table row
+func markdownRow(name, value string) string {
+	return fmt.Sprintf("| %s | %s |", name, value)
+}
+
+// markdownCode renders a value as an inline code span that is safe in a table cell
+func markdownCode(value string) string {
+	fence := "`"
+	if strings.Contains(value, "`") {
+		fence = "``"
+		value = " " + value + " "
+	}
+	return fence + markdownEscape(value) + fence
+}
+
+// markdownCodeOrNone renders a value as code, or _none_ when it is empty
+func markdownCodeOrNone(value string) string {
+	if value == "" {
+		return "_none_"
+	}
+	return markdownCode(value)
+}
+
+// markdownCodes renders values as code spans joined by sep, or _none_ when there are none
+func markdownCodes(values []string, sep string) string {
+	if len(values) == 0 {
+		return "_none_"
+	}
+	codes := make([]string, len(values))
+	for i, value := range values {
+		codes[i] = markdownCode(value)
+	}
+	return strings.Join(codes, sep)
+}
+
+// markdownEscape keeps text within its table cell: pipes end cells and newlines end rows
+func markdownEscape(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestMarkdownFormatter_Format(t *testing.T) {
+	subdir := "web"
+	options := &types.CLIOptions{Repository: "https://github.com/acme/shop.git", Subdir: &subdir}
+	plan := ciTestPlan()
+	plan.Port = 3000
+	plan.Commands.Run = nil
+	plan.Environment["QUERY"] = "a|b"
+	plan.Evidence.Reason = "Detected Node.js project based on: package.json"
+	plan.Evidence.Overrides = []types.OverrideEvidence{{File: "devbox-pack.toml", Fields: []string{"port"}}}
+
+	output, err := NewMarkdownFormatter().Format(plan, options)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"## Execution plan for `shop/web`",
+		"| Image | `node:18-alpine` |",
+		"| System packages | `build-essential` |",
+		"| Build | `pnpm run check`<br>`pnpm run build` |",
+		"| Run | _none_ |",
+		"| `QUERY` | `a\\|b` |",
+		"| `pnpm-lock.yaml` | _none_ |",
+		"| `devbox-pack.toml` | `port` |",
+		"| warning | `empty-run-command` | `package.json` |",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q:\n%s", expected, output)
+		}
+	}
+
+	plan.Commands.Run = []string{"pnpm start"}
+	output, err = NewMarkdownFormatter().FormatExplained(plan, &types.Explanation{
+		Selection: &types.SelectionExplanation{Provider: "node", Rule: types.SelectionRuleOnlyMatch, Reason: "Only node matched"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("FormatExplained failed: %v", err)
+	}
+	for _, expected := range []string{"## Execution plan\n", "No warnings.", "<summary>Detection explanation</summary>", "Reason: Only node matched"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q:\n%s", expected, output)
+		}
+	}
+}
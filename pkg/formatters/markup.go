@@ -0,0 +1,58 @@
+/**
+ * DevBox Pack Execution Plan Generator - YAML and TOML Formatters
+ */
+
+package formatters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// documentEncoders encode whole documents, such as the plans of every monorepo
+// service, in the structured formats other than JSON
+var documentEncoders = map[string]func(value interface{}) (string, error){
+	string(types.OutputFormatYAML): markup.EncodeYAML,
+	string(types.OutputFormatTOML): markup.EncodeTOML,
+}
+
+// YAMLFormatter YAML formatter, with fields in the same order as JSON output
+type YAMLFormatter struct{}
+
+// NewYAMLFormatter creates a new YAML formatter
+func NewYAMLFormatter() *YAMLFormatter {
+	return &YAMLFormatter{}
+}
+
+// Format formats execution plan as YAML
+func (f *YAMLFormatter) Format(plan *types.ExecutionPlan, _ *types.CLIOptions) (string, error) {
+	return encodePlan(plan, markup.EncodeYAML, "YAML")
+}
+
+// TOMLFormatter TOML formatter, with fields in the same order as JSON output
+type TOMLFormatter struct{}
+
+// NewTOMLFormatter creates a new TOML formatter
+func NewTOMLFormatter() *TOMLFormatter {
+	return &TOMLFormatter{}
+}
+
+// Format formats execution plan as TOML
+func (f *TOMLFormatter) Format(plan *types.ExecutionPlan, _ *types.CLIOptions) (string, error) {
+	return encodePlan(plan, markup.EncodeTOML, "TOML")
+}
+
+// encodePlan encodes a plan with a markup encoder
+func encodePlan(plan *types.ExecutionPlan, encode func(interface{}) (string, error), name string) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	output, err := encode(plan)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal plan to %s: %w", name, err)
+	}
+	return strings.TrimSuffix(output, "\n"), nil
+}
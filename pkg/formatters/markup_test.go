@@ -0,0 +1,83 @@
+package formatters
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// markupPlan returns a plan using every kind of plan field
+func markupPlan() *types.ExecutionPlan {
+	framework := "express"
+	return &types.ExecutionPlan{
+		Provider:    "node",
+		Runtime:     types.RuntimeConfig{Image: "node:20-alpine", Framework: &framework},
+		Environment: map[string]string{"PORT": "3000", "NODE_ENV": "production", "API_URL": "http://localhost:8080"},
+		Apt:         []string{"build-essential"},
+		Commands: types.Commands{
+			Setup: []string{"npm ci"},
+			Run:   []string{"npm start"},
+		},
+		Port:            3000,
+		BackingServices: []types.BackingService{{Name: "redis", Dependencies: []string{"ioredis"}}},
+		Evidence:        types.Evidence{Files: []string{"package.json"}, Reason: "Node.js project detected"},
+	}
+}
+
+func TestMarkupFormatters_RoundTrip(t *testing.T) {
+	tests := []struct {
+		format string
+		parse  func(string) (map[string]interface{}, error)
+	}{
+		{"yaml", markup.ParseYAML},
+		{"toml", markup.ParseTOML},
+	}
+
+	expectedData, _ := json.Marshal(markupPlan())
+	var expected types.ExecutionPlan
+	_ = json.Unmarshal(expectedData, &expected)
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output, err := NewFormatterFactory().Format(markupPlan(), tt.format)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if !strings.HasPrefix(output, `provider`) {
+				t.Errorf("expected provider first, as in JSON output:\n%s", output)
+			}
+
+			parsed, err := tt.parse(output)
+			if err != nil {
+				t.Fatalf("output does not parse: %v\n%s", err, output)
+			}
+			data, _ := json.Marshal(parsed)
+			var plan types.ExecutionPlan
+			if err := json.Unmarshal(data, &plan); err != nil {
+				t.Fatalf("parsed output is not a plan: %v", err)
+			}
+			if !reflect.DeepEqual(plan, expected) {
+				t.Errorf("round trip changed the plan:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestFormatters_Deterministic(t *testing.T) {
+	factory := NewFormatterFactory()
+	for _, format := range factory.GetSupportedFormats() {
+		first, err := factory.Format(markupPlan(), format)
+		if err != nil {
+			t.Fatalf("%s: Format failed: %v", format, err)
+		}
+		for i := 0; i < 10; i++ {
+			if output, _ := factory.Format(markupPlan(), format); output != first {
+				t.Fatalf("%s: expected identical output for identical plans", format)
+			}
+		}
+	}
+}
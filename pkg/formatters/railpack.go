@@ -0,0 +1,125 @@
+/**
+ * DevBox Pack Execution Plan Generator - Railpack Build Plan Formatter
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// RailpackFormatter renders an execution plan as a Railpack build plan,
+// the railpack-plan.json the Railpack BuildKit frontend builds images from
+type RailpackFormatter struct{}
+
+// NewRailpackFormatter creates a new Railpack build plan formatter
+func NewRailpackFormatter() *RailpackFormatter {
+	return &RailpackFormatter{}
+}
+
+// railpackPlan is a Railpack build plan
+type railpackPlan struct {
+	Steps  []railpackStep `json:"steps"`
+	Deploy railpackDeploy `json:"deploy"`
+}
+
+// railpackStep is a build step running commands on top of its input layers
+type railpackStep struct {
+	Name      string            `json:"name"`
+	Inputs    []railpackLayer   `json:"inputs"`
+	Commands  []railpackCommand `json:"commands,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// railpackLayer is an image, the output of a step or the local project files
+type railpackLayer struct {
+	Image   string   `json:"image,omitempty"`
+	Step    string   `json:"step,omitempty"`
+	Local   bool     `json:"local,omitempty"`
+	Include []string `json:"include,omitempty"`
+}
+
+// railpackCommand is a command executed in the step
+type railpackCommand struct {
+	Cmd        string `json:"cmd"`
+	CustomName string `json:"customName,omitempty"`
+}
+
+// railpackDeploy describes the image that runs the application
+type railpackDeploy struct {
+	Base      railpackLayer     `json:"base"`
+	StartCmd  string            `json:"startCommand,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// Format formats execution plan as a Railpack build plan
+func (f *RailpackFormatter) Format(plan *types.ExecutionPlan, _ *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	if plan.Runtime.Image == "" {
+		return "", fmt.Errorf("execution plan has no runtime image, set one with --base")
+	}
+
+	var steps []railpackStep
+	base := railpackLayer{Image: plan.Runtime.Image}
+	if packages := toolFor(plan.Runtime.Image).packages(plan.Apt); len(packages) > 0 {
+		install := fmt.Sprintf(toolFor(plan.Runtime.Image).install, strings.Join(packages, " "))
+		steps = append(steps, railpackStep{
+			Name:     "packages:apt",
+			Inputs:   []railpackLayer{base},
+			Commands: railpackCommands([]string{install}),
+		})
+		base = railpackLayer{Step: "packages:apt"}
+	}
+
+	// The project is copied in before installing, as the Dockerfile format does
+	steps = append(steps,
+		railpackStep{
+			Name:      "install",
+			Inputs:    []railpackLayer{base, {Local: true, Include: []string{"."}}},
+			Commands:  railpackCommands(plan.Commands.Setup),
+			Variables: plan.Environment,
+		},
+		railpackStep{
+			Name:      "build",
+			Inputs:    []railpackLayer{{Step: "install"}},
+			Commands:  railpackCommands(plan.Commands.Build),
+			Variables: plan.Environment,
+		},
+	)
+
+	// The deployed image extends the build step because dependencies are installed
+	// outside the working directory by several languages (site-packages, bundler)
+	railpack := railpackPlan{
+		Steps: steps,
+		Deploy: railpackDeploy{
+			Base:      railpackLayer{Step: "build"},
+			StartCmd:  strings.Join(plan.Commands.Run, " && "),
+			Variables: plan.Environment,
+		},
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(railpack); err != nil {
+		return "", fmt.Errorf("failed to encode Railpack build plan: %w", err)
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// railpackCommands runs each command through sh, as Railpack does for shell commands
+func railpackCommands(commands []string) []railpackCommand {
+	converted := make([]railpackCommand, len(commands))
+	for i, command := range commands {
+		converted[i] = railpackCommand{Cmd: "sh -c " + shellQuote(command), CustomName: command}
+	}
+	return converted
+}
@@ -0,0 +1,63 @@
+package formatters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestRailpackFormatter_Format(t *testing.T) {
+	plan := ciTestPlan()
+	plan.Commands.Run = []string{"pnpm run start"}
+
+	output, err := NewRailpackFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+
+	expected := map[string]interface{}{
+		"steps.0.name":                  "packages:apt",
+		"steps.0.inputs.0.image":        "node:18-alpine",
+		"steps.0.commands.0.cmd":        "sh -c 'apk add --no-cache build-base'",
+		"steps.1.name":                  "install",
+		"steps.1.inputs.0.step":         "packages:apt",
+		"steps.1.inputs.1.local":        true,
+		"steps.1.commands.0.cmd":        "sh -c 'pnpm install'",
+		"steps.1.commands.0.customName": "pnpm install",
+		"steps.1.variables.NODE_ENV":    "development",
+		"steps.2.name":                  "build",
+		"steps.2.inputs.0.step":         "install",
+		"steps.2.commands.1.cmd":        "sh -c 'pnpm run build'",
+		"deploy.base.step":              "build",
+		"deploy.startCommand":           "pnpm run start",
+		"deploy.variables.NODE_ENV":     "development",
+	}
+	for fieldPath, value := range expected {
+		if got := field(t, document, fieldPath); got != value {
+			t.Errorf("%s: expected %v, got %v", fieldPath, value, got)
+		}
+	}
+
+	// Without system packages the install step starts from the runtime image
+	plan.Apt = nil
+	output, err = NewRailpackFormatter().Format(plan, nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	document = nil
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	if image := field(t, document, "steps.0.inputs.0.image"); image != "node:18-alpine" {
+		t.Errorf("expected install step on the runtime image, got %v", image)
+	}
+
+	if _, err := NewRailpackFormatter().Format(&types.ExecutionPlan{Provider: "shell"}, nil); err == nil {
+		t.Error("expected error for plan without runtime image")
+	}
+}
@@ -0,0 +1,162 @@
+/**
+ * DevBox Pack Execution Plan Generator - SARIF Formatter
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/lint"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// SARIF log constants
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifSourceRoot is the base of result locations, the repository root
+	sarifSourceRoot = "%SRCROOT%"
+)
+
+// sarifLevels maps diagnostic severities to SARIF result levels
+var sarifLevels = map[string]string{
+	types.SeverityInfo:    "note",
+	types.SeverityWarning: "warning",
+	types.SeverityError:   "error",
+}
+
+// SARIFFormatter reports the lint findings of an execution plan as a SARIF log,
+// located at the evidence files so code scanning can annotate them
+type SARIFFormatter struct{}
+
+// NewSARIFFormatter creates a new SARIF formatter
+func NewSARIFFormatter() *SARIFFormatter {
+	return &SARIFFormatter{}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI       string `json:"uri"`
+			URIBaseID string `json:"uriBaseId"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// Format formats the findings of execution plan as a SARIF log
+func (f *SARIFFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+	return f.FormatPlans(map[string]*types.ExecutionPlan{".": plan}, options)
+}
+
+// FormatExplained formats the findings of execution plan, explanations have no place in SARIF
+func (f *SARIFFormatter) FormatExplained(plan *types.ExecutionPlan, _ *types.Explanation, options *types.CLIOptions) (string, error) {
+	return f.Format(plan, options)
+}
+
+// FormatPlans formats the findings of every service plan as one SARIF run
+func (f *SARIFFormatter) FormatPlans(plans map[string]*types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = "devbox-pack"
+	run.Tool.Driver.InformationURI = "https://github.com/labring/devbox-pack"
+	ruleIndexes := make(map[string]int, len(lint.Rules))
+	for i, rule := range lint.Rules {
+		sarif := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{Text: rule.Description}}
+		sarif.DefaultConfiguration.Level = sarifLevels[rule.Severity]
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarif)
+		ruleIndexes[rule.ID] = i
+	}
+
+	paths := make([]string, 0, len(plans))
+	for servicePath := range plans {
+		paths = append(paths, servicePath)
+	}
+	sort.Strings(paths)
+
+	for _, servicePath := range paths {
+		plan := plans[servicePath]
+		if plan == nil {
+			return "", fmt.Errorf("execution plan cannot be nil")
+		}
+		// Locations are relative to the repository root, below the analysed subdirectory
+		root := servicePath
+		if options != nil && options.Subdir != nil {
+			root = path.Join(*options.Subdir, servicePath)
+		}
+		for _, diagnostic := range lint.Check(plan) {
+			result := sarifResult{
+				RuleID:    diagnostic.Rule,
+				RuleIndex: ruleIndexes[diagnostic.Rule],
+				Level:     sarifLevels[diagnostic.Severity],
+				Message:   sarifMessage{Text: diagnostic.Message},
+			}
+			if diagnostic.File != "" {
+				var location sarifLocation
+				location.PhysicalLocation.ArtifactLocation.URI = path.Join(strings.TrimPrefix(root, "/"), diagnostic.File)
+				location.PhysicalLocation.ArtifactLocation.URIBaseID = sarifSourceRoot
+				location.PhysicalLocation.Region.StartLine = 1
+				result.Locations = []sarifLocation{location}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return "", fmt.Errorf("failed to encode SARIF log: %w", err)
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
@@ -0,0 +1,69 @@
+package formatters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/lint"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestSARIFFormatter_FormatPlans(t *testing.T) {
+	subdir := "apps"
+	plans := map[string]*types.ExecutionPlan{
+		"api": {
+			Provider: "deno",
+			Runtime:  types.RuntimeConfig{Image: "denoland/deno:alpine"},
+			Commands: types.Commands{Run: []string{"deno run --allow-all main.ts"}},
+			Evidence: types.Evidence{Files: []string{"deno.json"}},
+		},
+		"web": {
+			Provider: "node",
+			Runtime:  types.RuntimeConfig{Image: "node:20-alpine"},
+			Commands: types.Commands{Run: []string{"npm start"}},
+			Evidence: types.Evidence{Files: []string{"package.json", "package-lock.json"}},
+		},
+	}
+
+	output, err := NewSARIFFormatter().FormatPlans(plans, &types.CLIOptions{Subdir: &subdir})
+	if err != nil {
+		t.Fatalf("FormatPlans failed: %v", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+
+	expected := map[string]interface{}{
+		"version":                       "2.1.0",
+		"runs.0.tool.driver.name":       "devbox-pack",
+		"runs.0.tool.driver.rules.3.id": lint.RuleRiskyFlag,
+		"runs.0.results.0.ruleId":       lint.RuleRiskyFlag,
+		"runs.0.results.0.ruleIndex":    float64(3),
+		"runs.0.results.0.level":        "warning",
+		"runs.0.results.0.locations.0.physicalLocation.artifactLocation.uri":       "apps/api/deno.json",
+		"runs.0.results.0.locations.0.physicalLocation.artifactLocation.uriBaseId": "%SRCROOT%",
+		"runs.0.results.0.locations.0.physicalLocation.region.startLine":           float64(1),
+	}
+	for fieldPath, value := range expected {
+		if got := field(t, document, fieldPath); got != value {
+			t.Errorf("%s: expected %v, got %v", fieldPath, value, got)
+		}
+	}
+	if results := field(t, document, "runs.0.results").([]interface{}); len(results) != 1 {
+		t.Errorf("expected one result, got %d:\n%s", len(results), output)
+	}
+
+	// A plan without findings still produces a valid log with an empty result list
+	output, err = NewSARIFFormatter().Format(plans["web"], nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	document = nil
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	if results, ok := field(t, document, "runs.0.results").([]interface{}); !ok || len(results) != 0 {
+		t.Errorf("expected empty results, got %v", field(t, document, "runs.0.results"))
+	}
+}
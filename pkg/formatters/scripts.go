@@ -0,0 +1,263 @@
+/**
+ * DevBox Pack Execution Plan Generator - Shell Script Bundle Formatter
+ */
+
+package formatters
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// ScriptsFormatter renders an execution plan as one shell script per command phase
+type ScriptsFormatter struct{}
+
+// NewScriptsFormatter creates a new shell script bundle formatter
+func NewScriptsFormatter() *ScriptsFormatter {
+	return &ScriptsFormatter{}
+}
+
+// scriptPhase is a command phase written to its own script
+type scriptPhase struct {
+	name     string
+	commands func(types.Commands) []string
+	// Replace the shell with the last command so it receives signals directly
+	execLast bool
+	// Install the plan's system packages before the commands
+	packages bool
+}
+
+// scriptPhases lists the scripts of a bundle in the order they are run
+var scriptPhases = []scriptPhase{
+	{name: "setup", commands: func(c types.Commands) []string { return c.Setup }, packages: true},
+	{name: "build", commands: func(c types.Commands) []string { return c.Build }},
+	{name: "dev", commands: func(c types.Commands) []string { return c.Dev }, execLast: true},
+	{name: "run", commands: func(c types.Commands) []string { return c.Run }, execLast: true},
+}
+
+// Format formats execution plan as the bundle's scripts in run order, each under a header
+func (f *ScriptsFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	files, err := f.FormatFiles(plan, options)
+	if err != nil {
+		return "", err
+	}
+
+	sections := make([]string, len(scriptPhases))
+	for i, phase := range scriptPhases {
+		name := phase.name + ".sh"
+		sections[i] = fmt.Sprintf("# ==> %s <==\n%s", name, files[name])
+	}
+	return strings.TrimSuffix(strings.Join(sections, "\n"), "\n"), nil
+}
+
+// FormatFiles formats execution plan as setup.sh, build.sh, dev.sh and run.sh
+func (f *ScriptsFormatter) FormatFiles(plan *types.ExecutionPlan, _ *types.CLIOptions) (map[string]string, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("execution plan cannot be nil")
+	}
+
+	files := make(map[string]string, len(scriptPhases))
+	for _, phase := range scriptPhases {
+		var lines []string
+		lines = append(lines,
+			"#!/usr/bin/env bash",
+			fmt.Sprintf("# Generated by DevBox Pack from the %s execution plan: %s commands", plan.Provider, phase.name),
+			"# Run from the project root, or set PROJECT_DIR to it",
+			"set -euo pipefail",
+			`cd "${PROJECT_DIR:-.}"`,
+		)
+
+		if len(plan.Environment) > 0 {
+			lines = append(lines, "")
+			keys := make([]string, 0, len(plan.Environment))
+			for key := range plan.Environment {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				lines = append(lines, fmt.Sprintf("export %s=%s", key, shellQuote(plan.Environment[key])))
+			}
+		}
+
+		if phase.packages && len(plan.Apt) > 0 {
+			lines = append(lines, "")
+			lines = append(lines, packagePreamble(plan.Apt)...)
+		}
+
+		lines = append(lines, "")
+		commands := phase.commands(plan.Commands)
+		if len(commands) == 0 {
+			lines = append(lines, fmt.Sprintf("# The plan has no %s commands", phase.name))
+		}
+		for i, command := range commands {
+			if phase.execLast && i == len(commands)-1 {
+				command = execCommand(command)
+			}
+			lines = append(lines, command)
+		}
+
+		files[phase.name+".sh"] = strings.Join(lines, "\n") + "\n"
+	}
+	return files, nil
+}
+
+// shellKeywords start or end compound commands, which exec cannot replace the shell with
+var shellKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "until": true, "case": true,
+	"select": true, "function": true, "!": true, "[[": true, "{": true,
+	"then": true, "else": true, "do": true, "fi": true, "done": true, "esac": true, "}": true,
+}
+
+// execCommand makes the final simple command of a shell command replace the
+// shell, so that it receives the signals sent to the script. Commands whose
+// final part is a pipeline, background job or compound command run under
+// exec bash -c instead.
+func execCommand(command string) string {
+	command = strings.TrimSpace(command)
+	start, ok := finalCommand(command)
+	if !ok {
+		return "exec bash -c " + shellQuote(command)
+	}
+
+	// Leading variable assignments stay in front of exec, which passes them on
+	word := start
+	for {
+		for word < len(command) && (command[word] == ' ' || command[word] == '\t') {
+			word++
+		}
+		end := wordEnd(command, word)
+		text := command[word:end]
+		if !assignmentPattern.MatchString(text) {
+			switch {
+			case text == "exec":
+				return command
+			case text == "" || shellKeywords[text] || strings.HasPrefix(text, "("):
+				return "exec bash -c " + shellQuote(command)
+			}
+			return command[:word] + "exec " + command[word:]
+		}
+		word = end
+	}
+}
+
+// assignmentPattern matches a variable assignment word
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// finalCommand returns where the last command of a list separated by ;, &&,
+// || or newlines starts, and whether it is a simple command: not part of a
+// pipeline or background job, and outside parentheses
+func finalCommand(command string) (start int, ok bool) {
+	var quote byte
+	depth := 0
+	ok = true
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth < 0 {
+				return 0, false
+			}
+		case depth > 0:
+		case c == ';' || c == '\n':
+			start, ok = i+1, true
+		case c == '&' || c == '|':
+			if i+1 < len(command) && command[i+1] == c {
+				start, ok = i+2, true
+				i++
+			} else if !isRedirection(command, i) {
+				ok = false
+			}
+		}
+	}
+	return start, ok && quote == 0 && depth == 0
+}
+
+// isRedirection reports whether the & or | at i belongs to a redirection such as 2>&1, &> or >|
+func isRedirection(command string, i int) bool {
+	if i > 0 && (command[i-1] == '>' || command[i-1] == '<') {
+		return true
+	}
+	return command[i] == '&' && i+1 < len(command) && command[i+1] == '>'
+}
+
+// wordEnd returns the end of the shell word starting at i
+func wordEnd(command string, i int) int {
+	var quote byte
+	for ; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ' ' || c == '\t':
+			return i
+		}
+	}
+	return len(command)
+}
+
+// packagePreamble installs system packages with whichever package tool the machine has
+func packagePreamble(apt []string) []string {
+	lines := []string{
+		"# System packages",
+		`SUDO=""`,
+		`if [ "$(id -u)" -ne 0 ]; then SUDO="sudo"; fi`,
+	}
+	tools := []struct {
+		command string
+		tool    packageTool
+		install string
+	}{
+		{"apt-get", aptTool, "$SUDO apt-get update && $SUDO apt-get install -y --no-install-recommends %s"},
+		{"apk", apkTool, "$SUDO apk add --no-cache %s"},
+		{"dnf", dnfTool, "$SUDO dnf install -y %s"},
+	}
+	for i, candidate := range tools {
+		keyword := "elif"
+		if i == 0 {
+			keyword = "if"
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s command -v %s >/dev/null 2>&1; then", keyword, candidate.command),
+			"  "+fmt.Sprintf(candidate.install, strings.Join(candidate.tool.packages(apt), " ")),
+		)
+	}
+	return append(lines,
+		"else",
+		fmt.Sprintf(`  echo "No supported package manager found, install manually: %s" >&2`, strings.Join(aptTool.packages(apt), " ")),
+		"  exit 1",
+		"fi",
+	)
+}
+
+// shellQuote quotes a value for POSIX shells
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
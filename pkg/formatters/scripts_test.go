@@ -0,0 +1,161 @@
+package formatters
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func scriptsTestPlan() *types.ExecutionPlan {
+	return &types.ExecutionPlan{
+		Provider:    "node",
+		Runtime:     types.RuntimeConfig{Image: "node:20-alpine"},
+		Environment: map[string]string{"PORT": "3000", "GREETING": "it's $HOME"},
+		Apt:         []string{"build-essential"},
+		Commands: types.Commands{
+			Setup: []string{"npm ci"},
+			Dev:   []string{"echo warming up", "npm run dev"},
+			Run:   []string{"node server.js"},
+		},
+	}
+}
+
+func TestScriptsFormatter_FormatFiles(t *testing.T) {
+	files, err := NewScriptsFormatter().FormatFiles(scriptsTestPlan(), nil)
+	if err != nil {
+		t.Fatalf("FormatFiles failed: %v", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected 4 scripts, got %v", files)
+	}
+
+	for name, content := range files {
+		if !strings.HasPrefix(content, "#!/usr/bin/env bash\n") || !strings.Contains(content, "\nset -euo pipefail\n") {
+			t.Errorf("%s: expected bash shebang and strict mode:\n%s", name, content)
+		}
+		if !strings.Contains(content, `export GREETING='it'\''s $HOME'`) || !strings.Contains(content, "export PORT='3000'") {
+			t.Errorf("%s: expected quoted environment exports:\n%s", name, content)
+		}
+	}
+
+	setup := files["setup.sh"]
+	for _, expected := range []string{
+		"if command -v apt-get >/dev/null 2>&1; then",
+		"$SUDO apt-get install -y --no-install-recommends build-essential",
+		"$SUDO apk add --no-cache build-base",
+		"$SUDO dnf install -y gcc gcc-c++ make",
+		"\nnpm ci\n",
+	} {
+		if !strings.Contains(setup, expected) {
+			t.Errorf("setup.sh: expected %q:\n%s", expected, setup)
+		}
+	}
+	if strings.Contains(files["run.sh"], "apt-get") {
+		t.Errorf("run.sh should not install packages:\n%s", files["run.sh"])
+	}
+
+	if !strings.HasSuffix(files["dev.sh"], "\necho warming up\nexec npm run dev\n") {
+		t.Errorf("dev.sh: expected exec on the last command only:\n%s", files["dev.sh"])
+	}
+	if !strings.HasSuffix(files["run.sh"], "\nexec node server.js\n") {
+		t.Errorf("run.sh: expected exec on the run command:\n%s", files["run.sh"])
+	}
+	if !strings.Contains(files["build.sh"], "# The plan has no build commands") {
+		t.Errorf("build.sh: expected a note for the empty phase:\n%s", files["build.sh"])
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	tests := map[string]string{
+		"node server.js":                       "exec node server.js",
+		"cd app && node server.js":             "cd app && exec node server.js",
+		"npm run build; npm start":             "npm run build; exec npm start",
+		"test -f .env || cp .env.example .env": "test -f .env || exec cp .env.example .env",
+		"PORT=3000 NODE_ENV='a b' node app.js": "PORT=3000 NODE_ENV='a b' exec node app.js",
+		`echo "a && b" && ./start 2>&1`:        `echo "a && b" && exec ./start 2>&1`,
+		"exec java -jar app.jar":               "exec java -jar app.jar",
+		"node server.js | tee app.log":         `exec bash -c 'node server.js | tee app.log'`,
+		"(cd app && node server.js)":           `exec bash -c '(cd app && node server.js)'`,
+		"cd app && if true; then node x; fi":   `exec bash -c 'cd app && if true; then node x; fi'`,
+	}
+	for command, expected := range tests {
+		if actual := execCommand(command); actual != expected {
+			t.Errorf("execCommand(%q): expected %q, got %q", command, expected, actual)
+		}
+	}
+}
+
+func TestScriptsFormatter_Format(t *testing.T) {
+	output, err := NewScriptsFormatter().Format(scriptsTestPlan(), nil)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	last := -1
+	for _, name := range []string{"setup.sh", "build.sh", "dev.sh", "run.sh"} {
+		index := strings.Index(output, "# ==> "+name+" <==")
+		if index <= last {
+			t.Errorf("expected %s after the previous script:\n%s", name, output)
+		}
+		last = index
+	}
+
+	if _, err := NewScriptsFormatter().Format(nil, nil); err == nil {
+		t.Error("expected error for nil plan")
+	}
+}
+
+func TestScriptsFormatter_Run(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+
+	plan := &types.ExecutionPlan{
+		Provider:    "shell",
+		Environment: map[string]string{"GREETING": "it's $HOME"},
+		Commands:    types.Commands{Run: []string{"echo started", `echo "$GREETING"`}},
+	}
+	files, err := NewScriptsFormatter().FormatFiles(plan, nil)
+	if err != nil {
+		t.Fatalf("FormatFiles failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(script, []byte(files["run.sh"]), 0755); err != nil {
+		t.Fatal(err)
+	}
+	command := exec.Command(bash, script)
+	command.Env = append(os.Environ(), "PROJECT_DIR="+dir)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		t.Fatalf("run.sh failed: %v\n%s", err, output)
+	}
+	if string(output) != "started\nit's $HOME\n" {
+		t.Errorf("unexpected output: %q", output)
+	}
+}
+
+func TestOutputUtils_OutputPlan_WritesBundle(t *testing.T) {
+	dir := t.TempDir()
+	options := &types.CLIOptions{Format: "scripts", OutputDir: &dir, Quiet: true}
+
+	if err := NewOutputUtils().OutputPlans(map[string]*types.ExecutionPlan{"api": scriptsTestPlan()}, options); err != nil {
+		t.Fatalf("OutputPlans failed: %v", err)
+	}
+
+	for _, name := range []string{"setup.sh", "build.sh", "dev.sh", "run.sh"} {
+		info, err := os.Stat(filepath.Join(dir, "api", name))
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", name, err)
+		}
+		if info.Mode().Perm()&0100 == 0 {
+			t.Errorf("expected %s to be executable, mode %v", name, info.Mode())
+		}
+	}
+}
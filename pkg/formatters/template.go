@@ -0,0 +1,168 @@
+/**
+ * DevBox Pack Execution Plan Generator - Template Formatter
+ */
+
+package formatters
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// TemplateData is the value user templates are executed with
+type TemplateData struct {
+	// Execution plan
+	Plan *types.ExecutionPlan
+	// Analysed repository and subdirectory as given on the command line
+	Repository string
+	Subdir     string
+	// Resource name derived from the repository, the same as in k8s manifests
+	Name string
+	// Detection result of every matched provider in priority order: language,
+	// framework, version, package manager, confidence and evidence
+	Detections []*types.DetectResult
+	// Scoring of every provider and why the plan's provider won, nil without --explain
+	Explanation *types.Explanation
+}
+
+// TemplateFormatter renders an execution plan through a user-supplied text/template
+type TemplateFormatter struct {
+	template *template.Template
+}
+
+// NewTemplateFormatter parses text as a template named name, with the helper
+// functions join, quote, toYaml, toJson and default available
+func NewTemplateFormatter(name, text string) (*TemplateFormatter, error) {
+	parsed, err := template.New(name).Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return &TemplateFormatter{template: parsed}, nil
+}
+
+// NewTemplateFormatterFromFile parses the template file at path
+func NewTemplateFormatterFromFile(path string) (*TemplateFormatter, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return NewTemplateFormatter(filepath.Base(path), string(content))
+}
+
+// Format formats execution plan through the template
+func (f *TemplateFormatter) Format(plan *types.ExecutionPlan, options *types.CLIOptions) (string, error) {
+	return f.FormatExplained(plan, nil, options)
+}
+
+// FormatExplained formats execution plan through the template, with the explanation
+// available to it as .Explanation
+func (f *TemplateFormatter) FormatExplained(plan *types.ExecutionPlan, explanation *types.Explanation, options *types.CLIOptions) (string, error) {
+	return f.FormatDetections(plan, nil, explanation, options)
+}
+
+// FormatDetections formats execution plan through the template, with the detection
+// results available to it as .Detections and the explanation as .Explanation
+func (f *TemplateFormatter) FormatDetections(plan *types.ExecutionPlan, detections []*types.DetectResult, explanation *types.Explanation, options *types.CLIOptions) (string, error) {
+	if plan == nil {
+		return "", fmt.Errorf("execution plan cannot be nil")
+	}
+
+	data := TemplateData{
+		Plan:        plan,
+		Name:        KubernetesName(options),
+		Detections:  detections,
+		Explanation: explanation,
+	}
+	if options != nil {
+		data.Repository = options.Repository
+		if options.Subdir != nil {
+			data.Subdir = *options.Subdir
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := f.template.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// TemplateFuncs returns the helper functions available to user templates. Arguments
+// follow the Helm conventions, so values can be piped in: {{ .Plan.Apt | join " " }}.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"join":    templateJoin,
+		"quote":   templateQuote,
+		"toYaml":  templateToYAML,
+		"toJson":  templateToJSON,
+		"default": templateDefault,
+	}
+}
+
+// templateJoin joins the items of a list with sep
+func templateJoin(sep string, list interface{}) string {
+	if items, ok := list.([]string); ok {
+		return strings.Join(items, sep)
+	}
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+	items := make([]string, value.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+	return strings.Join(items, sep)
+}
+
+// templateQuote renders a value as a double-quoted string
+func templateQuote(value interface{}) string {
+	return strconv.Quote(fmt.Sprint(value))
+}
+
+// templateToYAML encodes a value as YAML without the trailing newline
+func templateToYAML(value interface{}) (string, error) {
+	output, err := markup.EncodeYAML(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+// templateToJSON encodes a value as compact JSON
+func templateToJSON(value interface{}) (string, error) {
+	return encodeJSON(value)
+}
+
+// templateDefault returns value, or fallback when value is empty: nil, zero, or an
+// empty string, slice or map
+func templateDefault(fallback, value interface{}) interface{} {
+	if value == nil {
+		return fallback
+	}
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		if reflected.Len() == 0 {
+			return fallback
+		}
+	case reflect.Ptr, reflect.Interface:
+		if reflected.IsNil() {
+			return fallback
+		}
+	default:
+		if reflected.IsZero() {
+			return fallback
+		}
+	}
+	return value
+}
@@ -0,0 +1,133 @@
+package formatters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestTemplateFormatter_Format(t *testing.T) {
+	framework := "express"
+	plan := &types.ExecutionPlan{
+		Provider:    "node",
+		Runtime:     types.RuntimeConfig{Image: "node:20-alpine", Framework: &framework},
+		Environment: map[string]string{"PORT": "3000", "URL": "a&b"},
+		Commands:    types.Commands{Build: []string{"npm ci", "npm run build"}},
+		BackingServices: []types.BackingService{
+			{Name: "redis", Dependencies: []string{"ioredis"}},
+		},
+	}
+	repository := "https://github.com/acme/shop.git"
+	subdir := "api"
+	options := &types.CLIOptions{Repository: repository, Subdir: &subdir}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"fields", "{{ .Name }} {{ .Repository }} {{ .Subdir }} {{ .Plan.Runtime.Framework }}", "shop-api https://github.com/acme/shop.git api express"},
+		{"join", `{{ .Plan.Commands.Build | join " && " }}`, "npm ci && npm run build"},
+		{"quote", "{{ .Plan.Provider | quote }}", `"node"`},
+		{"toJson", "{{ toJson .Plan.Environment }}", `{"PORT":"3000","URL":"a&b"}`},
+		{"toYaml", "{{ toYaml .Plan.BackingServices }}", "- name: redis\n  dependencies:\n    - ioredis"},
+		{"default empty", `{{ .Plan.Port | default 8080 }} {{ .Plan.Commands.Run | join " " | default "none" }}`, "8080 none"},
+		{"default set", `{{ .Plan.Runtime.Image | default "scratch" }}`, "node:20-alpine"},
+		{"no explanation", "{{ if .Explanation }}explained{{ else }}plain{{ end }}", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := NewTemplateFormatter(tt.name, tt.template+"\n")
+			if err != nil {
+				t.Fatalf("NewTemplateFormatter failed: %v", err)
+			}
+			output, err := formatter.Format(plan, options)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_Errors(t *testing.T) {
+	if _, err := NewTemplateFormatter("broken", "{{ .Plan"); err == nil {
+		t.Error("expected parse error")
+	}
+	if _, err := NewTemplateFormatterFromFile(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("expected error for missing template file")
+	}
+
+	formatter, err := NewTemplateFormatter("unknown", "{{ .Unknown }}")
+	if err != nil {
+		t.Fatalf("NewTemplateFormatter failed: %v", err)
+	}
+	if _, err := formatter.Format(&types.ExecutionPlan{}, nil); err == nil {
+		t.Error("expected error for unknown field")
+	}
+	if _, err := formatter.Format(nil, nil); err == nil {
+		t.Error("expected error for nil plan")
+	}
+}
+
+func TestTemplateFormatter_Explained(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "explained.tmpl")
+	if err := os.WriteFile(path, []byte("{{ .Plan.Provider }} won: {{ .Explanation.Selection.Reason }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	formatter, err := NewTemplateFormatterFromFile(path)
+	if err != nil {
+		t.Fatalf("NewTemplateFormatterFromFile failed: %v", err)
+	}
+
+	factory := NewFormatterFactory()
+	factory.RegisterFormatter("template", formatter)
+	utils := NewOutputUtilsWithFactory(factory)
+
+	explanation := &types.Explanation{Selection: &types.SelectionExplanation{Provider: "go", Reason: "only match"}}
+	output, err := utils.formatExplainedPlan(&types.ExecutionPlan{Provider: "go"}, explanation, &types.CLIOptions{Format: "template"})
+	if err != nil {
+		t.Fatalf("formatExplainedPlan failed: %v", err)
+	}
+	// The template renders the explanation, the text explanation is not appended
+	if output != "go won: only match" || strings.Contains(output, "Detection Explanation") {
+		t.Errorf("unexpected output: %q", output)
+	}
+}
+
+func TestTemplateFormatter_Detections(t *testing.T) {
+	formatter, err := NewTemplateFormatter("detections", `{{ range .Detections }}{{ .Language }} {{ .Framework }} {{ .Confidence }}{{ end }}{{ if .Explanation }} explained{{ end }}`)
+	if err != nil {
+		t.Fatalf("NewTemplateFormatter failed: %v", err)
+	}
+	provider := "node"
+	detections := []*types.DetectResult{{Matched: true, Provider: &provider, Language: "javascript", Framework: "express", Confidence: 0.9}}
+
+	// Detection results are available without --explain
+	output, err := formatter.FormatDetections(&types.ExecutionPlan{Provider: "node"}, detections, nil, &types.CLIOptions{})
+	if err != nil {
+		t.Fatalf("FormatDetections failed: %v", err)
+	}
+	if output != "javascript express 0.9" {
+		t.Errorf("unexpected output: %q", output)
+	}
+	output, _ = formatter.FormatDetections(&types.ExecutionPlan{Provider: "node"}, detections, &types.Explanation{}, &types.CLIOptions{})
+	if output != "javascript express 0.9 explained" {
+		t.Errorf("unexpected output: %q", output)
+	}
+
+	// Formatters without detections fall back to the plan
+	factory := NewFormatterFactory()
+	factory.RegisterFormatter("template", formatter)
+	if _, ok := NewOutputUtilsWithFactory(factory).detectionsFormatter(&types.CLIOptions{Format: "template"}); !ok {
+		t.Error("expected template formatter to render detections")
+	}
+	if _, ok := NewOutputUtilsWithFactory(factory).detectionsFormatter(&types.CLIOptions{Format: "json"}); ok {
+		t.Error("expected json formatter not to render detections")
+	}
+}
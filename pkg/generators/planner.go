@@ -3,8 +3,11 @@
 package generators
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/labring/devbox-pack/pkg/detector"
 	"github.com/labring/devbox-pack/pkg/registry"
 	"github.com/labring/devbox-pack/pkg/types"
 	"github.com/labring/devbox-pack/pkg/utils"
@@ -33,8 +36,16 @@ func NewExecutionPlanGenerator() *ExecutionPlanGenerator {
 	}
 }
 
+// RegisterProvider makes an additional Provider available for plan generation
+func (g *ExecutionPlanGenerator) RegisterProvider(provider detector.Provider) {
+	g.registry.RegisterProvider(provider)
+}
+
 // GeneratePlan generates execution plan
-func (g *ExecutionPlanGenerator) GeneratePlan(results []types.DetectResult, options types.CLIOptions) (*types.ExecutionPlan, error) {
+func (g *ExecutionPlanGenerator) GeneratePlan(ctx context.Context, results []types.DetectResult, options types.CLIOptions) (*types.ExecutionPlan, error) {
+	if err := types.ContextError(ctx); err != nil {
+		return nil, err
+	}
 	if len(results) == 0 {
 		return nil, fmt.Errorf("no detection results provided")
 	}
@@ -47,7 +58,7 @@ func (g *ExecutionPlanGenerator) GeneratePlan(results []types.DetectResult, opti
 
 	// Generate execution plan
 	plan := &types.ExecutionPlan{
-		Provider:    bestResult.Language,
+		Provider:    g.providerName(bestResult),
 		Runtime:     g.generateRuntime(bestResult, options),
 		Environment: g.generateEnvironment(bestResult, options),
 		Commands:    g.generateCommands(bestResult, options),
@@ -70,43 +81,73 @@ func (g *ExecutionPlanGenerator) GeneratePlan(results []types.DetectResult, opti
 
 // selectBestResult selects the best detection result with backend-first priority
 func (g *ExecutionPlanGenerator) selectBestResult(results []types.DetectResult) *types.DetectResult {
+	best, _ := g.SelectResult(results)
+	return best
+}
+
+// SelectResult selects the best detection result with backend-first priority
+// and explains the choice
+func (g *ExecutionPlanGenerator) SelectResult(results []types.DetectResult) (*types.DetectResult, *types.SelectionExplanation) {
 	if len(results) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Separate backend and frontend results
 	var backendResults []*types.DetectResult
 	var frontendResults []*types.DetectResult
+	candidates := make([]types.SelectionCandidate, len(results))
 
 	for i := range results {
 		result := &results[i]
-		if g.isBackendFramework(result) {
+		backend := g.isBackendFramework(result)
+		if backend {
 			backendResults = append(backendResults, result)
 		} else {
 			frontendResults = append(frontendResults, result)
 		}
+		candidates[i] = types.SelectionCandidate{
+			Provider:   g.providerName(result),
+			Language:   result.Language,
+			Framework:  result.Framework,
+			Confidence: result.Confidence,
+			Backend:    backend,
+		}
 	}
 
+	selection := &types.SelectionExplanation{Candidates: candidates}
+
 	// Prioritize backend frameworks for full-stack applications
-	if len(backendResults) > 0 {
+	var best *types.DetectResult
+	switch {
+	case len(results) == 1:
+		best = &results[0]
+		selection.Rule = types.SelectionRuleOnlyMatch
+		selection.Reason = "only one provider matched"
+	case len(backendResults) > 0:
 		// Return backend result with highest confidence
-		bestBackend := backendResults[0]
-		for _, result := range backendResults[1:] {
-			if result.Confidence > bestBackend.Confidence {
-				bestBackend = result
-			}
-		}
-		return bestBackend
+		best = highestConfidence(backendResults)
+		selection.Rule = types.SelectionRuleBackendFirst
+		selection.Reason = fmt.Sprintf("highest confidence (%.2f) among %d backend result(s), backend results win over frontend results", best.Confidence, len(backendResults))
+	default:
+		// If no backend results, return frontend result with highest confidence
+		best = highestConfidence(frontendResults)
+		selection.Rule = types.SelectionRuleConfidence
+		selection.Reason = fmt.Sprintf("no backend result matched, highest confidence (%.2f) among %d result(s)", best.Confidence, len(frontendResults))
 	}
 
-	// If no backend results, return frontend result with highest confidence
-	bestFrontend := frontendResults[0]
-	for _, result := range frontendResults[1:] {
-		if result.Confidence > bestFrontend.Confidence {
-			bestFrontend = result
+	selection.Provider = g.providerName(best)
+	return best, selection
+}
+
+// highestConfidence returns the first result with the highest confidence
+func highestConfidence(results []*types.DetectResult) *types.DetectResult {
+	best := results[0]
+	for _, result := range results[1:] {
+		if result.Confidence > best.Confidence {
+			best = result
 		}
 	}
-	return bestFrontend
+	return best
 }
 
 // isBackendFramework checks if a detection result represents a backend framework
@@ -136,24 +177,15 @@ func (g *ExecutionPlanGenerator) isBackendFramework(result *types.DetectResult)
 }
 
 // generateRuntime generates simplified runtime configuration
-func (g *ExecutionPlanGenerator) generateRuntime(result *types.DetectResult, _ types.CLIOptions) types.RuntimeConfig {
+func (g *ExecutionPlanGenerator) generateRuntime(result *types.DetectResult, options types.CLIOptions) types.RuntimeConfig {
 	runtime := types.RuntimeConfig{}
 
 	// Get base image from catalog using detected version
-	if catalog, exists := g.baseCatalog[types.SupportedLanguage(result.Language)]; exists {
-		version := result.Version
-		if version == "" {
-			// Use default version if none detected
-			if defaultVersion, exists := g.defaultVersions[types.SupportedLanguage(result.Language)]; exists {
-				version = defaultVersion
-			}
-		}
+	runtime.Version, runtime.Image = g.resolveVersion(result)
 
-		if version != "" {
-			if image, exists := catalog[version]; exists {
-				runtime.Image = image
-			}
-		}
+	// Explicit base image takes precedence over the catalog
+	if options.Base != nil && *options.Base != "" {
+		runtime.Image = *options.Base
 	}
 
 	// Add framework if detected
@@ -164,9 +196,40 @@ func (g *ExecutionPlanGenerator) generateRuntime(result *types.DetectResult, _ t
 	return runtime
 }
 
+// resolveVersion resolves the runtime version of a result, the detected version or the
+// language default, to its catalog version and base image. Detected versions match
+// the catalog version they are a release of, e.g. 18.0.0 matches 18 and 3.11.4 matches
+// 3.11. Versions missing from the catalog are returned as is, without an image.
+func (g *ExecutionPlanGenerator) resolveVersion(result *types.DetectResult) (string, string) {
+	language := types.SupportedLanguage(result.Language)
+	version := result.Version
+	if version == "" {
+		// Use default version if none detected
+		version = g.defaultVersions[language]
+	}
+	if version == "" {
+		return "", ""
+	}
+
+	catalog := g.baseCatalog[language]
+	if image, exists := catalog[version]; exists {
+		return version, image
+	}
+	matched := ""
+	for catalogVersion := range catalog {
+		if strings.HasPrefix(version, catalogVersion+".") && len(catalogVersion) > len(matched) {
+			matched = catalogVersion
+		}
+	}
+	if matched != "" {
+		return matched, catalog[matched]
+	}
+	return version, ""
+}
+
 // generateEnvironment generates environment variables (flattened)
 func (g *ExecutionPlanGenerator) generateEnvironment(result *types.DetectResult, _ types.CLIOptions) map[string]string {
-	provider := g.registry.GetProvider(result.Language)
+	provider := g.providerFor(result)
 	if provider == nil {
 		return nil
 	}
@@ -217,7 +280,7 @@ func (g *ExecutionPlanGenerator) generateAptDependencies(result *types.DetectRes
 
 // needsNativeCompilation checks if native compilation is needed
 func (g *ExecutionPlanGenerator) needsNativeCompilation(result *types.DetectResult) bool {
-	provider := g.registry.GetProvider(result.Language)
+	provider := g.providerFor(result)
 	if provider == nil {
 		return false
 	}
@@ -227,7 +290,7 @@ func (g *ExecutionPlanGenerator) needsNativeCompilation(result *types.DetectResu
 
 // generateCommands generates commands configuration
 func (g *ExecutionPlanGenerator) generateCommands(result *types.DetectResult, options types.CLIOptions) types.Commands {
-	provider := g.registry.GetProvider(result.Language)
+	provider := g.providerFor(result)
 	if provider == nil {
 		return types.Commands{}
 	}
@@ -235,8 +298,36 @@ func (g *ExecutionPlanGenerator) generateCommands(result *types.DetectResult, op
 	return provider.GenerateCommands(result, options)
 }
 
+// providerFor returns the Provider that produced result, falling back to its language
+func (g *ExecutionPlanGenerator) providerFor(result *types.DetectResult) detector.Provider {
+	if result.Provider != nil {
+		if provider := g.registry.GetProvider(*result.Provider); provider != nil {
+			return provider
+		}
+	}
+	return g.registry.GetProvider(result.Language)
+}
+
+// providerName returns the name of the Provider that produced result
+func (g *ExecutionPlanGenerator) providerName(result *types.DetectResult) string {
+	if result.Provider != nil && *result.Provider != "" {
+		return *result.Provider
+	}
+	return result.Language
+}
+
+// portProvider is implemented by Providers that know the application port
+type portProvider interface {
+	GetPort(result *types.DetectResult) int
+}
+
 // getPortForResult gets the port for a detection result
 func (g *ExecutionPlanGenerator) getPortForResult(result *types.DetectResult) int {
+	if provider, ok := g.providerFor(result).(portProvider); ok {
+		if port := provider.GetPort(result); port > 0 {
+			return port
+		}
+	}
 	if port, exists := g.defaultPorts[types.SupportedLanguage(result.Language)]; exists {
 		return port
 	}
@@ -1,6 +1,7 @@
 package generators
 
 import (
+	"context"
 	"testing"
 
 	"github.com/labring/devbox-pack/pkg/types"
@@ -33,7 +34,7 @@ func TestGeneratePlan_NodeProject(t *testing.T) {
 		Format:  "json",
 	}
 
-	plan, err := generator.GeneratePlan(detectResults, options)
+	plan, err := generator.GeneratePlan(context.Background(), detectResults, options)
 	if err != nil {
 		t.Fatalf("GeneratePlan failed: %v", err)
 	}
@@ -75,7 +76,7 @@ func TestGeneratePlan_PythonProject(t *testing.T) {
 		Format:  "json",
 	}
 
-	plan, err := generator.GeneratePlan(detectResults, options)
+	plan, err := generator.GeneratePlan(context.Background(), detectResults, options)
 	if err != nil {
 		t.Fatalf("GeneratePlan failed: %v", err)
 	}
@@ -113,7 +114,7 @@ func TestGeneratePlan_StaticProject(t *testing.T) {
 		Format:  "json",
 	}
 
-	plan, err := generator.GeneratePlan(detectResults, options)
+	plan, err := generator.GeneratePlan(context.Background(), detectResults, options)
 	if err != nil {
 		t.Fatalf("GeneratePlan failed: %v", err)
 	}
@@ -131,18 +132,84 @@ func TestGeneratePlan_StaticProject(t *testing.T) {
 	}
 }
 
+func TestGeneratePlan_RuntimeVersion(t *testing.T) {
+	generator := NewExecutionPlanGenerator()
+
+	tests := []struct {
+		language string
+		version  string
+		want     string
+		image    string
+	}{
+		{"node", "", "20", "node:20-alpine"},
+		{"node", "18", "18", "node:18-alpine"},
+		{"node", "18.0.0", "18", "node:18-alpine"},
+		{"python", "3.11.4", "3.11", "python:3.11-slim"},
+		{"go", "1.20.3", "1.20", "golang:1.20-alpine"},
+		{"node", "23.1.0", "23.1.0", ""},
+	}
+	for _, tt := range tests {
+		results := []types.DetectResult{{Matched: true, Language: tt.language, Version: tt.version, Confidence: 0.9}}
+		plan, err := generator.GeneratePlan(context.Background(), results, types.CLIOptions{})
+		if err != nil {
+			t.Fatalf("GeneratePlan failed: %v", err)
+		}
+		if plan.Runtime.Version != tt.want || plan.Runtime.Image != tt.image {
+			t.Errorf("%s %q: expected version %q and image %q, got %q and %q",
+				tt.language, tt.version, tt.want, tt.image, plan.Runtime.Version, plan.Runtime.Image)
+		}
+	}
+}
+
 func TestGeneratePlan_InvalidInput(t *testing.T) {
 	generator := NewExecutionPlanGenerator()
 
 	// Test with empty results
-	_, err := generator.GeneratePlan([]types.DetectResult{}, types.CLIOptions{})
+	_, err := generator.GeneratePlan(context.Background(), []types.DetectResult{}, types.CLIOptions{})
 	if err == nil {
 		t.Error("expected error for empty detection results")
 	}
 
 	// Test with nil results
-	_, err = generator.GeneratePlan(nil, types.CLIOptions{})
+	_, err = generator.GeneratePlan(context.Background(), nil, types.CLIOptions{})
 	if err == nil {
 		t.Error("expected error for nil detection results")
 	}
 }
+
+func TestSelectResult_Explanation(t *testing.T) {
+	generator := NewExecutionPlanGenerator()
+	node := types.DetectResult{Matched: true, Language: "node", Framework: "react", Confidence: 0.9}
+	python := types.DetectResult{Matched: true, Language: "python", Framework: "Flask", Confidence: 0.5}
+	static := types.DetectResult{Matched: true, Language: "staticfile", Confidence: 0.6}
+
+	tests := []struct {
+		name     string
+		results  []types.DetectResult
+		provider string
+		rule     string
+	}{
+		{"only match", []types.DetectResult{node}, "node", types.SelectionRuleOnlyMatch},
+		{"backend first", []types.DetectResult{node, python}, "python", types.SelectionRuleBackendFirst},
+		{"highest confidence", []types.DetectResult{static, node}, "node", types.SelectionRuleConfidence},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, selection := generator.SelectResult(tt.results)
+			if best == nil || selection == nil {
+				t.Fatal("expected a selection")
+			}
+			if best.Language != tt.provider || selection.Provider != tt.provider || selection.Rule != tt.rule {
+				t.Errorf("expected %s by %s, got %s by %s", tt.provider, tt.rule, selection.Provider, selection.Rule)
+			}
+			if len(selection.Candidates) != len(tt.results) || selection.Reason == "" {
+				t.Errorf("unexpected selection: %+v", selection)
+			}
+		})
+	}
+
+	if best, selection := generator.SelectResult(nil); best != nil || selection != nil {
+		t.Error("expected no selection without results")
+	}
+}
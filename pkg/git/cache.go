@@ -0,0 +1,481 @@
+package git
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// CacheDirEnv overrides the default cache directory
+const CacheDirEnv = "DEVBOX_PACK_CACHE_DIR"
+
+// Clone cache limits
+const (
+	// DefaultCacheMaxSize is the total size of mirrors kept by Prune
+	DefaultCacheMaxSize = 2 << 30
+	// DefaultCacheMaxAge is how long Prune keeps mirrors that were not used
+	DefaultCacheMaxAge = 7 * 24 * time.Hour
+	// lockStaleAfter is the age of a lock whose holder stopped refreshing it, having crashed
+	lockStaleAfter = 2 * time.Minute
+	// lockRefresh is how often a held lock is refreshed
+	lockRefresh = 30 * time.Second
+	// worktreeStaleAfter is the age of a worktree left behind by a killed run
+	worktreeStaleAfter = 24 * time.Hour
+	// pruneInterval is how often clones prune the cache, which walks every mirror
+	pruneInterval = time.Hour
+)
+
+// DefaultCacheDir returns $DEVBOX_PACK_CACHE_DIR, or devbox-pack in the user cache directory
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "devbox-pack"), nil
+}
+
+// CloneCache keeps bare mirrors of remote repositories between runs. Every run
+// fetches what changed into the mirror and checks the requested ref out into a
+// worktree of its own, which Cleanup removes.
+type CloneCache struct {
+	// Dir holds the mirrors, their locks and the worktrees of running analyses
+	Dir string
+	// MaxSize is the total size of mirrors kept by Prune; 0 disables the limit
+	MaxSize int64
+	// MaxAge is how long Prune keeps mirrors that were not used; 0 disables the limit
+	MaxAge time.Duration
+}
+
+// NewCloneCache creates a clone cache in the git directory below cacheDir with the default limits
+func NewCloneCache(cacheDir string) *CloneCache {
+	return &CloneCache{
+		Dir:     filepath.Join(cacheDir, "git"),
+		MaxSize: DefaultCacheMaxSize,
+		MaxAge:  DefaultCacheMaxAge,
+	}
+}
+
+// PruneResult reports what Prune removed and kept
+type PruneResult struct {
+	// Mirrors removed for their age or to fit the size limit
+	Removed int `json:"removed"`
+	// Bytes freed by removing mirrors
+	Freed int64 `json:"freed"`
+	// Mirrors kept
+	Kept int `json:"kept"`
+	// Bytes of the mirrors kept
+	Size int64 `json:"size"`
+}
+
+// mirrorPath returns the mirror of a repository URL, named after the repository
+// and a hash of the URL
+func (c *CloneCache) mirrorPath(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	sum := sha256.Sum256([]byte(url))
+	name := (&GitHandler{}).extractRepoName(url)
+	return filepath.Join(c.Dir, fmt.Sprintf("%s-%s.git", name, hex.EncodeToString(sum[:8])))
+}
+
+// worktreesDir returns the directory holding the worktrees of running analyses
+func (c *CloneCache) worktreesDir() string {
+	return filepath.Join(c.Dir, "worktrees")
+}
+
+// SetCloneCache makes the handler clone remote repositories through cache,
+// nil clones afresh into temporary directories
+func (g *GitHandler) SetCloneCache(cache *CloneCache) {
+	g.cache = cache
+}
+
+// CloneCache returns the clone cache of the handler, nil when it has none
+func (g *GitHandler) CloneCache() *CloneCache {
+	return g.cache
+}
+
+// cloneCached updates the cached mirror of a repository and checks the ref out into a new worktree
+func (g *GitHandler) cloneCached(ctx context.Context, repo *types.GitRepository) (string, error) {
+	if err := os.MkdirAll(g.cache.worktreesDir(), 0755); err != nil {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("failed to create cache directory: %s", err.Error()),
+			types.ErrorCodeTempDirError,
+			nil,
+		)
+	}
+
+	mirror := g.cache.mirrorPath(repo.URL)
+	unlock, err := lock(ctx, mirror+".lock")
+	if err != nil {
+		return "", err
+	}
+	worktree, err := g.checkoutCached(ctx, repo, mirror)
+	unlock()
+	if err != nil {
+		return "", err
+	}
+
+	// Evicting is best effort, mirrors in use by other runs are locked or
+	// checked out in their worktrees, and kept
+	g.cache.pruneIfDue()
+
+	if repo.Subdir != nil {
+		return subdirectory(worktree, *repo.Subdir)
+	}
+	return worktree, nil
+}
+
+// checkoutCached brings the mirror up to date and adds a worktree of the ref.
+// The caller holds the lock of the mirror.
+func (g *GitHandler) checkoutCached(ctx context.Context, repo *types.GitRepository, mirror string) (string, error) {
+	if _, err := os.Stat(mirror); err != nil {
+		// Clone under another name, so an interrupted clone is never taken for a mirror
+		partial := mirror + ".partial"
+		_ = os.RemoveAll(partial)
+		if _, err := g.execGit(ctx, []string{"clone", "--mirror", "--filter=blob:none", "--quiet", repo.URL, partial}, ""); err != nil {
+			_ = os.RemoveAll(partial)
+			return "", cloneError(ctx, repo, err)
+		}
+		if err := os.Rename(partial, mirror); err != nil {
+			_ = os.RemoveAll(partial)
+			return "", cloneError(ctx, repo, err)
+		}
+	} else {
+		if _, err := g.execGit(ctx, []string{"fetch", "--prune", "--quiet", "origin"}, mirror); err != nil {
+			return "", cloneError(ctx, repo, err)
+		}
+		// Forget the worktrees of runs that were killed before cleaning up
+		_, _ = g.execGit(ctx, []string{"worktree", "prune"}, mirror)
+	}
+	now := time.Now()
+	_ = os.Chtimes(mirror, now, now)
+
+	rev := "HEAD"
+	if repo.Ref != nil {
+		rev = *repo.Ref
+	}
+	commit, err := g.execGit(ctx, []string{"rev-parse", "--verify", "--quiet", rev + "^{commit}"}, mirror)
+	if err != nil && repo.Ref != nil {
+		// Commits that no branch or tag points at are fetched on their own
+		if _, fetchErr := g.execGit(ctx, []string{"fetch", "--quiet", "origin", rev}, mirror); fetchErr == nil {
+			commit, err = g.execGit(ctx, []string{"rev-parse", "--verify", "--quiet", "FETCH_HEAD^{commit}"}, mirror)
+		}
+	}
+	if err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("cannot switch to specified ref: %s", rev),
+			types.ErrorCodeGitCheckoutError,
+			map[string]interface{}{"ref": rev},
+		)
+	}
+
+	tempDir, err := os.MkdirTemp(g.cache.worktreesDir(), "devbox-pack-")
+	if err != nil {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("failed to create temporary directory: %s", err.Error()),
+			types.ErrorCodeTempDirError,
+			nil,
+		)
+	}
+	g.tempDirs = append(g.tempDirs, tempDir)
+
+	worktree := filepath.Join(tempDir, g.extractRepoName(repo.URL))
+	if _, err := g.execGit(ctx, []string{"worktree", "add", "--detach", "--quiet", worktree, commit}, mirror); err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("cannot switch to specified ref: %s", rev),
+			types.ErrorCodeGitCheckoutError,
+			map[string]interface{}{
+				"ref":   rev,
+				"error": err.Error(),
+			},
+		)
+	}
+	return worktree, nil
+}
+
+// cloneError reports a failed clone or fetch of a repository
+func cloneError(ctx context.Context, repo *types.GitRepository, err error) error {
+	if ctxErr := types.ContextError(ctx); ctxErr != nil {
+		return ctxErr
+	}
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("repository clone failed: %s", err.Error()),
+		types.ErrorCodeCloneError,
+		map[string]interface{}{"url": repo.URL},
+	)
+}
+
+// Prune removes worktrees left behind by killed runs, mirrors unused for longer
+// than MaxAge and then the least recently used mirrors until the rest fit MaxSize.
+// Mirrors locked by a running analysis, or checked out in the worktree of one, are kept.
+func (c *CloneCache) Prune() (*PruneResult, error) {
+	result := &PruneResult{}
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if worktrees, err := os.ReadDir(c.worktreesDir()); err == nil {
+		for _, worktree := range worktrees {
+			if info, err := worktree.Info(); err == nil && time.Since(info.ModTime()) > worktreeStaleAfter {
+				_ = os.RemoveAll(filepath.Join(c.worktreesDir(), worktree.Name()))
+			}
+		}
+	}
+
+	type mirror struct {
+		path string
+		used time.Time
+		size int64
+	}
+	var mirrors []mirror
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.Dir, entry.Name())
+		mirrors = append(mirrors, mirror{path: path, used: info.ModTime(), size: dirSize(path)})
+		result.Size += mirrors[len(mirrors)-1].size
+	}
+	// Least recently used first
+	sort.Slice(mirrors, func(i, j int) bool {
+		return mirrors[i].used.Before(mirrors[j].used)
+	})
+
+	for _, candidate := range mirrors {
+		expired := c.MaxAge > 0 && time.Since(candidate.used) > c.MaxAge
+		oversized := c.MaxSize > 0 && result.Size > c.MaxSize
+		if !expired && !oversized {
+			result.Kept++
+			continue
+		}
+		unlock, err := tryLock(candidate.path + ".lock")
+		if err != nil {
+			result.Kept++
+			continue
+		}
+		// Worktrees read objects from their mirror until the run cleans up
+		if hasWorktrees(candidate.path) {
+			unlock()
+			result.Kept++
+			continue
+		}
+		err = os.RemoveAll(candidate.path)
+		unlock()
+		if err != nil {
+			result.Kept++
+			continue
+		}
+		result.Removed++
+		result.Freed += candidate.size
+		result.Size -= candidate.size
+	}
+	return result, nil
+}
+
+// hasWorktrees reports whether a worktree of the mirror is still checked out.
+// Git records the .git file of every worktree it added in the mirror; those of
+// runs that cleaned up are gone.
+func hasWorktrees(mirror string) bool {
+	entries, err := os.ReadDir(filepath.Join(mirror, "worktrees"))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		gitdir, err := os.ReadFile(filepath.Join(mirror, "worktrees", entry.Name(), "gitdir"))
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(strings.TrimSpace(string(gitdir))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneIfDue prunes the cache unless a run did within pruneInterval, so clones
+// do not walk every mirror each time. The modification time of a marker file
+// records the last prune across processes.
+func (c *CloneCache) pruneIfDue() {
+	marker := filepath.Join(c.Dir, "pruned")
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < pruneInterval {
+		return
+	}
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		return
+	}
+	_, _ = c.Prune()
+}
+
+// dirSize returns the total size of the files below dir
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// lock takes the lock file at path, waiting while another process holds it
+func lock(ctx context.Context, path string) (func(), error) {
+	for {
+		unlock, err := tryLock(path)
+		if err == nil {
+			return unlock, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, types.NewDevBoxPackError(
+				fmt.Sprintf("failed to lock clone cache: %s", err.Error()),
+				types.ErrorCodeTempDirError,
+				map[string]interface{}{"lock": path},
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, types.ContextError(ctx)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// tryLock creates the lock file at path, failing with fs.ErrExist while another
+// process holds it. The lock is refreshed until the returned function releases it,
+// so a lock that stopped being refreshed belongs to a crashed process and is taken over.
+func tryLock(path string) (func(), error) {
+	token, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+	err = createLock(path, token)
+	if errors.Is(err, fs.ErrExist) && isStale(path) {
+		err = takeOver(path, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(lockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				if ownsLock(path, token) {
+					_ = os.Chtimes(path, now, now)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		// A run paused for longer than lockStaleAfter may have lost the lock
+		if ownsLock(path, token) {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
+
+// takeOver replaces the stale lock at path with one holding token. Waiters finding
+// the same stale lock serialise on a second lock file and check the lock is still
+// stale under it, so only one of them removes it; reading the new lock back
+// confirms the waiter owns it.
+func takeOver(path, token string) error {
+	guard := path + ".takeover"
+	err := createLock(guard, token)
+	// A guard is held for a moment, one left behind by a crashed process is removed
+	if errors.Is(err, fs.ErrExist) && isStale(guard) {
+		_ = os.Remove(guard)
+		err = createLock(guard, token)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if ownsLock(guard, token) {
+			_ = os.Remove(guard)
+		}
+	}()
+
+	if !isStale(path) {
+		return fs.ErrExist
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := createLock(path, token); err != nil {
+		return err
+	}
+	if !ownsLock(path, token) {
+		return fs.ErrExist
+	}
+	return nil
+}
+
+// createLock creates the lock file at path holding token, failing with fs.ErrExist when it exists
+func createLock(path, token string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(token)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// isStale reports whether the lock file at path stopped being refreshed
+func isStale(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) > lockStaleAfter
+}
+
+// ownsLock reports whether the lock file at path holds token
+func ownsLock(path, token string) bool {
+	content, err := os.ReadFile(path)
+	return err == nil && string(content) == token
+}
+
+// lockToken identifies the holder of a lock: the process and a random nonce,
+// telling apart locks of one process
+func lockToken() (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s\n", os.Getpid(), hex.EncodeToString(nonce)), nil
+}
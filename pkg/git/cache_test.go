@@ -0,0 +1,354 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// newRemote creates a bare repository with a main and a release branch and
+// returns it with a function committing a file to main
+func newRemote(t *testing.T) (string, func(name string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	remote := filepath.Join(root, "shop.git")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	commit := func(name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(work, name), []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		git(work, "add", "-A")
+		git(work, "commit", "-q", "-m", name)
+		git(work, "push", "-q", remote, "main")
+	}
+
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatalf("failed to create work tree: %v", err)
+	}
+	git(root, "init", "-q", "--bare", "-b", "main", remote)
+	git(work, "init", "-q", "-b", "main")
+	commit("package.json")
+	git(work, "checkout", "-q", "-b", "release")
+	commit("go.mod")
+	git(work, "push", "-q", remote, "release")
+	git(work, "checkout", "-q", "main")
+	return remote, commit
+}
+
+func TestCloneCache(t *testing.T) {
+	remote, commit := newRemote(t)
+	cache := NewCloneCache(t.TempDir())
+	ctx := context.Background()
+
+	clone := func(ref string) (*GitHandler, string) {
+		t.Helper()
+		handler := NewGitHandler()
+		handler.SetCloneCache(cache)
+		repo := &types.GitRepository{URL: remote}
+		if ref != "" {
+			repo.Ref = &ref
+		}
+		path, err := handler.cloneRepository(ctx, repo)
+		if err != nil {
+			t.Fatalf("cloneRepository(%q) failed: %v", ref, err)
+		}
+		return handler, path
+	}
+
+	handler, path := clone("")
+	if _, err := os.Stat(filepath.Join(path, "package.json")); err != nil {
+		t.Errorf("expected package.json in worktree: %v", err)
+	}
+	if !strings.HasPrefix(path, cache.worktreesDir()) {
+		t.Errorf("expected worktree below %s, got %s", cache.worktreesDir(), path)
+	}
+	if err := handler.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("worktree %s should be removed", path)
+	}
+
+	// A later run fetches the new commit into the same mirror
+	commit("index.js")
+	handler, path = clone("")
+	if _, err := os.Stat(filepath.Join(path, "index.js")); err != nil {
+		t.Errorf("expected fetched index.js in worktree: %v", err)
+	}
+	_, release := clone("release")
+	if _, err := os.Stat(filepath.Join(release, "go.mod")); err != nil {
+		t.Errorf("expected go.mod in release worktree: %v", err)
+	}
+	_ = handler.Cleanup()
+
+	mirrors, _ := filepath.Glob(filepath.Join(cache.Dir, "*.git"))
+	if len(mirrors) != 1 {
+		t.Fatalf("expected one mirror, got %v", mirrors)
+	}
+
+	handler = NewGitHandler()
+	handler.SetCloneCache(cache)
+	missing := "missing"
+	if _, err := handler.cloneRepository(ctx, &types.GitRepository{URL: remote, Ref: &missing}); err == nil {
+		t.Error("expected error for missing ref")
+	} else if devBoxErr, ok := err.(*types.DevBoxPackError); !ok || devBoxErr.Code != types.ErrorCodeGitCheckoutError {
+		t.Errorf("expected %s, got %v", types.ErrorCodeGitCheckoutError, err)
+	}
+}
+
+func TestCloneCache_Lock(t *testing.T) {
+	remote, _ := newRemote(t)
+	cache := NewCloneCache(t.TempDir())
+	if err := os.MkdirAll(cache.Dir, 0755); err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	unlock, err := tryLock(cache.mirrorPath(remote) + ".lock")
+	if err != nil {
+		t.Fatalf("tryLock failed: %v", err)
+	}
+	if _, err := tryLock(cache.mirrorPath(remote) + ".lock"); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expected held lock, got %v", err)
+	}
+
+	// Runs wait for the mirror while another process updates it
+	handler := NewGitHandler()
+	handler.SetCloneCache(cache)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err = handler.cloneRepository(ctx, &types.GitRepository{URL: remote})
+	if devBoxErr, ok := err.(*types.DevBoxPackError); !ok || devBoxErr.Code != types.ErrorCodeTimeout {
+		t.Errorf("expected %s while locked, got %v", types.ErrorCodeTimeout, err)
+	}
+
+	// Pruning keeps locked mirrors
+	if err := os.MkdirAll(cache.mirrorPath(remote), 0755); err != nil {
+		t.Fatalf("failed to create mirror: %v", err)
+	}
+	cache.MaxAge = time.Nanosecond
+	if result, err := cache.Prune(); err != nil || result.Removed != 0 || result.Kept != 1 {
+		t.Errorf("expected locked mirror to be kept, got %+v, %v", result, err)
+	}
+	unlock()
+
+	// A lock that is no longer refreshed is taken over
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	_ = os.WriteFile(cache.mirrorPath(remote)+".lock", nil, 0644)
+	_ = os.Chtimes(cache.mirrorPath(remote)+".lock", stale, stale)
+	unlock, err = tryLock(cache.mirrorPath(remote) + ".lock")
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	unlock()
+}
+
+func TestTryLock_StaleTakeover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirror.git.lock")
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	_ = os.WriteFile(path, []byte("1 crashed\n"), 0644)
+	_ = os.Chtimes(path, stale, stale)
+
+	// Waiters finding the same stale lock cannot all take it
+	var wg sync.WaitGroup
+	unlocks := make(chan func(), 8)
+	for i := 0; i < cap(unlocks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if unlock, err := tryLock(path); err == nil {
+				unlocks <- unlock
+			}
+		}()
+	}
+	wg.Wait()
+	close(unlocks)
+	if len(unlocks) != 1 {
+		t.Fatalf("expected one waiter to take the stale lock, got %d", len(unlocks))
+	}
+	previous := <-unlocks
+
+	// A holder that lost its lock to a takeover leaves the new holder's lock alone
+	_ = os.Chtimes(path, stale, stale)
+	unlock, err := tryLock(path)
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	previous()
+	if _, err := tryLock(path); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expected lock to stay held, got %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected lock to be released, got %v", err)
+	}
+}
+
+func TestCloneCache_Prune(t *testing.T) {
+	cache := &CloneCache{Dir: t.TempDir()}
+	old := time.Now().Add(-48 * time.Hour)
+	mirror := func(name string, size int, used time.Time) {
+		t.Helper()
+		dir := filepath.Join(cache.Dir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "pack"), make([]byte, size), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		_ = os.Chtimes(dir, used, used)
+	}
+	mirror("old-1.git", 100, old)
+	mirror("older-2.git", 100, old.Add(-time.Hour))
+	mirror("new-3.git", 100, time.Now())
+	abandoned := filepath.Join(cache.worktreesDir(), "devbox-pack-1")
+	if err := os.MkdirAll(abandoned, 0755); err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	_ = os.Chtimes(abandoned, old, old)
+
+	// The size limit evicts the least recently used mirror first
+	cache.MaxSize = 250
+	result, err := cache.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.Removed != 1 || result.Freed != 100 || result.Kept != 2 || result.Size != 200 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(cache.Dir, "older-2.git")); !os.IsNotExist(err) {
+		t.Error("least recently used mirror should be removed")
+	}
+	if _, err := os.Stat(abandoned); !os.IsNotExist(err) {
+		t.Error("abandoned worktree should be removed")
+	}
+
+	// The age limit evicts mirrors that were not used
+	cache.MaxAge = 24 * time.Hour
+	result, err = cache.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.Removed != 1 || result.Kept != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(cache.Dir, "new-3.git")); err != nil {
+		t.Errorf("recently used mirror should be kept: %v", err)
+	}
+
+	if result, err := (&CloneCache{Dir: filepath.Join(cache.Dir, "missing")}).Prune(); err != nil || result.Kept != 0 {
+		t.Errorf("expected empty result for missing cache, got %+v, %v", result, err)
+	}
+
+	// Clones prune at most once per interval
+	mirror("unused-4.git", 100, old)
+	cache.pruneIfDue()
+	if _, err := os.Stat(filepath.Join(cache.Dir, "unused-4.git")); !os.IsNotExist(err) {
+		t.Error("first clone should prune")
+	}
+	mirror("unused-5.git", 100, old)
+	cache.pruneIfDue()
+	if _, err := os.Stat(filepath.Join(cache.Dir, "unused-5.git")); err != nil {
+		t.Errorf("clone within the interval should not prune: %v", err)
+	}
+}
+
+func TestCloneCache_PruneCheckedOut(t *testing.T) {
+	remote, _ := newRemote(t)
+	cache := &CloneCache{Dir: t.TempDir(), MaxAge: time.Hour}
+	handler := NewGitHandler()
+	handler.SetCloneCache(cache)
+	path, err := handler.cloneRepository(context.Background(), &types.GitRepository{URL: remote})
+	if err != nil {
+		t.Fatalf("cloneRepository failed: %v", err)
+	}
+	mirror := cache.mirrorPath(remote)
+	old := time.Now().Add(-48 * time.Hour)
+	_ = os.Chtimes(mirror, old, old)
+
+	// The mirror the running analysis reads from is kept while its worktree exists
+	result, err := cache.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.Removed != 0 || result.Kept != 1 {
+		t.Errorf("expected the checked out mirror to be kept, got %+v", result)
+	}
+	if output, err := exec.Command("git", "-C", path, "log", "-1", "--format=%s").CombinedOutput(); err != nil {
+		t.Errorf("worktree should stay readable: %v\n%s", err, output)
+	}
+
+	if err := handler.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	result, err = cache.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("expected the mirror to be removed once its worktree is, got %+v", result)
+	}
+}
+
+func TestResolveRemoteCommit(t *testing.T) {
+	remote, _ := newRemote(t)
+	handler := NewGitHandler()
+	ctx := context.Background()
+
+	revParse := func(rev string) string {
+		t.Helper()
+		output, err := exec.Command("git", "-C", remote, "rev-parse", rev+"^{commit}").Output()
+		if err != nil {
+			t.Fatalf("rev-parse %s failed: %v", rev, err)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	tag := exec.Command("git", "-C", remote, "-c", "user.name=test", "-c", "user.email=test@example.com", "tag", "-a", "v1", "-m", "v1", "release")
+	if output, err := tag.CombinedOutput(); err != nil {
+		t.Fatalf("tag failed: %v\n%s", err, output)
+	}
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"", revParse("main")},
+		{"release", revParse("release")},
+		{"v1", revParse("release")},
+		{strings.ToUpper(revParse("release")), revParse("release")},
+	}
+	for _, tt := range tests {
+		var ref *string
+		if tt.ref != "" {
+			ref = &tt.ref
+		}
+		commit, err := handler.ResolveRemoteCommit(ctx, remote, ref)
+		if err != nil || commit != tt.expected {
+			t.Errorf("ResolveRemoteCommit(%q): expected %s, got %s, %v", tt.ref, tt.expected, commit, err)
+		}
+	}
+
+	for _, ref := range []string{"missing", "--upload-pack=id"} {
+		if _, err := handler.ResolveRemoteCommit(ctx, remote, &ref); err == nil {
+			t.Errorf("ResolveRemoteCommit(%q): expected error", ref)
+		}
+	}
+}
@@ -3,27 +3,30 @@
 package git
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
 // Scan configuration constants
 const (
-	DefaultDepth = 3
-	MaxFiles     = 1000
+	DefaultDepth = source.DefaultDepth
+	MaxFiles     = source.MaxFiles
 )
 
 // GitHandler Git repository handler
 type GitHandler struct {
 	tempDirs []string
+	cache    *CloneCache
 }
 
 // NewGitHandler creates a new Git handler instance
@@ -33,15 +36,18 @@ func NewGitHandler() *GitHandler {
 	}
 }
 
-// execGit executes Git commands
-func (g *GitHandler) execGit(args []string, cwd string) (string, error) {
-	cmd := exec.Command("git", args...)
+// execGit executes Git commands, killing the process once ctx is done
+func (g *GitHandler) execGit(ctx context.Context, args []string, cwd string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
 	if cwd != "" {
 		cmd.Dir = cwd
 	}
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
 		return "", types.NewDevBoxPackError(
 			fmt.Sprintf("Git operation failed: %s", string(output)),
 			types.ErrorCodeGitError,
@@ -56,19 +62,86 @@ func (g *GitHandler) execGit(args []string, cwd string) (string, error) {
 }
 
 // PrepareProject prepares project directory (local path or remote repository)
-func (g *GitHandler) PrepareProject(repoPath string) (string, error) {
-	repo := g.parseRepository(repoPath)
+func (g *GitHandler) PrepareProject(ctx context.Context, repoPath string) (string, error) {
+	return g.PrepareRepository(ctx, &types.GitRepository{URL: repoPath})
+}
+
+// PrepareRepository prepares the project directory of a repository, cloning remote
+// repositories at repository.Ref, and returns the path of repository.Subdir in it
+func (g *GitHandler) PrepareRepository(ctx context.Context, repository *types.GitRepository) (string, error) {
+	if err := types.ContextError(ctx); err != nil {
+		return "", err
+	}
+	// Refs are passed to git as arguments and must not be mistaken for options
+	if repository.Ref != nil && strings.HasPrefix(*repository.Ref, "-") {
+		return "", invalidRefError(*repository.Ref)
+	}
+
+	repo := g.parseRepository(repository.URL)
+	repo.Ref = repository.Ref
+	repo.Subdir = repository.Subdir
 
 	if repo.IsLocal {
-		return g.prepareLocalProject(repo)
+		projectPath, err := g.prepareLocalProject(ctx, repo)
+		if err != nil || repo.Subdir == nil {
+			return projectPath, err
+		}
+		return subdirectory(projectPath, *repo.Subdir)
+	}
+	return g.cloneRepository(ctx, repo)
+}
+
+// OpenRepository opens the project tree of a repository for reading. Refs of local
+// repositories are read from Git objects, leaving the working tree untouched, so bare
+// repositories can be opened too; anything else is prepared with PrepareRepository.
+func (g *GitHandler) OpenRepository(ctx context.Context, repository *types.GitRepository) (fs.FS, error) {
+	if repository.Ref == nil || !g.parseRepository(repository.URL).IsLocal {
+		projectPath, err := g.PrepareRepository(ctx, repository)
+		if err != nil {
+			return nil, err
+		}
+		return source.Dir(projectPath), nil
+	}
+
+	commit, err := g.ResolveLocalCommit(ctx, repository.URL, *repository.Ref)
+	if err != nil {
+		return nil, err
+	}
+	fsys, err := source.NewGitCommit(ctx, repository.URL, commit)
+	if err != nil || repository.Subdir == nil {
+		return fsys, err
+	}
+	return source.Sub(fsys, *repository.Subdir)
+}
+
+// ResolveLocalCommit returns the commit ref points at in the local repository at path
+func (g *GitHandler) ResolveLocalCommit(ctx context.Context, path, ref string) (string, error) {
+	if _, err := g.prepareLocalProject(ctx, &types.GitRepository{URL: path}); err != nil {
+		return "", err
+	}
+	return source.ResolveCommit(ctx, path, ref)
+}
+
+// RemoteForms lists the forms of remote repository URLs that IsRemote accepts
+var RemoteForms = []string{"https://", "http://", "ssh://", "git://", "user@host:path"}
+
+// scpPattern matches the scp-like form of SSH URLs, such as git@github.com:org/repo.git
+var scpPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]+@[A-Za-z0-9.-]+:`)
+
+// IsRemote reports whether repository is a remote repository URL rather than a local path
+func IsRemote(repository string) bool {
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://"} {
+		if strings.HasPrefix(repository, scheme) {
+			return true
+		}
 	}
-	return g.cloneRepository(repo)
+	return scpPattern.MatchString(repository)
 }
 
 // parseRepository parses repository path
 func (g *GitHandler) parseRepository(repoPath string) *types.GitRepository {
 	// Check if it's a local path
-	if !strings.HasPrefix(repoPath, "http") && !strings.Contains(repoPath, "@") {
+	if !IsRemote(repoPath) {
 		return &types.GitRepository{
 			URL:     repoPath,
 			IsLocal: true,
@@ -82,8 +155,77 @@ func (g *GitHandler) parseRepository(repoPath string) *types.GitRepository {
 	}
 }
 
+// invalidRefError reports a ref that git would take for an option
+func invalidRefError(ref string) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("invalid ref: %s", ref),
+		types.ErrorCodeInvalidArgument,
+		map[string]interface{}{"ref": ref},
+	)
+}
+
+// IsRemote reports whether repoPath is a remote repository URL rather than a local path
+func (g *GitHandler) IsRemote(repoPath string) bool {
+	return !g.parseRepository(repoPath).IsLocal
+}
+
+// ResolveRemoteCommit asks the remote at url which commit ref points at without
+// cloning it; nil ref resolves the default branch. Branches are preferred over
+// tags of the same name, and annotated tags resolve to the commit they tag.
+func (g *GitHandler) ResolveRemoteCommit(ctx context.Context, url string, ref *string) (string, error) {
+	name := "HEAD"
+	if ref != nil {
+		name = *ref
+		if isCommitID(name) {
+			return strings.ToLower(name), nil
+		}
+		if strings.HasPrefix(name, "-") {
+			return "", invalidRefError(name)
+		}
+	}
+
+	output, err := g.execGit(ctx, []string{"ls-remote", url, name, name + "^{}"}, "")
+	if err != nil {
+		return "", err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	for _, candidate := range []string{name, "refs/heads/" + name, "refs/tags/" + name + "^{}", "refs/tags/" + name} {
+		if commit, ok := refs[candidate]; ok {
+			return commit, nil
+		}
+	}
+	return "", types.NewDevBoxPackError(
+		fmt.Sprintf("ref not found on remote: %s", name),
+		types.ErrorCodeGitCheckoutError,
+		map[string]interface{}{"ref": name},
+	)
+}
+
+// HeadCommit returns the commit checked out at projectPath
+func (g *GitHandler) HeadCommit(ctx context.Context, projectPath string) (string, error) {
+	return g.execGit(ctx, []string{"rev-parse", "--verify", "HEAD^{commit}"}, projectPath)
+}
+
+// isCommitID reports whether ref is a full hexadecimal commit ID
+func isCommitID(ref string) bool {
+	if len(ref) != 40 && len(ref) != 64 {
+		return false
+	}
+	for _, c := range strings.ToLower(ref) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
 // prepareLocalProject prepares local project
-func (g *GitHandler) prepareLocalProject(repo *types.GitRepository) (string, error) {
+func (g *GitHandler) prepareLocalProject(ctx context.Context, repo *types.GitRepository) (string, error) {
 	projectPath := repo.URL
 
 	// Check if path exists
@@ -105,7 +247,7 @@ func (g *GitHandler) prepareLocalProject(repo *types.GitRepository) (string, err
 	}
 
 	// Check if it's a Git repository (optional)
-	_, err = g.execGit([]string{"rev-parse", "--git-dir"}, projectPath)
+	_, err = g.execGit(ctx, []string{"rev-parse", "--git-dir"}, projectPath)
 	if err != nil {
 		// It's okay if it's not a Git repository, continue processing
 	}
@@ -114,7 +256,11 @@ func (g *GitHandler) prepareLocalProject(repo *types.GitRepository) (string, err
 }
 
 // cloneRepository clones remote repository
-func (g *GitHandler) cloneRepository(repo *types.GitRepository) (string, error) {
+func (g *GitHandler) cloneRepository(ctx context.Context, repo *types.GitRepository) (string, error) {
+	if g.cache != nil {
+		return g.cloneCached(ctx, repo)
+	}
+
 	tempDir, err := g.createTempDir()
 	if err != nil {
 		return "", err
@@ -138,9 +284,12 @@ func (g *GitHandler) cloneRepository(repo *types.GitRepository) (string, error)
 	cloneArgs = append(cloneArgs, "--filter=blob:none")
 
 	// Execute clone
-	_, err = g.execGit(cloneArgs, "")
+	_, err = g.execGit(ctx, cloneArgs, "")
 	if err != nil {
 		g.cleanupTempDir(tempDir)
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
 		return "", types.NewDevBoxPackError(
 			fmt.Sprintf("repository clone failed: %s", err.Error()),
 			types.ErrorCodeCloneError,
@@ -151,16 +300,19 @@ func (g *GitHandler) cloneRepository(repo *types.GitRepository) (string, error)
 	// If ref is specified and wasn't cloned with --branch, switch to it
 	if repo.Ref != nil {
 		// Try direct checkout first (might already be available)
-		_, err = g.execGit([]string{"checkout", *repo.Ref}, clonePath)
+		_, err = g.execGit(ctx, []string{"checkout", *repo.Ref}, clonePath)
 		if err != nil {
 			// If checkout fails, fetch only the specific ref needed
 			fetchArgs := []string{"fetch", "origin", *repo.Ref}
-			_, err = g.execGit(fetchArgs, clonePath)
+			_, err = g.execGit(ctx, fetchArgs, clonePath)
 			if err != nil {
 				// If specific ref fetch fails, try fetching all as fallback
-				_, err = g.execGit([]string{"fetch", "--all", "--tags"}, clonePath)
+				_, err = g.execGit(ctx, []string{"fetch", "--all", "--tags"}, clonePath)
 				if err != nil {
 					g.cleanupTempDir(tempDir)
+					if ctxErr := types.ContextError(ctx); ctxErr != nil {
+						return "", ctxErr
+					}
 					return "", types.NewDevBoxPackError(
 						fmt.Sprintf("failed to fetch ref '%s': %s", *repo.Ref, err.Error()),
 						types.ErrorCodeGitCheckoutError,
@@ -173,9 +325,12 @@ func (g *GitHandler) cloneRepository(repo *types.GitRepository) (string, error)
 			}
 
 			// Try checkout again
-			_, err = g.execGit([]string{"checkout", *repo.Ref}, clonePath)
+			_, err = g.execGit(ctx, []string{"checkout", *repo.Ref}, clonePath)
 			if err != nil {
 				g.cleanupTempDir(tempDir)
+				if ctxErr := types.ContextError(ctx); ctxErr != nil {
+					return "", ctxErr
+				}
 				return "", types.NewDevBoxPackError(
 					fmt.Sprintf("cannot switch to specified ref: %s", *repo.Ref),
 					types.ErrorCodeGitCheckoutError,
@@ -190,23 +345,10 @@ func (g *GitHandler) cloneRepository(repo *types.GitRepository) (string, error)
 
 	// If subdirectory is specified, return subdirectory path
 	if repo.Subdir != nil {
-		subdirPath := filepath.Join(clonePath, *repo.Subdir)
-		stat, err := os.Stat(subdirPath)
+		subdirPath, err := subdirectory(clonePath, *repo.Subdir)
 		if err != nil {
 			g.cleanupTempDir(tempDir)
-			return "", types.NewDevBoxPackError(
-				fmt.Sprintf("cannot access subdirectory: %s", *repo.Subdir),
-				types.ErrorCodeSubdirAccessError,
-				nil,
-			)
-		}
-		if !stat.IsDir() {
-			g.cleanupTempDir(tempDir)
-			return "", types.NewDevBoxPackError(
-				fmt.Sprintf("subdirectory does not exist: %s", *repo.Subdir),
-				types.ErrorCodeSubdirNotFound,
-				nil,
-			)
+			return "", err
 		}
 		return subdirPath, nil
 	}
@@ -214,6 +356,45 @@ func (g *GitHandler) cloneRepository(repo *types.GitRepository) (string, error)
 	return clonePath, nil
 }
 
+// subdirectory returns the path of subdir below projectPath, which must be a directory
+func subdirectory(projectPath, subdir string) (string, error) {
+	cleaned, err := source.CleanSubdir(subdir)
+	if err != nil {
+		return "", err
+	}
+	subdirPath := filepath.Join(projectPath, filepath.FromSlash(cleaned))
+	stat, err := os.Stat(subdirPath)
+	if err != nil {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("cannot access subdirectory: %s", subdir),
+			types.ErrorCodeSubdirAccessError,
+			nil,
+		)
+	}
+	if !stat.IsDir() {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("subdirectory does not exist: %s", subdir),
+			types.ErrorCodeSubdirNotFound,
+			nil,
+		)
+	}
+	return subdirPath, nil
+}
+
+// RepoName returns the name of a repository URL or local project path.
+// Remote repositories are named like their clone directory, local paths by their directory.
+func RepoName(repository string) string {
+	handler := &GitHandler{}
+	repo := handler.parseRepository(repository)
+	if repo.IsLocal {
+		if absolute, err := filepath.Abs(repository); err == nil {
+			return filepath.Base(absolute)
+		}
+		return filepath.Base(repository)
+	}
+	return handler.extractRepoName(strings.TrimSuffix(repo.URL, "/"))
+}
+
 // extractRepoName extracts repository name from URL
 func (g *GitHandler) extractRepoName(url string) string {
 	// Handle SSH format: git@github.com:user/repo.git
@@ -251,264 +432,44 @@ func (g *GitHandler) createTempDir() (string, error) {
 	return tempDir, nil
 }
 
-// ScanProject scans project files
-func (g *GitHandler) ScanProject(projectPath string, options *types.ScanOptions) ([]*types.FileInfo, error) {
-	if options == nil {
-		options = &types.ScanOptions{
-			Depth:    DefaultDepth,
-			MaxFiles: MaxFiles,
-		}
-	}
-
-	files := make([]*types.FileInfo, 0)
-	err := g.scanDirectory(projectPath, "", &files, 0, options.Depth, options.MaxFiles)
-	if err != nil {
-		return nil, types.NewDevBoxPackError(
-			fmt.Sprintf("Project scan failed: %s", err.Error()),
-			types.ErrorCodeScanError,
-			nil,
-		)
-	}
-
-	return files, nil
-}
-
-// scanDirectory recursively scans directory
-func (g *GitHandler) scanDirectory(basePath, currentPath string, files *[]*types.FileInfo, currentDepth, maxDepth, maxFiles int) error {
-	if len(*files) >= maxFiles || currentDepth > maxDepth {
-		return nil
-	}
-
-	fullPath := filepath.Join(basePath, currentPath)
-
-	entries, err := os.ReadDir(fullPath)
-	if err != nil {
-		// Ignore inaccessible directories
-		return nil
-	}
-
-	for _, entry := range entries {
-		if len(*files) >= maxFiles {
-			break
-		}
-
-		entryPath := currentPath
-		if entryPath != "" {
-			entryPath = filepath.Join(entryPath, entry.Name())
-		} else {
-			entryPath = entry.Name()
-		}
-
-		if entry.IsDir() {
-			// Skip directories that should be ignored
-			if g.shouldIgnoreDirectory(entry.Name()) {
-				continue
-			}
-
-			// Recursively scan subdirectories
-			err = g.scanDirectory(basePath, entryPath, files, currentDepth+1, maxDepth, maxFiles)
-			if err != nil {
-				return err
-			}
-		} else {
-			// Skip hidden files unless they are important configuration files
-			if strings.HasPrefix(entry.Name(), ".") && !g.isImportantDotFile(entry.Name()) {
-				continue
-			}
-
-			entryFullPath := filepath.Join(basePath, entryPath)
-			stat, err := os.Stat(entryFullPath)
-			if err != nil {
-				// Ignore inaccessible files
-				continue
-			}
-
-			size := stat.Size()
-			ext := g.getFileExtension(entry.Name())
-			*files = append(*files, &types.FileInfo{
-				Path:        entryPath,
-				Name:        entry.Name(),
-				Size:        &size,
-				IsDirectory: false,
-				Extension:   &ext,
-			})
-		}
-	}
-
-	return nil
+// ScanProject scans project files, stopping early once ctx is done
+func (g *GitHandler) ScanProject(ctx context.Context, projectPath string, options *types.ScanOptions) ([]*types.FileInfo, error) {
+	return source.Scan(ctx, source.Dir(projectPath), options)
 }
 
 // getFileExtension gets file extension
 func (g *GitHandler) getFileExtension(filename string) string {
-	ext := filepath.Ext(filename)
-	if ext != "" {
-		return strings.ToLower(ext) // Keep dot and convert to lowercase
-	}
-	return ""
+	return source.FileExtension(filename)
 }
 
 // isImportantDotFile checks if it's an important dot file
 func (g *GitHandler) isImportantDotFile(name string) bool {
-	importantDotFiles := []string{
-		".gitignore", ".dockerignore", ".env", ".env.example", ".nvmrc", ".python-version",
-		".node-version", ".ruby-version", ".go-version",
-		".eslintrc.js", ".eslintrc.json", ".eslintrc.yml", ".eslintrc.yaml",
-		".prettierrc", ".prettierrc.js", ".prettierrc.json", ".prettierrc.yml", ".prettierrc.yaml",
-		".babelrc", ".babelrc.js", ".babelrc.json",
-	}
-	for _, file := range importantDotFiles {
-		if name == file {
-			return true
-		}
-	}
-	return false
+	return source.IsImportantDotFile(name)
 }
 
 // shouldIgnoreDirectory checks if directory should be ignored
 func (g *GitHandler) shouldIgnoreDirectory(name string) bool {
-	ignoredDirs := []string{
-		"node_modules", ".git", ".svn", "dist", "build", "__pycache__",
-		".pytest_cache", "target", "vendor", ".next", ".nuxt",
-	}
-	for _, dir := range ignoredDirs {
-		if name == dir {
-			return true
-		}
-	}
-	return false
+	return source.ShouldIgnoreDirectory(name)
 }
 
 // FileExists checks if file exists
 func (g *GitHandler) FileExists(projectPath, filePath string) bool {
-	fullPath := filepath.Join(projectPath, filePath)
-	_, err := os.Stat(fullPath)
-	return err == nil
+	return source.Exists(source.Dir(projectPath), filepath.ToSlash(filePath))
 }
 
 // ReadFile reads file content
 func (g *GitHandler) ReadFile(projectPath, filePath string) (string, error) {
-	fullPath := filepath.Join(projectPath, filePath)
-	content, err := os.ReadFile(fullPath)
-	if err != nil {
-		return "", types.NewDevBoxPackError(
-			fmt.Sprintf("Failed to read file: %s", filePath),
-			types.ErrorCodeFileReadError,
-			map[string]interface{}{
-				"path":  filePath,
-				"error": err.Error(),
-			},
-		)
-	}
-	return string(content), nil
+	return source.ReadText(source.Dir(projectPath), filepath.ToSlash(filePath))
 }
 
 // ReadJSONFile reads JSON file
 func (g *GitHandler) ReadJSONFile(projectPath, filePath string, v interface{}) error {
-	content, err := g.ReadFile(projectPath, filePath)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal([]byte(content), v)
-	if err != nil {
-		return types.NewDevBoxPackError(
-			fmt.Sprintf("Failed to parse JSON file: %s", filePath),
-			types.ErrorCodeJSONParseError,
-			map[string]interface{}{
-				"path":  filePath,
-				"error": err.Error(),
-			},
-		)
-	}
-
-	return nil
+	return source.ReadJSON(source.Dir(projectPath), filepath.ToSlash(filePath), v)
 }
 
 // ReadJSONCFile reads JSONC file (JSON with comments support)
 func (g *GitHandler) ReadJSONCFile(projectPath, filePath string, v interface{}) error {
-	content, err := g.ReadFile(projectPath, filePath)
-	if err != nil {
-		return err
-	}
-
-	// JSONC processing: remove both single-line and multi-line comments
-	cleanedContent := content
-
-	// Remove block comments /* ... */
-	inBlockComment := false
-	var result strings.Builder
-	i := 0
-	for i < len(cleanedContent) {
-		if !inBlockComment && i+1 < len(cleanedContent) && cleanedContent[i:i+2] == "/*" {
-			inBlockComment = true
-			i += 2
-			continue
-		}
-		if inBlockComment && i+1 < len(cleanedContent) && cleanedContent[i:i+2] == "*/" {
-			inBlockComment = false
-			i += 2
-			continue
-		}
-		if !inBlockComment {
-			result.WriteByte(cleanedContent[i])
-		}
-		i++
-	}
-	cleanedContent = result.String()
-
-	// Remove single-line comments // and clean up whitespace
-	lines := strings.Split(cleanedContent, "\n")
-	var cleanedLines []string
-	for _, line := range lines {
-		// Remove // comments (but not inside strings)
-		line = g.removeSingleLineComments(line)
-		// Trim whitespace
-		line = strings.TrimSpace(line)
-		// Skip empty lines
-		if line != "" {
-			cleanedLines = append(cleanedLines, line)
-		}
-	}
-	cleanedContent = strings.Join(cleanedLines, "\n")
-
-	err = json.Unmarshal([]byte(cleanedContent), v)
-	if err != nil {
-		return types.NewDevBoxPackError(
-			fmt.Sprintf("Failed to parse JSONC file: %s", filePath),
-			types.ErrorCodeJSONParseError,
-			map[string]interface{}{
-				"path":  filePath,
-				"error": err.Error(),
-			},
-		)
-	}
-
-	return nil
-}
-
-// removeSingleLineComments removes // comments from a line, respecting strings
-func (g *GitHandler) removeSingleLineComments(line string) string {
-	inString := false
-	escaped := false
-	var result strings.Builder
-
-	for i, char := range line {
-		switch {
-		case char == '\\' && inString:
-			// Escape character inside string
-			escaped = true
-		case char == '"' && !escaped:
-			// Start/end of string
-			inString = !inString
-		case char == '/' && !inString && i+1 < len(line) && line[i+1] == '/' && !escaped:
-			// Start of comment outside string
-			return line[:i]
-		default:
-			escaped = false
-		}
-		result.WriteRune(char)
-	}
-	return line
+	return source.ReadJSONC(source.Dir(projectPath), filepath.ToSlash(filePath), v)
 }
 
 // Cleanup cleans up temporary directories
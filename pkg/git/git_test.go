@@ -1,10 +1,15 @@
 package git
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/labring/devbox-pack/pkg/types"
 )
@@ -84,6 +89,31 @@ func TestParseRepository_Local(t *testing.T) {
 	}
 }
 
+func TestIsRemote(t *testing.T) {
+	tests := map[string]bool{
+		"https://github.com/user/repo.git": true,
+		"http://example.com/repo":          true,
+		"ssh://host/repo":                  true,
+		"ssh://git@host:2222/org/repo.git": true,
+		"git://host/repo.git":              true,
+		"git@github.com:user/repo.git":     true,
+		"deploy@10.0.0.1:repos/app":        true,
+		".":                                false,
+		"/home/user/project":               false,
+		"httpdocs":                         false,
+		"file:///etc":                      false,
+		"backup@2024/project":              false,
+	}
+	for repository, expected := range tests {
+		if IsRemote(repository) != expected {
+			t.Errorf("IsRemote(%q): expected %t", repository, expected)
+		}
+		if handler := NewGitHandler(); handler.parseRepository(repository).IsLocal == expected {
+			t.Errorf("parseRepository(%q): expected IsLocal %t", repository, !expected)
+		}
+	}
+}
+
 func TestPrepareLocalProject_ValidPath(t *testing.T) {
 	handler := NewGitHandler()
 
@@ -99,7 +129,7 @@ func TestPrepareLocalProject_ValidPath(t *testing.T) {
 		IsLocal: true,
 	}
 
-	projectPath, err := handler.prepareLocalProject(repo)
+	projectPath, err := handler.prepareLocalProject(context.Background(), repo)
 	if err != nil {
 		t.Fatalf("prepareLocalProject failed: %v", err)
 	}
@@ -117,7 +147,7 @@ func TestPrepareLocalProject_InvalidPath(t *testing.T) {
 		IsLocal: true,
 	}
 
-	_, err := handler.prepareLocalProject(repo)
+	_, err := handler.prepareLocalProject(context.Background(), repo)
 	if err == nil {
 		t.Error("expected error for non-existent path")
 	}
@@ -148,12 +178,141 @@ func TestPrepareLocalProject_FilePath(t *testing.T) {
 		IsLocal: true,
 	}
 
-	_, err = handler.prepareLocalProject(repo)
+	_, err = handler.prepareLocalProject(context.Background(), repo)
 	if err == nil {
 		t.Error("expected error for file path instead of directory")
 	}
 }
 
+func TestPrepareRepository_LocalSubdir(t *testing.T) {
+	handler := NewGitHandler()
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "apps", "web"), 0755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+
+	subdir := "apps/web/"
+	projectPath, err := handler.PrepareRepository(context.Background(), &types.GitRepository{URL: tmpDir, Subdir: &subdir})
+	if err != nil {
+		t.Fatalf("PrepareRepository failed: %v", err)
+	}
+	if expected := filepath.Join(tmpDir, "apps", "web"); projectPath != expected {
+		t.Errorf("expected project path %s, got %s", expected, projectPath)
+	}
+
+	expectedCodes := map[string]string{
+		"apps/api": types.ErrorCodeSubdirAccessError,
+		"../..":    types.ErrorCodeInvalidPath,
+	}
+	for subdir, code := range expectedCodes {
+		subdir := subdir
+		_, err := handler.PrepareRepository(context.Background(), &types.GitRepository{URL: tmpDir, Subdir: &subdir})
+		if devboxErr, ok := err.(*types.DevBoxPackError); !ok || devboxErr.Code != code {
+			t.Errorf("subdir %s: expected %s error, got %v", subdir, code, err)
+		}
+	}
+
+	ref := "--upload-pack=touch /tmp/pwned"
+	_, err = handler.PrepareRepository(context.Background(), &types.GitRepository{URL: "https://example.com/user/repo.git", Ref: &ref})
+	if devboxErr, ok := err.(*types.DevBoxPackError); !ok || devboxErr.Code != types.ErrorCodeInvalidArgument {
+		t.Errorf("expected invalid argument error for option-like ref, got %v", err)
+	}
+}
+
+func TestOpenRepository_LocalRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	handler := NewGitHandler()
+	ctx := context.Background()
+	work := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(work, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	git(work, "init", "-q", "-b", "main")
+	write("apps/web/package.json", `{"name": "web"}`)
+	git(work, "add", "-A")
+	git(work, "commit", "-q", "-m", "web")
+	git(work, "tag", "v1")
+	write("go.mod", "module example.com/app\n")
+	git(work, "add", "-A")
+	git(work, "commit", "-q", "-m", "go")
+	git(work, "update-ref", "refs/pull/1/head", "v1")
+	// Uncommitted changes are not part of any ref
+	write("go.mod", "module example.com/changed\n")
+	write("index.js", "")
+	bare := filepath.Join(t.TempDir(), "app.git")
+	git(work, "clone", "-q", "--bare", work, bare)
+
+	read := func(url, ref, name string) (string, error) {
+		t.Helper()
+		fsys, err := handler.OpenRepository(ctx, &types.GitRepository{URL: url, Ref: &ref})
+		if err != nil {
+			t.Fatalf("OpenRepository(%s, %q) failed: %v", url, ref, err)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		return string(data), err
+	}
+	if content, err := read(work, "main", "go.mod"); err != nil || content != "module example.com/app\n" {
+		t.Errorf("expected committed go.mod at main, got %q, %v", content, err)
+	}
+	if _, err := read(work, "main", "index.js"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected uncommitted index.js to be missing at main, got %v", err)
+	}
+	for url, ref := range map[string]string{bare: "v1", work: "refs/pull/1/head"} {
+		if _, err := read(url, ref, "go.mod"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected go.mod to be missing at %s, got %v", ref, err)
+		}
+	}
+	if content, err := read(bare, "main", "go.mod"); err != nil || content != "module example.com/app\n" {
+		t.Errorf("expected go.mod in bare repository, got %q, %v", content, err)
+	}
+
+	ref, subdir := "v1", "apps/web"
+	fsys, err := handler.OpenRepository(ctx, &types.GitRepository{URL: bare, Ref: &ref, Subdir: &subdir})
+	if err != nil {
+		t.Fatalf("OpenRepository with subdir failed: %v", err)
+	}
+	if _, err := fs.Stat(fsys, "package.json"); err != nil {
+		t.Errorf("expected package.json in subdir: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(work, "go.mod")); err != nil || string(data) != "module example.com/changed\n" {
+		t.Errorf("working tree should be untouched, got %q, %v", data, err)
+	}
+
+	expectedCodes := map[string]string{
+		"missing":            types.ErrorCodeGitCheckoutError,
+		"--output=/tmp/file": types.ErrorCodeInvalidArgument,
+	}
+	for ref, code := range expectedCodes {
+		ref := ref
+		_, err := handler.OpenRepository(ctx, &types.GitRepository{URL: bare, Ref: &ref})
+		if devboxErr, ok := err.(*types.DevBoxPackError); !ok || devboxErr.Code != code {
+			t.Errorf("ref %s: expected %s error, got %v", ref, code, err)
+		}
+	}
+	missing := filepath.Join(work, "missing")
+	if _, err := handler.OpenRepository(ctx, &types.GitRepository{URL: missing, Ref: &ref}); err == nil {
+		t.Error("expected error for missing repository")
+	}
+}
+
 func TestExtractRepoName(t *testing.T) {
 	handler := NewGitHandler()
 
@@ -199,6 +358,23 @@ func TestExtractRepoName(t *testing.T) {
 	}
 }
 
+func TestRepoName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-service")
+	tests := map[string]string{
+		"https://github.com/user/repo.git":  "repo",
+		"https://github.com/user/repo/":     "repo",
+		"git@github.com:user/ssh-repo.git":  "ssh-repo",
+		dir:                                 "my-service",
+		filepath.Join(dir, "..", "project"): "project",
+	}
+
+	for repository, expected := range tests {
+		if name := RepoName(repository); name != expected {
+			t.Errorf("RepoName(%q): expected %s, got %s", repository, expected, name)
+		}
+	}
+}
+
 func TestCreateTempDir(t *testing.T) {
 	handler := NewGitHandler()
 
@@ -265,7 +441,7 @@ func TestScanProject(t *testing.T) {
 		MaxFiles: 100,
 	}
 
-	files, err := handler.ScanProject(tmpDir, options)
+	files, err := handler.ScanProject(context.Background(), tmpDir, options)
 	if err != nil {
 		t.Fatalf("ScanProject failed: %v", err)
 	}
@@ -296,6 +472,37 @@ func TestScanProject(t *testing.T) {
 	}
 }
 
+func TestScanProject_Cancelled(t *testing.T) {
+	handler := NewGitHandler()
+
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to create package.json: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := handler.ScanProject(ctx, tmpDir, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPrepareProject_DeadlineExceeded(t *testing.T) {
+	handler := NewGitHandler()
+	defer handler.Cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	_, err := handler.PrepareProject(ctx, "https://example.com/user/repo.git")
+	var devBoxPackErr *types.DevBoxPackError
+	if !errors.As(err, &devBoxPackErr) || devBoxPackErr.Code != types.ErrorCodeTimeout {
+		t.Errorf("expected %s error, got %v", types.ErrorCodeTimeout, err)
+	}
+}
+
 func TestFileExists(t *testing.T) {
 	handler := NewGitHandler()
 
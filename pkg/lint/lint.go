@@ -0,0 +1,200 @@
+// Package lint checks execution plans for problems worth raising in review,
+// such as a missing lock file or commands with risky flags.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Source is the diagnostic source of lint findings
+const Source = "lint"
+
+// Rule identifiers
+const (
+	RuleMissingLockFile          = "missing-lock-file"
+	RuleUnresolvedRuntimeVersion = "unresolved-runtime-version"
+	RuleEmptyRunCommand          = "empty-run-command"
+	RuleRiskyFlag                = "risky-flag"
+)
+
+// Rule describes a check
+type Rule struct {
+	ID          string
+	Description string
+	// Severity of the rule's diagnostics
+	Severity string
+}
+
+// Rules lists every check in the order it runs
+var Rules = []Rule{
+	{RuleMissingLockFile, "Dependencies are resolved without a lock file, so builds are not reproducible", types.SeverityWarning},
+	{RuleUnresolvedRuntimeVersion, "The runtime version has no base image in the catalog", types.SeverityWarning},
+	{RuleEmptyRunCommand, "The plan has no command to run the application", types.SeverityWarning},
+	{RuleRiskyFlag, "A command uses a flag that weakens isolation or verification", types.SeverityWarning},
+}
+
+// lockFiles maps dependency manifests to the lock files pinning them
+var lockFiles = map[string][]string{
+	"package.json":  {"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb", "bun.lock"},
+	"Pipfile":       {"Pipfile.lock"},
+	"Gemfile":       {"Gemfile.lock"},
+	"composer.json": {"composer.lock"},
+	"Cargo.toml":    {"Cargo.lock"},
+}
+
+// versionFiles are files pinning a runtime version, the location of version findings
+var versionFiles = []string{".nvmrc", ".node-version", ".python-version", "runtime.txt", ".ruby-version", ".php-version", "rust-toolchain", "rust-toolchain.toml", ".tool-versions"}
+
+// riskyFlag is a command pattern and why it is risky
+type riskyFlag struct {
+	flag    string
+	pattern *regexp.Regexp
+	reason  string
+}
+
+// riskyFlags lists the flags and shell patterns reported by RuleRiskyFlag
+var riskyFlags = []riskyFlag{
+	{"--allow-all", regexp.MustCompile(`(^|\s)--allow-all(\s|$)`), "grants every permission; allow only the permissions the application needs"},
+	{"-A", regexp.MustCompile(`\bdeno\s.*(^|\s)-A(\s|$)`), "grants every Deno permission; allow only the permissions the application needs"},
+	{"--unsafe-perm", regexp.MustCompile(`(^|\s)--unsafe-perm(\s|=|$)`), "runs package scripts as root"},
+	{"--insecure", regexp.MustCompile(`(^|\s)--insecure(\s|$)`), "disables TLS certificate verification"},
+	{"--trusted-host", regexp.MustCompile(`(^|\s)--trusted-host(\s|=|$)`), "lets pip download packages without TLS verification"},
+	{"chmod 777", regexp.MustCompile(`\bchmod\s+(-R\s+)?0?777\b`), "makes files writable by every user"},
+	{"| sh", regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|z)?sh\b`), "runs a downloaded script without verifying it"},
+}
+
+// Check returns the findings of every rule for the plan
+func Check(plan *types.ExecutionPlan) []types.Diagnostic {
+	if plan == nil {
+		return nil
+	}
+	var diagnostics []types.Diagnostic
+	diagnostics = append(diagnostics, checkLockFiles(plan)...)
+	diagnostics = append(diagnostics, checkRuntimeVersion(plan)...)
+	diagnostics = append(diagnostics, checkRunCommand(plan)...)
+	diagnostics = append(diagnostics, checkRiskyFlags(plan)...)
+	return diagnostics
+}
+
+// checkLockFiles reports evidence manifests without any of their lock files in the evidence
+func checkLockFiles(plan *types.ExecutionPlan) []types.Diagnostic {
+	evidence := make(map[string]bool, len(plan.Evidence.Files))
+	for _, file := range plan.Evidence.Files {
+		evidence[file] = true
+	}
+
+	var diagnostics []types.Diagnostic
+	for _, manifest := range plan.Evidence.Files {
+		locks, ok := lockFiles[manifest]
+		if !ok {
+			continue
+		}
+		locked := false
+		for _, lock := range locks {
+			locked = locked || evidence[lock]
+		}
+		if !locked {
+			diagnostics = append(diagnostics, diagnostic(RuleMissingLockFile, manifest,
+				fmt.Sprintf("%s has no lock file; commit one of %s so dependency versions are pinned", manifest, strings.Join(locks, ", "))))
+		}
+	}
+	return diagnostics
+}
+
+// checkRuntimeVersion reports runtime versions that no image was found for
+func checkRuntimeVersion(plan *types.ExecutionPlan) []types.Diagnostic {
+	if plan.Runtime.Version == "" || plan.Runtime.Image != "" {
+		return nil
+	}
+	file := manifest(plan)
+	for _, candidate := range versionFiles {
+		if hasEvidence(plan, candidate) {
+			file = candidate
+			break
+		}
+	}
+	return []types.Diagnostic{diagnostic(RuleUnresolvedRuntimeVersion, file,
+		fmt.Sprintf("%s version %s has no base image; pin a supported version or set one with --base", plan.Provider, plan.Runtime.Version))}
+}
+
+// checkRunCommand reports plans without run commands
+func checkRunCommand(plan *types.ExecutionPlan) []types.Diagnostic {
+	if len(plan.Commands.Run) > 0 {
+		return nil
+	}
+	return []types.Diagnostic{diagnostic(RuleEmptyRunCommand, commandsFile(plan, "run"),
+		"The plan has no run command; add a start script or set commands.run in devbox-pack.toml")}
+}
+
+// checkRiskyFlags reports commands using risky flags, once per command and flag
+func checkRiskyFlags(plan *types.ExecutionPlan) []types.Diagnostic {
+	phases := []struct {
+		name     string
+		commands []string
+	}{
+		{"setup", plan.Commands.Setup},
+		{"dev", plan.Commands.Dev},
+		{"build", plan.Commands.Build},
+		{"run", plan.Commands.Run},
+	}
+
+	var diagnostics []types.Diagnostic
+	for _, phase := range phases {
+		for _, command := range phase.commands {
+			for _, risky := range riskyFlags {
+				if risky.pattern.MatchString(command) {
+					diagnostics = append(diagnostics, diagnostic(RuleRiskyFlag, commandsFile(plan, phase.name),
+						fmt.Sprintf("The %s command `%s` uses %s, which %s", phase.name, command, risky.flag, risky.reason)))
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+// diagnostic creates a finding of rule
+func diagnostic(rule, file, message string) types.Diagnostic {
+	severity := types.SeverityWarning
+	for _, candidate := range Rules {
+		if candidate.ID == rule {
+			severity = candidate.Severity
+		}
+	}
+	return types.Diagnostic{Severity: severity, Source: Source, Message: message, Rule: rule, File: file}
+}
+
+// commandsFile returns the file a command phase came from: the last file overriding it,
+// or the manifest it was detected from
+func commandsFile(plan *types.ExecutionPlan, phase string) string {
+	file := manifest(plan)
+	for _, override := range plan.Evidence.Overrides {
+		for _, field := range override.Fields {
+			if field == "commands."+phase {
+				file = override.File
+			}
+		}
+	}
+	return file
+}
+
+// manifest returns the first evidence file, the one detection found the project by
+func manifest(plan *types.ExecutionPlan) string {
+	if len(plan.Evidence.Files) == 0 {
+		return ""
+	}
+	return plan.Evidence.Files[0]
+}
+
+// hasEvidence reports whether file is among the plan's evidence files
+func hasEvidence(plan *types.ExecutionPlan, file string) bool {
+	for _, candidate := range plan.Evidence.Files {
+		if candidate == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     *types.ExecutionPlan
+		expected map[string]string // rule -> file
+	}{
+		{
+			name: "clean plan",
+			plan: &types.ExecutionPlan{
+				Provider: "node",
+				Runtime:  types.RuntimeConfig{Image: "node:20-alpine", Version: "20"},
+				Commands: types.Commands{Setup: []string{"npm ci"}, Run: []string{"npm start"}},
+				Evidence: types.Evidence{Files: []string{"package.json", "package-lock.json"}},
+			},
+			expected: map[string]string{},
+		},
+		{
+			name: "missing lock file and run command",
+			plan: &types.ExecutionPlan{
+				Provider: "ruby",
+				Runtime:  types.RuntimeConfig{Image: "ruby:3.2"},
+				Evidence: types.Evidence{Files: []string{"Gemfile", "config.ru"}},
+			},
+			expected: map[string]string{RuleMissingLockFile: "Gemfile", RuleEmptyRunCommand: "Gemfile"},
+		},
+		{
+			name: "unresolved version",
+			plan: &types.ExecutionPlan{
+				Provider: "python",
+				Runtime:  types.RuntimeConfig{Version: "2.7"},
+				Commands: types.Commands{Run: []string{"python app.py"}},
+				Evidence: types.Evidence{Files: []string{"requirements.txt", ".python-version"}},
+			},
+			expected: map[string]string{RuleUnresolvedRuntimeVersion: ".python-version"},
+		},
+		{
+			name: "risky flag in overridden command",
+			plan: &types.ExecutionPlan{
+				Provider: "deno",
+				Runtime:  types.RuntimeConfig{Image: "denoland/deno:alpine"},
+				Commands: types.Commands{
+					Setup: []string{"git add -A"},
+					Run:   []string{"deno run -A main.ts"},
+				},
+				Evidence: types.Evidence{
+					Files:     []string{"deno.json"},
+					Overrides: []types.OverrideEvidence{{File: "devbox-pack.toml", Fields: []string{"commands.run"}}},
+				},
+			},
+			expected: map[string]string{RuleRiskyFlag: "devbox-pack.toml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := Check(tt.plan)
+			if len(diagnostics) != len(tt.expected) {
+				t.Fatalf("expected %d diagnostics, got %+v", len(tt.expected), diagnostics)
+			}
+			for _, diagnostic := range diagnostics {
+				file, ok := tt.expected[diagnostic.Rule]
+				if !ok {
+					t.Errorf("unexpected diagnostic %+v", diagnostic)
+					continue
+				}
+				if diagnostic.File != file {
+					t.Errorf("%s: expected file %s, got %s", diagnostic.Rule, file, diagnostic.File)
+				}
+				if diagnostic.Source != Source || diagnostic.Severity != types.SeverityWarning {
+					t.Errorf("%s: unexpected source or severity: %+v", diagnostic.Rule, diagnostic)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_RiskyFlags(t *testing.T) {
+	risky := []string{
+		"deno run --allow-all main.ts",
+		"deno run --allow-net -A main.ts",
+		"npm install --unsafe-perm",
+		"curl --insecure https://example.com/tool.tgz -o tool.tgz",
+		"pip install --trusted-host pypi.example.com -r requirements.txt",
+		"chmod -R 777 storage",
+		"curl -fsSL https://example.com/install.sh | sudo bash",
+	}
+	safe := []string{
+		"deno run --allow-net main.ts",
+		"git add -A",
+		"chmod 755 bin/start",
+		"curl -fsSL https://example.com/install.sh -o install.sh",
+	}
+	for _, command := range risky {
+		plan := &types.ExecutionPlan{Commands: types.Commands{Build: []string{command}, Run: []string{"./app"}}}
+		if diagnostics := Check(plan); len(diagnostics) != 1 || diagnostics[0].Rule != RuleRiskyFlag {
+			t.Errorf("expected one risky flag for %q, got %+v", command, diagnostics)
+		}
+	}
+	for _, command := range safe {
+		plan := &types.ExecutionPlan{Commands: types.Commands{Build: []string{command}, Run: []string{"./app"}}}
+		if diagnostics := Check(plan); len(diagnostics) != 0 {
+			t.Errorf("expected no diagnostics for %q, got %+v", command, diagnostics)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package markup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// plainYAMLString matches strings YAML reads back unchanged without quotes
+	plainYAMLString = regexp.MustCompile(`^[A-Za-z_./][A-Za-z0-9_./:@-]*$`)
+	// bareTOMLKey matches keys TOML accepts without quotes
+	bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// node is a JSON value decoded with the order of object keys preserved
+type node struct {
+	// Object keys and values, in document order
+	keys   []string
+	fields []*node
+	// Array items
+	items []*node
+	// Scalar: string, json.Number, bool or nil
+	scalar interface{}
+	kind   nodeKind
+}
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	objectNode
+	arrayNode
+)
+
+// EncodeYAML encodes a value as a YAML document through its JSON encoding, so
+// json tags apply and keys keep the JSON order: struct fields in declaration
+// order and map keys sorted.
+func EncodeYAML(value interface{}) (string, error) {
+	root, err := decodeOrdered(value)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	switch root.kind {
+	case objectNode:
+		if len(root.keys) == 0 {
+			return "{}\n", nil
+		}
+		lines = yamlObjectLines(root, "")
+	case arrayNode:
+		if len(root.items) == 0 {
+			return "[]\n", nil
+		}
+		lines = yamlArrayLines(root, "")
+	default:
+		lines = []string{yamlScalar(root.scalar)}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// EncodeTOML encodes a value whose JSON encoding is an object as a TOML document.
+// Keys keep the JSON order within each table, with plain values written before
+// sub-tables as TOML requires. Null values are left out, TOML has no null.
+func EncodeTOML(value interface{}) (string, error) {
+	root, err := decodeOrdered(value)
+	if err != nil {
+		return "", err
+	}
+	if root.kind != objectNode {
+		return "", fmt.Errorf("TOML documents must be tables, got %T", value)
+	}
+
+	var lines []string
+	if err := tomlTableLines(root, nil, &lines); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// YAMLString renders a string as a YAML scalar, plain when YAML reads it back as
+// the same string and double-quoted otherwise
+func YAMLString(value string) string {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "y", "n", "on", "off", "null", "~", ".inf", ".nan":
+		return quoteString(value)
+	}
+	if plainYAMLString.MatchString(value) {
+		return value
+	}
+	return quoteString(value)
+}
+
+// decodeOrdered encodes value as JSON and decodes it into an ordered node tree
+func decodeOrdered(value interface{}) (*node, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeNode(decoder)
+}
+
+// decodeNode decodes the next JSON value from decoder
+func decodeNode(decoder *json.Decoder) (*node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := &node{kind: objectNode}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			field, err := decodeNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.keys = append(object.keys, keyToken.(string))
+			object.fields = append(object.fields, field)
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := &node{kind: arrayNode}
+		for decoder.More() {
+			item, err := decodeNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, item)
+		}
+		_, err = decoder.Token()
+		return array, err
+	default:
+		if _, ok := token.(json.Delim); ok {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return &node{kind: scalarNode, scalar: token}, nil
+	}
+}
+
+// yamlObjectLines renders the fields of an object at indent
+func yamlObjectLines(object *node, indent string) []string {
+	var lines []string
+	for i, key := range object.keys {
+		prefix := indent + YAMLString(key) + ":"
+		lines = append(lines, yamlValueLines(prefix, object.fields[i], indent)...)
+	}
+	return lines
+}
+
+// yamlArrayLines renders the items of an array at indent
+func yamlArrayLines(array *node, indent string) []string {
+	var lines []string
+	for _, item := range array.items {
+		switch {
+		case item.kind == objectNode && len(item.keys) > 0:
+			// The first field shares the line of the dash, the others align with it
+			fieldLines := yamlObjectLines(item, indent+"  ")
+			fieldLines[0] = indent + "- " + strings.TrimPrefix(fieldLines[0], indent+"  ")
+			lines = append(lines, fieldLines...)
+		case item.kind == arrayNode && len(item.items) > 0:
+			lines = append(lines, indent+"-")
+			lines = append(lines, yamlArrayLines(item, indent+"  ")...)
+		default:
+			lines = append(lines, indent+"- "+yamlInline(item))
+		}
+	}
+	return lines
+}
+
+// yamlValueLines renders "prefix value", nesting collections below prefix
+func yamlValueLines(prefix string, value *node, indent string) []string {
+	switch {
+	case value.kind == objectNode && len(value.keys) > 0:
+		return append([]string{prefix}, yamlObjectLines(value, indent+"  ")...)
+	case value.kind == arrayNode && len(value.items) > 0:
+		return append([]string{prefix}, yamlArrayLines(value, indent+"  ")...)
+	default:
+		return []string{prefix + " " + yamlInline(value)}
+	}
+}
+
+// yamlInline renders scalars and empty collections on one line
+func yamlInline(value *node) string {
+	switch value.kind {
+	case objectNode:
+		return "{}"
+	case arrayNode:
+		return "[]"
+	default:
+		return yamlScalar(value.scalar)
+	}
+}
+
+// yamlScalar renders a decoded JSON scalar
+func yamlScalar(scalar interface{}) string {
+	switch value := scalar.(type) {
+	case nil:
+		return "null"
+	case string:
+		return YAMLString(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// tomlTableLines renders the table at path: plain values first, then sub-tables
+// and arrays of tables
+func tomlTableLines(table *node, path []string, lines *[]string) error {
+	var tables, tableArrays []int
+	for i, key := range table.keys {
+		value := table.fields[i]
+		switch {
+		case value.kind == objectNode:
+			tables = append(tables, i)
+		case value.kind == arrayNode && isTableArray(value):
+			tableArrays = append(tableArrays, i)
+		case value.kind == scalarNode && value.scalar == nil:
+			continue
+		default:
+			inline, err := tomlInline(value)
+			if err != nil {
+				return err
+			}
+			*lines = append(*lines, tomlKey(key)+" = "+inline)
+		}
+	}
+
+	for _, i := range tables {
+		childPath := append(append([]string(nil), path...), table.keys[i])
+		if len(*lines) > 0 {
+			*lines = append(*lines, "")
+		}
+		*lines = append(*lines, "["+tomlKeyPath(childPath)+"]")
+		if err := tomlTableLines(table.fields[i], childPath, lines); err != nil {
+			return err
+		}
+	}
+	for _, i := range tableArrays {
+		childPath := append(append([]string(nil), path...), table.keys[i])
+		for _, item := range table.fields[i].items {
+			if len(*lines) > 0 {
+				*lines = append(*lines, "")
+			}
+			*lines = append(*lines, "[["+tomlKeyPath(childPath)+"]]")
+			if err := tomlTableLines(item, childPath, lines); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isTableArray reports whether a non-empty array holds only objects
+func isTableArray(array *node) bool {
+	if len(array.items) == 0 {
+		return false
+	}
+	for _, item := range array.items {
+		if item.kind != objectNode {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlInline renders a value on one line, using inline tables inside arrays
+func tomlInline(value *node) (string, error) {
+	switch value.kind {
+	case objectNode:
+		parts := make([]string, 0, len(value.keys))
+		for i, key := range value.keys {
+			if value.fields[i].kind == scalarNode && value.fields[i].scalar == nil {
+				continue
+			}
+			inline, err := tomlInline(value.fields[i])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlKey(key)+" = "+inline)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case arrayNode:
+		parts := make([]string, 0, len(value.items))
+		for _, item := range value.items {
+			inline, err := tomlInline(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, inline)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+
+	switch scalar := value.scalar.(type) {
+	case nil:
+		return "", fmt.Errorf("TOML cannot represent null array items")
+	case string:
+		return quoteString(scalar), nil
+	default:
+		return fmt.Sprint(scalar), nil
+	}
+}
+
+// tomlKey renders a key, quoting it unless it is bare
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return quoteString(key)
+}
+
+// tomlKeyPath renders the dotted key path of a table header
+func tomlKeyPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// quoteString renders a double-quoted string; JSON string escapes are valid in
+// YAML double-quoted scalars and TOML basic strings
+func quoteString(value string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
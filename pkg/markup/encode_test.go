@@ -0,0 +1,148 @@
+package markup
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sample exercises nested tables, arrays of tables, quoting and omitted nulls
+type sample struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Port        int               `json:"port"`
+	Ratio       float64           `json:"ratio"`
+	Enabled     bool              `json:"enabled"`
+	Missing     *string           `json:"missing"`
+	Command     string            `json:"command"`
+	Environment map[string]string `json:"environment"`
+	Tags        []string          `json:"tags"`
+	Empty       []string          `json:"empty"`
+	Matrix      [][]int           `json:"matrix"`
+	Services    []sampleService   `json:"services"`
+}
+
+type sampleService struct {
+	Name  string            `json:"name"`
+	Ports []int             `json:"ports,omitempty"`
+	Env   map[string]string `json:"env,omitempty"`
+}
+
+func newSample() sample {
+	return sample{
+		Name:        "acme",
+		Version:     "1.20",
+		Port:        8080,
+		Ratio:       0.5,
+		Enabled:     true,
+		Command:     "npm start && echo \"done\" # not a comment",
+		Environment: map[string]string{"Z_LAST": "yes", "A_FIRST": "on", "dotted.key": "x: y"},
+		Tags:        []string{"web", "-flag", "null"},
+		Empty:       []string{},
+		Matrix:      [][]int{{1, 2}, {3}},
+		Services: []sampleService{
+			{Name: "db", Ports: []int{5432}, Env: map[string]string{"USER": "app"}},
+			{Name: "cache"},
+		},
+	}
+}
+
+// normalize converts a value into the generic form the parsers return
+func normalize(t *testing.T, value interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return document
+}
+
+func TestEncodeYAML_RoundTrip(t *testing.T) {
+	output, err := EncodeYAML(newSample())
+	if err != nil {
+		t.Fatalf("EncodeYAML failed: %v", err)
+	}
+
+	parsed, err := ParseYAML(output)
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v\n%s", err, output)
+	}
+	if !reflect.DeepEqual(normalize(t, parsed), normalize(t, newSample())) {
+		t.Errorf("round trip changed the document:\n%s", output)
+	}
+
+	// Keys follow the JSON order: struct fields as declared, map keys sorted
+	if !strings.HasPrefix(output, "name: acme\nversion: \"1.20\"\nport: 8080\n") {
+		t.Errorf("unexpected field order:\n%s", output)
+	}
+	if strings.Index(output, "A_FIRST") > strings.Index(output, "Z_LAST") {
+		t.Errorf("expected sorted map keys:\n%s", output)
+	}
+}
+
+func TestEncodeTOML_RoundTrip(t *testing.T) {
+	output, err := EncodeTOML(newSample())
+	if err != nil {
+		t.Fatalf("EncodeTOML failed: %v", err)
+	}
+
+	parsed, err := ParseTOML(output)
+	if err != nil {
+		t.Fatalf("ParseTOML failed: %v\n%s", err, output)
+	}
+	expected := normalize(t, newSample())
+	delete(expected, "missing") // TOML has no null
+	if !reflect.DeepEqual(normalize(t, parsed), expected) {
+		t.Errorf("round trip changed the document:\n%s", output)
+	}
+
+	// Plain values come before tables, which come before arrays of tables
+	environment := strings.Index(output, "[environment]")
+	services := strings.Index(output, "[[services]]")
+	if strings.Index(output, "matrix = ") > environment || environment > services {
+		t.Errorf("unexpected table order:\n%s", output)
+	}
+}
+
+func TestEncode_Deterministic(t *testing.T) {
+	first, _ := EncodeYAML(newSample())
+	firstTOML, _ := EncodeTOML(newSample())
+	for i := 0; i < 10; i++ {
+		yaml, _ := EncodeYAML(newSample())
+		toml, _ := EncodeTOML(newSample())
+		if yaml != first || toml != firstTOML {
+			t.Fatal("expected identical output for identical input")
+		}
+	}
+}
+
+func TestEncodeTOML_RequiresTable(t *testing.T) {
+	if _, err := EncodeTOML([]string{"a"}); err == nil {
+		t.Error("expected error for a non-table document")
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := map[string]string{
+		"node:20-alpine": "node:20-alpine",
+		"/healthz":       "/healthz",
+		"20":             `"20"`,
+		"true":           `"true"`,
+		"y":              `"y"`,
+		".inf":           `".inf"`,
+		"a b":            `"a b"`,
+		"-c":             `"-c"`,
+		"":               `""`,
+		"a && b":         `"a && b"`,
+	}
+	for value, expected := range tests {
+		if got := YAMLString(value); got != expected {
+			t.Errorf("YAMLString(%q): expected %s, got %s", value, expected, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package markup
+
+// StripJSONC converts JSON with comments, as used by devcontainer.json, tsconfig.json
+// and deno.jsonc, into standard JSON by removing // and /* */ comments and trailing
+// commas before closing brackets. Strings are kept as they are.
+func StripJSONC(content []byte) []byte {
+	out := make([]byte, 0, len(content))
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '"':
+			// Copy the string, including escaped quotes
+			start := i
+			for i++; i < len(content) && content[i] != '"'; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(content) {
+				return append(out, content[start:]...)
+			}
+			out = append(out, content[start:i+1]...)
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			if i < len(content) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			i += 2
+			for i+1 < len(content) && !(content[i] == '*' && content[i+1] == '/') {
+				i++
+			}
+			i++
+			// Keep tokens on either side of the comment apart
+			out = append(out, ' ')
+		case c == ']' || c == '}':
+			out = trimTrailingComma(out)
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// trimTrailingComma removes a comma, and the whitespace after it, ending out
+func trimTrailingComma(out []byte) []byte {
+	end := len(out)
+	for end > 0 && isJSONSpace(out[end-1]) {
+		end--
+	}
+	if end > 0 && out[end-1] == ',' {
+		return append(out[:end-1], out[end:]...)
+	}
+	return out
+}
+
+// isJSONSpace reports whether c is JSON whitespace
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,31 @@
+package markup
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	content := `{
+	// Line comment
+	"image": "node:20", /* block
+	comment */ "url": "http://example.com/*not-a-comment*/",
+	"quote": "say \"hi\" // still text",
+	"ports": [3000, 9229,],
+}`
+
+	var value map[string]interface{}
+	if err := json.Unmarshal(StripJSONC([]byte(content)), &value); err != nil {
+		t.Fatalf("stripped content is not valid JSON: %v\n%s", err, StripJSONC([]byte(content)))
+	}
+	expected := map[string]interface{}{
+		"image": "node:20",
+		"url":   "http://example.com/*not-a-comment*/",
+		"quote": `say "hi" // still text`,
+		"ports": []interface{}{float64(3000), float64(9229)},
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("expected %v, got %v", expected, value)
+	}
+}
@@ -0,0 +1,529 @@
+// Package markup implements the small subsets of TOML and YAML that DevBox Pack
+// reads and writes, so the tool stays free of third-party dependencies.
+package markup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseTOML parses a TOML document into nested maps.
+// Supported: tables, arrays of tables, dotted keys, basic and literal strings
+// (including multi-line forms), integers, floats, booleans, arrays and inline tables.
+// Date/time values are returned as plain strings.
+func ParseTOML(content string) (map[string]interface{}, error) {
+	p := &tomlParser{src: content, line: 1}
+	root := make(map[string]interface{})
+	current := root
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, nil
+		}
+
+		switch {
+		case p.hasPrefix("[["):
+			p.pos += 2
+			keys, err := p.parseKeyPath("]]")
+			if err != nil {
+				return nil, err
+			}
+			table, err := appendTableArray(root, keys)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			current = table
+		case p.peek() == '[':
+			p.pos++
+			keys, err := p.parseKeyPath("]")
+			if err != nil {
+				return nil, err
+			}
+			table, err := descend(root, keys)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			current = table
+		default:
+			if err := p.parseKeyValue(current); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// tomlParser is a hand-written recursive descent parser over the raw document
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.src[p.pos:], prefix)
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipSpaces skips spaces and tabs on the current line
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment skips a comment up to (not including) the end of line
+func (p *tomlParser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine requires that only whitespace or a comment follows on the current line
+func (p *tomlParser) endOfLine() error {
+	p.skipSpaces()
+	p.skipComment()
+	if p.peek() == '\r' {
+		p.pos++
+	}
+	if p.eof() {
+		return nil
+	}
+	if p.peek() != '\n' {
+		return p.errorf("unexpected character %q", p.peek())
+	}
+	return nil
+}
+
+// parseKeyPath parses a dotted key terminated by the given closing sequence
+func (p *tomlParser) parseKeyPath(closing string) ([]string, error) {
+	keys, err := p.parseKey()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.hasPrefix(closing) {
+		return nil, p.errorf("expected %q after table name", closing)
+	}
+	p.pos += len(closing)
+	return keys, nil
+}
+
+// parseKey parses a (possibly dotted, possibly quoted) key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		var key string
+		switch p.peek() {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected key")
+			}
+			key = p.src[start:p.pos]
+		}
+		keys = append(keys, key)
+
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c == '_' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// parseKeyValue parses `key = value` into the given table
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after key %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpaces()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return p.errorf("duplicate key %q", strings.Join(keys, "."))
+	}
+	parent[last] = value
+	return nil
+}
+
+// parseValue parses any TOML value
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		return p.parseMultilineBasicString()
+	case p.hasPrefix(`'''`):
+		return p.parseMultilineLiteralString()
+	case c == '"':
+		return p.parseBasicString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	default:
+		return p.parseScalar()
+	}
+}
+
+// parseScalar parses numbers and date/time literals
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == ',' || c == ']' || c == '}' || c == '\n' || c == '\r' || c == '#' {
+			break
+		}
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.src[start:p.pos])
+	if raw == "" {
+		return nil, p.errorf("expected value")
+	}
+
+	cleaned := strings.ReplaceAll(raw, "_", "")
+	if i, err := strconv.ParseInt(cleaned, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(cleaned, 64); err == nil {
+		return f, nil
+	}
+	if len(raw) >= 10 && raw[4] == '-' && raw[7] == '-' {
+		// Date or date-time, kept verbatim
+		return raw, nil
+	}
+	return nil, p.errorf("invalid value %q", raw)
+}
+
+// parseBasicString parses a double-quoted string with escapes
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		if c == '"' {
+			p.pos++
+			return b.String(), nil
+		}
+		if c == '\\' {
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+}
+
+// parseMultilineBasicString parses a """ delimited string
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.trimLeadingNewline()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix(`"""`) {
+			p.pos += 3
+			return b.String(), nil
+		}
+		c := p.peek()
+		if c == '\\' {
+			// Line ending backslash trims the newline and following whitespace
+			rest := strings.TrimLeft(p.src[p.pos+1:], " \t\r")
+			if strings.HasPrefix(rest, "\n") {
+				p.pos++
+				for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+					if p.peek() == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if c == '\n' {
+			p.line++
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+}
+
+// parseLiteralString parses a single-quoted string
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		if p.peek() == '\'' {
+			s := p.src[start:p.pos]
+			p.pos++
+			return s, nil
+		}
+		p.pos++
+	}
+}
+
+// parseMultilineLiteralString parses a string delimited by three single quotes
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.trimLeadingNewline()
+	end := strings.Index(p.src[p.pos:], `'''`)
+	if end < 0 {
+		return "", p.errorf("unterminated multi-line string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(s, "\n")
+	p.pos += end + 3
+	return s, nil
+}
+
+// trimLeadingNewline drops a newline immediately following an opening delimiter
+func (p *tomlParser) trimLeadingNewline() {
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		p.line++
+	} else if p.peek() == '\n' {
+		p.pos++
+		p.line++
+	}
+}
+
+// parseEscape decodes a backslash escape sequence
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	p.pos++ // backslash
+	if p.eof() {
+		return p.errorf("unterminated escape sequence")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// parseArray parses an array which may span multiple lines
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	values := make([]interface{}, 0)
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return values, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseInlineTable parses `{ key = value, ... }`
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++ // {
+	table := make(map[string]interface{})
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+			p.skipSpaces()
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// descend walks (and creates) nested tables along keys
+func descend(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	current := table
+	for _, key := range keys {
+		switch next := current[key].(type) {
+		case nil:
+			created := make(map[string]interface{})
+			current[key] = created
+			current = created
+		case map[string]interface{}:
+			current = next
+		case []interface{}:
+			// Dotted access into an array of tables refers to its last element
+			if len(next) == 0 {
+				return nil, fmt.Errorf("key %q is an empty array", key)
+			}
+			last, ok := next[len(next)-1].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key %q is not a table", key)
+			}
+			current = last
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return current, nil
+}
+
+// appendTableArray appends a new table to the array of tables at keys
+func appendTableArray(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	parent, err := descend(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	table := make(map[string]interface{})
+	switch existing := parent[last].(type) {
+	case nil:
+		parent[last] = []interface{}{table}
+	case []interface{}:
+		parent[last] = append(existing, table)
+	default:
+		return nil, fmt.Errorf("key %q is not an array of tables", last)
+	}
+	return table, nil
+}
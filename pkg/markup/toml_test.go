@@ -0,0 +1,114 @@
+package markup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML_Basic(t *testing.T) {
+	content := `
+# Top level keys
+provider = "node"
+port = 8_080
+debug = true
+ratio = 0.5
+image = 'node:20-alpine' # literal string
+
+[commands]
+build = ["npm run build", "npm run lint"]
+run = [
+  "npm start",
+]
+
+[environment]
+NODE_ENV = "production"
+"QUOTED.KEY" = "a\tb"
+
+[runtime.extra]
+inline = { a = 1, b = "two" }
+`
+	result, err := ParseTOML(content)
+	if err != nil {
+		t.Fatalf("ParseTOML failed: %v", err)
+	}
+
+	if result["provider"] != "node" {
+		t.Errorf("expected provider 'node', got %v", result["provider"])
+	}
+	if result["port"] != int64(8080) {
+		t.Errorf("expected port 8080, got %v", result["port"])
+	}
+	if result["debug"] != true {
+		t.Errorf("expected debug true, got %v", result["debug"])
+	}
+	if result["ratio"] != 0.5 {
+		t.Errorf("expected ratio 0.5, got %v", result["ratio"])
+	}
+	if result["image"] != "node:20-alpine" {
+		t.Errorf("expected image 'node:20-alpine', got %v", result["image"])
+	}
+
+	commands := result["commands"].(map[string]interface{})
+	expectedBuild := []interface{}{"npm run build", "npm run lint"}
+	if !reflect.DeepEqual(commands["build"], expectedBuild) {
+		t.Errorf("expected build %v, got %v", expectedBuild, commands["build"])
+	}
+	if !reflect.DeepEqual(commands["run"], []interface{}{"npm start"}) {
+		t.Errorf("unexpected run commands: %v", commands["run"])
+	}
+
+	env := result["environment"].(map[string]interface{})
+	if env["QUOTED.KEY"] != "a\tb" {
+		t.Errorf("expected escaped value, got %q", env["QUOTED.KEY"])
+	}
+
+	runtime := result["runtime"].(map[string]interface{})
+	inline := runtime["extra"].(map[string]interface{})["inline"].(map[string]interface{})
+	if inline["a"] != int64(1) || inline["b"] != "two" {
+		t.Errorf("unexpected inline table: %v", inline)
+	}
+}
+
+func TestParseTOML_ArrayOfTables(t *testing.T) {
+	content := `
+[[build.env]]
+name = "A"
+value = "1"
+
+[[build.env]]
+name = "B"
+value = """
+multi
+line"""
+`
+	result, err := ParseTOML(content)
+	if err != nil {
+		t.Fatalf("ParseTOML failed: %v", err)
+	}
+
+	envs := result["build"].(map[string]interface{})["env"].([]interface{})
+	if len(envs) != 2 {
+		t.Fatalf("expected 2 env tables, got %d", len(envs))
+	}
+	second := envs[1].(map[string]interface{})
+	if second["value"] != "multi\nline" {
+		t.Errorf("expected multi-line value, got %q", second["value"])
+	}
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	testCases := []string{
+		`key = `,
+		`key = "unterminated`,
+		`key = 1
+key = 2`,
+		`[table`,
+		`key = [1, 2`,
+	}
+
+	for _, content := range testCases {
+		if _, err := ParseTOML(content); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
@@ -0,0 +1,610 @@
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParseYAML parses a YAML document whose root is a mapping into nested maps.
+// Supported: block mappings and sequences, single-line flow sequences and
+// mappings, plain, single- and double-quoted scalars, literal (|) and folded (>)
+// block scalars and comments. Anchors, aliases, tags and multiple documents are
+// not supported. Integers are returned as int64 and floats as float64.
+func ParseYAML(content string) (map[string]interface{}, error) {
+	p := newYAMLParser(content)
+
+	p.skipEmpty()
+	if !p.eof() && p.lines[p.pos].text == "---" {
+		p.pos++
+		p.skipEmpty()
+	}
+	if p.eof() {
+		return map[string]interface{}{}, nil
+	}
+
+	first := p.lines[p.pos]
+	value, err := p.parseBlock(first.indent)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, yamlErrorf(first.number, "document root must be a mapping")
+	}
+
+	p.skipEmpty()
+	if !p.eof() {
+		line := p.lines[p.pos]
+		if line.text == "---" {
+			return nil, yamlErrorf(line.number, "multiple documents are not supported")
+		}
+		if line.text != "..." {
+			return nil, yamlErrorf(line.number, "unexpected content %q", line.text)
+		}
+	}
+	return root, nil
+}
+
+// yamlLine is a physical line of the document
+type yamlLine struct {
+	number int
+	// Number of leading spaces
+	indent int
+	// Content without indentation, trailing comment and surrounding whitespace
+	text string
+	// Original line, used for block scalars
+	raw string
+	// Whether the indentation is followed by a tab
+	tabbed bool
+}
+
+// yamlParser is an indentation driven parser over the document lines
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func newYAMLParser(content string) *yamlParser {
+	rawLines := strings.Split(content, "\n")
+	lines := make([]yamlLine, 0, len(rawLines))
+	for i, raw := range rawLines {
+		raw = strings.TrimSuffix(raw, "\r")
+		trimmed := strings.TrimLeft(raw, " ")
+		text := strings.TrimSpace(stripYAMLComment(trimmed))
+		lines = append(lines, yamlLine{
+			number: i + 1,
+			indent: len(raw) - len(trimmed),
+			text:   text,
+			raw:    raw,
+			tabbed: text != "" && strings.HasPrefix(trimmed, "\t"),
+		})
+	}
+	return &yamlParser{lines: lines}
+}
+
+func yamlErrorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.lines)
+}
+
+// skipEmpty skips blank and comment-only lines
+func (p *yamlParser) skipEmpty() {
+	for !p.eof() && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+// parseBlock parses the block node starting at the current line
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	line := p.lines[p.pos]
+	if line.tabbed {
+		return nil, yamlErrorf(line.number, "tabs are not allowed for indentation")
+	}
+	if isYAMLSequenceItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(line.text); ok {
+		return p.parseMapping(indent)
+	}
+	p.pos++
+	return parseYAMLInline(line.text, line.number)
+}
+
+// parseMapping parses `key: value` entries at the given indentation
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	mapping := make(map[string]interface{})
+	for {
+		p.skipEmpty()
+		if p.eof() {
+			return mapping, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent < indent || line.text == "---" || line.text == "..." {
+			return mapping, nil
+		}
+		if line.indent > indent || line.tabbed {
+			return nil, yamlErrorf(line.number, "unexpected indentation")
+		}
+
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, yamlErrorf(line.number, "expected 'key: value', got %q", line.text)
+		}
+		if _, exists := mapping[key]; exists {
+			return nil, yamlErrorf(line.number, "duplicate key %q", key)
+		}
+
+		p.pos++
+		value, err := p.parseValue(rest, line, indent, true)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+}
+
+// parseSequence parses `- item` entries at the given indentation
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	items := make([]interface{}, 0)
+	for {
+		p.skipEmpty()
+		if p.eof() {
+			return items, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent < indent || !isYAMLSequenceItem(line.text) {
+			return items, nil
+		}
+		if line.indent > indent || line.tabbed {
+			return nil, yamlErrorf(line.number, "unexpected indentation")
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		var item interface{}
+		var err error
+		if _, _, isKey := splitYAMLKey(rest); isKey || isYAMLSequenceItem(rest) {
+			// A collection starting on the dash line: re-read the remainder at its own column
+			column := line.indent + len(line.text) - len(rest)
+			p.lines[p.pos] = yamlLine{number: line.number, indent: column, text: rest, raw: line.raw}
+			item, err = p.parseBlock(column)
+		} else {
+			p.pos++
+			item, err = p.parseValue(rest, line, indent, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// parseValue parses the value following a mapping key or sequence dash.
+// Sequences may share the indentation of their parent key but not of their parent dash.
+func (p *yamlParser) parseValue(rest string, line yamlLine, indent int, inMapping bool) (interface{}, error) {
+	if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+		return p.parseBlockScalar(rest, line, indent)
+	}
+	if rest != "" {
+		return parseYAMLInline(rest, line.number)
+	}
+
+	p.skipEmpty()
+	if p.eof() {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent {
+		return p.parseBlock(next.indent)
+	}
+	if inMapping && next.indent == indent && isYAMLSequenceItem(next.text) {
+		return p.parseSequence(indent)
+	}
+	return nil, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar
+func (p *yamlParser) parseBlockScalar(header string, line yamlLine, indent int) (string, error) {
+	folded := header[0] == '>'
+	chomping := header[1:]
+	if chomping != "" && chomping != "-" && chomping != "+" {
+		return "", yamlErrorf(line.number, "unsupported block scalar header %q", header)
+	}
+
+	var body []string
+	contentIndent := -1
+	for !p.eof() {
+		next := p.lines[p.pos]
+		if strings.TrimSpace(next.raw) == "" {
+			body = append(body, "")
+			p.pos++
+			continue
+		}
+		if contentIndent < 0 {
+			if next.indent <= indent {
+				break
+			}
+			contentIndent = next.indent
+		}
+		if next.indent < contentIndent {
+			break
+		}
+		body = append(body, next.raw[contentIndent:])
+		p.pos++
+	}
+
+	trailing := 0
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+		trailing++
+	}
+
+	var content string
+	if folded {
+		content = foldYAMLLines(body)
+	} else {
+		content = strings.Join(body, "\n")
+	}
+
+	switch {
+	case content == "" || chomping == "-":
+		return content, nil
+	case chomping == "+":
+		return content + "\n" + strings.Repeat("\n", trailing), nil
+	default:
+		return content + "\n", nil
+	}
+}
+
+// foldYAMLLines joins folded block scalar lines: line breaks between text lines
+// become spaces, empty lines become newlines and more-indented lines are kept
+func foldYAMLLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			previous := lines[i-1]
+			switch {
+			case line == "":
+				b.WriteByte('\n')
+				continue
+			case previous == "":
+			case strings.HasPrefix(line, " ") || strings.HasPrefix(previous, " "):
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// isYAMLSequenceItem checks if text starts a block sequence entry
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits `key: rest` and decodes quoted keys.
+// Flow collections and plain scalars are not keys.
+func splitYAMLKey(text string) (string, string, bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' || isYAMLSequenceItem(text) {
+		return "", "", false
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		f := &yamlFlow{src: text}
+		key, err := f.parseQuoted()
+		if err != nil || !strings.HasPrefix(f.src[f.pos:], ":") {
+			return "", "", false
+		}
+		after := f.src[f.pos+1:]
+		if after != "" && after[0] != ' ' && after[0] != '\t' {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(after), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != ':' {
+			continue
+		}
+		if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t' {
+			key := strings.TrimSpace(text[:i])
+			if key == "" {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a trailing `# comment` outside of quoted scalars
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote = 0
+			}
+		case quote == '\'':
+			if c == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", text[i-1]) >= 0):
+			quote = c
+		}
+	}
+	return text
+}
+
+// parseYAMLInline parses a single-line value: a flow collection or a scalar
+func parseYAMLInline(text string, line int) (interface{}, error) {
+	f := &yamlFlow{src: text, line: line}
+	value, err := f.parseValue(false)
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpaces()
+	if !f.eof() {
+		return nil, f.errorf("unexpected %q after value", f.src[f.pos:])
+	}
+	return value, nil
+}
+
+// yamlFlow parses flow collections and scalars within a single line
+type yamlFlow struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (f *yamlFlow) eof() bool {
+	return f.pos >= len(f.src)
+}
+
+func (f *yamlFlow) peek() byte {
+	if f.eof() {
+		return 0
+	}
+	return f.src[f.pos]
+}
+
+func (f *yamlFlow) errorf(format string, args ...interface{}) error {
+	return yamlErrorf(f.line, format, args...)
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for !f.eof() && (f.peek() == ' ' || f.peek() == '\t') {
+		f.pos++
+	}
+}
+
+// parseValue parses a flow collection, quoted scalar or plain scalar
+func (f *yamlFlow) parseValue(inFlow bool) (interface{}, error) {
+	f.skipSpaces()
+	switch f.peek() {
+	case '[':
+		return f.parseSequence()
+	case '{':
+		return f.parseMapping()
+	case '"', '\'':
+		return f.parseQuoted()
+	default:
+		return resolveYAMLScalar(f.parsePlain(inFlow, false)), nil
+	}
+}
+
+// parsePlain reads a plain scalar. Inside flow collections it ends at
+// flow indicators; keys additionally end at ':'.
+func (f *yamlFlow) parsePlain(inFlow, isKey bool) string {
+	start := f.pos
+	for !f.eof() {
+		c := f.peek()
+		if inFlow && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if isKey && c == ':' {
+			break
+		}
+		f.pos++
+	}
+	return strings.TrimSpace(f.src[start:f.pos])
+}
+
+// parseQuoted parses a single- or double-quoted scalar
+func (f *yamlFlow) parseQuoted() (string, error) {
+	quote := f.peek()
+	f.pos++
+	var b strings.Builder
+	for {
+		if f.eof() {
+			return "", f.errorf("unterminated quoted string")
+		}
+		c := f.peek()
+		f.pos++
+		switch {
+		case c == quote && quote == '\'' && f.peek() == '\'':
+			b.WriteByte('\'')
+			f.pos++
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && quote == '"':
+			if err := f.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// parseEscape decodes a double-quoted escape sequence after the backslash
+func (f *yamlFlow) parseEscape(b *strings.Builder) error {
+	if f.eof() {
+		return f.errorf("unterminated escape sequence")
+	}
+	c := f.peek()
+	f.pos++
+	switch c {
+	case '0':
+		b.WriteByte(0)
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 't', '\t':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'v':
+		b.WriteByte('\v')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case ' ', '"', '/', '\\':
+		b.WriteByte(c)
+	case 'x', 'u', 'U':
+		size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+		if f.pos+size > len(f.src) {
+			return f.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(f.src[f.pos:f.pos+size], 16, 32)
+		if err != nil {
+			return f.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(code))
+		f.pos += size
+	default:
+		return f.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// parseSequence parses `[a, b, c]`
+func (f *yamlFlow) parseSequence() ([]interface{}, error) {
+	f.pos++ // [
+	items := make([]interface{}, 0)
+	for {
+		f.skipSpaces()
+		if f.peek() == ']' {
+			f.pos++
+			return items, nil
+		}
+		item, err := f.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		f.skipSpaces()
+		switch f.peek() {
+		case ',':
+			f.pos++
+		case ']':
+			f.pos++
+			return items, nil
+		default:
+			return nil, f.errorf("expected ',' or ']' in flow sequence")
+		}
+	}
+}
+
+// parseMapping parses `{a: 1, b: 2}`
+func (f *yamlFlow) parseMapping() (map[string]interface{}, error) {
+	f.pos++ // {
+	mapping := make(map[string]interface{})
+	for {
+		f.skipSpaces()
+		if f.peek() == '}' {
+			f.pos++
+			return mapping, nil
+		}
+
+		var key string
+		if c := f.peek(); c == '"' || c == '\'' {
+			quoted, err := f.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted
+		} else {
+			key = f.parsePlain(true, true)
+		}
+		f.skipSpaces()
+		if f.peek() != ':' {
+			return nil, f.errorf("expected ':' after key %q in flow mapping", key)
+		}
+		f.pos++
+		if _, exists := mapping[key]; exists {
+			return nil, f.errorf("duplicate key %q", key)
+		}
+
+		value, err := f.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+
+		f.skipSpaces()
+		switch f.peek() {
+		case ',':
+			f.pos++
+		case '}':
+			f.pos++
+			return mapping, nil
+		default:
+			return nil, f.errorf("expected ',' or '}' in flow mapping")
+		}
+	}
+}
+
+var (
+	yamlIntPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// resolveYAMLScalar applies the YAML 1.2 core schema to a plain scalar
+func resolveYAMLScalar(raw string) interface{} {
+	switch raw {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if yamlIntPattern.MatchString(raw) {
+		if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return i
+		}
+	}
+	if strings.HasPrefix(raw, "0x") || strings.HasPrefix(raw, "0o") {
+		if i, err := strconv.ParseInt(raw, 0, 64); err == nil {
+			return i
+		}
+	}
+	if yamlFloatPattern.MatchString(raw) {
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	}
+	return raw
+}
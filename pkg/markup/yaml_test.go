@@ -0,0 +1,147 @@
+package markup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML_Basic(t *testing.T) {
+	content := `
+# Provider rule
+name: acme
+priority: 65
+ratio: 0.5
+enabled: true
+missing: ~
+version: "1.20"
+quoted: 'it''s'
+escaped: "a\tb"
+url: http://example.com/path # trailing comment
+hash: color#fff
+indicators:
+  - file: acme.toml
+    weight: 40
+  - dependency: acme-sdk
+    manifest: package.json
+    weight: 30
+commands:
+  run: [acme run, "acme serve --port 8080"]
+  build:
+  - acme build
+environment: {ACME_ENV: production, DEBUG: "false"}
+nested:
+  - - a
+    - b
+  - c
+`
+	result, err := ParseYAML(content)
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":     "acme",
+		"priority": int64(65),
+		"ratio":    0.5,
+		"enabled":  true,
+		"missing":  nil,
+		"version":  "1.20",
+		"quoted":   "it's",
+		"escaped":  "a\tb",
+		"url":      "http://example.com/path",
+		"hash":     "color#fff",
+		"indicators": []interface{}{
+			map[string]interface{}{"file": "acme.toml", "weight": int64(40)},
+			map[string]interface{}{"dependency": "acme-sdk", "manifest": "package.json", "weight": int64(30)},
+		},
+		"commands": map[string]interface{}{
+			"run":   []interface{}{"acme run", "acme serve --port 8080"},
+			"build": []interface{}{"acme build"},
+		},
+		"environment": map[string]interface{}{"ACME_ENV": "production", "DEBUG": "false"},
+		"nested": []interface{}{
+			[]interface{}{"a", "b"},
+			"c",
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("unexpected result:\n got: %#v\nwant: %#v", result, expected)
+	}
+}
+
+func TestParseYAML_BlockScalars(t *testing.T) {
+	content := `---
+literal: |
+  line one
+    indented
+
+  line three
+folded: >
+  folded
+  text
+
+  next paragraph
+stripped: |-
+  no newline
+kept: |+
+  keep
+
+steps:
+  - |
+    echo one
+    echo two
+after: done
+`
+	result, err := ParseYAML(content)
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v", err)
+	}
+
+	tests := map[string]interface{}{
+		"literal":  "line one\n  indented\n\nline three\n",
+		"folded":   "folded text\nnext paragraph\n",
+		"stripped": "no newline",
+		"kept":     "keep\n\n",
+		"steps":    []interface{}{"echo one\necho two\n"},
+		"after":    "done",
+	}
+	for key, want := range tests {
+		if !reflect.DeepEqual(result[key], want) {
+			t.Errorf("%s: expected %q, got %q", key, want, result[key])
+		}
+	}
+}
+
+func TestParseYAML_Empty(t *testing.T) {
+	result, err := ParseYAML("# only a comment\n")
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("expected empty mapping, got %v", result)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"root sequence", "- a\n- b\n"},
+		{"duplicate key", "a: 1\na: 2\n"},
+		{"bad indentation", "a: 1\n  b: 2\n"},
+		{"tab indentation", "a:\n\tb: 1\n"},
+		{"unterminated string", "a: \"open\n"},
+		{"unterminated flow", "a: [1, 2\n"},
+		{"multiple documents", "a: 1\n---\nb: 2\n"},
+		{"not a mapping entry", "a: 1\njust text\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseYAML(tt.content); err == nil {
+				t.Error("expected error but got none")
+			}
+		})
+	}
+}
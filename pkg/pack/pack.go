@@ -0,0 +1,250 @@
+// Package pack is the embeddable Go API of DevBox Pack.
+//
+// Analyze runs the full detection and planning pipeline and returns the plan,
+// every detection result and the diagnostics collected on the way. It never
+// writes to stdout or stderr; progress is reported through Options.Logger
+// or Options.Progress instead.
+package pack
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/labring/devbox-pack/pkg/detector"
+	"github.com/labring/devbox-pack/pkg/service"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Stage identifies a step of the analysis pipeline
+type Stage = service.Stage
+
+// Pipeline stages reported to Options.Progress
+const (
+	StagePrepare  = service.StagePrepare
+	StageDiscover = service.StageDiscover
+	StageScan     = service.StageScan
+	StageDetect   = service.StageDetect
+	StagePlan     = service.StagePlan
+	StageDone     = service.StageDone
+)
+
+// Logger receives progress and diagnostic messages during analysis
+type Logger = service.Logger
+
+// ProviderRun records the timing and outcome of a single provider
+type ProviderRun = detector.ProviderRun
+
+// ProviderInfo describes a provider available for detection
+type ProviderInfo = service.ProviderInfo
+
+// ProgressFunc is a lightweight alternative to Logger that only observes stages
+type ProgressFunc func(stage Stage, message string)
+
+// Source identifies the project to analyse
+type Source struct {
+	// Repository is a Git repository URL, local path or local .tar.gz/.zip archive
+	Repository string
+	// FS is an already opened project tree, see package source. It takes
+	// precedence over Repository, Ref and Subdir.
+	FS fs.FS
+	// Ref is the Git branch, tag or commit to analyse (optional)
+	Ref string
+	// Subdir is a subdirectory within the repository (optional)
+	Subdir string
+}
+
+// Options configures an analysis
+type Options struct {
+	// Provider forces a specific provider instead of auto-detection
+	Provider string
+	// Base overrides the runtime base image
+	Base string
+	// Platform is the target platform, e.g. linux/amd64
+	Platform string
+	// Monorepo analyses every service below the repository root
+	Monorepo bool
+	// PluginPaths are directories searched for devbox-pack-provider-* plugin
+	// executables, which are ranked alongside the built-in providers
+	PluginPaths []string
+	// RulesPaths are directories of declarative YAML or JSON provider rules
+	RulesPaths []string
+	// CacheDir keeps mirrors of remote repositories and plans between analyses, so
+	// later analyses only fetch what changed and reuse plans of unchanged sources;
+	// empty clones and analyses afresh every time
+	CacheDir string
+	// NoCache recomputes plans instead of reading them from CacheDir, replacing the cached plans
+	NoCache bool
+	// Explain records the scoring breakdown of every provider in Result.Explanation
+	Explain bool
+	// Logger receives progress and debug messages; nil discards them
+	Logger Logger
+	// Progress is called at every stage when Logger is nil
+	Progress ProgressFunc
+}
+
+// Result is the outcome of an analysis
+type Result struct {
+	// Execution plan, nil in monorepo mode
+	Plan *types.ExecutionPlan `json:"plan,omitempty"`
+	// Matched detection results
+	Detections []*types.DetectResult `json:"detections,omitempty"`
+	// Non-fatal problems raised during analysis
+	Diagnostics []types.Diagnostic `json:"diagnostics,omitempty"`
+	// Per-provider detection timings in provider priority order
+	Providers []ProviderRun `json:"providers,omitempty"`
+	// Scoring breakdown of every provider and why the plan's provider won,
+	// only set with Options.Explain
+	Explanation *types.Explanation `json:"explanation,omitempty"`
+	// Per-service results keyed by path, only set in monorepo mode
+	Services map[string]*Result `json:"services,omitempty"`
+	// Whether the result was loaded from the plan cache; its diagnostics are
+	// those of the original run and it carries no provider timings
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Analyze analyses source and generates its execution plan
+func Analyze(ctx context.Context, source Source, options Options) (*Result, error) {
+	if source.Repository == "" && source.FS == nil {
+		return nil, types.NewDevBoxPackError(
+			"please provide repository path or URL",
+			types.ErrorCodeInvalidInput,
+			nil,
+		)
+	}
+	if err := types.ContextError(ctx); err != nil {
+		return nil, err
+	}
+
+	devBoxPack, err := options.newDevBoxPack(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer devBoxPack.Cleanup()
+
+	cliOptions := options.cliOptions(source)
+
+	if options.Monorepo {
+		var analyses map[string]*service.Analysis
+		if source.FS != nil {
+			analyses, err = devBoxPack.AnalyzeMonorepoFS(ctx, source.FS, cliOptions)
+		} else {
+			analyses, err = devBoxPack.AnalyzeMonorepo(ctx, source.Repository, cliOptions)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result := &Result{Services: make(map[string]*Result, len(analyses))}
+		for path, analysis := range analyses {
+			result.Services[path] = newResult(analysis)
+		}
+		return result, nil
+	}
+
+	var analysis *service.Analysis
+	if source.FS != nil {
+		analysis, err = devBoxPack.AnalyzeFS(ctx, source.FS, cliOptions)
+	} else {
+		analysis, err = devBoxPack.Analyze(ctx, source.Repository, cliOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newResult(analysis), nil
+}
+
+// Providers lists every provider available for detection, including the
+// plugins and rules found through options, sorted by priority
+func Providers(ctx context.Context, options Options) ([]ProviderInfo, error) {
+	devBoxPack, err := options.newDevBoxPack(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer devBoxPack.Cleanup()
+	return devBoxPack.Providers(), nil
+}
+
+// newResult converts a service analysis into a public result
+func newResult(analysis *service.Analysis) *Result {
+	return &Result{
+		Plan:        analysis.Plan,
+		Detections:  analysis.Detections,
+		Diagnostics: analysis.Diagnostics,
+		Providers:   analysis.Providers,
+		Explanation: analysis.Explanation,
+		Cached:      analysis.Cached,
+	}
+}
+
+// newDevBoxPack creates the service and loads the configured rules and plugins
+func (o Options) newDevBoxPack(ctx context.Context) (*service.DevBoxPack, error) {
+	devBoxPack := service.NewDevBoxPackWithLogger(o.logger())
+	if o.CacheDir != "" {
+		devBoxPack.SetCacheDir(o.CacheDir)
+	}
+	if len(o.RulesPaths) > 0 {
+		if err := devBoxPack.LoadRules(o.RulesPaths); err != nil {
+			return nil, err
+		}
+	}
+	if len(o.PluginPaths) > 0 {
+		if err := devBoxPack.LoadPlugins(ctx, o.PluginPaths); err != nil {
+			return nil, err
+		}
+	}
+	return devBoxPack, nil
+}
+
+// logger resolves the logger to use for an analysis
+func (o Options) logger() Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	if o.Progress != nil {
+		return progressLogger(o.Progress)
+	}
+	return service.NopLogger{}
+}
+
+// cliOptions converts library options into the internal CLI options
+func (o Options) cliOptions(source Source) *types.CLIOptions {
+	cliOptions := &types.CLIOptions{
+		Repository:  source.Repository,
+		Format:      string(types.OutputFormatJSON),
+		Monorepo:    o.Monorepo,
+		Quiet:       true,
+		PluginPaths: o.PluginPaths,
+		RulesPaths:  o.RulesPaths,
+		Explain:     o.Explain,
+		NoCache:     o.NoCache,
+	}
+	if source.Ref != "" {
+		cliOptions.Ref = &source.Ref
+	}
+	if source.Subdir != "" {
+		cliOptions.Subdir = &source.Subdir
+	}
+	if o.Provider != "" {
+		cliOptions.Provider = &o.Provider
+	}
+	if o.Base != "" {
+		cliOptions.Base = &o.Base
+	}
+	if o.Platform != "" {
+		cliOptions.Platform = &o.Platform
+	}
+	return cliOptions
+}
+
+// progressLogger adapts a ProgressFunc to the Logger interface
+type progressLogger ProgressFunc
+
+// Progress forwards the stage to the callback
+func (p progressLogger) Progress(stage Stage, message string) {
+	p(stage, message)
+}
+
+// Debug discards the message
+func (p progressLogger) Debug(string) {}
+
+// Warning discards the message
+func (p progressLogger) Warning(string) {}
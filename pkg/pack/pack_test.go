@@ -0,0 +1,148 @@
+package pack
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+)
+
+// writeFiles writes files into dir, creating parent directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// captureOutput runs fn and returns everything written to stdout and stderr
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = writer, writer
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+	}()
+
+	fn()
+
+	writer.Close()
+	output, _ := io.ReadAll(reader)
+	return string(output)
+}
+
+func TestAnalyze_NodeProject(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"name": "lib-test", "scripts": {"start": "node index.js"}}`,
+		"index.js":     `console.log("hello");`,
+	})
+
+	var stages []Stage
+	var result *Result
+	var err error
+	output := captureOutput(t, func() {
+		result, err = Analyze(context.Background(), Source{Repository: dir}, Options{
+			Progress: func(stage Stage, _ string) {
+				stages = append(stages, stage)
+			},
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("expected no output on stdout/stderr, got %q", output)
+	}
+	if result.Plan == nil || result.Plan.Provider != "node" {
+		t.Fatalf("expected node plan, got %+v", result.Plan)
+	}
+	if len(result.Detections) == 0 {
+		t.Error("expected detection results")
+	}
+	if len(result.Providers) == 0 {
+		t.Error("expected per-provider timings")
+	}
+	if len(stages) == 0 || stages[len(stages)-1] != StageDone {
+		t.Errorf("expected progress to end with %s, got %v", StageDone, stages)
+	}
+}
+
+func TestAnalyze_Monorepo(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"web/package.json": `{"name": "web"}`,
+		"web/index.js":     `console.log("web");`,
+		"api/go.mod":       "module example.com/api\n\ngo 1.21\n",
+		"api/main.go":      "package main\n\nfunc main() {}\n",
+	})
+
+	var result *Result
+	var err error
+	output := captureOutput(t, func() {
+		result, err = Analyze(context.Background(), Source{Repository: dir}, Options{Monorepo: true})
+	})
+
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("expected no output on stdout/stderr, got %q", output)
+	}
+	if result.Plan != nil {
+		t.Error("expected no top-level plan in monorepo mode")
+	}
+	if len(result.Services) != 2 || result.Services["web"] == nil || result.Services["api"] == nil {
+		t.Errorf("expected web and api services, got %v", result.Services)
+	}
+}
+
+func TestAnalyze_FS(t *testing.T) {
+	fsys := source.NewMap(map[string]string{
+		"requirements.txt": "flask==2.3.0\n",
+		"app.py":           "from flask import Flask\n",
+	})
+
+	result, err := Analyze(context.Background(), Source{FS: fsys}, Options{})
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if result.Plan == nil || result.Plan.Provider != "python" {
+		t.Fatalf("expected python plan, got %+v", result.Plan)
+	}
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	if _, err := Analyze(context.Background(), Source{}, Options{}); err == nil {
+		t.Error("expected error for empty repository")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Analyze(ctx, Source{Repository: t.TempDir()}, Options{}); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+
+	output := captureOutput(t, func() {
+		if _, err := Analyze(context.Background(), Source{Repository: "/non/existent/path"}, Options{}); err == nil {
+			t.Error("expected error for non-existent path")
+		}
+	})
+	if output != "" {
+		t.Errorf("expected no output on stdout/stderr, got %q", output)
+	}
+}
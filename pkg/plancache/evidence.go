@@ -0,0 +1,134 @@
+package plancache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// Hashes of paths that hold no file contents
+const (
+	evidenceAbsent = "absent"
+	evidenceError  = "error"
+)
+
+// Recorder is a source that records every path read through it, so a result
+// computed from it can be checked against later versions of the tree
+type Recorder struct {
+	fsys  fs.FS
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// Record wraps fsys in a Recorder
+func Record(fsys fs.FS) *Recorder {
+	return &Recorder{fsys: fsys, paths: make(map[string]bool)}
+}
+
+// record notes that name was read
+func (r *Recorder) record(name string) {
+	r.mu.Lock()
+	r.paths[name] = true
+	r.mu.Unlock()
+}
+
+// Open implements fs.FS
+func (r *Recorder) Open(name string) (fs.File, error) {
+	r.record(name)
+	return r.fsys.Open(name)
+}
+
+// Stat implements fs.StatFS
+func (r *Recorder) Stat(name string) (fs.FileInfo, error) {
+	r.record(name)
+	return fs.Stat(r.fsys, name)
+}
+
+// ReadDir implements fs.ReadDirFS
+func (r *Recorder) ReadDir(name string) ([]fs.DirEntry, error) {
+	r.record(name)
+	return fs.ReadDir(r.fsys, name)
+}
+
+// ReadFile implements fs.ReadFileFS
+func (r *Recorder) ReadFile(name string) ([]byte, error) {
+	r.record(name)
+	return fs.ReadFile(r.fsys, name)
+}
+
+// Evidence hashes what is found at every recorded path: the contents of files,
+// the entry names of directories and the absence of anything else
+func (r *Recorder) Evidence() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	evidence := make(map[string]string, len(r.paths))
+	for name := range r.paths {
+		evidence[name] = hashPath(r.fsys, name)
+	}
+	return evidence
+}
+
+// Verify reports whether every path of evidence still hashes the same in fsys
+func Verify(fsys fs.FS, evidence map[string]string) bool {
+	for name, expected := range evidence {
+		if expected == evidenceError || hashPath(fsys, name) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// hashPath hashes what is found at name in fsys
+func hashPath(fsys fs.FS, name string) string {
+	info, err := fs.Stat(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return evidenceAbsent
+	}
+	if err != nil {
+		return evidenceError
+	}
+
+	hash := sha256.New()
+	if info.IsDir() {
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return evidenceError
+		}
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+			if entry.IsDir() {
+				names[i] += "/"
+			}
+		}
+		sort.Strings(names)
+		for _, entryName := range names {
+			hash.Write([]byte(entryName + "\n"))
+		}
+		return "dir:" + hex.EncodeToString(hash.Sum(nil))
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return evidenceError
+	}
+	hash.Write(data)
+	return "file:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// Digest hashes evidence into a single string that changes whenever any of its paths does
+func Digest(evidence map[string]string) string {
+	names := make([]string, 0, len(evidence))
+	for name := range evidence {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		hash.Write([]byte(name + "=" + evidence[name] + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
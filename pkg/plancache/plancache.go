@@ -0,0 +1,180 @@
+// Package plancache stores analysis results on disk, keyed by everything a
+// result depends on: the analysed commit or the files providers read, the
+// tool version and the analysis options. Results are served from the cache
+// until one of them changes.
+package plancache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAge is how long Prune keeps results that were not used
+const DefaultMaxAge = 30 * 24 * time.Hour
+
+// Cache is a directory of cached results written by one tool version
+type Cache struct {
+	// Dir holds one JSON file per result
+	Dir string
+	// Version of the tool, part of every key; Prune removes results of other versions
+	Version string
+	// MaxAge is how long Prune keeps results that were not used; 0 disables the limit
+	MaxAge time.Duration
+}
+
+// Entry is a cached result
+type Entry struct {
+	// Key the result is stored under
+	Key string `json:"key"`
+	// Version of the tool that computed the result
+	Version string `json:"version"`
+	// Commit the result was computed from, empty for content keyed results
+	Commit string `json:"commit,omitempty"`
+	// Evidence maps every path read while computing a content keyed result to a
+	// hash of what was found there; the result is stale once any of them differs
+	Evidence map[string]string `json:"evidence,omitempty"`
+	// When the result was computed
+	Created time.Time `json:"created"`
+	// The result
+	Value json.RawMessage `json:"value"`
+}
+
+// PruneResult reports what Prune removed and kept
+type PruneResult struct {
+	// Results removed for their age or version
+	Removed int `json:"removed"`
+	// Bytes freed by removing results
+	Freed int64 `json:"freed"`
+	// Results kept
+	Kept int `json:"kept"`
+	// Bytes of the results kept
+	Size int64 `json:"size"`
+}
+
+// New creates a cache of results computed by version in the plans directory below cacheDir
+func New(cacheDir, version string) *Cache {
+	return &Cache{
+		Dir:     filepath.Join(cacheDir, "plans"),
+		Version: version,
+		MaxAge:  DefaultMaxAge,
+	}
+}
+
+// Key hashes the tool version and the parts a result depends on into a cache key
+func (c *Cache) Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range append([]string{c.Version}, parts...) {
+		// Length prefixes keep ("ab", "c") and ("a", "bc") apart
+		fmt.Fprintf(hash, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// path returns the file of a key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// Get returns the entry stored under key. Missing, unreadable and corrupt
+// entries are all reported as misses.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	// Prune measures age from the last use
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &entry, true
+}
+
+// Put stores entry under its key, replacing any previous result
+func (c *Cache) Put(entry *Entry) error {
+	entry.Version = c.Version
+	if entry.Created.IsZero() {
+		entry.Created = time.Now().UTC()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	// Write aside and rename, so concurrent readers never see half an entry
+	file, err := os.CreateTemp(c.Dir, entry.Key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(entry.Key))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+	return err
+}
+
+// Prune removes results not used for longer than MaxAge, results computed by
+// other versions and files left behind by interrupted writes
+func (c *Cache) Prune() (*PruneResult, error) {
+	result := &PruneResult{}
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dirEntry := range entries {
+		name := dirEntry.Name()
+		info, err := dirEntry.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		path := filepath.Join(c.Dir, name)
+		stale := false
+		switch {
+		case strings.HasSuffix(name, ".tmp"):
+			stale = time.Since(info.ModTime()) > time.Hour
+		case strings.HasSuffix(name, ".json"):
+			stale = c.MaxAge > 0 && time.Since(info.ModTime()) > c.MaxAge
+			if !stale {
+				var entry struct {
+					Version string `json:"version"`
+				}
+				data, err := os.ReadFile(path)
+				stale = err != nil || json.Unmarshal(data, &entry) != nil || entry.Version != c.Version
+			}
+		default:
+			continue
+		}
+
+		if stale && os.Remove(path) == nil {
+			result.Removed++
+			result.Freed += info.Size()
+			continue
+		}
+		result.Kept++
+		result.Size += info.Size()
+	}
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package plancache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/source"
+)
+
+func TestKey(t *testing.T) {
+	cache := New(t.TempDir(), "1.0.0")
+	if cache.Key("a", "b") != cache.Key("a", "b") {
+		t.Error("expected stable keys")
+	}
+	if cache.Key("ab", "c") == cache.Key("a", "bc") {
+		t.Error("expected parts to be kept apart")
+	}
+	if other := New(cache.Dir, "1.1.0"); other.Key("a", "b") == cache.Key("a", "b") {
+		t.Error("expected the version to be part of the key")
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	cache := New(t.TempDir(), "1.0.0")
+	key := cache.Key("commit:abc")
+	if _, ok := cache.Get(key); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	if err := cache.Put(&Entry{Key: key, Commit: "abc", Value: json.RawMessage(`{"provider":"go"}`)}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	entry, ok := cache.Get(key)
+	if !ok {
+		t.Fatal("expected hit after Put")
+	}
+	if entry.Version != "1.0.0" || entry.Commit != "abc" || string(entry.Value) != `{"provider":"go"}` || entry.Created.IsZero() {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	// Corrupt entries are misses
+	if err := os.WriteFile(cache.path(key), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to corrupt entry: %v", err)
+	}
+	if _, ok := cache.Get(key); ok {
+		t.Error("expected miss for corrupt entry")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(dir, "1.0.0")
+	old := time.Now().Add(-2 * DefaultMaxAge)
+
+	put := func(c *Cache, part string) string {
+		t.Helper()
+		key := c.Key(part)
+		if err := c.Put(&Entry{Key: key, Value: json.RawMessage(`{}`)}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		return key
+	}
+	fresh := put(cache, "fresh")
+	unused := put(cache, "unused")
+	_ = os.Chtimes(cache.path(unused), old, old)
+	put(New(dir, "0.9.0"), "previous")
+	leftover := filepath.Join(cache.Dir, "abc.123.tmp")
+	_ = os.WriteFile(leftover, nil, 0644)
+	_ = os.Chtimes(leftover, old, old)
+
+	result, err := cache.Prune()
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.Removed != 3 || result.Kept != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if _, ok := cache.Get(fresh); !ok {
+		t.Error("recently used entry of this version should be kept")
+	}
+
+	if result, err := New(filepath.Join(dir, "missing"), "1.0.0").Prune(); err != nil || result.Kept != 0 {
+		t.Errorf("expected empty result for missing cache, got %+v, %v", result, err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	files := map[string]string{
+		"package.json":   `{"name": "web"}`,
+		"src/index.js":   `console.log("hello")`,
+		"docs/README.md": "# web",
+	}
+	recorder := Record(source.NewMap(files))
+	if _, err := recorder.ReadFile("package.json"); err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if _, err := recorder.Stat("go.mod"); err == nil {
+		t.Fatal("expected go.mod to be missing")
+	}
+	if _, err := recorder.ReadDir("src"); err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	evidence := recorder.Evidence()
+	if len(evidence) != 3 || evidence["go.mod"] != evidenceAbsent {
+		t.Fatalf("unexpected evidence %v", evidence)
+	}
+
+	changed := func(name, content string) map[string]string {
+		next := make(map[string]string, len(files)+1)
+		for file, data := range files {
+			next[file] = data
+		}
+		next[name] = content
+		return next
+	}
+	tests := []struct {
+		name  string
+		files map[string]string
+		valid bool
+	}{
+		{"unchanged", files, true},
+		{"file not read", changed("docs/README.md", "# shop"), true},
+		{"file read", changed("package.json", `{"name": "shop"}`), false},
+		{"missing file added", changed("go.mod", "module shop"), false},
+		{"listed directory grows", changed("src/util.js", ""), false},
+	}
+	for _, tt := range tests {
+		if valid := Verify(source.NewMap(tt.files), evidence); valid != tt.valid {
+			t.Errorf("%s: expected valid %t, got %t", tt.name, tt.valid, valid)
+		}
+	}
+	if Digest(evidence) == Digest(map[string]string{}) {
+		t.Error("expected digest to depend on the evidence")
+	}
+}
@@ -0,0 +1,334 @@
+// Package plugins runs detection providers as external executables.
+//
+// A plugin is any executable named devbox-pack-provider-<name> found on the
+// plugin path. It is started once per request, reads a single JSON Request
+// from stdin and writes a single JSON response to stdout. Every plugin is
+// asked for a handshake when it is loaded so that protocol mismatches are
+// reported before detection starts.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// ProtocolVersion is the plugin protocol version spoken by this build
+const ProtocolVersion = 1
+
+// ExecutablePrefix is the file name prefix that marks an executable as a plugin
+const ExecutablePrefix = "devbox-pack-provider-"
+
+// PathEnv names the environment variable holding the default plugin path
+const PathEnv = "DEVBOX_PACK_PLUGIN_PATH"
+
+// MaxContentSize is the largest file whose contents are sent to a plugin
+const MaxContentSize = 256 * 1024
+
+// Request methods
+const (
+	MethodHandshake = "handshake"
+	MethodDetect    = "detect"
+)
+
+// Metadata keys used to carry plugin output from Detect to plan generation
+const (
+	metadataCommands    = "pluginCommands"
+	metadataEnvironment = "pluginEnvironment"
+	metadataNative      = "needsNativeCompilation"
+)
+
+// Request is written to the plugin's stdin
+type Request struct {
+	// Protocol version of the host
+	ProtocolVersion int `json:"protocolVersion"`
+	// Either MethodHandshake or MethodDetect
+	Method string `json:"method"`
+	// Scanned project files, only set for MethodDetect
+	Files []types.FileInfo `json:"files,omitempty"`
+	// Contents of the files requested in the handshake, keyed by path
+	Contents map[string]string `json:"contents,omitempty"`
+}
+
+// HandshakeResponse describes a plugin
+type HandshakeResponse struct {
+	// Protocol version implemented by the plugin, must equal ProtocolVersion
+	ProtocolVersion int `json:"protocolVersion"`
+	// Provider name, used with --provider and in plans
+	Name string `json:"name"`
+	// Language reported in detection results, defaults to Name
+	Language string `json:"language,omitempty"`
+	// Provider priority (lower number = higher priority)
+	Priority int `json:"priority"`
+	// Glob patterns of files whose contents the plugin needs for detection.
+	// Patterns are matched against both the file path and the file name.
+	Contents []string `json:"contents,omitempty"`
+}
+
+// DetectResponse is the plugin's answer to MethodDetect
+type DetectResponse struct {
+	// Detection result, nil when the project does not match
+	Result *types.DetectResult `json:"result"`
+	// Commands for the execution plan
+	Commands types.Commands `json:"commands"`
+	// Environment variables for the execution plan
+	Environment map[string]string `json:"environment,omitempty"`
+	// Whether build-essential is needed
+	NeedsNativeCompilation bool `json:"needsNativeCompilation,omitempty"`
+	// Failure message, empty on success
+	Error string `json:"error,omitempty"`
+}
+
+// Provider is a detection provider backed by a plugin executable
+type Provider struct {
+	executable string
+	handshake  HandshakeResponse
+}
+
+// Discover returns the plugin executables found in dirs. When the same
+// plugin name appears in several directories the first one wins.
+// Missing directories are ignored.
+func Discover(dirs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var executables []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, pluginError(dir, err)
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, ExecutablePrefix) || seen[name] {
+				continue
+			}
+			executable := filepath.Join(dir, name)
+			if !isExecutable(executable) {
+				continue
+			}
+			seen[name] = true
+			executables = append(executables, executable)
+		}
+	}
+	return executables, nil
+}
+
+// isExecutable reports whether file is a regular file that can be run
+func isExecutable(file string) bool {
+	stat, err := os.Stat(file)
+	if err != nil || !stat.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(file), ".exe")
+	}
+	return stat.Mode().Perm()&0111 != 0
+}
+
+// Load starts the plugin for a handshake and returns it as a Provider.
+// Plugins speaking another protocol version are rejected.
+func Load(ctx context.Context, executable string) (*Provider, error) {
+	var handshake HandshakeResponse
+	err := call(ctx, executable, Request{
+		ProtocolVersion: ProtocolVersion,
+		Method:          MethodHandshake,
+	}, &handshake)
+	if err != nil {
+		return nil, err
+	}
+
+	if handshake.ProtocolVersion != ProtocolVersion {
+		return nil, pluginError(executable, fmt.Errorf(
+			"unsupported protocol version %d, expected %d", handshake.ProtocolVersion, ProtocolVersion))
+	}
+	if handshake.Name == "" {
+		return nil, pluginError(executable, errors.New("handshake did not include a provider name"))
+	}
+	if handshake.Language == "" {
+		handshake.Language = handshake.Name
+	}
+
+	return &Provider{executable: executable, handshake: handshake}, nil
+}
+
+// LoadAll discovers and loads every plugin in dirs
+func LoadAll(ctx context.Context, dirs []string) ([]*Provider, error) {
+	executables, err := Discover(dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]*Provider, 0, len(executables))
+	for _, executable := range executables {
+		provider, err := Load(ctx, executable)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// GetName gets provider name
+func (p *Provider) GetName() string {
+	return p.handshake.Name
+}
+
+// GetLanguage gets provider language
+func (p *Provider) GetLanguage() string {
+	return p.handshake.Language
+}
+
+// GetPriority gets provider priority
+func (p *Provider) GetPriority() int {
+	return p.handshake.Priority
+}
+
+// Executable returns the path of the plugin executable
+func (p *Provider) Executable() string {
+	return p.executable
+}
+
+// Detect sends the scanned files to the plugin and returns its result.
+// Commands, environment and native compilation needs are kept in the result
+// metadata for plan generation.
+func (p *Provider) Detect(ctx context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
+	var response DetectResponse
+	err := call(ctx, p.executable, Request{
+		ProtocolVersion: ProtocolVersion,
+		Method:          MethodDetect,
+		Files:           files,
+		Contents:        p.readContents(fsys, files),
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, pluginError(p.executable, errors.New(response.Error))
+	}
+
+	result := response.Result
+	if result == nil {
+		result = &types.DetectResult{}
+	}
+	if result.Language == "" {
+		result.Language = p.handshake.Language
+	}
+	name := p.handshake.Name
+	result.Provider = &name
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[metadataCommands] = response.Commands
+	result.Metadata[metadataEnvironment] = response.Environment
+	result.Metadata[metadataNative] = response.NeedsNativeCompilation
+
+	return result, nil
+}
+
+// GenerateCommands returns the commands reported by the plugin
+func (p *Provider) GenerateCommands(result *types.DetectResult, _ types.CLIOptions) types.Commands {
+	if commands, ok := result.Metadata[metadataCommands].(types.Commands); ok {
+		return commands
+	}
+	return types.Commands{}
+}
+
+// GenerateEnvironment returns the environment variables reported by the plugin
+func (p *Provider) GenerateEnvironment(result *types.DetectResult) map[string]string {
+	if environment, ok := result.Metadata[metadataEnvironment].(map[string]string); ok {
+		return environment
+	}
+	return nil
+}
+
+// NeedsNativeCompilation returns whether the plugin asked for native build tools
+func (p *Provider) NeedsNativeCompilation(result *types.DetectResult) bool {
+	needsNative, _ := result.Metadata[metadataNative].(bool)
+	return needsNative
+}
+
+// readContents reads the files matching the handshake content patterns
+func (p *Provider) readContents(fsys fs.FS, files []types.FileInfo) map[string]string {
+	if len(p.handshake.Contents) == 0 || fsys == nil {
+		return nil
+	}
+
+	contents := make(map[string]string)
+	for _, file := range files {
+		if file.IsDirectory || (file.Size != nil && *file.Size > MaxContentSize) || !p.wantsContent(file) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, file.Path)
+		if err != nil || len(data) > MaxContentSize {
+			continue
+		}
+		contents[file.Path] = string(data)
+	}
+	return contents
+}
+
+// wantsContent checks file against the handshake content patterns
+func (p *Provider) wantsContent(file types.FileInfo) bool {
+	for _, pattern := range p.handshake.Contents {
+		if matched, _ := path.Match(pattern, file.Path); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, file.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// call runs the plugin with request on stdin and decodes stdout into response
+func call(ctx context.Context, executable string, request Request, response interface{}) error {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return pluginError(executable, err)
+	}
+
+	cmd := exec.CommandContext(ctx, executable)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return ctxErr
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		return pluginError(executable, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), response); err != nil {
+		return pluginError(executable, fmt.Errorf("invalid %s response: %w", request.Method, err))
+	}
+	return nil
+}
+
+// pluginError wraps plugin failures
+func pluginError(executable string, err error) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("Plugin %s failed: %s", filepath.Base(executable), err.Error()),
+		types.ErrorCodePluginError,
+		map[string]interface{}{"plugin": executable},
+	)
+}
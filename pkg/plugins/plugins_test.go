@@ -0,0 +1,154 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// acmePlugin answers the handshake and matches projects whose acme.toml
+// contents were sent along with the file list
+const acmePlugin = `#!/bin/sh
+input=$(cat)
+case "$input" in
+*'"method":"handshake"'*)
+	echo '{"protocolVersion":1,"name":"acme","language":"java","priority":5,"contents":["acme.toml"]}'
+	;;
+*'"acme.toml":"[app]'*)
+	echo '{"result":{"matched":true,"confidence":0.9,"framework":"Acme"},"commands":{"run":["acme run"]},"environment":{"ACME_HOME":"/opt/acme"},"needsNativeCompilation":true}'
+	;;
+*)
+	echo '{"result":null}'
+	;;
+esac
+`
+
+// writePlugin writes an executable shell script plugin into dir
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+	executable := filepath.Join(dir, name)
+	if err := os.WriteFile(executable, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return executable
+}
+
+func TestDiscover(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+
+	expected := writePlugin(t, first, "devbox-pack-provider-acme", acmePlugin)
+	writePlugin(t, second, "devbox-pack-provider-acme", acmePlugin)
+	other := writePlugin(t, second, "devbox-pack-provider-other", acmePlugin)
+	writePlugin(t, first, "unrelated-tool", acmePlugin)
+	if err := os.WriteFile(filepath.Join(first, "devbox-pack-provider-data"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	executables, err := Discover([]string{first, filepath.Join(first, "missing"), second})
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	if len(executables) != 2 || executables[0] != expected || executables[1] != other {
+		t.Errorf("expected [%s %s], got %v", expected, other, executables)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	executable := writePlugin(t, t.TempDir(), "devbox-pack-provider-acme", acmePlugin)
+
+	provider, err := Load(context.Background(), executable)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if provider.GetName() != "acme" || provider.GetLanguage() != "java" || provider.GetPriority() != 5 {
+		t.Errorf("unexpected handshake: name=%s language=%s priority=%d",
+			provider.GetName(), provider.GetLanguage(), provider.GetPriority())
+	}
+}
+
+func TestLoad_InvalidHandshake(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"protocol mismatch", "#!/bin/sh\necho '{\"protocolVersion\":99,\"name\":\"acme\"}'\n"},
+		{"missing name", "#!/bin/sh\necho '{\"protocolVersion\":1}'\n"},
+		{"invalid json", "#!/bin/sh\necho 'hello'\n"},
+		{"failing executable", "#!/bin/sh\necho 'boom' >&2\nexit 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executable := writePlugin(t, t.TempDir(), "devbox-pack-provider-bad", tt.script)
+
+			_, err := Load(context.Background(), executable)
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			devBoxErr, ok := err.(*types.DevBoxPackError)
+			if !ok || devBoxErr.Code != types.ErrorCodePluginError {
+				t.Errorf("expected %s error, got %v", types.ErrorCodePluginError, err)
+			}
+		})
+	}
+}
+
+func TestProvider_Detect(t *testing.T) {
+	executable := writePlugin(t, t.TempDir(), "devbox-pack-provider-acme", acmePlugin)
+	provider, err := Load(context.Background(), executable)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	fsys := source.NewMap(map[string]string{
+		"acme.toml": "[app]\nname = \"demo\"\n",
+	})
+	files, err := source.Scan(context.Background(), fsys, nil)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	fileInfos := []types.FileInfo{*files[0]}
+
+	result, err := provider.Detect(context.Background(), fsys, fileInfos)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	if !result.Matched || result.Language != "java" || result.Framework != "Acme" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Provider == nil || *result.Provider != "acme" {
+		t.Errorf("expected provider acme, got %v", result.Provider)
+	}
+
+	commands := provider.GenerateCommands(result, types.CLIOptions{})
+	if len(commands.Run) != 1 || commands.Run[0] != "acme run" {
+		t.Errorf("expected run command from plugin, got %+v", commands)
+	}
+	if env := provider.GenerateEnvironment(result); env["ACME_HOME"] != "/opt/acme" {
+		t.Errorf("expected environment from plugin, got %v", env)
+	}
+	if !provider.NeedsNativeCompilation(result) {
+		t.Error("expected native compilation to be required")
+	}
+
+	// Without the requested contents the plugin does not match
+	result, err = provider.Detect(context.Background(), source.NewMap(nil), nil)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if result.Matched {
+		t.Error("expected no match for an empty project")
+	}
+}
@@ -4,11 +4,12 @@ package providers
 
 import (
 	"fmt"
+	"io/fs"
 	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -126,18 +127,16 @@ func (bp *BaseProvider) GetMatchingFiles(files []types.FileInfo, pattern string)
 
 // ParseVersionFromJSON parses version information from JSON file
 func (bp *BaseProvider) ParseVersionFromJSON(
-	projectPath string,
+	fsys fs.FS,
 	fileName string,
-	gitHandler interface{},
 	versionField string,
 ) (string, error) {
-	gh := gitHandler.(*git.GitHandler)
 	if versionField == "" {
 		versionField = "version"
 	}
 
 	var content map[string]interface{}
-	err := gh.ReadJSONFile(projectPath, fileName, &content)
+	err := source.ReadJSON(fsys, fileName, &content)
 	if err != nil {
 		return "", err
 	}
@@ -167,13 +166,11 @@ func (bp *BaseProvider) ParseVersionFromJSON(
 
 // ParseVersionFromText parses version information from text file
 func (bp *BaseProvider) ParseVersionFromText(
-	projectPath string,
+	fsys fs.FS,
 	fileName string,
-	gitHandler interface{},
 	pattern *regexp.Regexp,
 ) (string, error) {
-	gh := gitHandler.(*git.GitHandler)
-	content, err := gh.ReadFile(projectPath, fileName)
+	content, err := source.ReadText(fsys, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -364,15 +361,25 @@ func (bp *BaseProvider) CalculateConfidence(indicators []types.ConfidenceIndicat
 	return 0.0
 }
 
+// ScoreIndicators calculates confidence like CalculateConfidence and records
+// the breakdown. A match must exceed threshold.
+func (bp *BaseProvider) ScoreIndicators(indicators []types.ConfidenceIndicator, threshold float64) *types.Scoring {
+	confidence := bp.CalculateConfidence(indicators)
+	return &types.Scoring{
+		Indicators:    indicators,
+		RawConfidence: confidence,
+		Confidence:    confidence,
+		Threshold:     threshold,
+	}
+}
+
 // SafeReadJSON safely reads JSON file
 func (bp *BaseProvider) SafeReadJSON(
-	projectPath string,
+	fsys fs.FS,
 	fileName string,
-	gitHandler interface{},
 ) (map[string]interface{}, error) {
-	gh := gitHandler.(*git.GitHandler)
 	var content map[string]interface{}
-	err := gh.ReadJSONFile(projectPath, fileName, &content)
+	err := source.ReadJSON(fsys, fileName, &content)
 	if err != nil {
 		return nil, err
 	}
@@ -391,12 +398,10 @@ func (bp *BaseProvider) HasFileInEvidence(evidenceFiles []string, fileName strin
 
 // SafeReadText safely reads text file
 func (bp *BaseProvider) SafeReadText(
-	projectPath string,
+	fsys fs.FS,
 	fileName string,
-	gitHandler interface{},
 ) (string, error) {
-	gh := gitHandler.(*git.GitHandler)
-	content, err := gh.ReadFile(projectPath, fileName)
+	content, err := source.ReadText(fsys, fileName)
 	if err != nil {
 		return "", err
 	}
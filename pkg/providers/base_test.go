@@ -196,7 +196,7 @@ func TestBaseProvider_ParseVersionFromJSON(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := provider.ParseVersionFromJSON(helper.TempDir, "package.json", helper.GitHandler, tc.versionField)
+			result, err := provider.ParseVersionFromJSON(helper.FS(), "package.json", tc.versionField)
 
 			if tc.expectError {
 				if err == nil {
@@ -228,7 +228,7 @@ func TestBaseProvider_ParseVersionFromText(t *testing.T) {
 
 	pattern := regexp.MustCompile(`^v?(.+)$`)
 
-	result, err := provider.ParseVersionFromText(helper.TempDir, ".nvmrc", helper.GitHandler, pattern)
+	result, err := provider.ParseVersionFromText(helper.FS(), ".nvmrc", pattern)
 	if err != nil {
 		t.Fatalf("ParseVersionFromText failed: %v", err)
 	}
@@ -5,9 +5,10 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
 	"strings"
 
-	"github.com/labring/devbox-pack/pkg/git"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -27,6 +28,14 @@ func NewDenoProvider() *DenoProvider {
 	}
 }
 
+// Register the Deno Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewDenoProvider() },
+	Frameworks:      []string{"Fresh", "Oak", "Hono", "Aleph.js", "Ultra"},
+	KeyFiles:        []string{"deno.json", "deno.jsonc", "deno.lock", "import_map.json"},
+	PackageManagers: []string{"deno"},
+})
+
 // GetName gets Provider name
 func (p *DenoProvider) GetName() string {
 	return p.Name
@@ -38,8 +47,7 @@ func (p *DenoProvider) GetPriority() int {
 }
 
 // Detect detects Deno project
-func (p *DenoProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
-	gh := gitHandler.(*git.GitHandler)
+func (p *DenoProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 
 	// Check if Staticfile or go.work exists, if so, don't detect as Deno project
 	if p.HasFile(files, "Staticfile") || p.HasFile(files, "go.work") {
@@ -47,29 +55,32 @@ func (p *DenoProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 	}
 
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 40, Satisfied: p.HasAnyFile(files, []string{"deno.json", "deno.jsonc"})},
-		{Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.ts", "*.js"})},
-		{Weight: 15, Satisfied: p.HasFile(files, "deno.lock")},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"deps.ts", "mod.ts"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"import_map.json"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"main.ts", "app.ts", "server.ts"})},
+		{Description: "deno.json or deno.jsonc", Weight: 40, Satisfied: p.HasAnyFile(files, []string{"deno.json", "deno.jsonc"})},
+		{Description: "*.ts or *.js", Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.ts", "*.js"})},
+		{Description: "deno.lock", Weight: 15, Satisfied: p.HasFile(files, "deno.lock")},
+		{Description: "deps.ts or mod.ts", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"deps.ts", "mod.ts"})},
+		{Description: "import_map.json", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"import_map.json"})},
+		{Description: "main.ts, app.ts or server.ts", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"main.ts", "app.ts", "server.ts"})},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.2 // Lower detection threshold
+	scoring := p.ScoreIndicators(indicators, 0.2) // Lower detection threshold
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect version
-	version, err := p.detectDenoVersion(projectPath, gh)
+	version, err := p.detectDenoVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gh)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +147,7 @@ func (p *DenoProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"deno",
@@ -146,21 +157,22 @@ func (p *DenoProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 		"",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectDenoVersion detects Deno version
-func (p *DenoProvider) detectDenoVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
-	gh := gitHandler.(*git.GitHandler)
+func (p *DenoProvider) detectDenoVersion(fsys fs.FS) (*types.VersionInfo, error) {
 
 	// Read from .dvmrc file first (highest priority)
-	dvmrcContent, err := gh.ReadFile(projectPath, ".dvmrc")
+	dvmrcContent, err := p.SafeReadText(fsys, ".dvmrc")
 	if err == nil && strings.TrimSpace(dvmrcContent) != "" {
 		return p.CreateVersionInfo(strings.TrimSpace(dvmrcContent), ".dvmrc"), nil
 	}
 
 	// Read from deno.json
-	denoJson, err := p.SafeReadJSON(projectPath, "deno.json", gitHandler)
+	denoJson, err := p.SafeReadJSON(fsys, "deno.json")
 	if err != nil {
 		// If file doesn't exist, try deno.jsonc
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -176,7 +188,7 @@ func (p *DenoProvider) detectDenoVersion(projectPath string, gitHandler interfac
 	}
 
 	// Read from deno.jsonc
-	denoJsonc, err := p.SafeReadJSON(projectPath, "deno.jsonc", gitHandler)
+	denoJsonc, err := p.SafeReadJSON(fsys, "deno.jsonc")
 	if err != nil {
 		// If file doesn't exist, use default version
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -195,9 +207,9 @@ func (p *DenoProvider) detectDenoVersion(projectPath string, gitHandler interfac
 }
 
 // detectFramework detects framework
-func (p *DenoProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
+func (p *DenoProvider) detectFramework(fsys fs.FS) (string, error) {
 	// Check dependencies in deno.json
-	denoJson, err := p.SafeReadJSON(projectPath, "deno.json", gitHandler)
+	denoJson, err := p.SafeReadJSON(fsys, "deno.json")
 	if err != nil {
 		// If file doesn't exist, continue trying other methods
 		if !strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -227,7 +239,7 @@ func (p *DenoProvider) detectFramework(projectPath string, gitHandler interface{
 	}
 
 	// Check dependencies in deno.jsonc
-	denoJsonc, err := p.SafeReadJSON(projectPath, "deno.jsonc", gitHandler)
+	denoJsonc, err := p.SafeReadJSON(fsys, "deno.jsonc")
 	if err != nil {
 		// If file doesn't exist, continue trying other methods
 		if !strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -257,7 +269,7 @@ func (p *DenoProvider) detectFramework(projectPath string, gitHandler interface{
 	}
 
 	// Check deps.ts file
-	depsContent, err := p.SafeReadText(projectPath, "deps.ts", gitHandler)
+	depsContent, err := p.SafeReadText(fsys, "deps.ts")
 	if err != nil {
 		// If file doesn't exist, return empty string instead of error
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -1,12 +1,13 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -26,7 +27,7 @@ func TestDenoProvider_Detect_NoDenoFiles(t *testing.T) {
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(helper.TempDir, files, helper.GitHandler)
+	result, err := provider.Detect(context.Background(), helper.FS(), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -36,15 +37,13 @@ func TestDenoProvider_Detect_NoDenoFiles(t *testing.T) {
 
 func TestDenoProvider_Detect_WithStaticfile(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Staticfile", IsDirectory: false},
 		{Path: "deno.json", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -56,15 +55,13 @@ func TestDenoProvider_Detect_WithStaticfile(t *testing.T) {
 
 func TestDenoProvider_Detect_WithGoWork(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "go.work", IsDirectory: false},
 		{Path: "deno.json", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -76,8 +73,6 @@ func TestDenoProvider_Detect_WithGoWork(t *testing.T) {
 
 func TestDenoProvider_Detect_WithDenoJson(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with deno.json
 	tempDir, err := os.MkdirTemp("", "deno-test")
@@ -106,7 +101,7 @@ func TestDenoProvider_Detect_WithDenoJson(t *testing.T) {
 		{Path: "main.ts", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -122,15 +117,13 @@ func TestDenoProvider_Detect_WithDenoJson(t *testing.T) {
 
 func TestDenoProvider_Detect_WithDenoJsonc(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "deno.jsonc", IsDirectory: false},
 		{Path: "main.ts", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -142,15 +135,13 @@ func TestDenoProvider_Detect_WithDenoJsonc(t *testing.T) {
 
 func TestDenoProvider_Detect_WithDenoLock(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "deno.lock", IsDirectory: false},
 		{Path: "main.ts", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -162,8 +153,6 @@ func TestDenoProvider_Detect_WithDenoLock(t *testing.T) {
 
 func TestDenoProvider_Detect_WithTypeScriptFiles(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "main.ts", IsDirectory: false},
@@ -171,7 +160,7 @@ func TestDenoProvider_Detect_WithTypeScriptFiles(t *testing.T) {
 		{Path: "mod.ts", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -183,15 +172,13 @@ func TestDenoProvider_Detect_WithTypeScriptFiles(t *testing.T) {
 
 func TestDenoProvider_Detect_WithJavaScriptFiles(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "main.js", IsDirectory: false},
 		{Path: "utils.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -340,8 +327,6 @@ func TestDenoProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestDenoProvider_DetectFramework(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name              string
@@ -401,7 +386,7 @@ func TestDenoProvider_DetectFramework(t *testing.T) {
 				t.Fatalf("failed to write deno.json: %v", err)
 			}
 
-			framework, err := provider.detectFramework(tempDir, gitHandler)
+			framework, err := provider.detectFramework(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectFramework failed: %v", err)
 			}
@@ -415,8 +400,6 @@ func TestDenoProvider_DetectFramework(t *testing.T) {
 
 func TestDenoProvider_DetectDenoVersion(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with .dvmrc
 	tempDir, err := os.MkdirTemp("", "deno-version-test")
@@ -432,7 +415,7 @@ func TestDenoProvider_DetectDenoVersion(t *testing.T) {
 		t.Fatalf("failed to write .dvmrc: %v", err)
 	}
 
-	version, err := provider.detectDenoVersion(tempDir, gitHandler)
+	version, err := provider.detectDenoVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectDenoVersion failed: %v", err)
 	}
@@ -448,8 +431,6 @@ func TestDenoProvider_DetectDenoVersion(t *testing.T) {
 
 func TestDenoProvider_DetectDenoVersion_FromDenoJson(t *testing.T) {
 	provider := NewDenoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with deno.json
 	tempDir, err := os.MkdirTemp("", "deno-version-test")
@@ -470,7 +451,7 @@ func TestDenoProvider_DetectDenoVersion_FromDenoJson(t *testing.T) {
 		t.Fatalf("failed to write deno.json: %v", err)
 	}
 
-	version, err := provider.detectDenoVersion(tempDir, gitHandler)
+	version, err := provider.detectDenoVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectDenoVersion failed: %v", err)
 	}
@@ -5,7 +5,9 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -28,6 +30,14 @@ func NewGoProvider() *GoProvider {
 	}
 }
 
+// Register the Go Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewGoProvider() },
+	Frameworks:      []string{"Gin", "Echo", "Fiber", "Beego", "Revel", "Gorilla Mux", "Cobra CLI", "Fx"},
+	KeyFiles:        []string{"go.mod", "go.work", "go.sum", "main.go"},
+	PackageManagers: []string{"go"},
+})
+
 // GetName gets Provider name
 func (p *GoProvider) GetName() string {
 	return p.Name
@@ -39,36 +49,39 @@ func (p *GoProvider) GetPriority() int {
 }
 
 // Detect detects Go project
-func (p *GoProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *GoProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 40, Satisfied: p.HasFile(files, "go.mod")},
-		{Weight: 35, Satisfied: p.HasFile(files, "go.work")}, // Higher weight for workspaces
-		{Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.go"})},
-		{Weight: 15, Satisfied: p.HasFile(files, "go.sum")},
-		{Weight: 15, Satisfied: p.HasFile(files, "go.work.sum")},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"main.go", "cmd/"})},
-		{Weight: 5, Satisfied: p.HasFile(files, "vendor/")},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"Makefile", "makefile"})},
+		{Description: "go.mod", Weight: 40, Satisfied: p.HasFile(files, "go.mod")},
+		{Description: "go.work", Weight: 35, Satisfied: p.HasFile(files, "go.work")}, // Higher weight for workspaces
+		{Description: "*.go", Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.go"})},
+		{Description: "go.sum", Weight: 15, Satisfied: p.HasFile(files, "go.sum")},
+		{Description: "go.work.sum", Weight: 15, Satisfied: p.HasFile(files, "go.work.sum")},
+		{Description: "main.go or cmd/", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"main.go", "cmd/"})},
+		{Description: "vendor/", Weight: 5, Satisfied: p.HasFile(files, "vendor/")},
+		{Description: "Makefile or makefile", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"Makefile", "makefile"})},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.2 // Lower detection threshold to support go.work projects
+	scoring := p.ScoreIndicators(indicators, 0.2) // Lower detection threshold to support go.work projects
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect if this is a workspace
 	isWorkspace := p.HasFile(files, "go.work")
 
 	// Detect version
-	version, err := p.detectGoVersion(projectPath, gitHandler)
+	version, err := p.detectGoVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gitHandler)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +89,7 @@ func (p *GoProvider) Detect(projectPath string, files []types.FileInfo, gitHandl
 	// Detect workspace modules
 	var workspaceModules []string
 	if isWorkspace {
-		workspaceModules, err = p.detectWorkspaceModules(projectPath, gitHandler)
+		workspaceModules, err = p.detectWorkspaceModules(fsys)
 		if err != nil {
 			return nil, err
 		}
@@ -151,7 +164,7 @@ func (p *GoProvider) Detect(projectPath string, files []types.FileInfo, gitHandl
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"go",
@@ -161,13 +174,15 @@ func (p *GoProvider) Detect(projectPath string, files []types.FileInfo, gitHandl
 		"go",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectGoVersion detects Go version
-func (p *GoProvider) detectGoVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (p *GoProvider) detectGoVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from go.work
-	goWorkContent, err := p.SafeReadText(projectPath, "go.work", gitHandler)
+	goWorkContent, err := p.SafeReadText(fsys, "go.work")
 	if err != nil {
 		// If file doesn't exist, don't return error, continue trying other methods
 		if !strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -186,7 +201,7 @@ func (p *GoProvider) detectGoVersion(projectPath string, gitHandler interface{})
 	}
 
 	// Read from go.mod
-	goModContent, err := p.SafeReadText(projectPath, "go.mod", gitHandler)
+	goModContent, err := p.SafeReadText(fsys, "go.mod")
 	if err != nil {
 		// If file doesn't exist, don't return error, continue trying other methods
 		if !strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -206,9 +221,8 @@ func (p *GoProvider) detectGoVersion(projectPath string, gitHandler interface{})
 
 	// Read from .go-version
 	version, err := p.ParseVersionFromText(
-		projectPath,
+		fsys,
 		".go-version",
-		gitHandler,
 		regexp.MustCompile(`^(.+)$`),
 	)
 	if err != nil {
@@ -226,8 +240,8 @@ func (p *GoProvider) detectGoVersion(projectPath string, gitHandler interface{})
 }
 
 // detectWorkspaceModules detects modules in a Go workspace
-func (p *GoProvider) detectWorkspaceModules(projectPath string, gitHandler interface{}) ([]string, error) {
-	goWorkContent, err := p.SafeReadText(projectPath, "go.work", gitHandler)
+func (p *GoProvider) detectWorkspaceModules(fsys fs.FS) ([]string, error) {
+	goWorkContent, err := p.SafeReadText(fsys, "go.work")
 	if err != nil {
 		// If file doesn't exist, return empty slice
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -268,8 +282,8 @@ func (p *GoProvider) detectWorkspaceModules(projectPath string, gitHandler inter
 }
 
 // detectFramework detects framework
-func (p *GoProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
-	goModContent, err := p.SafeReadText(projectPath, "go.mod", gitHandler)
+func (p *GoProvider) detectFramework(fsys fs.FS) (string, error) {
+	goModContent, err := p.SafeReadText(fsys, "go.mod")
 	if err != nil {
 		// If file doesn't exist, return empty string instead of error
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -1,12 +1,13 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -26,7 +27,7 @@ func TestGoProvider_Detect_NoGoFiles(t *testing.T) {
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(helper.TempDir, files, helper.GitHandler)
+	result, err := provider.Detect(context.Background(), helper.FS(), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -44,7 +45,7 @@ func TestGoProvider_Detect_WithGoMod(t *testing.T) {
 	files := CreateTestFiles(helper, GoTestData.Files)
 	files = append(files, types.FileInfo{Path: "main.go", IsDirectory: false})
 
-	result, err := provider.Detect(helper.TempDir, files, helper.GitHandler)
+	result, err := provider.Detect(context.Background(), helper.FS(), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -58,8 +59,6 @@ func TestGoProvider_Detect_WithGoMod(t *testing.T) {
 
 func TestGoProvider_Detect_WithGoWork(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with go.work
 	tempDir, err := os.MkdirTemp("", "go-work-test")
@@ -87,7 +86,7 @@ use (
 		{Path: "module2/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -103,8 +102,6 @@ use (
 
 func TestGoProvider_Detect_WithGoFiles(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "main.go", IsDirectory: false},
@@ -112,7 +109,7 @@ func TestGoProvider_Detect_WithGoFiles(t *testing.T) {
 		{Path: "go.sum", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -124,8 +121,6 @@ func TestGoProvider_Detect_WithGoFiles(t *testing.T) {
 
 func TestGoProvider_Detect_WithVendor(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "main.go", IsDirectory: false},
@@ -133,7 +128,7 @@ func TestGoProvider_Detect_WithVendor(t *testing.T) {
 		{Path: "vendor/modules.txt", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -292,8 +287,6 @@ func TestGoProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestGoProvider_DetectFramework(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name              string
@@ -377,7 +370,7 @@ require (
 				t.Fatalf("failed to write go.mod: %v", err)
 			}
 
-			framework, err := provider.detectFramework(tempDir, gitHandler)
+			framework, err := provider.detectFramework(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectFramework failed: %v", err)
 			}
@@ -391,8 +384,6 @@ require (
 
 func TestGoProvider_DetectGoVersion(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name            string
@@ -443,7 +434,7 @@ require (
 				t.Fatalf("failed to write go.mod: %v", err)
 			}
 
-			version, err := provider.detectGoVersion(tempDir, gitHandler)
+			version, err := provider.detectGoVersion(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectGoVersion failed: %v", err)
 			}
@@ -461,8 +452,6 @@ require (
 
 func TestGoProvider_DetectGoVersion_FromGoWork(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with go.work
 	tempDir, err := os.MkdirTemp("", "go-work-version-test")
@@ -484,7 +473,7 @@ use (
 		t.Fatalf("failed to write go.work: %v", err)
 	}
 
-	version, err := provider.detectGoVersion(tempDir, gitHandler)
+	version, err := provider.detectGoVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectGoVersion failed: %v", err)
 	}
@@ -500,8 +489,6 @@ use (
 
 func TestGoProvider_DetectGoVersion_NoVersionFile(t *testing.T) {
 	provider := NewGoProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory without version files
 	tempDir, err := os.MkdirTemp("", "go-no-version-test")
@@ -510,7 +497,7 @@ func TestGoProvider_DetectGoVersion_NoVersionFile(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	version, err := provider.detectGoVersion(tempDir, gitHandler)
+	version, err := provider.detectGoVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectGoVersion failed: %v", err)
 	}
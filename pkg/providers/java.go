@@ -5,6 +5,8 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -27,6 +29,14 @@ func NewJavaProvider() *JavaProvider {
 	}
 }
 
+// Register the Java Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewJavaProvider() },
+	Frameworks:      []string{"Spring Boot", "Quarkus", "Micronaut", "Dropwizard", "Vert.x", "Jersey", "Struts", "Vaadin", "Spark Java", "Apache Wicket"},
+	KeyFiles:        []string{"pom.xml", "build.gradle", "build.gradle.kts", "gradlew", "mvnw"},
+	PackageManagers: []string{"mvn", "gradle"},
+})
+
 // GetName gets Provider name
 func (p *JavaProvider) GetName() string {
 	return p.Name
@@ -38,32 +48,35 @@ func (p *JavaProvider) GetPriority() int {
 }
 
 // Detect detects Java project
-func (p *JavaProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *JavaProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 30, Satisfied: p.HasAnyFile(files, []string{"pom.xml", "build.gradle", "build.gradle.kts"})},
-		{Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.java", "*.kt", "*.scala"})},
-		{Weight: 15, Satisfied: p.HasFile(files, "gradle.properties")},
-		{Weight: 10, Satisfied: p.HasFile(files, "gradlew")},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"src/main/java", "src/main/kotlin", "src/main/scala"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"mvnw", "mvnw.cmd"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{".mvn", ".gradle"})},
+		{Description: "pom.xml, build.gradle or build.gradle.kts", Weight: 30, Satisfied: p.HasAnyFile(files, []string{"pom.xml", "build.gradle", "build.gradle.kts"})},
+		{Description: "*.java, *.kt or *.scala", Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.java", "*.kt", "*.scala"})},
+		{Description: "gradle.properties", Weight: 15, Satisfied: p.HasFile(files, "gradle.properties")},
+		{Description: "gradlew", Weight: 10, Satisfied: p.HasFile(files, "gradlew")},
+		{Description: "src/main/java, src/main/kotlin or src/main/scala", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"src/main/java", "src/main/kotlin", "src/main/scala"})},
+		{Description: "mvnw or mvnw.cmd", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"mvnw", "mvnw.cmd"})},
+		{Description: ".mvn or .gradle", Weight: 5, Satisfied: p.HasAnyFile(files, []string{".mvn", ".gradle"})},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.2 // Lower detection threshold
+	scoring := p.ScoreIndicators(indicators, 0.2) // Lower detection threshold
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect version
-	version, err := p.detectJavaVersion(projectPath, gitHandler)
+	version, err := p.detectJavaVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gitHandler)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +161,7 @@ func (p *JavaProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"java",
@@ -158,13 +171,15 @@ func (p *JavaProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 		buildTool,
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectJavaVersion detects Java version
-func (p *JavaProvider) detectJavaVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (p *JavaProvider) detectJavaVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from pom.xml
-	pomContent, err := p.SafeReadText(projectPath, "pom.xml", gitHandler)
+	pomContent, err := p.SafeReadText(fsys, "pom.xml")
 	if err != nil {
 		// If file doesn't exist, continue trying other methods
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -197,7 +212,7 @@ func (p *JavaProvider) detectJavaVersion(projectPath string, gitHandler interfac
 	}
 
 	// Read from build.gradle
-	gradleContent, err := p.SafeReadText(projectPath, "build.gradle", gitHandler)
+	gradleContent, err := p.SafeReadText(fsys, "build.gradle")
 	if err != nil {
 		// If file doesn't exist, continue trying other methods
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -222,7 +237,7 @@ func (p *JavaProvider) detectJavaVersion(projectPath string, gitHandler interfac
 	}
 
 	// Read from build.gradle.kts
-	gradleKtsContent, err := p.SafeReadText(projectPath, "build.gradle.kts", gitHandler)
+	gradleKtsContent, err := p.SafeReadText(fsys, "build.gradle.kts")
 	if err != nil {
 		// If file doesn't exist, use default version
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -243,7 +258,7 @@ func (p *JavaProvider) detectJavaVersion(projectPath string, gitHandler interfac
 }
 
 // detectFramework detects framework
-func (p *JavaProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
+func (p *JavaProvider) detectFramework(fsys fs.FS) (string, error) {
 	// Priority-based framework detection - Spring Boot has highest priority
 	frameworkMap := map[string]string{
 		"spring-boot-starter":           "Spring Boot",
@@ -273,7 +288,7 @@ func (p *JavaProvider) detectFramework(projectPath string, gitHandler interface{
 	}
 
 	// Check dependencies in pom.xml with priority ordering
-	pomContent, err := p.SafeReadText(projectPath, "pom.xml", gitHandler)
+	pomContent, err := p.SafeReadText(fsys, "pom.xml")
 	if err != nil {
 		// If file doesn't exist, continue trying other methods
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -297,7 +312,7 @@ func (p *JavaProvider) detectFramework(projectPath string, gitHandler interface{
 	}
 
 	// Check dependencies in build.gradle with priority ordering
-	gradleContent, err := p.SafeReadText(projectPath, "build.gradle", gitHandler)
+	gradleContent, err := p.SafeReadText(fsys, "build.gradle")
 	if err != nil {
 		// If file doesn't exist, continue trying build.gradle.kts
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -321,7 +336,7 @@ func (p *JavaProvider) detectFramework(projectPath string, gitHandler interface{
 	}
 
 	// Check dependencies in build.gradle.kts
-	gradleKtsContent, err := p.SafeReadText(projectPath, "build.gradle.kts", gitHandler)
+	gradleKtsContent, err := p.SafeReadText(fsys, "build.gradle.kts")
 	if err != nil {
 		// If file doesn't exist, return empty string instead of error
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/labring/devbox-pack/pkg/types"
@@ -22,7 +23,7 @@ func TestJavaProvider_Detect_NoJavaFiles(t *testing.T) {
 		{Path: "package.json", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(helper.TempDir, files, helper.GitHandler)
+	result, err := provider.Detect(context.Background(), helper.FS(), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -58,7 +59,7 @@ func TestJavaProvider_Detect_WithMaven(t *testing.T) {
 		{Path: "src/main/java/Main.java", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(helper.TempDir, files, helper.GitHandler)
+	result, err := provider.Detect(context.Background(), helper.FS(), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -99,7 +100,7 @@ java {
 		{Path: "src/main/java/Main.java", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(helper.TempDir, files, helper.GitHandler)
+	result, err := provider.Detect(context.Background(), helper.FS(), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
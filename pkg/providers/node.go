@@ -5,11 +5,12 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"regexp"
 	"strings"
 
-	"github.com/labring/devbox-pack/pkg/git"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -29,34 +30,44 @@ func NewNodeProvider() *NodeProvider {
 	}
 }
 
+// Register the Node.js Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewNodeProvider() },
+	Frameworks:      []string{"next", "nuxt", "angular", "gatsby", "nestjs", "express", "koa", "fastify", "svelte", "sveltekit", "astro", "react", "vue", "vite", "electron", "expo"},
+	KeyFiles:        []string{"package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb", ".nvmrc"},
+	PackageManagers: []string{"npm", "yarn", "pnpm", "bun"},
+})
+
 // Detect detects if project uses Node.js
 func (np *NodeProvider) Detect(
-	projectPath string,
+	_ context.Context,
+	fsys fs.FS,
 	files []types.FileInfo,
-	gitHandler interface{},
 ) (*types.DetectResult, error) {
-	gh := gitHandler.(*git.GitHandler)
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 40, Satisfied: np.HasFile(files, "package.json")},
-		{Weight: 20, Satisfied: np.HasAnyFile(files, []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb"})},
-		{Weight: 15, Satisfied: np.HasFile(files, "node_modules")},
-		{Weight: 10, Satisfied: np.HasAnyFile(files, []string{".nvmrc", ".node-version"})},
-		{Weight: 10, Satisfied: np.HasAnyFile(files, []string{"*.js", "*.ts", "*.mjs", "*.cjs"})},
-		{Weight: 5, Satisfied: np.HasAnyFile(files, []string{"tsconfig.json", "jsconfig.json"})},
+		{Description: "package.json", Weight: 40, Satisfied: np.HasFile(files, "package.json")},
+		{Description: "package-lock.json, yarn.lock, pnpm-lock.yaml or bun.lockb", Weight: 20, Satisfied: np.HasAnyFile(files, []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb"})},
+		{Description: "node_modules", Weight: 15, Satisfied: np.HasFile(files, "node_modules")},
+		{Description: ".nvmrc or .node-version", Weight: 10, Satisfied: np.HasAnyFile(files, []string{".nvmrc", ".node-version"})},
+		{Description: "*.js, *.ts, *.mjs or *.cjs", Weight: 10, Satisfied: np.HasAnyFile(files, []string{"*.js", "*.ts", "*.mjs", "*.cjs"})},
+		{Description: "tsconfig.json or jsconfig.json", Weight: 5, Satisfied: np.HasAnyFile(files, []string{"tsconfig.json", "jsconfig.json"})},
 	}
 
-	confidence := np.CalculateConfidence(indicators)
-	detected := confidence > 0.3
+	scoring := np.ScoreIndicators(indicators, 0.3)
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return np.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := np.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Parse package.json
-	packageJSON, _ := np.SafeReadJSON(projectPath, "package.json", gh)
+	packageJSON, _ := np.SafeReadJSON(fsys, "package.json")
 
 	// Detect version
-	version, err := np.detectNodeVersion(projectPath, gh)
+	version, err := np.detectNodeVersion(fsys)
 	if err != nil {
 		// Use default version
 		version = np.CreateVersionInfo("20", "default")
@@ -200,7 +211,7 @@ func (np *NodeProvider) Detect(
 	}
 	evidence.Reason = reason
 
-	return np.CreateDetectResult(
+	result := np.CreateDetectResult(
 		true,
 		confidence,
 		"node",
@@ -210,7 +221,9 @@ func (np *NodeProvider) Detect(
 		buildTool,
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // GenerateCommands generates commands for Node.js project
@@ -424,20 +437,20 @@ func (np *NodeProvider) isAstroProject(result *types.DetectResult) bool {
 }
 
 // detectNodeVersion detects Node.js version
-func (np *NodeProvider) detectNodeVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (np *NodeProvider) detectNodeVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from .nvmrc
 	nvmrcPattern := regexp.MustCompile(`^v?(.+)$`)
-	if version, err := np.ParseVersionFromText(projectPath, ".nvmrc", gitHandler, nvmrcPattern); err == nil {
+	if version, err := np.ParseVersionFromText(fsys, ".nvmrc", nvmrcPattern); err == nil {
 		return np.CreateVersionInfo(np.NormalizeVersion(version), ".nvmrc"), nil
 	}
 
 	// Read from .node-version
-	if version, err := np.ParseVersionFromText(projectPath, ".node-version", gitHandler, nvmrcPattern); err == nil {
+	if version, err := np.ParseVersionFromText(fsys, ".node-version", nvmrcPattern); err == nil {
 		return np.CreateVersionInfo(np.NormalizeVersion(version), ".node-version"), nil
 	}
 
 	// Read from package.json engines
-	if packageJSON, err := np.SafeReadJSON(projectPath, "package.json", gitHandler); err == nil {
+	if packageJSON, err := np.SafeReadJSON(fsys, "package.json"); err == nil {
 		if engines, ok := packageJSON["engines"].(map[string]interface{}); ok {
 			if nodeVersion, ok := engines["node"].(string); ok {
 				return np.CreateVersionInfo(np.NormalizeVersion(nodeVersion), "package.json engines"), nil
@@ -1,11 +1,12 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -31,15 +32,13 @@ func TestNewNodeProvider(t *testing.T) {
 
 func TestNodeProvider_Detect_NoNodeFiles(t *testing.T) {
 	provider := NewNodeProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -55,8 +54,6 @@ func TestNodeProvider_Detect_NoNodeFiles(t *testing.T) {
 
 func TestNodeProvider_Detect_WithPackageJson(t *testing.T) {
 	provider := NewNodeProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with package.json
 	tmpDir, err := os.MkdirTemp("", "node-test-*")
@@ -92,7 +89,7 @@ func TestNodeProvider_Detect_WithPackageJson(t *testing.T) {
 		{Path: "package-lock.json", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(tmpDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tmpDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -124,8 +121,6 @@ func TestNodeProvider_Detect_WithPackageJson(t *testing.T) {
 
 func TestNodeProvider_Detect_WithYarnLock(t *testing.T) {
 	provider := NewNodeProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "package.json", IsDirectory: false},
@@ -133,7 +128,7 @@ func TestNodeProvider_Detect_WithYarnLock(t *testing.T) {
 		{Path: "index.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -149,8 +144,6 @@ func TestNodeProvider_Detect_WithYarnLock(t *testing.T) {
 
 func TestNodeProvider_Detect_WithPnpmLock(t *testing.T) {
 	provider := NewNodeProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "package.json", IsDirectory: false},
@@ -158,7 +151,7 @@ func TestNodeProvider_Detect_WithPnpmLock(t *testing.T) {
 		{Path: "index.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -5,7 +5,9 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -28,6 +30,14 @@ func NewPHPProvider() *PHPProvider {
 	}
 }
 
+// Register the PHP Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewPHPProvider() },
+	Frameworks:      []string{"Laravel", "Symfony", "CodeIgniter", "CakePHP", "Slim Framework", "Laminas", "Zend Framework", "Phalcon"},
+	KeyFiles:        []string{"composer.json", "composer.lock", "artisan", "index.php"},
+	PackageManagers: []string{"composer"},
+})
+
 // GetName gets Provider name
 func (p *PHPProvider) GetName() string {
 	return p.Name
@@ -39,34 +49,37 @@ func (p *PHPProvider) GetPriority() int {
 }
 
 // Detect detects PHP project
-func (p *PHPProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *PHPProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 30, Satisfied: p.HasFile(files, "composer.json")},
-		{Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.php"})},
-		{Weight: 15, Satisfied: p.HasFile(files, "composer.lock")},
-		{Weight: 20, Satisfied: p.HasAnyFile(files, []string{"index.php", "app.php"})},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"vendor/", "autoload.php"})},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{".php-version", "phpunit.xml"})},
-		{Weight: 50, Satisfied: p.HasFile(files, "artisan")},                                          // High weight for Laravel artisan
-		{Weight: 15, Satisfied: p.HasAnyFile(files, []string{"app/", "config/", "resources/views/"})}, // Laravel directory structure
-		{Weight: 10, Satisfied: p.HasFile(files, "wp-config.php")},                                    // WordPress detection
+		{Description: "composer.json", Weight: 30, Satisfied: p.HasFile(files, "composer.json")},
+		{Description: "*.php", Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.php"})},
+		{Description: "composer.lock", Weight: 15, Satisfied: p.HasFile(files, "composer.lock")},
+		{Description: "index.php or app.php", Weight: 20, Satisfied: p.HasAnyFile(files, []string{"index.php", "app.php"})},
+		{Description: "vendor/ or autoload.php", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"vendor/", "autoload.php"})},
+		{Description: ".php-version or phpunit.xml", Weight: 10, Satisfied: p.HasAnyFile(files, []string{".php-version", "phpunit.xml"})},
+		{Description: "artisan", Weight: 50, Satisfied: p.HasFile(files, "artisan")},                                                                    // High weight for Laravel artisan
+		{Description: "app/, config/ or resources/views/", Weight: 15, Satisfied: p.HasAnyFile(files, []string{"app/", "config/", "resources/views/"})}, // Laravel directory structure
+		{Description: "wp-config.php", Weight: 10, Satisfied: p.HasFile(files, "wp-config.php")},                                                        // WordPress detection
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.2 // Lower detection threshold
+	scoring := p.ScoreIndicators(indicators, 0.2) // Lower detection threshold
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect version
-	version, err := p.detectPHPVersion(projectPath, gitHandler)
+	version, err := p.detectPHPVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gitHandler)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +145,7 @@ func (p *PHPProvider) Detect(projectPath string, files []types.FileInfo, gitHand
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"php",
@@ -142,13 +155,15 @@ func (p *PHPProvider) Detect(projectPath string, files []types.FileInfo, gitHand
 		"composer",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectPHPVersion detects PHP version
-func (p *PHPProvider) detectPHPVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (p *PHPProvider) detectPHPVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from composer.json
-	composerJson, err := p.SafeReadJSON(projectPath, "composer.json", gitHandler)
+	composerJson, err := p.SafeReadJSON(fsys, "composer.json")
 	if err != nil {
 		// If file doesn't exist, continue trying other methods
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -167,9 +182,8 @@ func (p *PHPProvider) detectPHPVersion(projectPath string, gitHandler interface{
 
 	// Read from .php-version
 	version, err := p.ParseVersionFromText(
-		projectPath,
+		fsys,
 		".php-version",
-		gitHandler,
 		regexp.MustCompile(`^(.+)$`),
 	)
 	if err != nil {
@@ -188,8 +202,8 @@ func (p *PHPProvider) detectPHPVersion(projectPath string, gitHandler interface{
 }
 
 // detectFramework detects framework
-func (p *PHPProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
-	composerJson, err := p.SafeReadJSON(projectPath, "composer.json", gitHandler)
+func (p *PHPProvider) detectFramework(fsys fs.FS) (string, error) {
+	composerJson, err := p.SafeReadJSON(fsys, "composer.json")
 	if err != nil {
 		// If file doesn't exist, return empty string instead of error
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
@@ -1,12 +1,13 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -28,15 +29,13 @@ func TestNewPHPProvider(t *testing.T) {
 
 func TestPHPProvider_Detect_NoPHPFiles(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -48,8 +47,6 @@ func TestPHPProvider_Detect_NoPHPFiles(t *testing.T) {
 
 func TestPHPProvider_Detect_WithComposer(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with composer.json
 	tempDir, err := os.MkdirTemp("", "php-composer-test")
@@ -87,7 +84,7 @@ func TestPHPProvider_Detect_WithComposer(t *testing.T) {
 		{Path: "app/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -112,8 +109,6 @@ func TestPHPProvider_Detect_WithComposer(t *testing.T) {
 
 func TestPHPProvider_Detect_WithPHPFiles(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.php", IsDirectory: false},
@@ -121,7 +116,7 @@ func TestPHPProvider_Detect_WithPHPFiles(t *testing.T) {
 		{Path: "vendor/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -133,8 +128,6 @@ func TestPHPProvider_Detect_WithPHPFiles(t *testing.T) {
 
 func TestPHPProvider_Detect_WithLaravel(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "composer.json", IsDirectory: false},
@@ -143,7 +136,7 @@ func TestPHPProvider_Detect_WithLaravel(t *testing.T) {
 		{Path: "config/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -155,8 +148,6 @@ func TestPHPProvider_Detect_WithLaravel(t *testing.T) {
 
 func TestPHPProvider_Detect_WithWordPress(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "wp-config.php", IsDirectory: false},
@@ -166,7 +157,7 @@ func TestPHPProvider_Detect_WithWordPress(t *testing.T) {
 		{Path: "wp-load.php", IsDirectory: false}, // Another WordPress file
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -332,8 +323,6 @@ func TestPHPProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestPHPProvider_DetectFramework(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name              string
@@ -401,7 +390,7 @@ func TestPHPProvider_DetectFramework(t *testing.T) {
 				t.Fatalf("failed to write composer.json: %v", err)
 			}
 
-			framework, err := provider.detectFramework(tempDir, gitHandler)
+			framework, err := provider.detectFramework(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectFramework failed: %v", err)
 			}
@@ -415,8 +404,6 @@ func TestPHPProvider_DetectFramework(t *testing.T) {
 
 func TestPHPProvider_DetectPHPVersion(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name            string
@@ -463,7 +450,7 @@ func TestPHPProvider_DetectPHPVersion(t *testing.T) {
 				t.Fatalf("failed to write composer.json: %v", err)
 			}
 
-			version, err := provider.detectPHPVersion(tempDir, gitHandler)
+			version, err := provider.detectPHPVersion(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectPHPVersion failed: %v", err)
 			}
@@ -481,8 +468,6 @@ func TestPHPProvider_DetectPHPVersion(t *testing.T) {
 
 func TestPHPProvider_DetectPHPVersion_FromPHPVersion(t *testing.T) {
 	provider := NewPHPProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with .php-version
 	tempDir, err := os.MkdirTemp("", "php-version-file-test")
@@ -498,7 +483,7 @@ func TestPHPProvider_DetectPHPVersion_FromPHPVersion(t *testing.T) {
 		t.Fatalf("failed to write .php-version: %v", err)
 	}
 
-	version, err := provider.detectPHPVersion(tempDir, gitHandler)
+	version, err := provider.detectPHPVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectPHPVersion failed: %v", err)
 	}
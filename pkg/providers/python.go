@@ -5,7 +5,9 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -28,6 +30,14 @@ func NewPythonProvider() *PythonProvider {
 	}
 }
 
+// Register the Python Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewPythonProvider() },
+	Frameworks:      []string{"Django", "Flask", "FastAPI", "Tornado", "Pyramid", "Bottle", "Sanic", "Quart", "Starlette", "Streamlit", "Dash", "Jupyter"},
+	KeyFiles:        []string{"requirements.txt", "pyproject.toml", "setup.py", "Pipfile", "poetry.lock", "pdm.lock", ".python-version"},
+	PackageManagers: []string{"pip", "poetry", "pipenv", "uv", "pdm"},
+})
+
 // GetName gets Provider name
 func (p *PythonProvider) GetName() string {
 	return p.Name
@@ -39,33 +49,36 @@ func (p *PythonProvider) GetPriority() int {
 }
 
 // Detect detects Python project
-func (p *PythonProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *PythonProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 30, Satisfied: p.HasAnyFile(files, []string{"requirements.txt", "pyproject.toml", "setup.py", "Pipfile"})},
-		{Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.py"})},
-		{Weight: 20, Satisfied: p.HasFile(files, "pdm.lock")}, // PDM lock file gets high weight
-		{Weight: 15, Satisfied: p.HasFile(files, "poetry.lock")},
-		{Weight: 15, Satisfied: p.HasFile(files, "Pipfile.lock")},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{".python-version", "runtime.txt"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"manage.py", "app.py", "main.py"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"__pycache__", "*.pyc"})},
+		{Description: "requirements.txt, pyproject.toml, setup.py or Pipfile", Weight: 30, Satisfied: p.HasAnyFile(files, []string{"requirements.txt", "pyproject.toml", "setup.py", "Pipfile"})},
+		{Description: "*.py", Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.py"})},
+		{Description: "pdm.lock", Weight: 20, Satisfied: p.HasFile(files, "pdm.lock")}, // PDM lock file gets high weight
+		{Description: "poetry.lock", Weight: 15, Satisfied: p.HasFile(files, "poetry.lock")},
+		{Description: "Pipfile.lock", Weight: 15, Satisfied: p.HasFile(files, "Pipfile.lock")},
+		{Description: ".python-version or runtime.txt", Weight: 10, Satisfied: p.HasAnyFile(files, []string{".python-version", "runtime.txt"})},
+		{Description: "manage.py, app.py or main.py", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"manage.py", "app.py", "main.py"})},
+		{Description: "__pycache__ or *.pyc", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"__pycache__", "*.pyc"})},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.3
+	scoring := p.ScoreIndicators(indicators, 0.3)
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect version
-	version, err := p.detectPythonVersion(projectPath, gitHandler)
+	version, err := p.detectPythonVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gitHandler)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +188,7 @@ func (p *PythonProvider) Detect(projectPath string, files []types.FileInfo, gitH
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"python",
@@ -185,7 +198,9 @@ func (p *PythonProvider) Detect(projectPath string, files []types.FileInfo, gitH
 		packageManager,
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // GenerateCommands generates commands for Python project
@@ -311,12 +326,11 @@ func (p *PythonProvider) NeedsNativeCompilation(result *types.DetectResult) bool
 // Helper methods
 
 // detectPythonVersion detects Python version
-func (p *PythonProvider) detectPythonVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (p *PythonProvider) detectPythonVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from .python-version
 	version, err := p.ParseVersionFromText(
-		projectPath,
+		fsys,
 		".python-version",
-		gitHandler,
 		regexp.MustCompile(`^(.+?)(?:\s|$)`),
 	)
 	if err == nil && version != "" {
@@ -325,9 +339,8 @@ func (p *PythonProvider) detectPythonVersion(projectPath string, gitHandler inte
 
 	// Read from runtime.txt (Heroku)
 	version, err = p.ParseVersionFromText(
-		projectPath,
+		fsys,
 		"runtime.txt",
-		gitHandler,
 		regexp.MustCompile(`python-(.+)$`),
 	)
 	if err == nil && version != "" {
@@ -335,7 +348,7 @@ func (p *PythonProvider) detectPythonVersion(projectPath string, gitHandler inte
 	}
 
 	// Read from pyproject.toml
-	pyprojectContent, err := p.SafeReadText(projectPath, "pyproject.toml", gitHandler)
+	pyprojectContent, err := p.SafeReadText(fsys, "pyproject.toml")
 	if err == nil && pyprojectContent != "" {
 		re := regexp.MustCompile(`python\s*=\s*["']([^"']+)["']`)
 		matches := re.FindStringSubmatch(pyprojectContent)
@@ -345,7 +358,7 @@ func (p *PythonProvider) detectPythonVersion(projectPath string, gitHandler inte
 	}
 
 	// Read from Pipfile
-	pipfileContent, err := p.SafeReadText(projectPath, "Pipfile", gitHandler)
+	pipfileContent, err := p.SafeReadText(fsys, "Pipfile")
 	if err == nil && pipfileContent != "" {
 		re := regexp.MustCompile(`python_version\s*=\s*["']([^"']+)["']`)
 		matches := re.FindStringSubmatch(pipfileContent)
@@ -359,7 +372,7 @@ func (p *PythonProvider) detectPythonVersion(projectPath string, gitHandler inte
 }
 
 // detectFramework detects framework
-func (p *PythonProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
+func (p *PythonProvider) detectFramework(fsys fs.FS) (string, error) {
 	frameworkMap := map[string]string{
 		"django":    "Django",
 		"flask":     "Flask",
@@ -376,7 +389,7 @@ func (p *PythonProvider) detectFramework(projectPath string, gitHandler interfac
 	}
 
 	// Check requirements.txt
-	requirements, err := p.SafeReadText(projectPath, "requirements.txt", gitHandler)
+	requirements, err := p.SafeReadText(fsys, "requirements.txt")
 	if err == nil && requirements != "" {
 		requirementsLower := strings.ToLower(requirements)
 		for pkg, framework := range frameworkMap {
@@ -387,7 +400,7 @@ func (p *PythonProvider) detectFramework(projectPath string, gitHandler interfac
 	}
 
 	// Check pyproject.toml
-	pyprojectToml, err := p.SafeReadText(projectPath, "pyproject.toml", gitHandler)
+	pyprojectToml, err := p.SafeReadText(fsys, "pyproject.toml")
 	if err == nil && pyprojectToml != "" {
 		pyprojectLower := strings.ToLower(pyprojectToml)
 		for pkg, framework := range frameworkMap {
@@ -398,7 +411,7 @@ func (p *PythonProvider) detectFramework(projectPath string, gitHandler interfac
 	}
 
 	// Check Pipfile
-	pipfile, err := p.SafeReadText(projectPath, "Pipfile", gitHandler)
+	pipfile, err := p.SafeReadText(fsys, "Pipfile")
 	if err == nil && pipfile != "" {
 		pipfileLower := strings.ToLower(pipfile)
 		for pkg, framework := range frameworkMap {
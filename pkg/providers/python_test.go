@@ -1,12 +1,13 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -28,15 +29,13 @@ func TestNewPythonProvider(t *testing.T) {
 
 func TestPythonProvider_Detect_NoPythonFiles(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
 		{Path: "main.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -48,8 +47,6 @@ func TestPythonProvider_Detect_NoPythonFiles(t *testing.T) {
 
 func TestPythonProvider_Detect_WithRequirements(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with requirements.txt
 	tempDir, err := os.MkdirTemp("", "python-requirements-test")
@@ -75,7 +72,7 @@ gunicorn==21.2.0
 		{Path: "app/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -100,8 +97,6 @@ gunicorn==21.2.0
 
 func TestPythonProvider_Detect_WithPyprojectToml(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with pyproject.toml
 	tempDir, err := os.MkdirTemp("", "python-pyproject-test")
@@ -139,7 +134,7 @@ pytest = "^7.4.0"
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -160,8 +155,6 @@ pytest = "^7.4.0"
 
 func TestPythonProvider_Detect_WithPipfile(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Pipfile", IsDirectory: false},
@@ -169,7 +162,7 @@ func TestPythonProvider_Detect_WithPipfile(t *testing.T) {
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -190,8 +183,6 @@ func TestPythonProvider_Detect_WithPipfile(t *testing.T) {
 
 func TestPythonProvider_Detect_WithSetupPy(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "setup.py", IsDirectory: false},
@@ -199,7 +190,7 @@ func TestPythonProvider_Detect_WithSetupPy(t *testing.T) {
 		{Path: "src/mypackage/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -211,8 +202,6 @@ func TestPythonProvider_Detect_WithSetupPy(t *testing.T) {
 
 func TestPythonProvider_Detect_WithDjango(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "manage.py", IsDirectory: false},
@@ -221,7 +210,7 @@ func TestPythonProvider_Detect_WithDjango(t *testing.T) {
 		{Path: "myproject/settings.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -423,8 +412,6 @@ func TestPythonProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestPythonProvider_DetectFramework(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name                string
@@ -480,7 +467,7 @@ numpy==1.24.0
 				t.Fatalf("failed to write requirements.txt: %v", err)
 			}
 
-			framework, err := provider.detectFramework(tempDir, gitHandler)
+			framework, err := provider.detectFramework(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectFramework failed: %v", err)
 			}
@@ -494,8 +481,6 @@ numpy==1.24.0
 
 func TestPythonProvider_DetectPythonVersion(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name            string
@@ -535,7 +520,7 @@ func TestPythonProvider_DetectPythonVersion(t *testing.T) {
 				t.Fatalf("failed to write %s: %v", tc.fileName, err)
 			}
 
-			version, err := provider.detectPythonVersion(tempDir, gitHandler)
+			version, err := provider.detectPythonVersion(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectPythonVersion failed: %v", err)
 			}
@@ -553,8 +538,6 @@ func TestPythonProvider_DetectPythonVersion(t *testing.T) {
 
 func TestPythonProvider_DetectPythonVersion_FromPyprojectToml(t *testing.T) {
 	provider := NewPythonProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with pyproject.toml
 	tempDir, err := os.MkdirTemp("", "python-pyproject-version-test")
@@ -577,7 +560,7 @@ fastapi = "^0.100.0"
 		t.Fatalf("failed to write pyproject.toml: %v", err)
 	}
 
-	version, err := provider.detectPythonVersion(tempDir, gitHandler)
+	version, err := provider.detectPythonVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectPythonVersion failed: %v", err)
 	}
@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Provider interface definition
+type Provider interface {
+	GetName() string
+	GetLanguage() string
+	GetPriority() int
+	Detect(ctx context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error)
+	GenerateCommands(result *types.DetectResult, options types.CLIOptions) types.Commands
+	GenerateEnvironment(result *types.DetectResult) map[string]string
+	NeedsNativeCompilation(result *types.DetectResult) bool
+}
+
+// Registration describes a built-in Provider.
+// Name, Language and Priority are read from a Provider created by New.
+type Registration struct {
+	// Provider name
+	Name string `json:"name"`
+	// Provider language
+	Language string `json:"language"`
+	// Provider priority (lower number = higher priority)
+	Priority int `json:"priority"`
+	// Frameworks the Provider can report
+	Frameworks []string `json:"frameworks,omitempty"`
+	// Files whose presence indicates the Provider's stack
+	KeyFiles []string `json:"keyFiles,omitempty"`
+	// Package managers and build tools the Provider's commands use
+	PackageManagers []string `json:"packageManagers,omitempty"`
+	// Creates a new Provider instance
+	New func() Provider `json:"-"`
+}
+
+// registrations holds every built-in Provider by name
+var registrations = make(map[string]Registration)
+
+// Register registers a built-in Provider. Providers call it from a package-level
+// variable declaration in their own file, so adding a Provider touches one place.
+// Registering the same name twice panics.
+func Register(registration Registration) Registration {
+	provider := registration.New()
+	registration.Name = provider.GetName()
+	registration.Language = provider.GetLanguage()
+	registration.Priority = provider.GetPriority()
+
+	if _, exists := registrations[registration.Name]; exists {
+		panic(fmt.Sprintf("providers: Provider %s registered twice", registration.Name))
+	}
+	registrations[registration.Name] = registration
+	return registration
+}
+
+// Registered returns every built-in Provider registration sorted by priority, then name
+func Registered() []Registration {
+	list := make([]Registration, 0, len(registrations))
+	for _, registration := range registrations {
+		list = append(list, registration)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Priority != list[j].Priority {
+			return list[i].Priority < list[j].Priority
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Lookup gets the registration of a built-in Provider by name
+func Lookup(name string) (Registration, bool) {
+	registration, exists := registrations[name]
+	return registration, exists
+}
+
+// RegisteredNames returns the names of every built-in Provider in alphabetical order
+func RegisteredNames() []string {
+	names := make([]string, 0, len(registrations))
+	for name := range registrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRegistered creates a new instance of every built-in Provider in priority order
+func NewRegistered() []Provider {
+	list := Registered()
+	instances := make([]Provider, len(list))
+	for i, registration := range list {
+		instances[i] = registration.New()
+	}
+	return instances
+}
@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestRegistered(t *testing.T) {
+	expected := []string{"deno", "go", "java", "node", "php", "python", "ruby", "rust", "shell", "staticfile"}
+	names := RegisteredNames()
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("expected %v, got %v", expected, names)
+			break
+		}
+	}
+
+	list := Registered()
+	if !sort.SliceIsSorted(list, func(i, j int) bool { return list[i].Priority < list[j].Priority }) {
+		t.Error("expected registrations sorted by priority")
+	}
+	for _, registration := range list {
+		provider := registration.New()
+		if registration.Name != provider.GetName() || registration.Language != provider.GetLanguage() || registration.Priority != provider.GetPriority() {
+			t.Errorf("registration %s does not match its Provider", registration.Name)
+		}
+		if len(registration.KeyFiles) == 0 {
+			t.Errorf("expected key files for %s", registration.Name)
+		}
+	}
+
+	instances := NewRegistered()
+	if len(instances) != len(list) || instances[0].GetName() != list[0].Name {
+		t.Errorf("expected one Provider per registration in priority order")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	registration, ok := Lookup("python")
+	if !ok || registration.Language != "python" || len(registration.PackageManagers) == 0 {
+		t.Errorf("unexpected python registration: %+v", registration)
+	}
+	if _, ok := Lookup("elixir"); ok {
+		t.Error("expected no registration for elixir")
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected duplicate registration to panic")
+		}
+	}()
+	Register(Registration{New: func() Provider { return NewNodeProvider() }})
+}
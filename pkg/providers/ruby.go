@@ -5,6 +5,8 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -27,6 +29,14 @@ func NewRubyProvider() *RubyProvider {
 	}
 }
 
+// Register the Ruby Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewRubyProvider() },
+	Frameworks:      []string{"Rails", "Sinatra", "Hanami", "Grape", "Roda", "Cuba", "Padrino", "Jekyll", "Middleman"},
+	KeyFiles:        []string{"Gemfile", "Gemfile.lock", "config.ru", "Rakefile", ".ruby-version"},
+	PackageManagers: []string{"bundle"},
+})
+
 // GetName gets Provider name
 func (p *RubyProvider) GetName() string {
 	return p.Name
@@ -38,7 +48,7 @@ func (p *RubyProvider) GetPriority() int {
 }
 
 // Detect detects Ruby project
-func (p *RubyProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *RubyProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	// Check for Rails-specific files first
 	isRailsProject := p.HasAnyFile(files, []string{
 		"config/application.rb",
@@ -52,32 +62,35 @@ func (p *RubyProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 
 	// Adjust indicators for Rails detection
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 30, Satisfied: p.HasFile(files, "Gemfile")},
-		{Weight: 20, Satisfied: p.HasFile(files, "Gemfile.lock")},
-		{Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.rb"})}, // Higher weight for .rb files
-		{Weight: 15, Satisfied: isRailsProject}, // Higher weight for Rails-specific files
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{".ruby-version", ".rvmrc"})},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"config.ru", "Rakefile"})},
-		{Weight: 10, Satisfied: p.HasFile(files, "config/database.yml")},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"app/", "lib/"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"spec/", "test/"})},
+		{Description: "Gemfile", Weight: 30, Satisfied: p.HasFile(files, "Gemfile")},
+		{Description: "Gemfile.lock", Weight: 20, Satisfied: p.HasFile(files, "Gemfile.lock")},
+		{Description: "*.rb", Weight: 25, Satisfied: p.HasAnyFile(files, []string{"*.rb"})}, // Higher weight for .rb files
+		{Description: "Rails application files", Weight: 15, Satisfied: isRailsProject}, // Higher weight for Rails-specific files
+		{Description: ".ruby-version or .rvmrc", Weight: 10, Satisfied: p.HasAnyFile(files, []string{".ruby-version", ".rvmrc"})},
+		{Description: "config.ru or Rakefile", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"config.ru", "Rakefile"})},
+		{Description: "config/database.yml", Weight: 10, Satisfied: p.HasFile(files, "config/database.yml")},
+		{Description: "app/ or lib/", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"app/", "lib/"})},
+		{Description: "spec/ or test/", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"spec/", "test/"})},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.2 // Lower threshold for projects with only .rb files
+	scoring := p.ScoreIndicators(indicators, 0.2) // Lower threshold for projects with only .rb files
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect version
-	version, err := p.detectRubyVersion(projectPath, gitHandler)
+	version, err := p.detectRubyVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gitHandler)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -85,11 +98,11 @@ func (p *RubyProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 	// Detect Rails-specific features
 	var railsFeatures []string
 	if framework == "Rails" {
-		railsFeatures = p.detectRailsFeatures(projectPath, files, gitHandler)
+		railsFeatures = p.detectRailsFeatures(fsys, files)
 	}
 
 	// Detect asset pipeline
-	assetPipeline := p.detectAssetPipeline(projectPath, gitHandler)
+	assetPipeline := p.detectAssetPipeline(fsys)
 
 	metadata := map[string]interface{}{
 		"hasGemfile":       p.HasFile(files, "Gemfile"),
@@ -183,7 +196,7 @@ func (p *RubyProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"ruby",
@@ -193,16 +206,17 @@ func (p *RubyProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 		"bundler",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectRubyVersion detects Ruby version
-func (p *RubyProvider) detectRubyVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (p *RubyProvider) detectRubyVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from .ruby-version
 	version, err := p.ParseVersionFromText(
-		projectPath,
+		fsys,
 		".ruby-version",
-		gitHandler,
 		regexp.MustCompile(`^(.+?)(?:\s|$)`),
 	)
 	if err == nil && version != "" {
@@ -211,9 +225,8 @@ func (p *RubyProvider) detectRubyVersion(projectPath string, gitHandler interfac
 
 	// Read from .rvmrc
 	version, err = p.ParseVersionFromText(
-		projectPath,
+		fsys,
 		".rvmrc",
-		gitHandler,
 		regexp.MustCompile(`rvm use ([\d\.]+)`),
 	)
 	if err == nil && version != "" {
@@ -221,7 +234,7 @@ func (p *RubyProvider) detectRubyVersion(projectPath string, gitHandler interfac
 	}
 
 	// Read from Gemfile
-	gemfileContent, err := p.SafeReadText(projectPath, "Gemfile", gitHandler)
+	gemfileContent, err := p.SafeReadText(fsys, "Gemfile")
 	if err == nil && gemfileContent != "" {
 		re := regexp.MustCompile(`ruby\s+['"]([^'"]+)['"]`)
 		matches := re.FindStringSubmatch(gemfileContent)
@@ -235,7 +248,7 @@ func (p *RubyProvider) detectRubyVersion(projectPath string, gitHandler interfac
 }
 
 // detectRailsFeatures detects Rails-specific features
-func (p *RubyProvider) detectRailsFeatures(projectPath string, files []types.FileInfo, gitHandler interface{}) []string {
+func (p *RubyProvider) detectRailsFeatures(fsys fs.FS, files []types.FileInfo) []string {
 	var features []string
 
 	// Check for ActiveRecord
@@ -277,9 +290,9 @@ func (p *RubyProvider) detectRailsFeatures(projectPath string, files []types.Fil
 }
 
 // detectAssetPipeline detects which asset pipeline is used
-func (p *RubyProvider) detectAssetPipeline(projectPath string, gitHandler interface{}) string {
+func (p *RubyProvider) detectAssetPipeline(fsys fs.FS) string {
 	// Check for Sprockets
-	gemfileContent, err := p.SafeReadText(projectPath, "Gemfile", gitHandler)
+	gemfileContent, err := p.SafeReadText(fsys, "Gemfile")
 	if err == nil && gemfileContent != "" {
 		if regexp.MustCompile(`(?i)gem\s+['"]sprockets['"]`).MatchString(gemfileContent) {
 			return "Sprockets"
@@ -311,8 +324,8 @@ func (p *RubyProvider) detectAssetPipeline(projectPath string, gitHandler interf
 }
 
 // detectFramework detects framework
-func (p *RubyProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
-	gemfileContent, err := p.SafeReadText(projectPath, "Gemfile", gitHandler)
+func (p *RubyProvider) detectFramework(fsys fs.FS) (string, error) {
+	gemfileContent, err := p.SafeReadText(fsys, "Gemfile")
 	if err != nil {
 		// If file doesn't exist, return empty string instead of error
 		if strings.Contains(err.Error(), "FILE_READ_ERROR") {
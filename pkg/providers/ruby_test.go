@@ -1,12 +1,13 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -28,15 +29,13 @@ func TestNewRubyProvider(t *testing.T) {
 
 func TestRubyProvider_Detect_NoRubyFiles(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -48,8 +47,6 @@ func TestRubyProvider_Detect_NoRubyFiles(t *testing.T) {
 
 func TestRubyProvider_Detect_WithGemfile(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with Gemfile
 	tempDir, err := os.MkdirTemp("", "ruby-gemfile-test")
@@ -84,7 +81,7 @@ end
 		{Path: "config/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -109,8 +106,6 @@ end
 
 func TestRubyProvider_Detect_WithRubyFiles(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "main.rb", IsDirectory: false},
@@ -119,7 +114,7 @@ func TestRubyProvider_Detect_WithRubyFiles(t *testing.T) {
 		{Path: "spec/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -131,8 +126,6 @@ func TestRubyProvider_Detect_WithRubyFiles(t *testing.T) {
 
 func TestRubyProvider_Detect_WithRails(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Gemfile", IsDirectory: false},
@@ -142,7 +135,7 @@ func TestRubyProvider_Detect_WithRails(t *testing.T) {
 		{Path: "config/application.rb", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -154,8 +147,6 @@ func TestRubyProvider_Detect_WithRails(t *testing.T) {
 
 func TestRubyProvider_Detect_WithSinatra(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Gemfile", IsDirectory: false},
@@ -163,7 +154,7 @@ func TestRubyProvider_Detect_WithSinatra(t *testing.T) {
 		{Path: "app.rb", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -359,8 +350,6 @@ func TestRubyProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestRubyProvider_DetectFramework(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name              string
@@ -428,7 +417,7 @@ gem 'httparty'
 				t.Fatalf("failed to write Gemfile: %v", err)
 			}
 
-			framework, err := provider.detectFramework(tempDir, gitHandler)
+			framework, err := provider.detectFramework(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectFramework failed: %v", err)
 			}
@@ -442,8 +431,6 @@ gem 'httparty'
 
 func TestRubyProvider_DetectRubyVersion(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name            string
@@ -483,7 +470,7 @@ func TestRubyProvider_DetectRubyVersion(t *testing.T) {
 				t.Fatalf("failed to write %s: %v", tc.fileName, err)
 			}
 
-			version, err := provider.detectRubyVersion(tempDir, gitHandler)
+			version, err := provider.detectRubyVersion(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectRubyVersion failed: %v", err)
 			}
@@ -501,8 +488,6 @@ func TestRubyProvider_DetectRubyVersion(t *testing.T) {
 
 func TestRubyProvider_DetectRubyVersion_FromGemfile(t *testing.T) {
 	provider := NewRubyProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with Gemfile
 	tempDir, err := os.MkdirTemp("", "ruby-gemfile-version-test")
@@ -523,7 +508,7 @@ gem 'rails', '~> 7.0.0'
 		t.Fatalf("failed to write Gemfile: %v", err)
 	}
 
-	version, err := provider.detectRubyVersion(tempDir, gitHandler)
+	version, err := provider.detectRubyVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectRubyVersion failed: %v", err)
 	}
@@ -5,7 +5,9 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"regexp"
 	"strings"
 
@@ -35,6 +37,14 @@ func NewRustProvider() *RustProvider {
 	}
 }
 
+// Register the Rust Provider
+var _ = Register(Registration{
+	New:             func() Provider { return NewRustProvider() },
+	Frameworks:      []string{"Actix Web", "Axum", "Rocket", "Warp", "Tide", "Hyper", "Leptos", "Yew", "Dioxus", "Tauri"},
+	KeyFiles:        []string{"Cargo.toml", "Cargo.lock", "rust-toolchain"},
+	PackageManagers: []string{"cargo"},
+})
+
 // GetName gets Provider name
 func (p *RustProvider) GetName() string {
 	return p.Name
@@ -46,47 +56,50 @@ func (p *RustProvider) GetPriority() int {
 }
 
 // Detect detects Rust project
-func (p *RustProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *RustProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	// Check for workspace first
 	isWorkspace := p.HasFile(files, "Cargo.toml") // Will check for [workspace] section later
 
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 40, Satisfied: p.HasFile(files, "Cargo.toml")},
-		{Weight: 20, Satisfied: p.HasFile(files, "Cargo.lock")},
-		{Weight: 20, Satisfied: p.HasAnyFile(files, []string{"*.rs"})},
-		{Weight: 15, Satisfied: p.HasFile(files, "Cargo.lock")}, // Higher weight for locked dependencies
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"src/main.rs", "src/lib.rs"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"target/"})},
-		{Weight: 5, Satisfied: p.HasFile(files, "rust-toolchain")},
+		{Description: "Cargo.toml", Weight: 40, Satisfied: p.HasFile(files, "Cargo.toml")},
+		{Description: "Cargo.lock", Weight: 20, Satisfied: p.HasFile(files, "Cargo.lock")},
+		{Description: "*.rs", Weight: 20, Satisfied: p.HasAnyFile(files, []string{"*.rs"})},
+		{Description: "Cargo.lock", Weight: 15, Satisfied: p.HasFile(files, "Cargo.lock")}, // Higher weight for locked dependencies
+		{Description: "src/main.rs or src/lib.rs", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"src/main.rs", "src/lib.rs"})},
+		{Description: "target/", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"target/"})},
+		{Description: "rust-toolchain", Weight: 5, Satisfied: p.HasFile(files, "rust-toolchain")},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.3
+	scoring := p.ScoreIndicators(indicators, 0.3)
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Detect version
-	version, err := p.detectRustVersion(projectPath, gitHandler)
+	version, err := p.detectRustVersion(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect framework
-	framework, err := p.detectFramework(projectPath, gitHandler)
+	framework, err := p.detectFramework(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect workspace and binary targets
-	workspaceInfo, err := p.detectWorkspaceInfo(projectPath, gitHandler)
+	workspaceInfo, err := p.detectWorkspaceInfo(fsys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Detect binary targets
-	binaryTargets, err := p.detectBinaryTargets(projectPath, gitHandler)
+	binaryTargets, err := p.detectBinaryTargets(fsys)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +181,7 @@ func (p *RustProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"rust",
@@ -178,13 +191,15 @@ func (p *RustProvider) Detect(projectPath string, files []types.FileInfo, gitHan
 		"cargo",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectRustVersion detects Rust version
-func (p *RustProvider) detectRustVersion(projectPath string, gitHandler interface{}) (*types.VersionInfo, error) {
+func (p *RustProvider) detectRustVersion(fsys fs.FS) (*types.VersionInfo, error) {
 	// Read from rust-toolchain.toml
-	toolchainToml, err := p.SafeReadText(projectPath, "rust-toolchain.toml", gitHandler)
+	toolchainToml, err := p.SafeReadText(fsys, "rust-toolchain.toml")
 	if err == nil && toolchainToml != "" {
 		re := regexp.MustCompile(`channel\s*=\s*"([^"]+)"`)
 		matches := re.FindStringSubmatch(toolchainToml)
@@ -194,14 +209,14 @@ func (p *RustProvider) detectRustVersion(projectPath string, gitHandler interfac
 	}
 
 	// Read from rust-toolchain
-	toolchain, err := p.SafeReadText(projectPath, "rust-toolchain", gitHandler)
+	toolchain, err := p.SafeReadText(fsys, "rust-toolchain")
 	if err == nil && toolchain != "" {
 		version := strings.TrimSpace(toolchain)
 		return p.CreateVersionInfo(version, "rust-toolchain"), nil
 	}
 
 	// Read MSRV (Minimum Supported Rust Version) from Cargo.toml
-	cargoToml, err := p.SafeReadText(projectPath, "Cargo.toml", gitHandler)
+	cargoToml, err := p.SafeReadText(fsys, "Cargo.toml")
 	if err == nil && cargoToml != "" {
 		re := regexp.MustCompile(`rust-version\s*=\s*"([^"]+)"`)
 		matches := re.FindStringSubmatch(cargoToml)
@@ -215,8 +230,8 @@ func (p *RustProvider) detectRustVersion(projectPath string, gitHandler interfac
 }
 
 // detectWorkspaceInfo detects Cargo workspace information
-func (p *RustProvider) detectWorkspaceInfo(projectPath string, gitHandler interface{}) (*RustWorkspaceInfo, error) {
-	cargoToml, err := p.SafeReadText(projectPath, "Cargo.toml", gitHandler)
+func (p *RustProvider) detectWorkspaceInfo(fsys fs.FS) (*RustWorkspaceInfo, error) {
+	cargoToml, err := p.SafeReadText(fsys, "Cargo.toml")
 	if err != nil || cargoToml == "" {
 		return nil, nil
 	}
@@ -256,8 +271,8 @@ func (p *RustProvider) detectWorkspaceInfo(projectPath string, gitHandler interf
 }
 
 // detectBinaryTargets detects binary targets in Cargo.toml
-func (p *RustProvider) detectBinaryTargets(projectPath string, gitHandler interface{}) ([]string, error) {
-	cargoToml, err := p.SafeReadText(projectPath, "Cargo.toml", gitHandler)
+func (p *RustProvider) detectBinaryTargets(fsys fs.FS) ([]string, error) {
+	cargoToml, err := p.SafeReadText(fsys, "Cargo.toml")
 	if err != nil || cargoToml == "" {
 		return []string{}, nil
 	}
@@ -286,8 +301,8 @@ func (p *RustProvider) detectBinaryTargets(projectPath string, gitHandler interf
 }
 
 // detectFramework detects framework
-func (p *RustProvider) detectFramework(projectPath string, gitHandler interface{}) (string, error) {
-	cargoToml, err := p.SafeReadText(projectPath, "Cargo.toml", gitHandler)
+func (p *RustProvider) detectFramework(fsys fs.FS) (string, error) {
+	cargoToml, err := p.SafeReadText(fsys, "Cargo.toml")
 	if err != nil || cargoToml == "" {
 		return "", nil
 	}
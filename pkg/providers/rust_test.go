@@ -1,12 +1,13 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -28,15 +29,13 @@ func TestNewRustProvider(t *testing.T) {
 
 func TestRustProvider_Detect_NoRustFiles(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
 		{Path: "main.py", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -48,8 +47,6 @@ func TestRustProvider_Detect_NoRustFiles(t *testing.T) {
 
 func TestRustProvider_Detect_WithCargoToml(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with Cargo.toml
 	tempDir, err := os.MkdirTemp("", "rust-cargo-test")
@@ -79,7 +76,7 @@ tokio = { version = "1.0", features = ["full"] }
 		{Path: "src/main.rs", IsDirectory: false},
 	}
 
-	result, err := provider.Detect(tempDir, files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(tempDir), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -104,8 +101,6 @@ tokio = { version = "1.0", features = ["full"] }
 
 func TestRustProvider_Detect_WithRustFiles(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "main.rs", IsDirectory: false},
@@ -116,7 +111,7 @@ func TestRustProvider_Detect_WithRustFiles(t *testing.T) {
 		{Path: "Cargo.toml", IsDirectory: false},  // Add Cargo.toml to ensure detection
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -128,8 +123,6 @@ func TestRustProvider_Detect_WithRustFiles(t *testing.T) {
 
 func TestRustProvider_Detect_WithCargoLock(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Cargo.toml", IsDirectory: false},
@@ -137,7 +130,7 @@ func TestRustProvider_Detect_WithCargoLock(t *testing.T) {
 		{Path: "src/main.rs", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -149,8 +142,6 @@ func TestRustProvider_Detect_WithCargoLock(t *testing.T) {
 
 func TestRustProvider_Detect_WithTargetDirectory(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Cargo.toml", IsDirectory: false},
@@ -159,7 +150,7 @@ func TestRustProvider_Detect_WithTargetDirectory(t *testing.T) {
 		{Path: "target/debug/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -358,8 +349,6 @@ func TestRustProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestRustProvider_DetectFramework(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name              string
@@ -461,7 +450,7 @@ serde = { version = "1.0", features = ["derive"] }
 				t.Fatalf("failed to write Cargo.toml: %v", err)
 			}
 
-			framework, err := provider.detectFramework(tempDir, gitHandler)
+			framework, err := provider.detectFramework(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectFramework failed: %v", err)
 			}
@@ -475,8 +464,6 @@ serde = { version = "1.0", features = ["derive"] }
 
 func TestRustProvider_DetectRustVersion(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	testCases := []struct {
 		name            string
@@ -516,7 +503,7 @@ func TestRustProvider_DetectRustVersion(t *testing.T) {
 				t.Fatalf("failed to write %s: %v", tc.fileName, err)
 			}
 
-			version, err := provider.detectRustVersion(tempDir, gitHandler)
+			version, err := provider.detectRustVersion(source.Dir(tempDir))
 			if err != nil {
 				t.Fatalf("detectRustVersion failed: %v", err)
 			}
@@ -534,8 +521,6 @@ func TestRustProvider_DetectRustVersion(t *testing.T) {
 
 func TestRustProvider_DetectRustVersion_FromCargoToml(t *testing.T) {
 	provider := NewRustProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Create temporary directory with Cargo.toml
 	tempDir, err := os.MkdirTemp("", "rust-cargo-version-test")
@@ -559,7 +544,7 @@ serde = "1.0"
 		t.Fatalf("failed to write Cargo.toml: %v", err)
 	}
 
-	version, err := provider.detectRustVersion(tempDir, gitHandler)
+	version, err := provider.detectRustVersion(source.Dir(tempDir))
 	if err != nil {
 		t.Fatalf("detectRustVersion failed: %v", err)
 	}
@@ -5,6 +5,9 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
+
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -24,6 +27,12 @@ func NewShellProvider() *ShellProvider {
 	}
 }
 
+// Register the Shell Provider
+var _ = Register(Registration{
+	New:      func() Provider { return NewShellProvider() },
+	KeyFiles: []string{"*.sh", "Makefile", "install.sh", "setup.sh", "build.sh"},
+})
+
 // GetName gets Provider name
 func (p *ShellProvider) GetName() string {
 	return p.Name
@@ -35,21 +44,24 @@ func (p *ShellProvider) GetPriority() int {
 }
 
 // Detect detects Shell project
-func (p *ShellProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *ShellProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 30, Satisfied: p.HasAnyFile(files, []string{"*.sh", "*.bash", "*.zsh"})},
-		{Weight: 20, Satisfied: p.HasAnyFile(files, []string{"Makefile", "makefile"})},
-		{Weight: 15, Satisfied: p.HasAnyFile(files, []string{"*.fish", "*.csh", "*.ksh"})},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"install.sh", "setup.sh", "build.sh"})},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"bin/", "scripts/"})},
-		{Weight: 5, Satisfied: p.HasFile(files, "README.md")},
+		{Description: "*.sh, *.bash or *.zsh", Weight: 30, Satisfied: p.HasAnyFile(files, []string{"*.sh", "*.bash", "*.zsh"})},
+		{Description: "Makefile or makefile", Weight: 20, Satisfied: p.HasAnyFile(files, []string{"Makefile", "makefile"})},
+		{Description: "*.fish, *.csh or *.ksh", Weight: 15, Satisfied: p.HasAnyFile(files, []string{"*.fish", "*.csh", "*.ksh"})},
+		{Description: "install.sh, setup.sh or build.sh", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"install.sh", "setup.sh", "build.sh"})},
+		{Description: "bin/ or scripts/", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"bin/", "scripts/"})},
+		{Description: "README.md", Weight: 5, Satisfied: p.HasFile(files, "README.md")},
 	}
 
-	confidence := p.CalculateConfidence(indicators)
-	detected := confidence > 0.3
+	scoring := p.ScoreIndicators(indicators, 0.3)
+	confidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Shell projects usually don't have specific versions
@@ -101,7 +113,7 @@ func (p *ShellProvider) Detect(projectPath string, files []types.FileInfo, gitHa
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		confidence,
 		"shell",
@@ -111,7 +123,9 @@ func (p *ShellProvider) Detect(projectPath string, files []types.FileInfo, gitHa
 		"",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // detectShellType detects Shell type
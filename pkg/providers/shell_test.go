@@ -1,10 +1,11 @@
 package providers
 
 import (
+	"context"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -26,8 +27,6 @@ func TestNewShellProvider(t *testing.T) {
 
 func TestShellProvider_Detect_NoShellFiles(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
@@ -35,7 +34,7 @@ func TestShellProvider_Detect_NoShellFiles(t *testing.T) {
 		{Path: "package.json", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -47,8 +46,6 @@ func TestShellProvider_Detect_NoShellFiles(t *testing.T) {
 
 func TestShellProvider_Detect_WithShellFiles(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "install.sh", IsDirectory: false},
@@ -57,7 +54,7 @@ func TestShellProvider_Detect_WithShellFiles(t *testing.T) {
 		{Path: "scripts/build.sh", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -73,8 +70,6 @@ func TestShellProvider_Detect_WithShellFiles(t *testing.T) {
 
 func TestShellProvider_Detect_WithBashFiles(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "script.bash", IsDirectory: false},
@@ -82,7 +77,7 @@ func TestShellProvider_Detect_WithBashFiles(t *testing.T) {
 		{Path: "bin/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -94,15 +89,13 @@ func TestShellProvider_Detect_WithBashFiles(t *testing.T) {
 
 func TestShellProvider_Detect_WithZshFiles(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "config.zsh", IsDirectory: false},
 		{Path: "functions.zsh", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -114,8 +107,6 @@ func TestShellProvider_Detect_WithZshFiles(t *testing.T) {
 
 func TestShellProvider_Detect_WithMakefile(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Makefile", IsDirectory: false},
@@ -124,7 +115,7 @@ func TestShellProvider_Detect_WithMakefile(t *testing.T) {
 		{Path: "README.md", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -136,8 +127,6 @@ func TestShellProvider_Detect_WithMakefile(t *testing.T) {
 
 func TestShellProvider_Detect_WithFishFiles(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "config.fish", IsDirectory: false},
@@ -146,7 +135,7 @@ func TestShellProvider_Detect_WithFishFiles(t *testing.T) {
 		{Path: "README.md", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -158,8 +147,6 @@ func TestShellProvider_Detect_WithFishFiles(t *testing.T) {
 
 func TestShellProvider_Detect_WithCommonScripts(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "install.sh", IsDirectory: false},
@@ -167,7 +154,7 @@ func TestShellProvider_Detect_WithCommonScripts(t *testing.T) {
 		{Path: "build.sh", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -179,8 +166,6 @@ func TestShellProvider_Detect_WithCommonScripts(t *testing.T) {
 
 func TestShellProvider_Detect_WithScriptsDirectory(t *testing.T) {
 	provider := NewShellProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "scripts/", IsDirectory: true},
@@ -189,7 +174,7 @@ func TestShellProvider_Detect_WithScriptsDirectory(t *testing.T) {
 		{Path: "bin/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
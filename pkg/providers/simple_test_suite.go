@@ -1,9 +1,10 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -35,8 +36,10 @@ func (suite *SimpleTestSuite) RunDetectionTest(t *testing.T, provider any, files
 
 		// For now, we'll use a type assertion approach
 		switch p := provider.(type) {
-		case interface{ Detect(string, []types.FileInfo, *git.GitHandler) (*types.DetectResult, error) }:
-			result, err := p.Detect(helper.TempDir, files, helper.GitHandler)
+		case interface {
+			Detect(context.Context, fs.FS, []types.FileInfo) (*types.DetectResult, error)
+		}:
+			result, err := p.Detect(context.Background(), helper.FS(), files)
 			if err != nil {
 				t.Fatalf("Detect failed: %v", err)
 			}
@@ -1,6 +1,9 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
+
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -20,6 +23,12 @@ func NewStaticFileProvider() *StaticFileProvider {
 	}
 }
 
+// Register the static file Provider
+var _ = Register(Registration{
+	New:      func() Provider { return NewStaticFileProvider() },
+	KeyFiles: []string{"index.html", "Staticfile", "*.html"},
+})
+
 // GetName gets Provider name
 func (p *StaticFileProvider) GetName() string {
 	return p.Name
@@ -31,16 +40,16 @@ func (p *StaticFileProvider) GetPriority() int {
 }
 
 // Detect detects static file project
-func (p *StaticFileProvider) Detect(projectPath string, files []types.FileInfo, gitHandler interface{}) (*types.DetectResult, error) {
+func (p *StaticFileProvider) Detect(_ context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
 	indicators := []types.ConfidenceIndicator{
-		{Weight: 30, Satisfied: p.HasAnyFile(files, []string{"*.html", "*.htm"})},
-		{Weight: 20, Satisfied: p.HasAnyFile(files, []string{"*.css"})},
-		{Weight: 15, Satisfied: p.HasAnyFile(files, []string{"*.js"})},
-		{Weight: 10, Satisfied: p.HasAnyFile(files, []string{"*.png", "*.jpg", "*.jpeg", "*.gif", "*.svg"})},
-		{Weight: 25, Satisfied: p.HasFile(files, "index.html")}, // Increase weight for index.html
-		{Weight: 15, Satisfied: p.HasFile(files, "Staticfile")}, // Add support for Staticfile
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"assets/", "static/", "public/"})},
-		{Weight: 5, Satisfied: p.HasAnyFile(files, []string{"favicon.ico", "robots.txt"})},
+		{Description: "*.html or *.htm", Weight: 30, Satisfied: p.HasAnyFile(files, []string{"*.html", "*.htm"})},
+		{Description: "*.css", Weight: 20, Satisfied: p.HasAnyFile(files, []string{"*.css"})},
+		{Description: "*.js", Weight: 15, Satisfied: p.HasAnyFile(files, []string{"*.js"})},
+		{Description: "*.png, *.jpg, *.jpeg, *.gif or *.svg", Weight: 10, Satisfied: p.HasAnyFile(files, []string{"*.png", "*.jpg", "*.jpeg", "*.gif", "*.svg"})},
+		{Description: "index.html", Weight: 25, Satisfied: p.HasFile(files, "index.html")}, // Increase weight for index.html
+		{Description: "Staticfile", Weight: 15, Satisfied: p.HasFile(files, "Staticfile")}, // Add support for Staticfile
+		{Description: "assets/, static/ or public/", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"assets/", "static/", "public/"})},
+		{Description: "favicon.ico or robots.txt", Weight: 5, Satisfied: p.HasAnyFile(files, []string{"favicon.ico", "robots.txt"})},
 	}
 
 	// If there are project files from other languages, reduce static file confidence
@@ -50,7 +59,7 @@ func (p *StaticFileProvider) Detect(projectPath string, files []types.FileInfo,
 		p.HasAnyFile(files, []string{"*.py", "*.java", "*.go", "*.rs", "*.rb", "*.php", "*.ex", "*.exs"}),
 	}
 
-	confidence := p.CalculateConfidence(indicators)
+	scoring := p.ScoreIndicators(indicators, 0.2) // Lower detection threshold
 	hasExclusions := false
 	for _, exclusion := range exclusions {
 		if exclusion {
@@ -59,35 +68,27 @@ func (p *StaticFileProvider) Detect(projectPath string, files []types.FileInfo,
 		}
 	}
 
-	// If there are characteristic files from other languages, significantly reduce confidence
-	adjustedConfidence := confidence
-	if hasExclusions {
-		adjustedConfidence = confidence * 0.3
-	}
-
 	// For pure static file projects (only HTML/CSS/JS/images), should not be excluded
 	// test.json is a test configuration file and should not affect static file detection
 	isPureStaticFile := p.HasAnyFile(files, []string{"*.html", "*.htm"}) &&
 		!p.HasAnyFile(files, []string{"package.json", "composer.json", "requirements.txt", "go.mod", "Cargo.toml", "Gemfile", "pom.xml", "build.gradle", "*.py", "*.java", "*.go", "*.rs", "*.rb", "*.php"})
 
-	if isPureStaticFile {
-		adjustedConfidence = confidence // Do not reduce confidence
-	}
-
 	// Special handling: if only index.html and test.json exist, should be recognized as static file project
-	if len(files) == 2 && p.HasFile(files, "index.html") && p.HasFile(files, "test.json") {
-		adjustedConfidence = confidence
-	}
+	isIndexWithTestConfig := len(files) == 2 && p.HasFile(files, "index.html") && p.HasFile(files, "test.json")
 
-	// Special handling: if there is a Staticfile configuration file, should be recognized as static file project
-	if p.HasFile(files, "Staticfile") {
-		adjustedConfidence = confidence
+	// If there are characteristic files from other languages, significantly reduce confidence,
+	// unless a Staticfile configuration file marks the project as static
+	if hasExclusions && !isPureStaticFile && !isIndexWithTestConfig && !p.HasFile(files, "Staticfile") {
+		scoring.Adjust("project files of another language present", 0.3)
 	}
 
-	detected := adjustedConfidence > 0.2 // Lower detection threshold
+	adjustedConfidence := scoring.Confidence
+	detected := scoring.Matched()
 
 	if !detected {
-		return p.CreateDetectResult(false, adjustedConfidence, "", nil, "", "", "", nil, types.Evidence{}), nil
+		result := p.CreateDetectResult(false, adjustedConfidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
 	}
 
 	// Static file projects usually don't have specific versions
@@ -162,7 +163,7 @@ func (p *StaticFileProvider) Detect(projectPath string, files []types.FileInfo,
 	}
 	evidence.Reason = reason
 
-	return p.CreateDetectResult(
+	result := p.CreateDetectResult(
 		true,
 		adjustedConfidence,
 		"staticfile",
@@ -172,7 +173,9 @@ func (p *StaticFileProvider) Detect(projectPath string, files []types.FileInfo,
 		"",
 		metadata,
 		evidence,
-	), nil
+	)
+	result.Scoring = scoring
+	return result, nil
 }
 
 // GenerateCommands generates commands for static file project
@@ -1,10 +1,11 @@
 package providers
 
 import (
+	"context"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -26,8 +27,6 @@ func TestNewStaticFileProvider(t *testing.T) {
 
 func TestStaticFileProvider_Detect_NoStaticFiles(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "README.md", IsDirectory: false},
@@ -35,7 +34,7 @@ func TestStaticFileProvider_Detect_NoStaticFiles(t *testing.T) {
 		{Path: "package.json", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -47,8 +46,6 @@ func TestStaticFileProvider_Detect_NoStaticFiles(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithHTMLFiles(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.html", IsDirectory: false},
@@ -57,7 +54,7 @@ func TestStaticFileProvider_Detect_WithHTMLFiles(t *testing.T) {
 		{Path: "script.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -73,8 +70,6 @@ func TestStaticFileProvider_Detect_WithHTMLFiles(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithIndexHTML(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.html", IsDirectory: false},
@@ -82,7 +77,7 @@ func TestStaticFileProvider_Detect_WithIndexHTML(t *testing.T) {
 		{Path: "assets/style.css", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -94,8 +89,6 @@ func TestStaticFileProvider_Detect_WithIndexHTML(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithStaticfile(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "Staticfile", IsDirectory: false},
@@ -103,7 +96,7 @@ func TestStaticFileProvider_Detect_WithStaticfile(t *testing.T) {
 		{Path: "public/index.html", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -115,8 +108,6 @@ func TestStaticFileProvider_Detect_WithStaticfile(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithCSSAndJS(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "styles.css", IsDirectory: false},
@@ -125,7 +116,7 @@ func TestStaticFileProvider_Detect_WithCSSAndJS(t *testing.T) {
 		{Path: "utils.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -137,8 +128,6 @@ func TestStaticFileProvider_Detect_WithCSSAndJS(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithImages(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.html", IsDirectory: false},
@@ -147,7 +136,7 @@ func TestStaticFileProvider_Detect_WithImages(t *testing.T) {
 		{Path: "icon.svg", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -159,8 +148,6 @@ func TestStaticFileProvider_Detect_WithImages(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithAssetsDirectory(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.html", IsDirectory: false},
@@ -170,7 +157,7 @@ func TestStaticFileProvider_Detect_WithAssetsDirectory(t *testing.T) {
 		{Path: "assets/images/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -182,8 +169,6 @@ func TestStaticFileProvider_Detect_WithAssetsDirectory(t *testing.T) {
 
 func TestStaticFileProvider_Detect_WithCommonFiles(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.html", IsDirectory: false},
@@ -192,7 +177,7 @@ func TestStaticFileProvider_Detect_WithCommonFiles(t *testing.T) {
 		{Path: "sitemap.xml", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -204,8 +189,6 @@ func TestStaticFileProvider_Detect_WithCommonFiles(t *testing.T) {
 
 func TestStaticFileProvider_Detect_ExcludeOtherLanguages(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Test with Node.js project that has HTML files
 	files := []types.FileInfo{
@@ -216,7 +199,7 @@ func TestStaticFileProvider_Detect_ExcludeOtherLanguages(t *testing.T) {
 		{Path: "src/app.js", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -229,8 +212,6 @@ func TestStaticFileProvider_Detect_ExcludeOtherLanguages(t *testing.T) {
 
 func TestStaticFileProvider_Detect_ExcludePythonProject(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	// Test with Python project that has HTML templates
 	files := []types.FileInfo{
@@ -242,7 +223,7 @@ func TestStaticFileProvider_Detect_ExcludePythonProject(t *testing.T) {
 		{Path: "static/style.css", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -350,8 +331,6 @@ func TestStaticFileProvider_NeedsNativeCompilation(t *testing.T) {
 
 func TestStaticFileProvider_Detect_HTMExtension(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.htm", IsDirectory: false},
@@ -359,7 +338,7 @@ func TestStaticFileProvider_Detect_HTMExtension(t *testing.T) {
 		{Path: "contact.html", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -371,8 +350,6 @@ func TestStaticFileProvider_Detect_HTMExtension(t *testing.T) {
 
 func TestStaticFileProvider_Detect_PublicDirectory(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "public/", IsDirectory: true},
@@ -382,7 +359,7 @@ func TestStaticFileProvider_Detect_PublicDirectory(t *testing.T) {
 		{Path: "static/", IsDirectory: true},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
@@ -394,8 +371,6 @@ func TestStaticFileProvider_Detect_PublicDirectory(t *testing.T) {
 
 func TestStaticFileProvider_Detect_ImageFormats(t *testing.T) {
 	provider := NewStaticFileProvider()
-	gitHandler := git.NewGitHandler()
-	defer gitHandler.Cleanup()
 
 	files := []types.FileInfo{
 		{Path: "index.html", IsDirectory: false},
@@ -405,7 +380,7 @@ func TestStaticFileProvider_Detect_ImageFormats(t *testing.T) {
 		{Path: "vector.svg", IsDirectory: false},
 	}
 
-	result, err := provider.Detect("", files, gitHandler)
+	result, err := provider.Detect(context.Background(), source.Dir(""), files)
 	if err != nil {
 		t.Fatalf("Detect failed: %v", err)
 	}
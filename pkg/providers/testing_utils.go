@@ -1,23 +1,24 @@
 package providers
 
 import (
+	"context"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
-	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
 // TestHelper provides common utilities for provider tests
 type TestHelper struct {
-	T         *testing.T
-	GitHandler *git.GitHandler
-	TempDir   string
+	T       *testing.T
+	TempDir string
 }
 
-// NewTestHelper creates a new test helper with temporary directory and git handler
+// NewTestHelper creates a new test helper with a temporary directory
 func NewTestHelper(t *testing.T) *TestHelper {
 	tempDir, err := os.MkdirTemp("", "provider-test-*")
 	if err != nil {
@@ -25,17 +26,18 @@ func NewTestHelper(t *testing.T) *TestHelper {
 	}
 
 	return &TestHelper{
-		T:         t,
-		GitHandler: git.NewGitHandler(),
-		TempDir:   tempDir,
+		T:       t,
+		TempDir: tempDir,
 	}
 }
 
+// FS returns the temporary directory as a detection source
+func (h *TestHelper) FS() fs.FS {
+	return source.Dir(h.TempDir)
+}
+
 // Cleanup cleans up temporary resources
 func (h *TestHelper) Cleanup() {
-	if h.GitHandler != nil {
-		h.GitHandler.Cleanup()
-	}
 	if h.TempDir != "" {
 		os.RemoveAll(h.TempDir)
 	}
@@ -157,13 +159,15 @@ type ProviderTestCase struct {
 }
 
 // RunProviderTestCases runs multiple provider test cases
-func RunProviderTestCases(t *testing.T, provider interface{ Detect(string, []types.FileInfo, *git.GitHandler) (*types.DetectResult, error) }, testCases []ProviderTestCase) {
+func RunProviderTestCases(t *testing.T, provider interface {
+	Detect(context.Context, fs.FS, []types.FileInfo) (*types.DetectResult, error)
+}, testCases []ProviderTestCase) {
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
 			helper := NewTestHelper(t)
 			defer helper.Cleanup()
 
-			result, err := provider.Detect(helper.TempDir, tc.Files, helper.GitHandler)
+			result, err := provider.Detect(context.Background(), helper.FS(), tc.Files)
 			if err != nil {
 				t.Fatalf("Detect failed: %v", err)
 			}
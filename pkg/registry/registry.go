@@ -21,19 +21,16 @@ func NewProviderRegistry() *ProviderRegistry {
 	return registry
 }
 
-// initializeProviders initializes all Providers
+// initializeProviders initializes every registered built-in Provider
 func (r *ProviderRegistry) initializeProviders() {
-	// Register providers for various languages
-	r.providers["node"] = providers.NewNodeProvider()
-	r.providers["python"] = providers.NewPythonProvider()
-	r.providers["java"] = providers.NewJavaProvider()
-	r.providers["go"] = providers.NewGoProvider()
-	r.providers["php"] = providers.NewPHPProvider()
-	r.providers["ruby"] = providers.NewRubyProvider()
-	r.providers["rust"] = providers.NewRustProvider()
-	r.providers["deno"] = providers.NewDenoProvider()
-	r.providers["shell"] = providers.NewShellProvider()
-	r.providers["staticfile"] = providers.NewStaticFileProvider()
+	for _, provider := range providers.NewRegistered() {
+		r.providers[provider.GetName()] = provider
+	}
+}
+
+// RegisterProvider adds a Provider to the registry, replacing any Provider of the same name
+func (r *ProviderRegistry) RegisterProvider(provider detector.Provider) {
+	r.providers[provider.GetName()] = provider
 }
 
 // GetProvider gets Provider by name
@@ -0,0 +1,344 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// manifestParsers extract dependency names from the manifests known to rules, keyed by file name
+var manifestParsers = map[string]func(content string) []string{
+	"package.json":     jsonKeys("dependencies", "devDependencies", "peerDependencies", "optionalDependencies"),
+	"composer.json":    jsonKeys("require", "require-dev"),
+	"requirements.txt": requirementsDependencies,
+	"pyproject.toml":   pyprojectDependencies,
+	"Pipfile":          tomlKeys("packages", "dev-packages"),
+	"go.mod":           goModDependencies,
+	"Gemfile":          gemfileDependencies,
+	"Cargo.toml":       tomlKeys("dependencies", "dev-dependencies", "build-dependencies"),
+	"pom.xml":          pomDependencies,
+	"build.gradle":     gradleDependencies,
+	"build.gradle.kts": gradleDependencies,
+}
+
+var (
+	requirementNamePattern  = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+	goRequirePattern        = regexp.MustCompile(`^(?:require\s+)?([^\s()]+)\s+v\S+`)
+	gemPattern              = regexp.MustCompile(`^\s*gem\s+['"]([^'"]+)['"]`)
+	artifactPattern         = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+	gradleCoordinatePattern = regexp.MustCompile(`['"]([\w.-]+):([\w.-]+)(?::[^'"]*)?['"]`)
+)
+
+// Dependencies returns the dependency names declared by the manifests rules understand
+// that are present in the root of fsys, keyed by manifest file name
+func Dependencies(fsys fs.FS) map[string][]string {
+	dependencies := make(map[string][]string)
+	for name, parse := range manifestParsers {
+		content, err := source.ReadText(fsys, name)
+		if err != nil || content == "" {
+			continue
+		}
+		if declared := parse(content); len(declared) > 0 {
+			dependencies[name] = declared
+		}
+	}
+	return dependencies
+}
+
+// project evaluates matchers against one project, caching file reads
+type project struct {
+	fsys  fs.FS
+	files []types.FileInfo
+	base  *providers.BaseProvider
+
+	contents     map[string]string
+	dependencies map[string][]string
+}
+
+// matches reports whether every condition of m holds and which files satisfied it
+func (p *project) matches(m matcher) (bool, []string) {
+	var matchedFiles []string
+
+	if m.File != "" {
+		for _, file := range p.base.GetMatchingFiles(p.files, m.File) {
+			if m.pattern == nil || m.pattern.MatchString(p.read(file.Path)) {
+				matchedFiles = append(matchedFiles, file.Path)
+			}
+		}
+		if len(matchedFiles) == 0 {
+			return false, nil
+		}
+	}
+
+	if m.Dependency != "" {
+		manifest, found := p.findDependency(m.Dependency, m.Manifest)
+		if !found {
+			return false, nil
+		}
+		matchedFiles = append(matchedFiles, manifest)
+	}
+
+	return true, matchedFiles
+}
+
+// findDependency looks for a dependency in manifest, or in every known manifest
+// present in the project root, and returns the manifest declaring it
+func (p *project) findDependency(dependency, manifest string) (string, bool) {
+	manifests := []string{manifest}
+	if manifest == "" {
+		manifests = nil
+		for name := range manifestParsers {
+			if p.base.HasFile(p.files, name) {
+				manifests = append(manifests, name)
+			}
+		}
+		sort.Strings(manifests)
+	}
+
+	dependency = strings.ToLower(dependency)
+	for _, name := range manifests {
+		for _, declared := range p.manifestDependencies(name) {
+			declared = strings.ToLower(declared)
+			if declared == dependency {
+				return name, true
+			}
+			if matched, _ := path.Match(dependency, declared); matched {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// manifestDependencies parses a manifest once, choosing the parser by file name
+func (p *project) manifestDependencies(manifest string) []string {
+	if dependencies, ok := p.dependencies[manifest]; ok {
+		return dependencies
+	}
+	if p.dependencies == nil {
+		p.dependencies = make(map[string][]string)
+	}
+
+	var dependencies []string
+	if parse, ok := manifestParsers[path.Base(manifest)]; ok {
+		if content := p.read(manifest); content != "" {
+			dependencies = parse(content)
+		}
+	}
+	p.dependencies[manifest] = dependencies
+	return dependencies
+}
+
+// read returns file content, or "" when the file cannot be read
+func (p *project) read(fileName string) string {
+	if content, ok := p.contents[fileName]; ok {
+		return content
+	}
+	if p.contents == nil {
+		p.contents = make(map[string]string)
+	}
+	content, err := source.ReadText(p.fsys, fileName)
+	if err != nil {
+		content = ""
+	}
+	p.contents[fileName] = content
+	return content
+}
+
+// readVersion reads a version from a version source, "" when not found
+func (p *project) readVersion(versionSource VersionSource, pattern *regexp.Regexp) string {
+	content := p.read(versionSource.File)
+	if content == "" {
+		return ""
+	}
+
+	switch {
+	case versionSource.Field != "":
+		document := decodeDocument(versionSource.File, content)
+		value := lookupField(document, versionSource.Field)
+		if value == nil {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprint(value))
+	case pattern != nil:
+		if matches := pattern.FindStringSubmatch(content); len(matches) > 1 {
+			return strings.TrimSpace(matches[1])
+		}
+		return ""
+	default:
+		firstLine, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+		return strings.TrimSpace(firstLine)
+	}
+}
+
+// decodeDocument decodes JSON, TOML or YAML content by file extension
+func decodeDocument(fileName, content string) map[string]interface{} {
+	var document map[string]interface{}
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".toml":
+		document, _ = markup.ParseTOML(content)
+	case ".yaml", ".yml":
+		document, _ = markup.ParseYAML(content)
+	default:
+		_ = json.Unmarshal(markup.StripJSONC([]byte(content)), &document)
+	}
+	return document
+}
+
+// lookupField follows a dotted field path through nested maps
+func lookupField(document map[string]interface{}, field string) interface{} {
+	var current interface{} = document
+	for _, part := range strings.Split(field, ".") {
+		table, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = table[part]
+	}
+	return current
+}
+
+// jsonKeys returns a parser listing the keys of the given top-level JSON objects
+func jsonKeys(fields ...string) func(string) []string {
+	return func(content string) []string {
+		var document map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &document); err != nil {
+			return nil
+		}
+		return tableKeys(document, fields)
+	}
+}
+
+// tomlKeys returns a parser listing the keys of the given top-level TOML tables
+func tomlKeys(fields ...string) func(string) []string {
+	return func(content string) []string {
+		document, err := markup.ParseTOML(content)
+		if err != nil {
+			return nil
+		}
+		return tableKeys(document, fields)
+	}
+}
+
+// tableKeys lists the keys of the given tables in document
+func tableKeys(document map[string]interface{}, fields []string) []string {
+	var keys []string
+	for _, field := range fields {
+		if table, ok := lookupField(document, field).(map[string]interface{}); ok {
+			for key := range table {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// requirementsDependencies parses requirements.txt
+func requirementsDependencies(content string) []string {
+	var dependencies []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if matches := requirementNamePattern.FindStringSubmatch(line); len(matches) > 1 {
+			dependencies = append(dependencies, matches[1])
+		}
+	}
+	return dependencies
+}
+
+// pyprojectDependencies parses PEP 621 and Poetry dependencies from pyproject.toml
+func pyprojectDependencies(content string) []string {
+	document, err := markup.ParseTOML(content)
+	if err != nil {
+		return nil
+	}
+
+	var requirements []interface{}
+	if list, ok := lookupField(document, "project.dependencies").([]interface{}); ok {
+		requirements = append(requirements, list...)
+	}
+	if optional, ok := lookupField(document, "project.optional-dependencies").(map[string]interface{}); ok {
+		for _, group := range optional {
+			if list, ok := group.([]interface{}); ok {
+				requirements = append(requirements, list...)
+			}
+		}
+	}
+
+	var dependencies []string
+	for _, requirement := range requirements {
+		if text, ok := requirement.(string); ok {
+			if matches := requirementNamePattern.FindStringSubmatch(strings.TrimSpace(text)); len(matches) > 1 {
+				dependencies = append(dependencies, matches[1])
+			}
+		}
+	}
+
+	dependencies = append(dependencies, tableKeys(document, []string{"tool.poetry.dependencies", "tool.poetry.dev-dependencies"})...)
+	if groups, ok := lookupField(document, "tool.poetry.group").(map[string]interface{}); ok {
+		for name := range groups {
+			dependencies = append(dependencies, tableKeys(groups, []string{name + ".dependencies"})...)
+		}
+	}
+	return dependencies
+}
+
+// goModDependencies parses require directives from go.mod
+func goModDependencies(content string) []string {
+	var dependencies []string
+	inRequireBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "require ("):
+			inRequireBlock = true
+			continue
+		case inRequireBlock && line == ")":
+			inRequireBlock = false
+			continue
+		case !inRequireBlock && !strings.HasPrefix(line, "require "):
+			continue
+		}
+		if matches := goRequirePattern.FindStringSubmatch(line); len(matches) > 1 {
+			dependencies = append(dependencies, matches[1])
+		}
+	}
+	return dependencies
+}
+
+// gemfileDependencies parses gem declarations from a Gemfile
+func gemfileDependencies(content string) []string {
+	var dependencies []string
+	for _, line := range strings.Split(content, "\n") {
+		if matches := gemPattern.FindStringSubmatch(line); len(matches) > 1 {
+			dependencies = append(dependencies, matches[1])
+		}
+	}
+	return dependencies
+}
+
+// pomDependencies lists the artifact IDs in pom.xml
+func pomDependencies(content string) []string {
+	var dependencies []string
+	for _, matches := range artifactPattern.FindAllStringSubmatch(content, -1) {
+		dependencies = append(dependencies, matches[1])
+	}
+	return dependencies
+}
+
+// gradleDependencies lists group:artifact coordinates and bare artifact names from Gradle build files
+func gradleDependencies(content string) []string {
+	var dependencies []string
+	for _, matches := range gradleCoordinatePattern.FindAllStringSubmatch(content, -1) {
+		dependencies = append(dependencies, matches[1]+":"+matches[2], matches[2])
+	}
+	return dependencies
+}
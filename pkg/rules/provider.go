@@ -0,0 +1,219 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Provider is a detection provider compiled from a Rule
+type Provider struct {
+	providers.BaseProvider
+
+	rule            *Rule
+	threshold       float64
+	indicators      []matcher
+	frameworks      []matcher
+	versionPatterns []*regexp.Regexp
+	commands        commandTemplates
+	environment     map[string]*template.Template
+}
+
+// matcher is a Matcher with its pattern compiled
+type matcher struct {
+	Matcher
+	pattern *regexp.Regexp
+}
+
+// commandTemplates holds the compiled command templates per phase
+type commandTemplates struct {
+	setup []*template.Template
+	dev   []*template.Template
+	build []*template.Template
+	run   []*template.Template
+}
+
+// Rule returns the rule the Provider was compiled from
+func (p *Provider) Rule() *Rule {
+	return p.rule
+}
+
+// Detect evaluates the rule indicators against the project
+func (p *Provider) Detect(ctx context.Context, fsys fs.FS, files []types.FileInfo) (*types.DetectResult, error) {
+	project := &project{fsys: fsys, files: files, base: &p.BaseProvider}
+
+	indicators := make([]types.ConfidenceIndicator, len(p.indicators))
+	var evidenceFiles, reasons []string
+	for i, indicator := range p.indicators {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		satisfied, matchedFiles := project.matches(indicator)
+		indicators[i] = types.ConfidenceIndicator{
+			Description: indicator.describe(),
+			Weight:      p.rule.Indicators[i].Weight,
+			Satisfied:   satisfied,
+		}
+		if satisfied {
+			evidenceFiles = appendUnique(evidenceFiles, matchedFiles...)
+			reasons = append(reasons, indicator.describe())
+		}
+	}
+
+	// Unlike built-in providers, rules match at the threshold itself
+	scoring := p.ScoreIndicators(indicators, p.threshold)
+	scoring.ThresholdInclusive = true
+	if !scoring.Matched() {
+		result := p.CreateDetectResult(false, scoring.Confidence, "", nil, "", "", "", nil, types.Evidence{})
+		result.Scoring = scoring
+		return result, nil
+	}
+
+	framework := p.rule.Framework
+	for i, candidate := range p.frameworks {
+		if satisfied, _ := project.matches(candidate); satisfied {
+			framework = p.rule.Frameworks[i].Name
+			break
+		}
+	}
+	if framework != "" {
+		reasons = append(reasons, "framework: "+framework)
+	}
+
+	metadata := map[string]interface{}{
+		"rule": p.rule.File,
+	}
+	evidence := types.Evidence{
+		Files:  evidenceFiles,
+		Reason: fmt.Sprintf("Detected %s project based on: %s", p.Name, strings.Join(reasons, ", ")),
+	}
+
+	result := p.CreateDetectResult(
+		true,
+		scoring.Confidence,
+		p.Language,
+		p.detectVersion(project),
+		framework,
+		p.rule.PackageManager,
+		"",
+		metadata,
+		evidence,
+	)
+	result.Scoring = scoring
+	return result, nil
+}
+
+// detectVersion tries the version sources in order and falls back to the default
+func (p *Provider) detectVersion(project *project) *types.VersionInfo {
+	for i, versionSource := range p.rule.Version.Sources {
+		if version := project.readVersion(versionSource, p.versionPatterns[i]); version != "" {
+			return p.CreateVersionInfo(version, versionSource.File)
+		}
+	}
+	if p.rule.Version.Default != "" {
+		return p.CreateVersionInfo(p.rule.Version.Default, "default")
+	}
+	return nil
+}
+
+// GenerateCommands renders the rule command templates
+func (p *Provider) GenerateCommands(result *types.DetectResult, _ types.CLIOptions) types.Commands {
+	data := p.templateData(result)
+	return types.Commands{
+		Setup: render(p.commands.setup, data),
+		Dev:   render(p.commands.dev, data),
+		Build: render(p.commands.build, data),
+		Run:   render(p.commands.run, data),
+	}
+}
+
+// GenerateEnvironment renders the rule environment templates
+func (p *Provider) GenerateEnvironment(result *types.DetectResult) map[string]string {
+	if len(p.environment) == 0 {
+		return nil
+	}
+	data := p.templateData(result)
+	environment := make(map[string]string, len(p.environment))
+	for key, tmpl := range p.environment {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err == nil {
+			environment[key] = b.String()
+		}
+	}
+	return environment
+}
+
+// NeedsNativeCompilation returns the rule's native compilation setting
+func (p *Provider) NeedsNativeCompilation(_ *types.DetectResult) bool {
+	return p.rule.NativeCompilation
+}
+
+// GetPort returns the rule's application port, zero when unset
+func (p *Provider) GetPort(_ *types.DetectResult) int {
+	return p.rule.Port
+}
+
+// templateData collects the values available to templates
+func (p *Provider) templateData(result *types.DetectResult) TemplateData {
+	data := TemplateData{
+		Name:      p.Name,
+		Language:  p.Language,
+		Version:   result.Version,
+		Framework: result.Framework,
+		Port:      p.rule.Port,
+	}
+	if result.PackageManager != nil {
+		data.PackageManager = result.PackageManager.Name
+	}
+	return data
+}
+
+// render executes templates, skipping commands that render empty
+func render(templates []*template.Template, data TemplateData) []string {
+	var commands []string
+	for _, tmpl := range templates {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			continue
+		}
+		if command := strings.TrimSpace(b.String()); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// describe summarises a satisfied indicator for the detection reason
+func (m matcher) describe() string {
+	var parts []string
+	if m.File != "" {
+		parts = append(parts, m.File)
+	}
+	if m.Dependency != "" {
+		parts = append(parts, "dependency "+m.Dependency)
+	}
+	return strings.Join(parts, " with ")
+}
+
+// appendUnique appends values that are not yet in list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
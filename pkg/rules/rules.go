@@ -0,0 +1,368 @@
+// Package rules compiles declarative provider rule files into detection providers.
+//
+// A rule file describes a provider in YAML or JSON: weighted indicators that
+// mirror types.ConfidenceIndicator, dependency matchers against the project's
+// manifests, version sources, framework matchers, command templates per phase,
+// environment variables and the application port. Rule files are loaded from
+// directories at runtime, so simple custom detections need no Go code.
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// PathEnv names the environment variable holding the default rules path
+const PathEnv = "DEVBOX_PACK_RULES_PATH"
+
+// DefaultPriority is used for rules that do not set a priority
+const DefaultPriority = 100
+
+// DefaultThreshold is the minimum confidence for a match when a rule sets none
+const DefaultThreshold = 0.3
+
+// FileExtensions lists the extensions of rule files loaded from a directory
+var FileExtensions = []string{".yaml", ".yml", ".json"}
+
+// Rule is the declarative description of a provider
+type Rule struct {
+	// File the rule was loaded from
+	File string `json:"-"`
+
+	// Provider name, used with --provider and in plans
+	Name string `json:"name"`
+	// Language reported in detection results, defaults to Name
+	Language string `json:"language,omitempty"`
+	// Provider priority (lower number = higher priority), defaults to DefaultPriority
+	Priority int `json:"priority,omitempty"`
+	// Minimum confidence for a match, defaults to DefaultThreshold
+	Threshold float64 `json:"threshold,omitempty"`
+	// Weighted detection indicators
+	Indicators []Indicator `json:"indicators"`
+	// Framework reported when no framework matcher applies
+	Framework string `json:"framework,omitempty"`
+	// Framework matchers, the first satisfied one wins
+	Frameworks []Framework `json:"frameworks,omitempty"`
+	// Version sources
+	Version VersionRule `json:"version,omitempty"`
+	// Package manager reported in detection results
+	PackageManager string `json:"packageManager,omitempty"`
+	// Command templates per phase
+	Commands types.Commands `json:"commands,omitempty"`
+	// Environment variable templates
+	Environment map[string]string `json:"environment,omitempty"`
+	// Application port, zero keeps the language default
+	Port int `json:"port,omitempty"`
+	// Whether build-essential is needed
+	NativeCompilation bool `json:"nativeCompilation,omitempty"`
+}
+
+// Matcher is a condition on the project. Every field that is set must hold.
+type Matcher struct {
+	// File path or wildcard pattern that must exist
+	File string `json:"file,omitempty"`
+	// Regular expression the content of File must match
+	Pattern string `json:"pattern,omitempty"`
+	// Dependency name or wildcard pattern declared in a manifest
+	Dependency string `json:"dependency,omitempty"`
+	// Manifest searched for Dependency, defaults to every known manifest in the project root
+	Manifest string `json:"manifest,omitempty"`
+}
+
+// Indicator is a weighted matcher, see types.ConfidenceIndicator
+type Indicator struct {
+	Matcher
+	Weight int `json:"weight"`
+}
+
+// Framework names the framework reported when its matcher holds
+type Framework struct {
+	Matcher
+	Name string `json:"name"`
+}
+
+// VersionRule describes where the version is read from
+type VersionRule struct {
+	// Sources tried in order, the first one yielding a version wins
+	Sources []VersionSource `json:"sources,omitempty"`
+	// Version used when no source yields one
+	Default string `json:"default,omitempty"`
+}
+
+// VersionSource reads a version from a project file. With Field the file is
+// decoded (JSON, TOML or YAML) and the dotted field is used; with Pattern the
+// first capture group is used; otherwise the first line of the file is used.
+type VersionSource struct {
+	File    string `json:"file"`
+	Field   string `json:"field,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// TemplateData is available to command and environment templates, e.g. {{.Version}}
+type TemplateData struct {
+	Name           string
+	Language       string
+	Version        string
+	Framework      string
+	PackageManager string
+	Port           int
+}
+
+// Parse parses rule file content, choosing the format from the file extension
+func Parse(fileName string, content []byte) (*Rule, error) {
+	data := content
+	if ext := strings.ToLower(filepath.Ext(fileName)); ext == ".yaml" || ext == ".yml" {
+		document, err := markup.ParseYAML(string(content))
+		if err != nil {
+			return nil, ruleError(fileName, err)
+		}
+		// Round-trip through JSON so both formats share one schema
+		data, err = json.Marshal(document)
+		if err != nil {
+			return nil, ruleError(fileName, err)
+		}
+	}
+
+	rule := &Rule{}
+	if err := json.Unmarshal(data, rule); err != nil {
+		return nil, ruleError(fileName, err)
+	}
+	rule.File = fileName
+	return rule, nil
+}
+
+// LoadDir parses and compiles every rule file in dir, in file name order.
+// A missing directory holds no rules, as a missing plugin directory holds no plugins.
+func LoadDir(dir string) ([]*Provider, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, ruleError(dir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isRuleFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	compiled := make([]*Provider, 0, len(names))
+	for _, name := range names {
+		fileName := filepath.Join(dir, name)
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, ruleError(fileName, err)
+		}
+		rule, err := Parse(fileName, content)
+		if err != nil {
+			return nil, err
+		}
+		provider, err := Compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, provider)
+	}
+	return compiled, nil
+}
+
+// Load loads the rule files of every directory in dirs
+func Load(dirs []string) ([]*Provider, error) {
+	var compiled []*Provider
+	for _, dir := range dirs {
+		dirProviders, err := LoadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, dirProviders...)
+	}
+	return compiled, nil
+}
+
+// isRuleFile checks the file extension against FileExtensions
+func isRuleFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, allowed := range FileExtensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile validates rule and turns it into a Provider
+func Compile(rule *Rule) (*Provider, error) {
+	if rule.Name == "" {
+		return nil, ruleError(rule.File, errors.New("name is required"))
+	}
+	if len(rule.Indicators) == 0 {
+		return nil, ruleError(rule.File, errors.New("at least one indicator is required"))
+	}
+	if rule.Threshold < 0 || rule.Threshold > 1 {
+		return nil, ruleError(rule.File, fmt.Errorf("threshold %v is outside 0-1", rule.Threshold))
+	}
+
+	provider := &Provider{
+		BaseProvider: providers.BaseProvider{
+			Name:     rule.Name,
+			Language: rule.Language,
+			Priority: rule.Priority,
+		},
+		rule:      rule,
+		threshold: rule.Threshold,
+	}
+	if provider.Language == "" {
+		provider.Language = rule.Name
+	}
+	if provider.Priority == 0 {
+		provider.Priority = DefaultPriority
+	}
+	if provider.threshold == 0 {
+		provider.threshold = DefaultThreshold
+	}
+
+	for i, indicator := range rule.Indicators {
+		if indicator.Weight <= 0 {
+			return nil, ruleError(rule.File, fmt.Errorf("indicator %d: weight must be positive", i+1))
+		}
+		compiled, err := compileMatcher(indicator.Matcher)
+		if err != nil {
+			return nil, ruleError(rule.File, fmt.Errorf("indicator %d: %w", i+1, err))
+		}
+		provider.indicators = append(provider.indicators, compiled)
+	}
+
+	for i, framework := range rule.Frameworks {
+		if framework.Name == "" {
+			return nil, ruleError(rule.File, fmt.Errorf("framework %d: name is required", i+1))
+		}
+		compiled, err := compileMatcher(framework.Matcher)
+		if err != nil {
+			return nil, ruleError(rule.File, fmt.Errorf("framework %d: %w", i+1, err))
+		}
+		provider.frameworks = append(provider.frameworks, compiled)
+	}
+
+	for i, versionSource := range rule.Version.Sources {
+		if versionSource.File == "" {
+			return nil, ruleError(rule.File, fmt.Errorf("version source %d: file is required", i+1))
+		}
+		var pattern *regexp.Regexp
+		if versionSource.Pattern != "" {
+			var err error
+			if pattern, err = regexp.Compile(versionSource.Pattern); err != nil {
+				return nil, ruleError(rule.File, fmt.Errorf("version source %d: %w", i+1, err))
+			}
+		}
+		provider.versionPatterns = append(provider.versionPatterns, pattern)
+	}
+
+	var err error
+	if provider.commands, err = compileCommands(rule.Commands); err != nil {
+		return nil, ruleError(rule.File, err)
+	}
+	if provider.environment, err = compileEnvironment(rule.Environment); err != nil {
+		return nil, ruleError(rule.File, err)
+	}
+
+	return provider, nil
+}
+
+// compileMatcher validates a matcher and compiles its pattern
+func compileMatcher(m Matcher) (matcher, error) {
+	if m.File == "" && m.Dependency == "" {
+		return matcher{}, errors.New("file or dependency is required")
+	}
+	if m.Pattern != "" && m.File == "" {
+		return matcher{}, errors.New("pattern requires file")
+	}
+	if m.Manifest != "" && m.Dependency == "" {
+		return matcher{}, errors.New("manifest requires dependency")
+	}
+
+	compiled := matcher{Matcher: m}
+	if m.Pattern != "" {
+		pattern, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return matcher{}, err
+		}
+		compiled.pattern = pattern
+	}
+	return compiled, nil
+}
+
+// compileCommands parses the command templates of every phase
+func compileCommands(commands types.Commands) (commandTemplates, error) {
+	var compiled commandTemplates
+	phases := []struct {
+		name   string
+		source []string
+		target *[]*template.Template
+	}{
+		{"setup", commands.Setup, &compiled.setup},
+		{"dev", commands.Dev, &compiled.dev},
+		{"build", commands.Build, &compiled.build},
+		{"run", commands.Run, &compiled.run},
+	}
+	for _, phase := range phases {
+		for i, command := range phase.source {
+			tmpl, err := compileTemplate(fmt.Sprintf("commands.%s[%d]", phase.name, i), command)
+			if err != nil {
+				return commandTemplates{}, err
+			}
+			*phase.target = append(*phase.target, tmpl)
+		}
+	}
+	return compiled, nil
+}
+
+// compileEnvironment parses the environment variable templates
+func compileEnvironment(environment map[string]string) (map[string]*template.Template, error) {
+	compiled := make(map[string]*template.Template, len(environment))
+	for key, value := range environment {
+		tmpl, err := compileTemplate("environment."+key, value)
+		if err != nil {
+			return nil, err
+		}
+		compiled[key] = tmpl
+	}
+	return compiled, nil
+}
+
+// compileTemplate parses a template and checks it against TemplateData
+func compileTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Executing once with empty data reports unknown fields up front
+	if err := tmpl.Execute(&strings.Builder{}, TemplateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// ruleError wraps rule loading failures
+func ruleError(fileName string, err error) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("Invalid rule file %s: %s", fileName, err.Error()),
+		types.ErrorCodeInvalidRule,
+		map[string]interface{}{"path": fileName},
+	)
+}
@@ -0,0 +1,251 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+const acmeRule = `
+name: acme
+language: java
+priority: 65
+indicators:
+  - file: acme.toml
+    weight: 50
+  - file: pom.xml
+    pattern: <artifactId>acme-parent</artifactId>
+    weight: 20
+  - dependency: acme-sdk
+    weight: 30
+framework: Acme
+frameworks:
+  - name: Acme Web
+    dependency: acme-web*
+version:
+  sources:
+    - file: acme.toml
+      field: runtime.version
+    - file: .acme-version
+  default: "1.0"
+packageManager: maven
+commands:
+  setup: ["mvn -q install"]
+  run:
+    - "acme run --version {{.Version}} --port {{.Port}}"
+    - "{{if eq .Framework \"Acme Web\"}}acme web{{end}}"
+environment:
+  ACME_FRAMEWORK: "{{.Framework}}"
+port: 9090
+`
+
+// detect compiles the rule and runs it against an in-memory project
+func detect(t *testing.T, rule *Rule, files map[string]string) (*Provider, *types.DetectResult) {
+	t.Helper()
+	provider, err := Compile(rule)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	fsys := source.NewMap(files)
+	scanned, err := source.Scan(context.Background(), fsys, nil)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	fileInfos := make([]types.FileInfo, len(scanned))
+	for i, file := range scanned {
+		fileInfos[i] = *file
+	}
+
+	result, err := provider.Detect(context.Background(), fsys, fileInfos)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	return provider, result
+}
+
+func TestParse_YAMLAndJSON(t *testing.T) {
+	fromYAML, err := Parse("acme.yaml", []byte(acmeRule))
+	if err != nil {
+		t.Fatalf("Parse YAML failed: %v", err)
+	}
+
+	fromJSON, err := Parse("acme.json", []byte(`{
+		"name": "acme", "language": "java", "priority": 65,
+		"indicators": [{"file": "acme.toml", "weight": 50}]
+	}`))
+	if err != nil {
+		t.Fatalf("Parse JSON failed: %v", err)
+	}
+
+	if fromYAML.Name != "acme" || fromYAML.Priority != 65 || fromYAML.Port != 9090 {
+		t.Errorf("unexpected YAML rule: %+v", fromYAML)
+	}
+	if len(fromYAML.Indicators) != 3 || fromYAML.Indicators[1].Pattern == "" || fromYAML.Indicators[2].Dependency != "acme-sdk" {
+		t.Errorf("unexpected YAML indicators: %+v", fromYAML.Indicators)
+	}
+	if !reflect.DeepEqual(fromJSON.Indicators, fromYAML.Indicators[:1]) {
+		t.Errorf("expected JSON and YAML indicators to match, got %+v", fromJSON.Indicators)
+	}
+}
+
+func TestProvider_Detect(t *testing.T) {
+	rule, err := Parse("acme.yaml", []byte(acmeRule))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	provider, result := detect(t, rule, map[string]string{
+		"acme.toml":    "[runtime]\nversion = \"2.3\"\n",
+		"package.json": `{"dependencies": {"acme-sdk": "^1.0.0", "acme-web-server": "^2.0.0"}}`,
+	})
+
+	if !result.Matched || result.Confidence != 0.8 {
+		t.Fatalf("expected match with confidence 0.8, got %+v", result)
+	}
+	if result.Language != "java" || result.Framework != "Acme Web" || result.Version != "2.3" {
+		t.Errorf("unexpected result: language=%s framework=%s version=%s", result.Language, result.Framework, result.Version)
+	}
+	if result.PackageManager == nil || result.PackageManager.Name != "maven" {
+		t.Errorf("expected maven package manager, got %v", result.PackageManager)
+	}
+	if !reflect.DeepEqual(result.Evidence.Files, []string{"acme.toml", "package.json"}) {
+		t.Errorf("unexpected evidence files: %v", result.Evidence.Files)
+	}
+
+	commands := provider.GenerateCommands(result, types.CLIOptions{})
+	expectedRun := []string{"acme run --version 2.3 --port 9090", "acme web"}
+	if !reflect.DeepEqual(commands.Run, expectedRun) {
+		t.Errorf("expected run %v, got %v", expectedRun, commands.Run)
+	}
+	if env := provider.GenerateEnvironment(result); env["ACME_FRAMEWORK"] != "Acme Web" {
+		t.Errorf("unexpected environment: %v", env)
+	}
+	if provider.GetPort(result) != 9090 || provider.GetPriority() != 65 {
+		t.Errorf("unexpected port %d or priority %d", provider.GetPort(result), provider.GetPriority())
+	}
+}
+
+func TestProvider_DetectFallbacks(t *testing.T) {
+	rule, err := Parse("acme.yaml", []byte(acmeRule))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	provider, result := detect(t, rule, map[string]string{
+		"acme.toml": "name = \"demo\"\n",
+		"pom.xml":   "<project><artifactId>other-parent</artifactId></project>",
+	})
+	if !result.Matched || result.Framework != "Acme" || result.Version != "1.0" {
+		t.Errorf("expected default framework and version, got %+v", result)
+	}
+	commands := provider.GenerateCommands(result, types.CLIOptions{})
+	if !reflect.DeepEqual(commands.Run, []string{"acme run --version 1.0 --port 9090"}) {
+		t.Errorf("expected conditional command to be dropped, got %v", commands.Run)
+	}
+
+	// The pom.xml pattern alone stays below the threshold
+	_, result = detect(t, rule, map[string]string{
+		"pom.xml": "<project><artifactId>acme-parent</artifactId></project>",
+	})
+	if result.Matched {
+		t.Errorf("expected no match below threshold, got confidence %v", result.Confidence)
+	}
+}
+
+func TestDependencyManifests(t *testing.T) {
+	manifests := map[string]string{
+		"requirements.txt": "# web\nFlask==2.0\nacme_client>=1.0 ; python_version > '3'\n-r base.txt\n",
+		"pyproject.toml":   "[project]\ndependencies = [\"acme-core>=1\"]\n[tool.poetry.group.dev.dependencies]\nacme-test = \"*\"\n",
+		"go.mod":           "module example.com/app\n\nrequire github.com/acme/sdk v1.2.0\n\nrequire (\n\tgithub.com/acme/web v0.1.0 // indirect\n)\n",
+		"Gemfile":          "source 'https://rubygems.org'\ngem 'acme-rails', '~> 1.0'\n",
+		"Cargo.toml":       "[dependencies]\nacme = \"1\"\n",
+		"build.gradle":     "dependencies {\n  implementation 'com.acme:acme-starter:1.0'\n}\n",
+	}
+	expected := map[string][]string{
+		"requirements.txt": {"Flask", "acme_client"},
+		"pyproject.toml":   {"acme-core", "acme-test"},
+		"go.mod":           {"github.com/acme/sdk", "github.com/acme/web"},
+		"Gemfile":          {"acme-rails"},
+		"Cargo.toml":       {"acme"},
+		"build.gradle":     {"com.acme:acme-starter", "acme-starter"},
+	}
+
+	for name, content := range manifests {
+		got := manifestParsers[name](content)
+		if !reflect.DeepEqual(got, expected[name]) {
+			t.Errorf("%s: expected %v, got %v", name, expected[name], got)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"missing name", Rule{Indicators: []Indicator{{Matcher: Matcher{File: "a"}, Weight: 1}}}},
+		{"no indicators", Rule{Name: "x"}},
+		{"zero weight", Rule{Name: "x", Indicators: []Indicator{{Matcher: Matcher{File: "a"}}}}},
+		{"empty matcher", Rule{Name: "x", Indicators: []Indicator{{Weight: 1}}}},
+		{"pattern without file", Rule{Name: "x", Indicators: []Indicator{{Matcher: Matcher{Dependency: "a", Pattern: "b"}, Weight: 1}}}},
+		{"invalid pattern", Rule{Name: "x", Indicators: []Indicator{{Matcher: Matcher{File: "a", Pattern: "("}, Weight: 1}}}},
+		{"unknown template field", Rule{
+			Name:       "x",
+			Indicators: []Indicator{{Matcher: Matcher{File: "a"}, Weight: 1}},
+			Commands:   types.Commands{Run: []string{"{{.Unknown}}"}},
+		}},
+		{"threshold out of range", Rule{Name: "x", Threshold: 2, Indicators: []Indicator{{Matcher: Matcher{File: "a"}, Weight: 1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			_, err := Compile(&rule)
+			if err == nil {
+				t.Fatal("expected error but got none")
+			}
+			devBoxErr, ok := err.(*types.DevBoxPackError)
+			if !ok || devBoxErr.Code != types.ErrorCodeInvalidRule {
+				t.Errorf("expected %s error, got %v", types.ErrorCodeInvalidRule, err)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b-acme.yaml":  acmeRule,
+		"a-other.json": `{"name": "other", "indicators": [{"file": "other.txt", "weight": 1}]}`,
+		"README.md":    "not a rule",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	loaded, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].GetName() != "other" || loaded[1].GetName() != "acme" {
+		t.Fatalf("expected [other acme] in file name order, got %d providers", len(loaded))
+	}
+	if loaded[0].GetPriority() != DefaultPriority || loaded[0].GetLanguage() != "other" {
+		t.Errorf("expected defaults for other rule, got priority %d language %s", loaded[0].GetPriority(), loaded[0].GetLanguage())
+	}
+
+	if loaded, err := LoadDir(filepath.Join(dir, "missing")); err != nil || len(loaded) != 0 {
+		t.Errorf("expected no rules for missing directory, got %d, %v", len(loaded), err)
+	}
+	if _, err := LoadDir(filepath.Join(dir, "README.md")); err == nil {
+		t.Error("expected error for a file instead of a directory")
+	}
+}
@@ -0,0 +1,428 @@
+// Package server exposes execution plan generation as an HTTP API, so platforms
+// can analyse repositories without running the devbox-pack binary per request.
+//
+// Endpoints:
+//
+//	POST /v1/plans         analyse a remote Git repository, see PlanRequest
+//	POST /v1/plans/upload  analyse a .tar.gz or .zip archive sent as the request body
+//	GET  /healthz          report that the server is up
+//
+// Plans are returned in the requested output format, JSON by default. Failures
+// are returned as an ErrorResponse carrying the types.DevBoxPackError code.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/formatters"
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/service"
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Server defaults
+const (
+	// DefaultTimeout bounds a request, including cloning the repository
+	DefaultTimeout = 2 * time.Minute
+	// DefaultMaxClones is the number of repositories cloned or archives read at the same time
+	DefaultMaxClones = 4
+	// DefaultMaxUploadSize is the largest accepted archive upload in bytes
+	DefaultMaxUploadSize = 64 << 20
+)
+
+// errorStatus maps error codes to HTTP status codes, unknown codes are internal errors
+var errorStatus = map[string]int{
+	types.ErrorCodeInvalidInput:      http.StatusBadRequest,
+	types.ErrorCodeInvalidArgument:   http.StatusBadRequest,
+	types.ErrorCodeInvalidFormat:     http.StatusBadRequest,
+	types.ErrorCodeInvalidProvider:   http.StatusBadRequest,
+	types.ErrorCodeInvalidGitURL:     http.StatusBadRequest,
+	types.ErrorCodeInvalidPath:       http.StatusBadRequest,
+	types.ErrorCodeArchiveError:      http.StatusBadRequest,
+	types.ErrorCodeSubdirAccessError: http.StatusUnprocessableEntity,
+	types.ErrorCodeSubdirNotFound:    http.StatusUnprocessableEntity,
+	types.ErrorCodeGitCheckoutError:  http.StatusUnprocessableEntity,
+	types.ErrorCodeInvalidOverride:   http.StatusUnprocessableEntity,
+	types.ErrorCodeJSONParseError:    http.StatusUnprocessableEntity,
+	types.ErrorCodeUnsupported:       http.StatusUnprocessableEntity,
+	types.ErrorCodeCloneError:        http.StatusBadGateway,
+	types.ErrorCodeGitError:          http.StatusBadGateway,
+	types.ErrorCodeTimeout:           http.StatusGatewayTimeout,
+}
+
+// contentTypes maps output formats to response content types, other formats are plain text
+var contentTypes = map[string]string{
+	string(types.OutputFormatJSON):          "application/json",
+	string(types.OutputFormatYAML):          "application/yaml",
+	string(types.OutputFormatTOML):          "application/toml",
+	string(types.OutputFormatMarkdown):      "text/markdown; charset=utf-8",
+	string(types.OutputFormatSARIF):         "application/sarif+json",
+	string(types.OutputFormatKubernetes):    "application/yaml",
+	string(types.OutputFormatCompose):       "application/yaml",
+	string(types.OutputFormatGitHubActions): "application/yaml",
+	string(types.OutputFormatGitLabCI):      "application/yaml",
+	string(types.OutputFormatRailpack):      "application/json",
+}
+
+// Options configures a Server
+type Options struct {
+	// Timeout bounds each request, including waiting for a clone slot; 0 uses DefaultTimeout
+	Timeout time.Duration
+	// MaxClones limits the repositories cloned and archive uploads read at the same
+	// time; 0 uses DefaultMaxClones
+	MaxClones int
+	// MaxUploadSize limits archive uploads in bytes; 0 uses DefaultMaxUploadSize
+	MaxUploadSize int64
+	// MaxArchiveSize limits what uploads expand to in bytes; 0 uses source.DefaultArchiveLimits
+	MaxArchiveSize int64
+	// CacheDir keeps mirrors of cloned repositories and their plans between requests;
+	// empty clones and analyses every request afresh
+	CacheDir string
+	// PluginPaths are directories searched for devbox-pack-provider-* plugins
+	PluginPaths []string
+	// RulesPaths are directories of declarative YAML or JSON provider rules
+	RulesPaths []string
+	// Logger receives progress of every analysis; nil discards it
+	Logger service.Logger
+}
+
+// PlanRequest is the body of POST /v1/plans
+type PlanRequest struct {
+	// Git repository URL, local paths are not accepted
+	Repository string `json:"repository"`
+	// Git branch, tag or commit (optional)
+	Ref string `json:"ref,omitempty"`
+	// Subdirectory within the repository (optional)
+	Subdir string `json:"subdir,omitempty"`
+	// Provider to use instead of auto-detection (optional)
+	Provider string `json:"provider,omitempty"`
+	// Output format of the plan, json by default
+	Format string `json:"format,omitempty"`
+}
+
+// ErrorResponse is the body of failed requests
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes a failure
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Server serves execution plans over HTTP. It is safe for concurrent use.
+type Server struct {
+	options Options
+	// base holds the loaded Providers, every request analyses with a fork of it
+	base    *service.DevBoxPack
+	factory *formatters.FormatterFactory
+	// slots holds one token per repository being cloned or archive being read
+	slots chan struct{}
+}
+
+// New creates a Server, loading the configured rules and plugins once
+func New(ctx context.Context, options Options) (*Server, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.MaxClones <= 0 {
+		options.MaxClones = DefaultMaxClones
+	}
+	if options.MaxUploadSize <= 0 {
+		options.MaxUploadSize = DefaultMaxUploadSize
+	}
+	if options.MaxArchiveSize <= 0 {
+		options.MaxArchiveSize = source.DefaultArchiveLimits.MaxSize
+	}
+	if options.Logger == nil {
+		options.Logger = service.NopLogger{}
+	}
+
+	s := &Server{
+		options: options,
+		base:    service.NewDevBoxPackWithLogger(options.Logger),
+		factory: formatters.NewFormatterFactory(),
+		slots:   make(chan struct{}, options.MaxClones),
+	}
+	if options.CacheDir != "" {
+		s.base.SetCacheDir(options.CacheDir)
+	}
+	if len(options.RulesPaths) > 0 {
+		if err := s.base.LoadRules(options.RulesPaths); err != nil {
+			return nil, err
+		}
+	}
+	if len(options.PluginPaths) > 0 {
+		if err := s.base.LoadPlugins(ctx, options.PluginPaths); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Handler returns the HTTP handler of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/plans", s.handlePlans)
+	mux.HandleFunc("/v1/plans/upload", s.handleUpload)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	return mux
+}
+
+// handleHealth reports that the server is up
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handlePlans analyses the remote repository of a PlanRequest
+func (s *Server) handlePlans(w http.ResponseWriter, r *http.Request) {
+	if !allowPost(w, r) {
+		return
+	}
+
+	var request PlanRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, types.NewDevBoxPackError(
+			fmt.Sprintf("invalid request body: %s", err.Error()),
+			types.ErrorCodeInvalidInput,
+			nil,
+		))
+		return
+	}
+	// Local paths are rejected so clients cannot read the server's file system
+	if !git.IsRemote(request.Repository) {
+		writeError(w, types.NewDevBoxPackError(
+			fmt.Sprintf("invalid Git repository URL: %s", request.Repository),
+			types.ErrorCodeInvalidGitURL,
+			map[string]interface{}{"supported": git.RemoteForms},
+		))
+		return
+	}
+
+	options := newCLIOptions(request)
+	formatter, err := s.formatter(options.Format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.options.Timeout)
+	defer cancel()
+
+	if !s.acquire(ctx, w) {
+		return
+	}
+	defer s.release()
+
+	// Every request prepares its project with its own Git handler, so its cleanup
+	// removes its clone, whatever the outcome, and no other request's
+	devBoxPack := s.base.Fork()
+	defer devBoxPack.Cleanup()
+	analysis, err := devBoxPack.Analyze(ctx, request.Repository, options)
+	s.writePlan(ctx, w, formatter, analysis, options, err)
+}
+
+// handleUpload analyses the archive sent as request body. The subdir, provider and
+// format query parameters mirror the fields of PlanRequest.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if !allowPost(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	options := newCLIOptions(PlanRequest{
+		Subdir:   query.Get("subdir"),
+		Provider: query.Get("provider"),
+		Format:   query.Get("format"),
+	})
+	formatter, err := s.formatter(options.Format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.options.Timeout)
+	defer cancel()
+
+	// Archives expand in memory, so uploads share the slots of clones
+	if !s.acquire(ctx, w) {
+		return
+	}
+	defer s.release()
+
+	fsys, err := s.readArchive(w, r)
+	if err == nil && options.Subdir != nil {
+		fsys, err = source.Sub(fsys, *options.Subdir)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	devBoxPack := s.base.Fork()
+	defer devBoxPack.Cleanup()
+	analysis, err := devBoxPack.AnalyzeFS(ctx, fsys, options)
+	s.writePlan(ctx, w, formatter, analysis, options, err)
+}
+
+// acquire waits for a slot, writing the error and returning false when the request gives up first
+func (s *Server) acquire(ctx context.Context, w http.ResponseWriter) bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		writeError(w, types.ContextError(ctx))
+		return false
+	}
+}
+
+// release frees the slot taken by acquire
+func (s *Server) release() {
+	<-s.slots
+}
+
+// readArchive reads a .tar.gz or .zip request body into a source, recognising
+// the archive by its content
+func (s *Server) readArchive(w http.ResponseWriter, r *http.Request) (fs.FS, error) {
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.options.MaxUploadSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, types.NewDevBoxPackError(
+				fmt.Sprintf("archive exceeds %d bytes", s.options.MaxUploadSize),
+				types.ErrorCodeArchiveError,
+				map[string]interface{}{"limit": s.options.MaxUploadSize},
+			)
+		}
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("failed to read request body: %s", err.Error()),
+			types.ErrorCodeInvalidInput,
+			nil,
+		)
+	}
+
+	limits := source.DefaultArchiveLimits
+	limits.MaxSize = s.options.MaxArchiveSize
+	switch http.DetectContentType(content) {
+	case "application/zip":
+		return source.NewZipWithLimits(bytes.NewReader(content), int64(len(content)), limits)
+	case "application/x-gzip":
+		return source.NewTarGzWithLimits(bytes.NewReader(content), limits)
+	default:
+		return nil, types.NewDevBoxPackError(
+			"request body is not a .tar.gz or .zip archive",
+			types.ErrorCodeArchiveError,
+			nil,
+		)
+	}
+}
+
+// formatter returns the formatter of an output format
+func (s *Server) formatter(format string) (formatters.Formatter, error) {
+	formatter, err := s.factory.GetFormatter(format)
+	if err != nil {
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("unsupported output format: %s", format),
+			types.ErrorCodeInvalidFormat,
+			map[string]interface{}{
+				"format":    format,
+				"supported": s.factory.GetSupportedFormats(),
+			},
+		)
+	}
+	return formatter, nil
+}
+
+// writePlan writes the formatted plan of an analysis, or the error that ended it
+func (s *Server) writePlan(ctx context.Context, w http.ResponseWriter, formatter formatters.Formatter, analysis *service.Analysis, options *types.CLIOptions, err error) {
+	if err != nil {
+		// Failures caused by the deadline are reported as timeouts
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			err = ctxErr
+		}
+		writeError(w, err)
+		return
+	}
+
+	output, err := formatter.Format(analysis.Plan, options)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to format plan: %w", err))
+		return
+	}
+	contentType, ok := contentTypes[options.Format]
+	if !ok {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, output+"\n")
+}
+
+// newCLIOptions converts a request into analysis options
+func newCLIOptions(request PlanRequest) *types.CLIOptions {
+	options := &types.CLIOptions{
+		Repository: request.Repository,
+		Format:     request.Format,
+		Quiet:      true,
+	}
+	if options.Format == "" {
+		options.Format = string(types.OutputFormatJSON)
+	}
+	if request.Ref != "" {
+		options.Ref = &request.Ref
+	}
+	if request.Subdir != "" {
+		options.Subdir = &request.Subdir
+	}
+	if request.Provider != "" {
+		options.Provider = &request.Provider
+	}
+	return options
+}
+
+// allowPost rejects requests that are not POST requests, reporting whether r is one
+func allowPost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	w.Header().Set("Allow", http.MethodPost)
+	writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: ErrorBody{
+		Code:    types.ErrorCodeInvalidInput,
+		Message: fmt.Sprintf("method %s is not allowed, use POST", r.Method),
+	}})
+	return false
+}
+
+// writeError writes err as an ErrorResponse with the status of its code
+func writeError(w http.ResponseWriter, err error) {
+	body := ErrorBody{Code: types.ErrorCodeInternal, Message: err.Error()}
+	var devBoxErr *types.DevBoxPackError
+	if errors.As(err, &devBoxErr) {
+		body = ErrorBody{Code: devBoxErr.Code, Message: devBoxErr.Message, Details: devBoxErr.Details}
+	}
+	status, ok := errorStatus[body.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, ErrorResponse{Error: body})
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+var nodeProject = map[string]string{
+	"package.json": `{"name": "web", "scripts": {"start": "node index.js"}}`,
+	"index.js":     `console.log("hello");`,
+}
+
+// newTestServer starts the API with options
+func newTestServer(t *testing.T, options Options) *httptest.Server {
+	t.Helper()
+	s, err := New(context.Background(), options)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// post sends body to the API and returns the status and response body
+func post(t *testing.T, url, contentType string, body []byte) (int, string) {
+	t.Helper()
+	response, err := http.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", url, err)
+	}
+	defer response.Body.Close()
+	var buffer bytes.Buffer
+	_, _ = buffer.ReadFrom(response.Body)
+	return response.StatusCode, buffer.String()
+}
+
+// errorCode decodes the code of an ErrorResponse
+func errorCode(t *testing.T, body string) string {
+	t.Helper()
+	var response ErrorResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("response is not an error response: %v\n%s", err, body)
+	}
+	return response.Error.Code
+}
+
+// tarGz builds a gzip-compressed tar archive of files below prefix
+func tarGz(t *testing.T, prefix string, files map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		header := &tar.Header{Name: prefix + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar entry: %v", err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestHealthz(t *testing.T) {
+	server := newTestServer(t, Options{})
+	response, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz failed: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", response.StatusCode)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	server := newTestServer(t, Options{MaxUploadSize: 4096, MaxArchiveSize: 1 << 16})
+	archive := tarGz(t, "shop-main/", map[string]string{
+		"web/package.json": nodeProject["package.json"],
+		"web/index.js":     nodeProject["index.js"],
+		"README.md":        "# shop",
+	})
+
+	status, body := post(t, server.URL+"/v1/plans/upload?subdir=web", "application/gzip", archive)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	var plan types.ExecutionPlan
+	if err := json.Unmarshal([]byte(body), &plan); err != nil {
+		t.Fatalf("response is not a plan: %v\n%s", err, body)
+	}
+	if plan.Provider != "node" {
+		t.Errorf("expected node plan, got %s", plan.Provider)
+	}
+
+	status, body = post(t, server.URL+"/v1/plans/upload?subdir=web&format=markdown", "application/gzip", archive)
+	if status != http.StatusOK || !strings.Contains(body, "### Commands") {
+		t.Errorf("expected markdown plan, got %d: %s", status, body)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		body   []byte
+		status int
+		code   string
+	}{
+		{"not an archive", "", []byte("hello"), http.StatusBadRequest, types.ErrorCodeArchiveError},
+		{"too large", "", bytes.Repeat([]byte("x"), 8192), http.StatusBadRequest, types.ErrorCodeArchiveError},
+		{"expands too far", "", tarGz(t, "", map[string]string{"zeros": strings.Repeat("\x00", 1<<17)}), http.StatusBadRequest, types.ErrorCodeInvalidInput},
+		{"unknown format", "?format=docx", archive, http.StatusBadRequest, types.ErrorCodeInvalidFormat},
+		{"missing subdir", "?subdir=api", archive, http.StatusUnprocessableEntity, types.ErrorCodeSubdirNotFound},
+		{"escaping subdir", "?subdir=../etc", archive, http.StatusBadRequest, types.ErrorCodeInvalidPath},
+		{"unsupported project", "", tarGz(t, "", map[string]string{"notes.txt": "hi"}), http.StatusUnprocessableEntity, types.ErrorCodeUnsupported},
+		{"unknown provider", "?subdir=web&provider=cobol", archive, http.StatusBadRequest, types.ErrorCodeInvalidProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := post(t, server.URL+"/v1/plans/upload"+tt.query, "application/octet-stream", tt.body)
+			if status != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, status, body)
+			}
+			if code := errorCode(t, body); code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestUpload_WaitsForSlot(t *testing.T) {
+	s, err := New(context.Background(), Options{MaxClones: 1, Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+
+	// Uploads share the slots of clones
+	s.slots <- struct{}{}
+	status, body := post(t, server.URL+"/v1/plans/upload", "application/gzip", tarGz(t, "", nodeProject))
+	if status != http.StatusGatewayTimeout || errorCode(t, body) != types.ErrorCodeTimeout {
+		t.Errorf("expected timeout while the slot is taken, got %d: %s", status, body)
+	}
+	<-s.slots
+	if status, body := post(t, server.URL+"/v1/plans/upload", "application/gzip", tarGz(t, "", nodeProject)); status != http.StatusOK {
+		t.Errorf("expected 200 once the slot is free, got %d: %s", status, body)
+	}
+}
+
+func TestPlans_InvalidRequests(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	response, err := http.Get(server.URL + "/v1/plans")
+	if err != nil {
+		t.Fatalf("GET /v1/plans failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", response.StatusCode)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"malformed body", `{"repository":`, types.ErrorCodeInvalidInput},
+		{"unknown field", `{"repo": "https://example.com/a.git"}`, types.ErrorCodeInvalidInput},
+		{"local path", `{"repository": "/etc"}`, types.ErrorCodeInvalidGitURL},
+		{"file URL", `{"repository": "file:///etc"}`, types.ErrorCodeInvalidGitURL},
+		{"unknown format", `{"repository": "https://example.com/a.git", "format": "docx"}`, types.ErrorCodeInvalidFormat},
+		{"option-like ref", `{"repository": "https://example.com/a.git", "ref": "--upload-pack=id"}`, types.ErrorCodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := post(t, server.URL+"/v1/plans", "application/json", []byte(tt.body))
+			if status != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", status, body)
+			}
+			if code := errorCode(t, body); code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestPlans_SSHURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Connecting fails at once, the URL must still be cloned rather than read as a path
+	t.Setenv("GIT_SSH_COMMAND", "false")
+	server := newTestServer(t, Options{})
+
+	status, body := post(t, server.URL+"/v1/plans", "application/json", []byte(`{"repository": "ssh://host/repo"}`))
+	if status != http.StatusBadGateway || errorCode(t, body) != types.ErrorCodeCloneError {
+		t.Errorf("expected %s, got %d: %s", types.ErrorCodeCloneError, status, body)
+	}
+}
+
+func TestPlans_Clone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git exec path is unknown")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend")
+	if _, err := os.Stat(backend); err != nil {
+		t.Skip("git-http-backend is not installed")
+	}
+
+	// Clones are made below TMPDIR, which must be empty again after every request
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	for name, content := range nodeProject {
+		path := filepath.Join(work, "web", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	git(work, "init", "-q", "-b", "main")
+	git(work, "add", "-A")
+	git(work, "commit", "-q", "-m", "initial")
+	git(work, "branch", "release")
+	git(root, "clone", "-q", "--bare", work, "shop.git")
+
+	gitServer := httptest.NewServer(&cgi.Handler{
+		Path:   backend,
+		Env:    []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+		Stderr: io.Discard,
+	})
+	defer gitServer.Close()
+
+	server := newTestServer(t, Options{MaxClones: 1})
+	request := `{"repository": "` + gitServer.URL + `/shop.git", "ref": "release", "subdir": "web"}`
+	status, body := post(t, server.URL+"/v1/plans", "application/json", []byte(request))
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", status, body)
+	}
+	var plan types.ExecutionPlan
+	if err := json.Unmarshal([]byte(body), &plan); err != nil {
+		t.Fatalf("response is not a plan: %v\n%s", err, body)
+	}
+	if plan.Provider != "node" {
+		t.Errorf("expected node plan, got %s", plan.Provider)
+	}
+
+	request = `{"repository": "` + gitServer.URL + `/missing.git"}`
+	status, body = post(t, server.URL+"/v1/plans", "application/json", []byte(request))
+	if status != http.StatusBadGateway || errorCode(t, body) != types.ErrorCodeCloneError {
+		t.Errorf("expected 502 clone error, got %d: %s", status, body)
+	}
+
+	request = `{"repository": "` + gitServer.URL + `/shop.git", "subdir": "api"}`
+	status, body = post(t, server.URL+"/v1/plans", "application/json", []byte(request))
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a missing subdirectory, got %d: %s", status, body)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("failed to read TMPDIR: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "devbox-pack-") {
+			t.Errorf("clone directory %s was not cleaned up", entry.Name())
+		}
+	}
+
+	// With a cache, requests share a mirror and remove their worktrees, and
+	// plans are stored under the commit they were computed from
+	cacheDir := t.TempDir()
+	server = newTestServer(t, Options{CacheDir: cacheDir})
+	for _, ref := range []string{"main", "release", "main"} {
+		request = `{"repository": "` + gitServer.URL + `/shop.git", "ref": "` + ref + `", "subdir": "web"}`
+		status, body = post(t, server.URL+"/v1/plans", "application/json", []byte(request))
+		if status != http.StatusOK {
+			t.Fatalf("expected 200 for cached %s, got %d: %s", ref, status, body)
+		}
+		var plan types.ExecutionPlan
+		if err := json.Unmarshal([]byte(body), &plan); err != nil || plan.Cache == nil || len(plan.Cache.Commit) != 40 {
+			t.Errorf("expected plan with cache commit for %s, got %s", ref, body)
+		}
+	}
+	if plans, _ := filepath.Glob(filepath.Join(cacheDir, "plans", "*.json")); len(plans) != 1 {
+		t.Errorf("expected one plan for the commit of main and release, got %d", len(plans))
+	}
+	if mirrors, _ := filepath.Glob(filepath.Join(cacheDir, "git", "shop-*.git")); len(mirrors) != 1 {
+		t.Errorf("expected one mirror, got %v", mirrors)
+	}
+	if worktrees, _ := os.ReadDir(filepath.Join(cacheDir, "git", "worktrees")); len(worktrees) != 0 {
+		t.Errorf("expected worktrees to be removed, got %d", len(worktrees))
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/plancache"
+	"github.com/labring/devbox-pack/pkg/plugins"
+	"github.com/labring/devbox-pack/pkg/rules"
+	"github.com/labring/devbox-pack/pkg/source"
+	"github.com/labring/devbox-pack/pkg/types"
+	"github.com/labring/devbox-pack/pkg/utils"
+)
+
+// Kinds of cached results
+const (
+	cacheKindPlan     = "plan"
+	cacheKindMonorepo = "monorepo"
+)
+
+// cachedSource is a source opened for an analysis through the plan cache
+type cachedSource struct {
+	fsys fs.FS
+	// Key the result is stored under, empty when it cannot be cached
+	key string
+	// Commit the source was read at, empty for local directories and archives
+	commit string
+	// Records what providers read from local directories, nil for commits and archives
+	recorder *plancache.Recorder
+	// Evidence of the recorder, set by cacheInfo
+	evidence map[string]string
+}
+
+// SetCacheDir caches mirrors of remote repositories and plans below dir
+func (d *DevBoxPack) SetCacheDir(dir string) {
+	d.SetCloneCache(git.NewCloneCache(dir))
+	d.SetPlanCache(plancache.New(dir, utils.BuildVersion()))
+}
+
+// SetPlanCache serves analyses from cache while the commit or files they were
+// computed from, the options and the Providers are unchanged; nil analyses every time
+func (d *DevBoxPack) SetPlanCache(cache *plancache.Cache) {
+	d.planCache = cache
+}
+
+// openCached opens repoPath for an analysis through the plan cache. Commits of
+// repositories and archives are looked up before they are cloned or read;
+// local directories are looked up by path and checked against the evidence of the
+// cached result. On a hit the cached result is decoded into target and hit is true,
+// otherwise the returned source is analysed and its result stored with storeCached.
+func (d *DevBoxPack) openCached(ctx context.Context, repoPath string, options *types.CLIOptions, kind string, target interface{}) (src *cachedSource, hit bool, err error) {
+	parts := d.cacheParts(options, kind)
+	lookup := func(key string, fsys fs.FS) bool {
+		if options.NoCache {
+			return false
+		}
+		entry, ok := d.planCache.Get(key)
+		if !ok || (entry.Evidence != nil && (fsys == nil || !plancache.Verify(fsys, entry.Evidence))) {
+			return false
+		}
+		return json.Unmarshal(entry.Value, target) == nil
+	}
+
+	if source.ArchiveFormat(repoPath) != "" {
+		if sum, err := hashFile(repoPath); err == nil {
+			key := d.planCache.Key(append(parts, "archive:"+sum)...)
+			if lookup(key, nil) {
+				return &cachedSource{key: key}, true, nil
+			}
+			fsys, err := d.openSource(ctx, repoPath, options)
+			if err != nil {
+				return nil, false, err
+			}
+			return &cachedSource{fsys: fsys, key: key}, false, nil
+		}
+	}
+
+	repository := &types.GitRepository{URL: repoPath, Ref: options.Ref, Subdir: options.Subdir}
+	if d.gitHandler.IsRemote(repoPath) {
+		// Asking the remote for the commit is cheaper than cloning it
+		if commit, err := d.gitHandler.ResolveRemoteCommit(ctx, repoPath, options.Ref); err == nil {
+			key := d.planCache.Key(append(parts, "commit:"+commit)...)
+			if lookup(key, nil) {
+				return &cachedSource{key: key, commit: commit}, true, nil
+			}
+		}
+		projectPath, err := d.gitHandler.PrepareRepository(ctx, repository)
+		if err != nil {
+			return nil, false, err
+		}
+		src := &cachedSource{fsys: source.Dir(projectPath)}
+		// The result is stored under the commit actually cloned, which is also
+		// looked up for refs the remote could not resolve, such as short commit IDs
+		if commit, err := d.gitHandler.HeadCommit(ctx, projectPath); err == nil {
+			src.commit = commit
+			src.key = d.planCache.Key(append(parts, "commit:"+commit)...)
+			if lookup(src.key, nil) {
+				return src, true, nil
+			}
+		}
+		return src, false, nil
+	}
+
+	// Refs of local repositories are read from commits, which never change
+	if options.Ref != nil {
+		commit, err := d.gitHandler.ResolveLocalCommit(ctx, repoPath, *options.Ref)
+		if err != nil {
+			return nil, false, err
+		}
+		key := d.planCache.Key(append(parts, "commit:"+commit)...)
+		if lookup(key, nil) {
+			return &cachedSource{key: key, commit: commit}, true, nil
+		}
+		// Reading the resolved commit keeps the result in step with the key
+		repository.Ref = &commit
+		fsys, err := d.gitHandler.OpenRepository(ctx, repository)
+		if err != nil {
+			return nil, false, err
+		}
+		return &cachedSource{fsys: fsys, key: key, commit: commit}, false, nil
+	}
+
+	projectPath, err := d.gitHandler.PrepareRepository(ctx, repository)
+	if err != nil {
+		return nil, false, err
+	}
+	fsys := source.Dir(projectPath)
+	absPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return &cachedSource{fsys: fsys}, false, nil
+	}
+	key := d.planCache.Key(append(parts, "path:"+absPath)...)
+	if lookup(key, fsys) {
+		return &cachedSource{key: key}, true, nil
+	}
+	recorder := plancache.Record(fsys)
+	return &cachedSource{fsys: recorder, key: key, recorder: recorder}, false, nil
+}
+
+// cacheInfo returns the cache entry of a result computed from src, nil when it is not cached.
+// The key of local directories includes their evidence, so it changes with the files read.
+func (d *DevBoxPack) cacheInfo(src *cachedSource) *types.CacheInfo {
+	if src.key == "" {
+		return nil
+	}
+	info := &types.CacheInfo{Key: src.key, Commit: src.commit}
+	if src.recorder != nil {
+		src.evidence = src.recorder.Evidence()
+		info.Key = d.planCache.Key(src.key, plancache.Digest(src.evidence))
+	}
+	return info
+}
+
+// storeCached stores the result of an analysis of src, warning when it cannot
+func (d *DevBoxPack) storeCached(src *cachedSource, value interface{}, logger Logger) {
+	if src.key == "" {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = d.planCache.Put(&plancache.Entry{
+			Key:      src.key,
+			Commit:   src.commit,
+			Evidence: src.evidence,
+			Value:    data,
+		})
+	}
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Failed to cache execution plan: %s", err.Error()))
+	}
+}
+
+// cacheParts lists what cached results depend on besides the analysed source:
+// the kind of result, the options shaping it and the registered Providers
+func (d *DevBoxPack) cacheParts(options *types.CLIOptions, kind string) []string {
+	settings := struct {
+		Kind     string  `json:"kind"`
+		Subdir   *string `json:"subdir,omitempty"`
+		Provider *string `json:"provider,omitempty"`
+		Platform *string `json:"platform,omitempty"`
+		Base     *string `json:"base,omitempty"`
+		Explain  bool    `json:"explain,omitempty"`
+	}{kind, options.Subdir, options.Provider, options.Platform, options.Base, options.Explain}
+	encoded, _ := json.Marshal(settings)
+	parts := []string{string(encoded)}
+
+	// Plugins and rules change with their files, built-in Providers with the version
+	for _, name := range d.detectionEngine.GetAvailableProviders() {
+		provider, _ := d.detectionEngine.GetProvider(name)
+		part := "provider:" + name
+		switch provider := provider.(type) {
+		case *plugins.Provider:
+			if info, err := os.Stat(provider.Executable()); err == nil {
+				part += fmt.Sprintf("@%s:%d:%d", provider.Executable(), info.Size(), info.ModTime().UnixNano())
+			}
+		case *rules.Provider:
+			if sum, err := hashFile(provider.Rule().File); err == nil {
+				part += "@" + sum
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// hashFile returns the SHA-256 of a file's contents
+func hashFile(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labring/devbox-pack/pkg/plancache"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// stageLogger records the progress messages of an analysis
+type stageLogger struct {
+	NopLogger
+	messages []string
+}
+
+func (l *stageLogger) Progress(_ Stage, message string) {
+	l.messages = append(l.messages, message)
+}
+
+// fromCache reports whether the last analysis was served from the cache
+func (l *stageLogger) fromCache() bool {
+	return len(l.messages) > 0 && strings.Contains(l.messages[len(l.messages)-1], "from cache")
+}
+
+func TestAnalyze_PlanCache(t *testing.T) {
+	logger := &stageLogger{}
+	devbox := NewDevBoxPackWithLogger(logger)
+	devbox.SetPlanCache(plancache.New(t.TempDir(), "test"))
+
+	project := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(project, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	write("package.json", `{"name": "web", "scripts": {"start": "node index.js"}}`)
+	write("index.js", `console.log("hello");`)
+	if err := os.MkdirAll(filepath.Join(project, "node_modules"), 0755); err != nil {
+		t.Fatalf("Failed to create node_modules: %v", err)
+	}
+	write("node_modules/left-pad.js", "module.exports = 1")
+
+	analyze := func(options *types.CLIOptions) *types.ExecutionPlan {
+		t.Helper()
+		logger.messages = nil
+		analysis, err := devbox.Analyze(context.Background(), project, options)
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+		if analysis.Plan.Cache == nil || analysis.Plan.Cache.Key == "" {
+			t.Fatalf("expected cache key in plan, got %+v", analysis.Plan.Cache)
+		}
+		// Timings of the run that stored a cached analysis are not reported again
+		if analysis.Cached != logger.fromCache() || analysis.Cached != (len(analysis.Providers) == 0) {
+			t.Errorf("expected cached %t with provider timings only when computed, got %t with %d timings",
+				logger.fromCache(), analysis.Cached, len(analysis.Providers))
+		}
+		return analysis.Plan
+	}
+
+	first := analyze(&types.CLIOptions{})
+	if logger.fromCache() {
+		t.Error("first analysis should not be served from the cache")
+	}
+	second := analyze(&types.CLIOptions{})
+	if !logger.fromCache() {
+		t.Error("unchanged project should be served from the cache")
+	}
+	if second.Cache.Key != first.Cache.Key || second.Provider != "node" {
+		t.Errorf("expected cached node plan with key %s, got %+v", first.Cache.Key, second)
+	}
+
+	// Files that were not read do not invalidate the plan
+	write("node_modules/left-pad.js", "module.exports = 2")
+	analyze(&types.CLIOptions{})
+	if !logger.fromCache() {
+		t.Error("changing a file no provider read should keep the cached plan")
+	}
+
+	// Options shaping the plan are part of the key
+	platform := "linux/arm64"
+	analyze(&types.CLIOptions{Platform: &platform})
+	if logger.fromCache() {
+		t.Error("other options should not be served from the cache")
+	}
+
+	write("package.json", `{"name": "web", "scripts": {"start": "node server.js"}}`)
+	changed := analyze(&types.CLIOptions{})
+	if logger.fromCache() {
+		t.Error("changed package.json should invalidate the cached plan")
+	}
+	if changed.Cache.Key == first.Cache.Key {
+		t.Error("changed project should report a new cache key")
+	}
+
+	write("go.mod", "module example.com/app\n\ngo 1.21\n")
+	analyze(&types.CLIOptions{})
+	if logger.fromCache() {
+		t.Error("a new file should invalidate the cached plan")
+	}
+
+	analyze(&types.CLIOptions{NoCache: true})
+	if logger.fromCache() {
+		t.Error("NoCache should recompute the plan")
+	}
+}
+
+func TestAnalyzeMonorepo_PlanCache(t *testing.T) {
+	logger := &stageLogger{}
+	devbox := NewDevBoxPackWithLogger(logger)
+	devbox.SetPlanCache(plancache.New(t.TempDir(), "test"))
+
+	project := t.TempDir()
+	files := map[string]string{
+		"frontend/package.json": `{"name": "frontend", "scripts": {"start": "node index.js"}}`,
+		"backend/go.mod":        "module example.com/backend\n\ngo 1.21\n",
+		"backend/main.go":       "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(project, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	options := &types.CLIOptions{Monorepo: true}
+	for i, cached := range []bool{false, true} {
+		logger.messages = nil
+		analyses, err := devbox.AnalyzeMonorepo(context.Background(), project, options)
+		if err != nil {
+			t.Fatalf("AnalyzeMonorepo failed: %v", err)
+		}
+		if logger.fromCache() != cached {
+			t.Errorf("run %d: expected served from cache %t", i, cached)
+		}
+		if len(analyses) != 2 || analyses["backend"].Plan.Provider != "go" || analyses["backend"].Plan.Cache == nil {
+			t.Errorf("run %d: unexpected analyses %+v", i, analyses)
+		}
+		for path, analysis := range analyses {
+			if analysis.Cached != cached || (cached && analysis.Providers != nil) {
+				t.Errorf("run %d: expected %s cached %t without stored timings, got %+v", i, path, cached, analysis)
+			}
+		}
+	}
+
+	// A single plan of the same directory is cached apart from the monorepo plans
+	logger.messages = nil
+	if _, err := devbox.Analyze(context.Background(), project, &types.CLIOptions{}); err == nil && logger.fromCache() {
+		t.Error("single plan should not be served from the monorepo entry")
+	}
+}
+
+func TestAnalyze_LocalRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	logger := &stageLogger{}
+	devbox := NewDevBoxPackWithLogger(logger)
+	cacheDir := t.TempDir()
+	devbox.SetPlanCache(plancache.New(cacheDir, "test"))
+
+	project := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = project
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(project, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	write("go.mod", "module example.com/app\n\ngo 1.21\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "go")
+	// The working tree moves on to another language without committing
+	for _, name := range []string{"go.mod", "main.go"} {
+		if err := os.Remove(filepath.Join(project, name)); err != nil {
+			t.Fatalf("Failed to remove %s: %v", name, err)
+		}
+	}
+	write("package.json", `{"name": "web", "scripts": {"start": "node index.js"}}`)
+
+	ref := "main"
+	for i, cached := range []bool{false, true} {
+		logger.messages = nil
+		analysis, err := devbox.Analyze(context.Background(), project, &types.CLIOptions{Ref: &ref})
+		if err != nil {
+			t.Fatalf("run %d: Analyze failed: %v", i, err)
+		}
+		if logger.fromCache() != cached {
+			t.Errorf("run %d: expected served from cache %t", i, cached)
+		}
+		if analysis.Plan.Provider != "go" || analysis.Plan.Cache == nil || analysis.Plan.Cache.Commit == "" {
+			t.Errorf("run %d: expected go plan of the main commit, got %+v", i, analysis.Plan)
+		}
+	}
+
+	// Plans of a commit computed by another build of the tool are not served
+	devbox.SetPlanCache(plancache.New(cacheDir, "other"))
+	logger.messages = nil
+	if _, err := devbox.Analyze(context.Background(), project, &types.CLIOptions{Ref: &ref}); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if logger.fromCache() {
+		t.Error("a plan of another version should not be served from the cache")
+	}
+
+	analysis, err := devbox.Analyze(context.Background(), project, &types.CLIOptions{})
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if analysis.Plan.Provider != "node" {
+		t.Errorf("expected node plan of the working tree, got %s", analysis.Plan.Provider)
+	}
+
+	missing := "missing"
+	if _, err := devbox.Analyze(context.Background(), project, &types.CLIOptions{Ref: &missing}); err == nil {
+		t.Error("expected error for missing ref")
+	}
+}
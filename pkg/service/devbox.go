@@ -3,24 +3,61 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
 
+	"github.com/labring/devbox-pack/pkg/backing"
+	"github.com/labring/devbox-pack/pkg/config"
 	"github.com/labring/devbox-pack/pkg/detector"
 	"github.com/labring/devbox-pack/pkg/formatters"
 	"github.com/labring/devbox-pack/pkg/generators"
 	"github.com/labring/devbox-pack/pkg/git"
+	"github.com/labring/devbox-pack/pkg/plancache"
+	"github.com/labring/devbox-pack/pkg/plugins"
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/rules"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
+// MonorepoScanDepth is the directory depth searched for service roots in monorepo mode
+const MonorepoScanDepth = 4
+
+// sourceName identifies a project passed in as a source in error messages
+const sourceName = "<source>"
+
 // DevBoxPack core service class
 type DevBoxPack struct {
 	gitHandler      *git.GitHandler
 	detectionEngine *detector.DetectionEngine
 	planGenerator   *generators.ExecutionPlanGenerator
 	outputUtils     *formatters.OutputUtils
+	logger          Logger
+	planCache       *plancache.Cache
 }
 
-// NewDevBoxPack creates a DevBox Pack instance
+// Analysis is the outcome of analysing a single project
+type Analysis struct {
+	// Generated execution plan
+	Plan *types.ExecutionPlan `json:"plan"`
+	// All matched detection results in provider priority order
+	Detections []*types.DetectResult `json:"detections"`
+	// Non-fatal problems raised during analysis
+	Diagnostics []types.Diagnostic `json:"diagnostics,omitempty"`
+	// Per-provider detection timings in provider priority order
+	Providers []detector.ProviderRun `json:"providers,omitempty"`
+	// Scoring breakdown and selection reasoning, only set with CLIOptions.Explain
+	Explanation *types.Explanation `json:"explanation,omitempty"`
+	// Whether the analysis was loaded from the plan cache; its diagnostics are
+	// those of the original run and it carries no provider timings
+	Cached bool `json:"cached,omitempty"`
+}
+
+// NewDevBoxPack creates a DevBox Pack instance that reports progress on stdout
 func NewDevBoxPack() *DevBoxPack {
 	return &DevBoxPack{
 		gitHandler:      git.NewGitHandler(),
@@ -30,61 +67,522 @@ func NewDevBoxPack() *DevBoxPack {
 	}
 }
 
+// NewDevBoxPackWithLogger creates a DevBox Pack instance that reports progress to logger only
+func NewDevBoxPackWithLogger(logger Logger) *DevBoxPack {
+	devBoxPack := NewDevBoxPack()
+	if logger == nil {
+		logger = NopLogger{}
+	}
+	devBoxPack.logger = logger
+	return devBoxPack
+}
+
+// Fork returns a DevBoxPack sharing the Providers and logger of d with its own Git
+// handler, so concurrent analyses clean up their clones independently
+func (d *DevBoxPack) Fork() *DevBoxPack {
+	gitHandler := git.NewGitHandler()
+	gitHandler.SetCloneCache(d.gitHandler.CloneCache())
+	return &DevBoxPack{
+		gitHandler:      gitHandler,
+		detectionEngine: d.detectionEngine,
+		planGenerator:   d.planGenerator,
+		outputUtils:     d.outputUtils,
+		logger:          d.logger,
+		planCache:       d.planCache,
+	}
+}
+
+// SetCloneCache makes remote repositories clone through cache, nil clones afresh
+func (d *DevBoxPack) SetCloneCache(cache *git.CloneCache) {
+	d.gitHandler.SetCloneCache(cache)
+}
+
+// RegisterProvider makes an additional Provider available for detection and
+// plan generation. Names already taken by another Provider are rejected.
+func (d *DevBoxPack) RegisterProvider(provider detector.Provider) error {
+	if _, exists := d.detectionEngine.GetProvider(provider.GetName()); exists {
+		return types.NewDevBoxPackError(
+			fmt.Sprintf("Provider %s is already registered", provider.GetName()),
+			types.ErrorCodeInvalidProvider,
+			nil,
+		)
+	}
+	d.detectionEngine.RegisterProvider(provider)
+	d.planGenerator.RegisterProvider(provider)
+	return nil
+}
+
+// LoadPlugins discovers provider plugins in dirs, validates them with a
+// handshake and registers them next to the built-in Providers
+func (d *DevBoxPack) LoadPlugins(ctx context.Context, dirs []string) error {
+	loaded, err := plugins.LoadAll(ctx, dirs)
+	if err != nil {
+		return err
+	}
+	for _, provider := range loaded {
+		if err := d.RegisterProvider(provider); err != nil {
+			return err
+		}
+		if d.logger != nil {
+			d.logger.Debug(fmt.Sprintf("Loaded plugin %s from %s", provider.GetName(), provider.Executable()))
+		}
+	}
+	return nil
+}
+
+// LoadRules compiles the declarative rule files in dirs and registers them
+// next to the built-in Providers
+func (d *DevBoxPack) LoadRules(dirs []string) error {
+	loaded, err := rules.Load(dirs)
+	if err != nil {
+		return err
+	}
+	for _, provider := range loaded {
+		if err := d.RegisterProvider(provider); err != nil {
+			return err
+		}
+		if d.logger != nil {
+			d.logger.Debug(fmt.Sprintf("Loaded rule %s from %s", provider.GetName(), provider.Rule().File))
+		}
+	}
+	return nil
+}
+
+// Provider sources reported by Providers
+const (
+	ProviderSourceBuiltin = "builtin"
+	ProviderSourcePlugin  = "plugin"
+	ProviderSourceRule    = "rule"
+	ProviderSourceCustom  = "custom"
+)
+
+// ProviderInfo describes a Provider available for detection
+type ProviderInfo struct {
+	providers.Registration
+	// Where the Provider comes from: builtin, plugin, rule or custom
+	Source string `json:"source"`
+}
+
+// Providers lists every Provider available for detection, including loaded
+// plugins and rules, sorted by priority, then name
+func (d *DevBoxPack) Providers() []ProviderInfo {
+	var list []ProviderInfo
+	for _, name := range d.detectionEngine.GetAvailableProviders() {
+		provider, _ := d.detectionEngine.GetProvider(name)
+		info := ProviderInfo{
+			Registration: providers.Registration{
+				Name:     provider.GetName(),
+				Language: provider.GetLanguage(),
+				Priority: provider.GetPriority(),
+			},
+		}
+
+		switch provider := provider.(type) {
+		case *plugins.Provider:
+			info.Source = ProviderSourcePlugin
+		case *rules.Provider:
+			info.Source = ProviderSourceRule
+			rule := provider.Rule()
+			if rule.Framework != "" {
+				info.Frameworks = append(info.Frameworks, rule.Framework)
+			}
+			for _, framework := range rule.Frameworks {
+				info.Frameworks = append(info.Frameworks, framework.Name)
+			}
+			for _, indicator := range rule.Indicators {
+				if indicator.File != "" {
+					info.KeyFiles = append(info.KeyFiles, indicator.File)
+				}
+			}
+			if rule.PackageManager != "" {
+				info.PackageManagers = []string{rule.PackageManager}
+			}
+		default:
+			info.Source = ProviderSourceCustom
+			if registration, ok := providers.Lookup(name); ok {
+				info.Source = ProviderSourceBuiltin
+				info.Registration = registration
+			}
+		}
+		list = append(list, info)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Priority < list[j].Priority
+	})
+	return list
+}
+
+// loggerFor returns the configured logger, falling back to console output
+func (d *DevBoxPack) loggerFor(options *types.CLIOptions) Logger {
+	if d.logger != nil {
+		return d.logger
+	}
+	return NewConsoleLogger(options)
+}
+
 // GeneratePlan generates execution plan
-func (d *DevBoxPack) GeneratePlan(repoPath string, options *types.CLIOptions) (*types.ExecutionPlan, error) {
-	// 1. Prepare project directory
-	d.outputUtils.OutputInfo("Preparing project directory...", options)
-	projectPath, err := d.gitHandler.PrepareProject(repoPath)
+func (d *DevBoxPack) GeneratePlan(ctx context.Context, repoPath string, options *types.CLIOptions) (*types.ExecutionPlan, error) {
+	analysis, err := d.Analyze(ctx, repoPath, options)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.Plan, nil
+}
+
+// Analyze prepares, scans, detects and plans a project, returning every intermediate result
+func (d *DevBoxPack) Analyze(ctx context.Context, repoPath string, options *types.CLIOptions) (*Analysis, error) {
+	logger := d.loggerFor(options)
+
+	// 1. Prepare project source
+	logger.Progress(StagePrepare, "Preparing project directory...")
+	if d.planCache != nil {
+		return d.analyzeCached(ctx, repoPath, options, logger)
+	}
+	fsys, err := d.openSource(ctx, repoPath, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare project: %w", err)
 	}
 
+	return d.analyzeSource(ctx, fsys, repoPath, options, logger)
+}
+
+// analyzeCached analyses repoPath through the plan cache
+func (d *DevBoxPack) analyzeCached(ctx context.Context, repoPath string, options *types.CLIOptions, logger Logger) (*Analysis, error) {
+	var cached Analysis
+	src, hit, err := d.openCached(ctx, repoPath, options, cacheKindPlan, &cached)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare project: %w", err)
+	}
+	if hit {
+		logger.Progress(StageDone, "Execution plan loaded from cache")
+		cached.markCached()
+		return &cached, nil
+	}
+
+	analysis, err := d.analyzeSource(ctx, src.fsys, repoPath, options, logger)
+	if err != nil {
+		return nil, err
+	}
+	analysis.Plan.Cache = d.cacheInfo(src)
+	d.storeCached(src, analysis, logger)
+	return analysis, nil
+}
+
+// markCached marks an analysis loaded from the plan cache, dropping the
+// provider timings of the run that stored it
+func (a *Analysis) markCached() {
+	a.Cached = true
+	a.Providers = nil
+}
+
+// AnalyzeFS scans, detects and plans a project tree that is already available as a source
+func (d *DevBoxPack) AnalyzeFS(ctx context.Context, fsys fs.FS, options *types.CLIOptions) (*Analysis, error) {
+	return d.analyzeSource(ctx, fsys, sourceName, options, d.loggerFor(options))
+}
+
+// analyzeSource analyses a prepared source and reports completion
+func (d *DevBoxPack) analyzeSource(ctx context.Context, fsys fs.FS, name string, options *types.CLIOptions, logger Logger) (*Analysis, error) {
+	analysis, err := d.analyzeProject(ctx, fsys, name, options, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Progress(StageDone, "Execution plan generated successfully")
+	return analysis, nil
+}
+
+// GenerateMonorepoPlans generates one execution plan per service found in the repository.
+// The returned map is keyed by service path relative to the repository root.
+func (d *DevBoxPack) GenerateMonorepoPlans(ctx context.Context, repoPath string, options *types.CLIOptions) (map[string]*types.ExecutionPlan, error) {
+	analyses, err := d.AnalyzeMonorepo(ctx, repoPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make(map[string]*types.ExecutionPlan, len(analyses))
+	for servicePath, analysis := range analyses {
+		plans[servicePath] = analysis.Plan
+	}
+	return plans, nil
+}
+
+// AnalyzeMonorepo analyses every service found in the repository, keyed by service path
+func (d *DevBoxPack) AnalyzeMonorepo(ctx context.Context, repoPath string, options *types.CLIOptions) (map[string]*Analysis, error) {
+	logger := d.loggerFor(options)
+
+	logger.Progress(StagePrepare, "Preparing project directory...")
+	if d.planCache != nil {
+		return d.analyzeMonorepoCached(ctx, repoPath, options, logger)
+	}
+	fsys, err := d.openSource(ctx, repoPath, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare project: %w", err)
+	}
+
+	return d.analyzeMonorepo(ctx, fsys, repoPath, options, logger)
+}
+
+// analyzeMonorepoCached analyses every service of repoPath through the plan cache
+func (d *DevBoxPack) analyzeMonorepoCached(ctx context.Context, repoPath string, options *types.CLIOptions, logger Logger) (map[string]*Analysis, error) {
+	var cached map[string]*Analysis
+	src, hit, err := d.openCached(ctx, repoPath, options, cacheKindMonorepo, &cached)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare project: %w", err)
+	}
+	if hit {
+		logger.Progress(StageDone, fmt.Sprintf("Loaded %d execution plans from cache", len(cached)))
+		for _, analysis := range cached {
+			analysis.markCached()
+		}
+		return cached, nil
+	}
+
+	analyses, err := d.analyzeMonorepo(ctx, src.fsys, repoPath, options, logger)
+	if err != nil {
+		return nil, err
+	}
+	info := d.cacheInfo(src)
+	for _, analysis := range analyses {
+		analysis.Plan.Cache = info
+	}
+	d.storeCached(src, analyses, logger)
+	return analyses, nil
+}
+
+// AnalyzeMonorepoFS analyses every service found in a source, keyed by service path
+func (d *DevBoxPack) AnalyzeMonorepoFS(ctx context.Context, fsys fs.FS, options *types.CLIOptions) (map[string]*Analysis, error) {
+	return d.analyzeMonorepo(ctx, fsys, sourceName, options, d.loggerFor(options))
+}
+
+// analyzeMonorepo discovers service roots in a prepared source and analyses each of them
+func (d *DevBoxPack) analyzeMonorepo(ctx context.Context, fsys fs.FS, name string, options *types.CLIOptions, logger Logger) (map[string]*Analysis, error) {
+	logger.Progress(StageDiscover, "Discovering services...")
+	files, err := source.Scan(ctx, fsys, &types.ScanOptions{
+		Depth:    MonorepoScanDepth,
+		MaxFiles: source.MaxFiles,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+
+	roots := detector.FindServiceRoots(fsys, dereferenceFiles(files))
+	if len(roots) == 0 {
+		return nil, unsupportedError(fmt.Sprintf("no project roots found in path: %s", name))
+	}
+	logger.Debug(fmt.Sprintf("Found %d service roots: %v", len(roots), roots))
+
+	analyses := make(map[string]*Analysis, len(roots))
+	for _, root := range roots {
+		logger.Progress(StageScan, fmt.Sprintf("Analyzing service %s...", root))
+		serviceFS, err := fs.Sub(fsys, root)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Skipping %s: %s", root, err.Error()))
+			continue
+		}
+		analysis, err := d.analyzeProject(ctx, serviceFS, path.Join(name, root), options, logger)
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			// A root that no provider understands should not sink the other services
+			logger.Warning(fmt.Sprintf("Skipping %s: %s", root, err.Error()))
+			continue
+		}
+		analyses[root] = analysis
+	}
+
+	if len(analyses) == 0 {
+		return nil, unsupportedError(fmt.Sprintf("no supported language or framework detected in any service of: %s", name))
+	}
+
+	logger.Progress(StageDone, fmt.Sprintf("Generated %d execution plans", len(analyses)))
+	return analyses, nil
+}
+
+// openSource opens the options.Subdir directory of repoPath for analysis. Local .tar.gz
+// and .zip files are read in memory; anything else is opened by the Git handler at
+// options.Ref.
+func (d *DevBoxPack) openSource(ctx context.Context, repoPath string, options *types.CLIOptions) (fs.FS, error) {
+	if source.ArchiveFormat(repoPath) != "" {
+		if stat, err := os.Stat(repoPath); err == nil && !stat.IsDir() {
+			fsys, err := source.OpenArchive(repoPath)
+			if err != nil || options.Subdir == nil {
+				return fsys, err
+			}
+			return source.Sub(fsys, *options.Subdir)
+		}
+	}
+
+	return d.gitHandler.OpenRepository(ctx, &types.GitRepository{
+		URL:    repoPath,
+		Ref:    options.Ref,
+		Subdir: options.Subdir,
+	})
+}
+
+// analyzeProject scans, detects and plans a single project source.
+// name identifies the project in error messages.
+func (d *DevBoxPack) analyzeProject(ctx context.Context, fsys fs.FS, name string, options *types.CLIOptions, logger Logger) (*Analysis, error) {
+	// Load repository override file and the configuration of other tools
+	override, err := config.LoadOverride(fsys)
+	if err != nil {
+		return nil, err
+	}
+	imports, err := config.LoadImports(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		logger.Debug(fmt.Sprintf("Using override file %s", override.File))
+	}
+	// --provider wins over providers pinned in files, which would otherwise
+	// name a provider that did not produce the plan's commands
+	if options.Provider != nil && *options.Provider != "" {
+		override = unpinProvider(override, *options.Provider, logger)
+		for i, imported := range imports {
+			imports[i] = unpinProvider(imported, *options.Provider, logger)
+		}
+	}
+	// A provider pinned in the override file, or else in the last imported file pinning one,
+	// applies unless --provider was given
+	var provider *string
+	for _, imported := range imports {
+		if imported.Provider != nil {
+			provider = imported.Provider
+		}
+	}
+	if override != nil && override.Provider != nil {
+		provider = override.Provider
+	}
+	if provider != nil && (options.Provider == nil || *options.Provider == "") {
+		pinned := *options
+		pinned.Provider = provider
+		options = &pinned
+	}
+
 	// 2. Scan project files
-	d.outputUtils.OutputInfo("Scanning project files...", options)
+	logger.Progress(StageScan, "Scanning project files...")
 	scanOptions := &types.ScanOptions{
 		MaxDepth: 3,
 		MaxFiles: 1000,
 	}
-	files, err := d.gitHandler.ScanProject(projectPath, scanOptions)
+	files, err := source.Scan(ctx, fsys, scanOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan project: %w", err)
 	}
 
-	d.outputUtils.OutputDebug(fmt.Sprintf("Scanned %d files", len(files)), options)
+	logger.Debug(fmt.Sprintf("Scanned %d files", len(files)))
 
 	// 3. Detect language and framework
-	d.outputUtils.OutputInfo("Detecting language and framework...", options)
-	fileInfos := make([]types.FileInfo, len(files))
-	for i, file := range files {
-		fileInfos[i] = *file
-	}
-	detectResults, err := d.detectionEngine.DetectProject(projectPath, fileInfos, d.gitHandler, options)
+	logger.Progress(StageDetect, "Detecting language and framework...")
+	report, err := d.detectionEngine.Detect(ctx, fsys, dereferenceFiles(files), options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect project: %w", err)
 	}
+	for _, diagnostic := range report.Diagnostics {
+		logger.Debug(diagnostic.Message)
+	}
+	for _, run := range report.Providers {
+		logger.Debug(fmt.Sprintf("Provider %s finished in %s (matched: %t)", run.Name, run.Duration, run.Matched))
+	}
 
-	if len(detectResults) == 0 {
-		return nil, fmt.Errorf("no supported language or framework detected in path: %s", projectPath)
+	if len(report.Results) == 0 {
+		return nil, unsupportedError(fmt.Sprintf("no supported language or framework detected in path: %s", name))
 	}
 
 	// 4. Generate execution plan
-	d.outputUtils.OutputInfo("Generating execution plan...", options)
-	detectResultValues := make([]types.DetectResult, len(detectResults))
-	for i, result := range detectResults {
+	logger.Progress(StagePlan, "Generating execution plan...")
+	detectResultValues := make([]types.DetectResult, len(report.Results))
+	for i, result := range report.Results {
 		detectResultValues[i] = *result
 	}
-	plan, err := d.planGenerator.GeneratePlan(detectResultValues, *options)
+	plan, err := d.planGenerator.GeneratePlan(ctx, detectResultValues, *options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate plan: %w", err)
 	}
 
-	d.outputUtils.OutputSuccess("Execution plan generated successfully", options)
-	return plan, nil
+	// 5. Seed the plan from checked-in buildpack, Nixpacks, Railpack and Dev Container files
+	for _, imported := range imports {
+		logger.Debug(fmt.Sprintf("Using %s", imported.File))
+		plan.Evidence.Files = append(plan.Evidence.Files, imported.File)
+		imported.Apply(plan)
+	}
+
+	// 6. Infer databases, caches and brokers from dependencies and environment
+	plan.BackingServices = backing.Detect(fsys, plan.Environment)
+
+	// 7. Merge repository overrides over the generated plan
+	override.Apply(plan)
+
+	analysis := &Analysis{
+		Plan:        plan,
+		Detections:  report.Results,
+		Diagnostics: report.Diagnostics,
+		Providers:   report.Providers,
+	}
+	if options.Explain {
+		analysis.Explanation = d.explain(report, detectResultValues, options)
+	}
+	return analysis, nil
+}
+
+// explain combines the detection scoring with the reasoning behind the selected result
+func (d *DevBoxPack) explain(report *detector.DetectionReport, results []types.DetectResult, options *types.CLIOptions) *types.Explanation {
+	explanation := &types.Explanation{Providers: report.Explanations}
+	_, explanation.Selection = d.planGenerator.SelectResult(results)
+	if explanation.Selection != nil && options.Provider != nil && *options.Provider != "" {
+		explanation.Selection.Rule = types.SelectionRuleForced
+		explanation.Selection.Reason = fmt.Sprintf("Provider %s was forced, other providers did not run", *options.Provider)
+	}
+	return explanation
+}
+
+// unpinProvider drops a provider pinned by an override or imported file that
+// differs from the forced one, warning about the conflict
+func unpinProvider(override *config.Override, forced string, logger Logger) *config.Override {
+	if override == nil || override.Provider == nil || *override.Provider == forced {
+		return override
+	}
+	logger.Warning(fmt.Sprintf("Ignoring provider %s pinned in %s, --provider %s was given", *override.Provider, override.File, forced))
+	unpinned := *override
+	unpinned.Provider = nil
+	return &unpinned
+}
+
+// unsupportedError reports a project that no provider understands
+func unsupportedError(message string) error {
+	return types.NewDevBoxPackError(message, types.ErrorCodeUnsupported, nil)
+}
+
+// dereferenceFiles converts scanned file pointers into values
+func dereferenceFiles(files []*types.FileInfo) []types.FileInfo {
+	fileInfos := make([]types.FileInfo, len(files))
+	for i, file := range files {
+		fileInfos[i] = *file
+	}
+	return fileInfos
+}
+
+// Cleanup removes temporary directories created while preparing projects
+func (d *DevBoxPack) Cleanup() error {
+	return d.gitHandler.Cleanup()
 }
 
 // Run executes the complete workflow
-func (d *DevBoxPack) Run(repoPath string, options *types.CLIOptions) error {
-	plan, err := d.GeneratePlan(repoPath, options)
+func (d *DevBoxPack) Run(ctx context.Context, repoPath string, options *types.CLIOptions) error {
+	defer d.Cleanup()
+
+	if options.Monorepo {
+		plans, err := d.GenerateMonorepoPlans(ctx, repoPath, options)
+		if err != nil {
+			d.outputUtils.OutputError(err, options)
+			return err
+		}
+		return d.outputUtils.OutputPlans(plans, options)
+	}
+
+	plan, err := d.GeneratePlan(ctx, repoPath, options)
 	if err != nil {
 		d.outputUtils.OutputError(err, options)
 		return err
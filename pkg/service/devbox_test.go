@@ -1,10 +1,18 @@
 package service
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/labring/devbox-pack/pkg/providers"
+	"github.com/labring/devbox-pack/pkg/source"
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
@@ -39,7 +47,7 @@ func TestGeneratePlan_InvalidPath(t *testing.T) {
 	}
 
 	// Test with non-existent path
-	_, err := devbox.GeneratePlan("/non/existent/path", options)
+	_, err := devbox.GeneratePlan(context.Background(), "/non/existent/path", options)
 	if err == nil {
 		t.Fatal("Expected error for non-existent path, but got nil")
 	}
@@ -81,7 +89,7 @@ func TestGeneratePlan_ValidPath(t *testing.T) {
 	}
 
 	// Test plan generation
-	plan, err := devbox.GeneratePlan(tmpDir, options)
+	plan, err := devbox.GeneratePlan(context.Background(), tmpDir, options)
 	if err != nil {
 		t.Fatalf("GeneratePlan failed: %v", err)
 	}
@@ -108,8 +116,415 @@ func TestRun_InvalidPath(t *testing.T) {
 	}
 
 	// Test with non-existent path
-	err := devbox.Run("/non/existent/path", options)
+	err := devbox.Run(context.Background(), "/non/existent/path", options)
 	if err == nil {
 		t.Fatal("Expected error for non-existent path, but got nil")
 	}
 }
+
+func TestGeneratePlan_WithOverrideFile(t *testing.T) {
+	devbox := NewDevBoxPack()
+	options := &types.CLIOptions{
+		Quiet:  true,
+		Format: "json",
+	}
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"name": "override-test", "scripts": {"start": "node index.js"}}`,
+		"index.js":     `console.log("hello");`,
+		"devbox-pack.toml": `
+port = 4000
+
+[commands]
+run = ["node server.js"]
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	plan, err := devbox.GeneratePlan(context.Background(), tmpDir, options)
+	if err != nil {
+		t.Fatalf("GeneratePlan failed: %v", err)
+	}
+
+	if plan.Port != 4000 {
+		t.Errorf("expected overridden port 4000, got %d", plan.Port)
+	}
+	if len(plan.Commands.Run) != 1 || plan.Commands.Run[0] != "node server.js" {
+		t.Errorf("expected overridden run command, got %v", plan.Commands.Run)
+	}
+	if len(plan.Commands.Setup) == 0 {
+		t.Error("expected detected setup commands to be kept")
+	}
+	if len(plan.Evidence.Overrides) != 1 || plan.Evidence.Overrides[0].File != "devbox-pack.toml" {
+		t.Errorf("expected override evidence for devbox-pack.toml, got %+v", plan.Evidence.Overrides)
+	}
+}
+
+// warningLogger records the warnings of an analysis
+type warningLogger struct {
+	NopLogger
+	warnings []string
+}
+
+func (l *warningLogger) Warning(message string) {
+	l.warnings = append(l.warnings, message)
+}
+
+func TestAnalyzeFS_OverrideProviderWithForcedProvider(t *testing.T) {
+	logger := &warningLogger{}
+	devbox := NewDevBoxPackWithLogger(logger)
+	fsys := source.NewMap(map[string]string{
+		"package.json":     `{"name": "web", "scripts": {"start": "node index.js"}}`,
+		"index.js":         `console.log("hello");`,
+		"requirements.txt": "flask\n",
+		"app.py":           "print('hello')\n",
+		"devbox-pack.json": `{"provider": "python", "port": 4000}`,
+	})
+
+	forced := "node"
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, &types.CLIOptions{Provider: &forced})
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	if analysis.Plan.Provider != "node" {
+		t.Errorf("expected forced node provider to be kept, got %s", analysis.Plan.Provider)
+	}
+	if analysis.Plan.Port != 4000 {
+		t.Errorf("expected the other overrides to apply, got port %d", analysis.Plan.Port)
+	}
+	if len(logger.warnings) != 1 || !strings.Contains(logger.warnings[0], "python") {
+		t.Errorf("expected a warning about the pinned provider, got %v", logger.warnings)
+	}
+
+	// Without --provider the pinned provider applies
+	logger.warnings = nil
+	analysis, err = devbox.AnalyzeFS(context.Background(), fsys, &types.CLIOptions{})
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	if analysis.Plan.Provider != "python" || len(logger.warnings) != 0 {
+		t.Errorf("expected pinned python provider without warnings, got %s, %v", analysis.Plan.Provider, logger.warnings)
+	}
+}
+
+func TestAnalyzeFS_Devcontainer(t *testing.T) {
+	devbox := NewDevBoxPackWithLogger(nil)
+	options := &types.CLIOptions{Format: "json"}
+
+	fsys := source.NewMap(map[string]string{
+		"package.json": `{"name": "devcontainer-test", "scripts": {"start": "node index.js"}}`,
+		"index.js":     `console.log("hello");`,
+		".devcontainer/devcontainer.json": `{
+			// Seeds the plan
+			"image": "mcr.microsoft.com/devcontainers/javascript-node:20",
+			"forwardPorts": [5173],
+			"containerEnv": {"API_URL": "http://localhost:4000"},
+			"postCreateCommand": "npm ci",
+		}`,
+		"devbox-pack.json": `{"port": 4000}`,
+	})
+
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, options)
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	plan := analysis.Plan
+	if plan.Runtime.Image != "mcr.microsoft.com/devcontainers/javascript-node:20" {
+		t.Errorf("expected the Dev Container image, got %s", plan.Runtime.Image)
+	}
+	if len(plan.Commands.Setup) != 1 || plan.Commands.Setup[0] != "npm ci" {
+		t.Errorf("expected the post-create command as setup, got %v", plan.Commands.Setup)
+	}
+	if plan.Environment["API_URL"] != "http://localhost:4000" {
+		t.Errorf("expected the container environment, got %v", plan.Environment)
+	}
+	// The repository override file still wins
+	if plan.Port != 4000 {
+		t.Errorf("expected overridden port 4000, got %d", plan.Port)
+	}
+	if len(plan.Evidence.Overrides) != 2 || plan.Evidence.Overrides[0].File != ".devcontainer/devcontainer.json" {
+		t.Errorf("expected Dev Container then override evidence, got %+v", plan.Evidence.Overrides)
+	}
+}
+
+func TestAnalyzeFS_BuilderImports(t *testing.T) {
+	devbox := NewDevBoxPackWithLogger(nil)
+	options := &types.CLIOptions{Format: "json"}
+
+	fsys := source.NewMap(map[string]string{
+		// A Python project with a Node.js frontend, pinned to Node.js by Railpack
+		"requirements.txt": "flask\n",
+		"app.py":           "print('hello')\n",
+		"package.json":     `{"name": "builder-test", "scripts": {"build": "vite build"}}`,
+		"railpack.json":    `{"provider": "node", "steps": {"build": {"commands": ["...", "npm run lint"]}}, "deploy": {"startCommand": "node server.js"}}`,
+		"nixpacks.toml":    "[variables]\nAPI_URL = \"http://localhost:4000\"\n",
+	})
+
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, options)
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	plan := analysis.Plan
+	if plan.Provider != "node" {
+		t.Errorf("expected the provider pinned by railpack.json, got %s", plan.Provider)
+	}
+	if len(plan.Commands.Build) != 2 || plan.Commands.Build[0] != "npm run build" || plan.Commands.Build[1] != "npm run lint" {
+		t.Errorf("expected detected build commands followed by npm run lint, got %v", plan.Commands.Build)
+	}
+	if len(plan.Commands.Run) != 1 || plan.Commands.Run[0] != "node server.js" {
+		t.Errorf("expected the Railpack start command, got %v", plan.Commands.Run)
+	}
+	if plan.Environment["API_URL"] != "http://localhost:4000" {
+		t.Errorf("expected the Nixpacks variables, got %v", plan.Environment)
+	}
+	if len(plan.Evidence.Overrides) != 2 || plan.Evidence.Overrides[0].File != "nixpacks.toml" || plan.Evidence.Overrides[1].File != "railpack.json" {
+		t.Errorf("expected Nixpacks then Railpack evidence, got %+v", plan.Evidence.Overrides)
+	}
+}
+
+func TestGenerateMonorepoPlans(t *testing.T) {
+	devbox := NewDevBoxPack()
+	options := &types.CLIOptions{
+		Quiet:    true,
+		Format:   "json",
+		Monorepo: true,
+	}
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"frontend/package.json": `{"name": "frontend", "scripts": {"start": "node index.js"}}`,
+		"frontend/index.js":     `console.log("frontend");`,
+		"backend/go.mod":        "module example.com/backend\n\ngo 1.21\n",
+		"backend/main.go":       "package main\n\nfunc main() {}\n",
+		"docs/README.md":        "# docs",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	plans, err := devbox.GenerateMonorepoPlans(context.Background(), tmpDir, options)
+	if err != nil {
+		t.Fatalf("GenerateMonorepoPlans failed: %v", err)
+	}
+
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 plans, got %d: %v", len(plans), plans)
+	}
+	if plans["frontend"] == nil || plans["frontend"].Provider != "node" {
+		t.Errorf("expected node plan for frontend, got %+v", plans["frontend"])
+	}
+	if plans["backend"] == nil || plans["backend"].Provider != "go" {
+		t.Errorf("expected go plan for backend, got %+v", plans["backend"])
+	}
+}
+
+func TestAnalyzeFS_InMemory(t *testing.T) {
+	devbox := NewDevBoxPackWithLogger(nil)
+	options := &types.CLIOptions{Format: "json"}
+
+	fsys := source.NewMap(map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, options)
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	if analysis.Plan.Provider != "go" {
+		t.Errorf("expected go plan, got %s", analysis.Plan.Provider)
+	}
+}
+
+func TestGeneratePlan_Archive(t *testing.T) {
+	devbox := NewDevBoxPackWithLogger(nil)
+	options := &types.CLIOptions{Format: "json"}
+
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	files := map[string]string{
+		"app-1.0/package.json": `{"name": "archived", "scripts": {"start": "node index.js"}}`,
+		"app-1.0/index.js":     `console.log("hello");`,
+	}
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+
+	archivePath := filepath.Join(t.TempDir(), "app.tar.gz")
+	if err := os.WriteFile(archivePath, buffer.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	plan, err := devbox.GeneratePlan(context.Background(), archivePath, options)
+	if err != nil {
+		t.Fatalf("GeneratePlan failed: %v", err)
+	}
+	if plan.Provider != "node" {
+		t.Errorf("expected node plan, got %s", plan.Provider)
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+
+	pluginDir := t.TempDir()
+	script := `#!/bin/sh
+case "$(cat)" in
+*'"method":"handshake"'*)
+	echo '{"protocolVersion":1,"name":"acme","language":"java","priority":1}'
+	;;
+*'acme.toml'*)
+	echo '{"result":{"matched":true,"confidence":1,"framework":"Acme"},"commands":{"run":["acme run"]}}'
+	;;
+*)
+	echo '{"result":null}'
+	;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(pluginDir, "devbox-pack-provider-acme"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+
+	devbox := NewDevBoxPackWithLogger(nil)
+	if err := devbox.LoadPlugins(context.Background(), []string{pluginDir}); err != nil {
+		t.Fatalf("LoadPlugins failed: %v", err)
+	}
+
+	fsys := source.NewMap(map[string]string{"acme.toml": "[app]\n"})
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, &types.CLIOptions{Format: "json"})
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	if analysis.Plan.Provider != "acme" {
+		t.Errorf("expected acme plan, got %s", analysis.Plan.Provider)
+	}
+	if len(analysis.Plan.Commands.Run) != 1 || analysis.Plan.Commands.Run[0] != "acme run" {
+		t.Errorf("expected run command from plugin, got %+v", analysis.Plan.Commands)
+	}
+
+	// Loading the same plugin again conflicts with the registered name
+	if err := devbox.LoadPlugins(context.Background(), []string{pluginDir}); err == nil {
+		t.Error("expected error for duplicate provider name")
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	rulesDir := t.TempDir()
+	rule := `name: acme
+language: java
+indicators:
+  - file: acme.toml
+    weight: 1
+commands:
+  run: ["acme run --port {{.Port}}"]
+port: 9090
+`
+	if err := os.WriteFile(filepath.Join(rulesDir, "acme.yaml"), []byte(rule), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	devbox := NewDevBoxPackWithLogger(nil)
+	if err := devbox.LoadRules([]string{rulesDir}); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	fsys := source.NewMap(map[string]string{"acme.toml": "[app]\n"})
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, &types.CLIOptions{Format: "json"})
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	if analysis.Plan.Provider != "acme" || analysis.Plan.Port != 9090 {
+		t.Errorf("expected acme plan on port 9090, got %s on %d", analysis.Plan.Provider, analysis.Plan.Port)
+	}
+	if len(analysis.Plan.Commands.Run) != 1 || analysis.Plan.Commands.Run[0] != "acme run --port 9090" {
+		t.Errorf("expected rendered run command, got %+v", analysis.Plan.Commands)
+	}
+}
+
+func TestProviders(t *testing.T) {
+	rulesDir := t.TempDir()
+	rule := "name: acme\nlanguage: java\npriority: 65\nframework: Acme\nindicators:\n  - file: acme.toml\n    weight: 1\n"
+	if err := os.WriteFile(filepath.Join(rulesDir, "acme.yaml"), []byte(rule), 0644); err != nil {
+		t.Fatalf("Failed to write rule: %v", err)
+	}
+
+	devbox := NewDevBoxPackWithLogger(nil)
+	if err := devbox.LoadRules([]string{rulesDir}); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	list := devbox.Providers()
+	if len(list) != len(providers.Registered())+1 {
+		t.Fatalf("expected built-in Providers plus the rule, got %d", len(list))
+	}
+	for i, info := range list {
+		if i > 0 && list[i-1].Priority > info.Priority {
+			t.Errorf("expected Providers sorted by priority, %s before %s", list[i-1].Name, info.Name)
+		}
+		switch info.Name {
+		case "acme":
+			if info.Source != ProviderSourceRule || info.Priority != 65 || len(info.KeyFiles) != 1 || info.Frameworks[0] != "Acme" {
+				t.Errorf("unexpected rule Provider: %+v", info)
+			}
+		case "node":
+			if info.Source != ProviderSourceBuiltin || len(info.Frameworks) == 0 {
+				t.Errorf("unexpected node Provider: %+v", info)
+			}
+		}
+	}
+}
+
+func TestAnalyzeFS_Explain(t *testing.T) {
+	devbox := NewDevBoxPackWithLogger(nil)
+	fsys := source.NewMap(map[string]string{
+		"package.json":     `{"dependencies": {"react": "^18.0.0"}}`,
+		"requirements.txt": "flask==3.0.0\n",
+		"app.py":           "from flask import Flask\n",
+	})
+
+	analysis, err := devbox.AnalyzeFS(context.Background(), fsys, &types.CLIOptions{Format: "json", Explain: true})
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	explanation := analysis.Explanation
+	if explanation == nil || explanation.Selection == nil {
+		t.Fatal("expected an explanation with a selection")
+	}
+	if len(explanation.Providers) != len(providers.Registered()) {
+		t.Errorf("expected every provider to be explained, got %d", len(explanation.Providers))
+	}
+	if explanation.Selection.Provider != analysis.Plan.Provider || explanation.Selection.Rule != types.SelectionRuleBackendFirst {
+		t.Errorf("unexpected selection %+v for plan provider %s", explanation.Selection, analysis.Plan.Provider)
+	}
+
+	forced := "node"
+	analysis, err = devbox.AnalyzeFS(context.Background(), fsys, &types.CLIOptions{Format: "json", Explain: true, Provider: &forced})
+	if err != nil {
+		t.Fatalf("AnalyzeFS failed: %v", err)
+	}
+	if len(analysis.Explanation.Providers) != 1 || analysis.Explanation.Selection.Rule != types.SelectionRuleForced {
+		t.Errorf("expected forced selection, got %+v", analysis.Explanation.Selection)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"github.com/labring/devbox-pack/pkg/formatters"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Stage identifies a step of the plan generation pipeline
+type Stage string
+
+const (
+	// StagePrepare prepares the project directory (clone or local path)
+	StagePrepare Stage = "prepare"
+	// StageDiscover discovers service roots in monorepo mode
+	StageDiscover Stage = "discover"
+	// StageScan scans project files
+	StageScan Stage = "scan"
+	// StageDetect runs the detection providers
+	StageDetect Stage = "detect"
+	// StagePlan generates the execution plan
+	StagePlan Stage = "plan"
+	// StageDone reports that analysis finished successfully
+	StageDone Stage = "done"
+)
+
+// Logger receives progress and diagnostic messages from the service.
+// Implementations decide where (if anywhere) messages are written.
+type Logger interface {
+	// Progress reports that a pipeline stage has started or finished
+	Progress(stage Stage, message string)
+	// Debug reports detailed information
+	Debug(message string)
+	// Warning reports a non-fatal problem
+	Warning(message string)
+}
+
+// NopLogger discards all messages
+type NopLogger struct{}
+
+// Progress discards the message
+func (NopLogger) Progress(Stage, string) {}
+
+// Debug discards the message
+func (NopLogger) Debug(string) {}
+
+// Warning discards the message
+func (NopLogger) Warning(string) {}
+
+// consoleLogger writes messages through OutputUtils, honouring the CLI verbosity options
+type consoleLogger struct {
+	outputUtils *formatters.OutputUtils
+	options     *types.CLIOptions
+}
+
+// NewConsoleLogger creates a Logger that prints to stdout like the CLI does
+func NewConsoleLogger(options *types.CLIOptions) Logger {
+	return &consoleLogger{
+		outputUtils: formatters.NewOutputUtils(),
+		options:     options,
+	}
+}
+
+// Progress prints stage messages as info lines and completion as success
+func (l *consoleLogger) Progress(stage Stage, message string) {
+	if stage == StageDone {
+		l.outputUtils.OutputSuccess(message, l.options)
+		return
+	}
+	l.outputUtils.OutputInfo(message, l.options)
+}
+
+// Debug prints the message in verbose mode
+func (l *consoleLogger) Debug(message string) {
+	l.outputUtils.OutputDebug(message, l.options)
+}
+
+// Warning prints the message unless quiet
+func (l *consoleLogger) Warning(message string) {
+	l.outputUtils.OutputWarning(message, l.options)
+}
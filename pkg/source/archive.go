@@ -0,0 +1,207 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Archive formats understood by OpenArchive
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// ArchiveLimits bounds what an archive may expand to, so a small compressed
+// archive cannot exhaust memory
+type ArchiveLimits struct {
+	// MaxSize is the total size of the files once decompressed, in bytes
+	MaxSize int64
+	// MaxEntries is the number of files, directories and other entries
+	MaxEntries int
+}
+
+// DefaultArchiveLimits are the limits of OpenArchive, NewTarGz and NewZip
+var DefaultArchiveLimits = ArchiveLimits{MaxSize: 256 << 20, MaxEntries: 100000}
+
+// ArchiveFormat returns the archive format implied by a file name, or "" when
+// the name does not look like a supported archive
+func ArchiveFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip
+	default:
+		return ""
+	}
+}
+
+// OpenArchive reads a .tar.gz, .tgz or .zip file into an in-memory source
+// without extracting it to disk, within DefaultArchiveLimits
+func OpenArchive(name string) (fs.FS, error) {
+	format := ArchiveFormat(name)
+	if format == "" {
+		return nil, archiveError(name, errors.New("unsupported archive format"))
+	}
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, archiveError(name, err)
+	}
+
+	if format == ArchiveZip {
+		return NewZip(bytes.NewReader(content), int64(len(content)))
+	}
+	return NewTarGz(bytes.NewReader(content))
+}
+
+// NewTarGz reads a gzip-compressed tar stream into an in-memory source within
+// DefaultArchiveLimits. A single top-level directory, as found in release
+// tarballs, becomes the root.
+func NewTarGz(r io.Reader) (fs.FS, error) {
+	return NewTarGzWithLimits(r, DefaultArchiveLimits)
+}
+
+// NewTarGzWithLimits is NewTarGz with the given limits
+func NewTarGzWithLimits(r io.Reader, limits ArchiveLimits) (fs.FS, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, archiveError(ArchiveTarGz, err)
+	}
+	defer gzipReader.Close()
+
+	tree := newMemFS()
+	tarReader := tar.NewReader(gzipReader)
+	var size int64
+	for entries := 1; ; entries++ {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, archiveError(ArchiveTarGz, err)
+		}
+		if entries > limits.MaxEntries {
+			return nil, entriesError(ArchiveTarGz, limits)
+		}
+
+		name := strings.TrimPrefix(header.Name, "./")
+		switch header.Typeflag {
+		case tar.TypeDir:
+			tree.addDir(name)
+		case tar.TypeReg:
+			// The header size is not trusted, reading stops one byte past the limit
+			data, err := io.ReadAll(io.LimitReader(tarReader, limits.MaxSize-size+1))
+			if err != nil {
+				return nil, archiveError(ArchiveTarGz, err)
+			}
+			if size += int64(len(data)); size > limits.MaxSize {
+				return nil, sizeError(ArchiveTarGz, limits)
+			}
+			tree.addFile(name, data, header.ModTime)
+		}
+		// Links and special files are not needed for detection
+	}
+	tree.finish()
+
+	return stripSingleRoot(tree)
+}
+
+// NewZip reads a zip archive into a source within DefaultArchiveLimits.
+// A single top-level directory, as found in repository downloads, becomes the root.
+func NewZip(r io.ReaderAt, size int64) (fs.FS, error) {
+	return NewZipWithLimits(r, size, DefaultArchiveLimits)
+}
+
+// NewZipWithLimits is NewZip with the given limits
+func NewZipWithLimits(r io.ReaderAt, size int64, limits ArchiveLimits) (fs.FS, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, archiveError(ArchiveZip, err)
+	}
+	if len(zipReader.File) > limits.MaxEntries {
+		return nil, entriesError(ArchiveZip, limits)
+	}
+	// Reading a zip file fails once it exceeds its declared size,
+	// so the declared sizes bound what the files expand to
+	var total uint64
+	for _, file := range zipReader.File {
+		if total += file.UncompressedSize64; total > uint64(limits.MaxSize) {
+			return nil, sizeError(ArchiveZip, limits)
+		}
+	}
+
+	tree := newMemFS()
+	for _, file := range zipReader.File {
+		name := strings.TrimPrefix(file.Name, "./")
+		if file.FileInfo().IsDir() {
+			tree.addDir(name)
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		zipFile := file
+		tree.addLazyFile(name, int64(zipFile.UncompressedSize64), func() ([]byte, error) {
+			reader, err := zipFile.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer reader.Close()
+			return io.ReadAll(reader)
+		})
+	}
+	tree.finish()
+
+	return stripSingleRoot(tree)
+}
+
+// stripSingleRoot descends into the only top-level entry when it is a directory
+func stripSingleRoot(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return fs.Sub(fsys, entries[0].Name())
+	}
+	return fsys, nil
+}
+
+// sizeError reports an archive expanding beyond limits.MaxSize
+func sizeError(name string, limits ArchiveLimits) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("archive %s expands beyond %d bytes", name, limits.MaxSize),
+		types.ErrorCodeInvalidInput,
+		map[string]interface{}{"archive": name, "limit": limits.MaxSize},
+	)
+}
+
+// entriesError reports an archive holding more than limits.MaxEntries entries
+func entriesError(name string, limits ArchiveLimits) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("archive %s holds more than %d entries", name, limits.MaxEntries),
+		types.ErrorCodeInvalidInput,
+		map[string]interface{}{"archive": name, "limit": limits.MaxEntries},
+	)
+}
+
+// archiveError wraps archive read failures
+func archiveError(name string, err error) error {
+	return types.NewDevBoxPackError(
+		fmt.Sprintf("Failed to read archive %s: %s", name, err.Error()),
+		types.ErrorCodeArchiveError,
+		map[string]interface{}{"archive": name},
+	)
+}
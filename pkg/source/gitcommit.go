@@ -0,0 +1,129 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// NewGitCommit returns the tree of revision rev in the Git repository at
+// repoPath as a source. The repository may be bare; nothing is checked out.
+// File contents are read with git cat-file on first access, using ctx.
+func NewGitCommit(ctx context.Context, repoPath, rev string) (fs.FS, error) {
+	commit, err := ResolveCommit(ctx, repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	listing, err := runGit(ctx, repoPath, "ls-tree", "-r", "-z", "--long", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := newMemFS()
+	scanner := bufio.NewScanner(strings.NewReader(listing))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(splitNUL)
+	for scanner.Scan() {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, name, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" || fields[0] == "120000" {
+			// Skip submodules and symlinks
+			continue
+		}
+		object := fields[2]
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		tree.addLazyFile(name, size, func() ([]byte, error) {
+			content, err := runGit(ctx, repoPath, "cat-file", "blob", object)
+			return []byte(content), err
+		})
+	}
+	tree.finish()
+
+	return tree, nil
+}
+
+// ResolveCommit returns the commit that revision rev of the Git repository at
+// repoPath points at; an empty rev resolves HEAD
+func ResolveCommit(ctx context.Context, repoPath, rev string) (string, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	// Revisions are passed to git as arguments and must not be mistaken for options
+	if strings.HasPrefix(rev, "-") {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("invalid ref: %s", rev),
+			types.ErrorCodeInvalidArgument,
+			map[string]interface{}{"ref": rev},
+		)
+	}
+
+	if _, err := runGit(ctx, repoPath, "rev-parse", "--git-dir"); err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("not a Git repository, cannot read ref %s: %s", rev, repoPath),
+			types.ErrorCodeGitError,
+			map[string]interface{}{"path": repoPath, "ref": rev},
+		)
+	}
+	commit, err := runGit(ctx, repoPath, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("ref not found in repository: %s", rev),
+			types.ErrorCodeGitCheckoutError,
+			map[string]interface{}{"path": repoPath, "ref": rev},
+		)
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// runGit runs a git command in repoPath and returns its standard output
+func runGit(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := types.ContextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("Git operation failed: %s", strings.TrimSpace(stderr.String())),
+			types.ErrorCodeGitError,
+			map[string]interface{}{
+				"command": strings.Join(args, " "),
+				"output":  stderr.String(),
+			},
+		)
+	}
+	return stdout.String(), nil
+}
+
+// splitNUL is a bufio.SplitFunc for NUL-terminated records
+func splitNUL(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
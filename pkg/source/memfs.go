@@ -0,0 +1,273 @@
+package source
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memFS is an immutable in-memory tree shared by the map, archive and Git
+// commit sources. File contents may be loaded lazily on first read.
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+// memEntry is a file or directory in a memFS
+type memEntry struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+	// children holds sorted child names of a directory
+	children []string
+
+	once sync.Once
+	data []byte
+	load func() ([]byte, error)
+	err  error
+}
+
+// NewMap returns an in-memory source holding files keyed by slash-separated path.
+// Parent directories are created implicitly.
+func NewMap(files map[string]string) fs.FS {
+	tree := newMemFS()
+	for name, content := range files {
+		tree.addFile(name, []byte(content), time.Time{})
+	}
+	tree.finish()
+	return tree
+}
+
+// newMemFS creates an empty tree containing only the root directory
+func newMemFS() *memFS {
+	return &memFS{
+		entries: map[string]*memEntry{
+			".": {name: ".", dir: true},
+		},
+	}
+}
+
+// addFile adds a file with known content, ignoring invalid paths
+func (m *memFS) addFile(name string, data []byte, modTime time.Time) {
+	if entry := m.add(name, int64(len(data)), modTime); entry != nil {
+		entry.data = data
+	}
+}
+
+// addLazyFile adds a file whose content is produced by load on first read
+func (m *memFS) addLazyFile(name string, size int64, load func() ([]byte, error)) {
+	if entry := m.add(name, size, time.Time{}); entry != nil {
+		entry.load = load
+	}
+}
+
+// addDir adds an explicit, possibly empty, directory
+func (m *memFS) addDir(name string) {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) || name == "." {
+		return
+	}
+	if _, exists := m.entries[name]; exists {
+		return
+	}
+	m.entries[name] = &memEntry{name: path.Base(name), dir: true}
+	m.link(name)
+}
+
+// add creates the file entry and any missing parent directories
+func (m *memFS) add(name string, size int64, modTime time.Time) *memEntry {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) || name == "." {
+		return nil
+	}
+	if existing, ok := m.entries[name]; ok && existing.dir {
+		return nil
+	}
+
+	entry := &memEntry{name: path.Base(name), size: size, modTime: modTime}
+	if _, exists := m.entries[name]; !exists {
+		m.link(name)
+	}
+	m.entries[name] = entry
+	return entry
+}
+
+// link registers name with its parent directory, creating parents as needed
+func (m *memFS) link(name string) {
+	parent := path.Dir(name)
+	dir, ok := m.entries[parent]
+	if !ok {
+		dir = &memEntry{name: path.Base(parent), dir: true}
+		m.entries[parent] = dir
+		m.link(parent)
+	}
+	dir.children = append(dir.children, path.Base(name))
+}
+
+// finish sorts directory listings once all entries are added
+func (m *memFS) finish() {
+	for _, entry := range m.entries {
+		if entry.dir {
+			sort.Strings(entry.children)
+		}
+	}
+}
+
+// lookup finds the entry for name
+func (m *memFS) lookup(op, name string) (*memEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return entry, nil
+}
+
+// Open implements fs.FS
+func (m *memFS) Open(name string) (fs.File, error) {
+	entry, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.dir {
+		return &memDir{fsys: m, path: name, entry: entry}, nil
+	}
+	data, err := entry.content()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &memFile{entry: entry, reader: bytes.NewReader(data)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	entry, err := m.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.dir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	data, err := entry.content()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Stat implements fs.StatFS
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	entry, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return entry.info(), nil
+}
+
+// ReadDir implements fs.ReadDirFS
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return m.dirEntries(name, entry), nil
+}
+
+// dirEntries lists the children of a directory entry
+func (m *memFS) dirEntries(dirPath string, entry *memEntry) []fs.DirEntry {
+	list := make([]fs.DirEntry, 0, len(entry.children))
+	for _, child := range entry.children {
+		list = append(list, fs.FileInfoToDirEntry(m.entries[path.Join(dirPath, child)].info()))
+	}
+	return list
+}
+
+// content returns the file data, loading it once if needed
+func (e *memEntry) content() ([]byte, error) {
+	e.once.Do(func() {
+		if e.load != nil {
+			e.data, e.err = e.load()
+		}
+	})
+	return e.data, e.err
+}
+
+// info describes the entry
+func (e *memEntry) info() fs.FileInfo {
+	return memInfo{entry: e}
+}
+
+// memInfo implements fs.FileInfo for a memEntry
+type memInfo struct {
+	entry *memEntry
+}
+
+func (i memInfo) Name() string       { return i.entry.name }
+func (i memInfo) Size() int64        { return i.entry.size }
+func (i memInfo) ModTime() time.Time { return i.entry.modTime }
+func (i memInfo) IsDir() bool        { return i.entry.dir }
+func (i memInfo) Sys() interface{}   { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.entry.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// memFile is an open regular file
+type memFile struct {
+	entry  *memEntry
+	reader *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry.info(), nil }
+func (f *memFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *memFile) Close() error               { return nil }
+
+// Seek implements io.Seeker
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
+
+// ReadAt implements io.ReaderAt
+func (f *memFile) ReadAt(b []byte, offset int64) (int, error) {
+	return f.reader.ReadAt(b, offset)
+}
+
+// memDir is an open directory
+type memDir struct {
+	fsys   *memFS
+	path   string
+	entry  *memEntry
+	offset int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.entry.info(), nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	list := d.fsys.dirEntries(d.path, d.entry)[d.offset:]
+	if count > 0 && len(list) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && len(list) > count {
+		list = list[:count]
+	}
+	d.offset += len(list)
+	return list, nil
+}
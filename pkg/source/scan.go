@@ -0,0 +1,136 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Default scan limits used when no options are given
+const (
+	DefaultDepth = 3
+	MaxFiles     = 1000
+)
+
+// importantDotFiles are hidden files that are still reported by Scan
+var importantDotFiles = map[string]bool{
+	".gitignore": true, ".dockerignore": true, ".env": true, ".env.example": true,
+	".nvmrc": true, ".python-version": true, ".node-version": true, ".ruby-version": true, ".go-version": true,
+	".eslintrc.js": true, ".eslintrc.json": true, ".eslintrc.yml": true, ".eslintrc.yaml": true,
+	".prettierrc": true, ".prettierrc.js": true, ".prettierrc.json": true, ".prettierrc.yml": true, ".prettierrc.yaml": true,
+	".babelrc": true, ".babelrc.js": true, ".babelrc.json": true,
+}
+
+// ignoredDirectories are never descended into by Scan
+var ignoredDirectories = map[string]bool{
+	"node_modules": true, ".git": true, ".svn": true, "dist": true, "build": true, "__pycache__": true,
+	".pytest_cache": true, "target": true, "vendor": true, ".next": true, ".nuxt": true,
+}
+
+// Scan lists project files in fsys up to options.Depth directories deep and
+// at most options.MaxFiles files. Dependency and build output directories and
+// unimportant hidden files are skipped. Scanning stops once ctx is done.
+func Scan(ctx context.Context, fsys fs.FS, options *types.ScanOptions) ([]*types.FileInfo, error) {
+	if options == nil {
+		options = &types.ScanOptions{
+			Depth:    DefaultDepth,
+			MaxFiles: MaxFiles,
+		}
+	}
+
+	files := make([]*types.FileInfo, 0)
+	err := scanDirectory(ctx, fsys, ".", &files, 0, options.Depth, options.MaxFiles)
+	if ctxErr := types.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("Project scan failed: %s", err.Error()),
+			types.ErrorCodeScanError,
+			nil,
+		)
+	}
+
+	return files, nil
+}
+
+// scanDirectory recursively scans directory
+func scanDirectory(ctx context.Context, fsys fs.FS, currentPath string, files *[]*types.FileInfo, currentDepth, maxDepth, maxFiles int) error {
+	if len(*files) >= maxFiles || currentDepth > maxDepth {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(fsys, currentPath)
+	if err != nil {
+		// Ignore inaccessible directories
+		return nil
+	}
+
+	for _, entry := range entries {
+		if len(*files) >= maxFiles {
+			break
+		}
+
+		entryPath := path.Join(currentPath, entry.Name())
+
+		if entry.IsDir() {
+			// Skip directories that should be ignored
+			if ShouldIgnoreDirectory(entry.Name()) {
+				continue
+			}
+
+			// Recursively scan subdirectories
+			err = scanDirectory(ctx, fsys, entryPath, files, currentDepth+1, maxDepth, maxFiles)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Skip hidden files unless they are important configuration files
+		if strings.HasPrefix(entry.Name(), ".") && !IsImportantDotFile(entry.Name()) {
+			continue
+		}
+
+		// Follow symlinks so the size reflects the target
+		stat, err := fs.Stat(fsys, entryPath)
+		if err != nil || stat.IsDir() {
+			// Ignore inaccessible files
+			continue
+		}
+
+		size := stat.Size()
+		ext := FileExtension(entry.Name())
+		*files = append(*files, &types.FileInfo{
+			Path:        entryPath,
+			Name:        entry.Name(),
+			Size:        &size,
+			IsDirectory: false,
+			Extension:   &ext,
+		})
+	}
+
+	return nil
+}
+
+// FileExtension returns the lower-cased extension of filename including the dot
+func FileExtension(filename string) string {
+	return strings.ToLower(path.Ext(filename))
+}
+
+// IsImportantDotFile checks if a hidden file is relevant for detection
+func IsImportantDotFile(name string) bool {
+	return importantDotFiles[name]
+}
+
+// ShouldIgnoreDirectory checks if a directory is skipped while scanning
+func ShouldIgnoreDirectory(name string) bool {
+	return ignoredDirectories[name]
+}
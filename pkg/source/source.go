@@ -0,0 +1,126 @@
+// Package source provides the read-only project trees that DevBox Pack
+// analyses. Every source is an io/fs.FS rooted at the project directory, so
+// providers can read a local checkout, an uploaded archive, a Git commit or
+// an in-memory tree through the same calls.
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/labring/devbox-pack/pkg/markup"
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+// Dir returns the local directory at path as a source.
+// An empty path refers to the current working directory.
+func Dir(path string) fs.FS {
+	if path == "" {
+		path = "."
+	}
+	return os.DirFS(path)
+}
+
+// CleanSubdir cleans a subdirectory of the project root into a slash separated
+// path, "." for the root itself. Paths leaving the root are rejected.
+func CleanSubdir(dir string) (string, error) {
+	cleaned := path.Clean(strings.Trim(filepath.ToSlash(dir), "/"))
+	if cleaned == "" || !fs.ValidPath(cleaned) {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("invalid subdirectory: %s", dir),
+			types.ErrorCodeInvalidPath,
+			map[string]interface{}{"subdir": dir},
+		)
+	}
+	return cleaned, nil
+}
+
+// Sub returns the subdirectory dir of fsys as a source
+func Sub(fsys fs.FS, dir string) (fs.FS, error) {
+	cleaned, err := CleanSubdir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if cleaned == "." {
+		return fsys, nil
+	}
+
+	stat, err := fs.Stat(fsys, cleaned)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && !stat.IsDir()) {
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("subdirectory does not exist: %s", dir),
+			types.ErrorCodeSubdirNotFound,
+			nil,
+		)
+	}
+	if err != nil {
+		return nil, types.NewDevBoxPackError(
+			fmt.Sprintf("cannot access subdirectory: %s", dir),
+			types.ErrorCodeSubdirAccessError,
+			nil,
+		)
+	}
+	return fs.Sub(fsys, cleaned)
+}
+
+// Exists checks if name exists in fsys
+func Exists(fsys fs.FS, name string) bool {
+	_, err := fs.Stat(fsys, name)
+	return err == nil
+}
+
+// ReadText reads a file from fsys as a string
+func ReadText(fsys fs.FS, name string) (string, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", types.NewDevBoxPackError(
+			fmt.Sprintf("Failed to read file: %s", name),
+			types.ErrorCodeFileReadError,
+			map[string]interface{}{
+				"path":  name,
+				"error": err.Error(),
+			},
+		)
+	}
+	return string(content), nil
+}
+
+// ReadJSON reads and decodes a JSON file from fsys
+func ReadJSON(fsys fs.FS, name string, v interface{}) error {
+	content, err := ReadText(fsys, name)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(name, "JSON", content, v)
+}
+
+// ReadJSONC reads and decodes a JSON file that may contain // and /* */ comments
+// and trailing commas
+func ReadJSONC(fsys fs.FS, name string, v interface{}) error {
+	content, err := ReadText(fsys, name)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(name, "JSONC", string(markup.StripJSONC([]byte(content))), v)
+}
+
+// decodeJSON decodes content, reporting failures against the file name
+func decodeJSON(name, kind, content string, v interface{}) error {
+	if err := json.Unmarshal([]byte(content), v); err != nil {
+		return types.NewDevBoxPackError(
+			fmt.Sprintf("Failed to parse %s file: %s", kind, name),
+			types.ErrorCodeJSONParseError,
+			map[string]interface{}{
+				"path":  name,
+				"error": err.Error(),
+			},
+		)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labring/devbox-pack/pkg/types"
+)
+
+var testFiles = map[string]string{
+	"package.json":     `{"name": "test"}`,
+	"src/index.js":     `console.log("hello");`,
+	"src/lib/util.js":  `module.exports = {};`,
+	"config/app.jsonc": "{\n  // comment\n  \"port\": 3000 /* inline */\n}\n",
+}
+
+// tarGz builds a gzip-compressed tar archive of files below prefix
+func tarGz(t *testing.T, prefix string, files map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		header := &tar.Header{Name: prefix + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar entry: %v", err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// zipArchive builds a zip archive of files below prefix
+func zipArchive(t *testing.T, prefix string, files map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for name, content := range files {
+		writer, err := zipWriter.Create(prefix + name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write zip entry: %v", err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("failed to close zip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// expectedPaths lists the file paths of testFiles for fstest.TestFS
+func expectedPaths() []string {
+	paths := make([]string, 0, len(testFiles))
+	for name := range testFiles {
+		paths = append(paths, name)
+	}
+	return paths
+}
+
+func TestNewMap(t *testing.T) {
+	fsys := NewMap(testFiles)
+	if err := fstest.TestFS(fsys, expectedPaths()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewTarGz(t *testing.T) {
+	fsys, err := NewTarGz(bytes.NewReader(tarGz(t, "project-1.0/", testFiles)))
+	if err != nil {
+		t.Fatalf("NewTarGz failed: %v", err)
+	}
+	if err := fstest.TestFS(fsys, expectedPaths()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewZip(t *testing.T) {
+	content := zipArchive(t, "", testFiles)
+	fsys, err := NewZip(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("NewZip failed: %v", err)
+	}
+	if err := fstest.TestFS(fsys, expectedPaths()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir := t.TempDir()
+	archives := map[string][]byte{
+		"project.tgz": tarGz(t, "./", testFiles),
+		"project.zip": zipArchive(t, "repo-main/", testFiles),
+	}
+	for name, content := range archives {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+
+		fsys, err := OpenArchive(path)
+		if err != nil {
+			t.Fatalf("OpenArchive(%s) failed: %v", name, err)
+		}
+		if !Exists(fsys, "package.json") {
+			t.Errorf("%s: expected package.json at the archive root", name)
+		}
+	}
+
+	if _, err := OpenArchive(filepath.Join(dir, "project.rar")); err == nil {
+		t.Error("expected error for unsupported archive format")
+	}
+}
+
+func TestArchiveLimits(t *testing.T) {
+	tarContent := tarGz(t, "", testFiles)
+	zipContent := zipArchive(t, "", testFiles)
+	open := map[string]func(ArchiveLimits) (fs.FS, error){
+		ArchiveTarGz: func(limits ArchiveLimits) (fs.FS, error) {
+			return NewTarGzWithLimits(bytes.NewReader(tarContent), limits)
+		},
+		ArchiveZip: func(limits ArchiveLimits) (fs.FS, error) {
+			return NewZipWithLimits(bytes.NewReader(zipContent), int64(len(zipContent)), limits)
+		},
+	}
+	var size int64
+	for _, content := range testFiles {
+		size += int64(len(content))
+	}
+
+	for format, open := range open {
+		if _, err := open(ArchiveLimits{MaxSize: size, MaxEntries: len(testFiles)}); err != nil {
+			t.Errorf("%s: expected archive within limits to open, got %v", format, err)
+		}
+		for _, limits := range []ArchiveLimits{
+			{MaxSize: size - 1, MaxEntries: len(testFiles)},
+			{MaxSize: size, MaxEntries: len(testFiles) - 1},
+		} {
+			_, err := open(limits)
+			if devboxErr, ok := err.(*types.DevBoxPackError); !ok || devboxErr.Code != types.ErrorCodeInvalidInput {
+				t.Errorf("%s: expected %s beyond %+v, got %v", format, types.ErrorCodeInvalidInput, limits, err)
+			}
+		}
+	}
+}
+
+func TestSub(t *testing.T) {
+	fsys := NewMap(testFiles)
+	for _, dir := range []string{"src", "./src/", "/src"} {
+		sub, err := Sub(fsys, dir)
+		if err != nil {
+			t.Fatalf("Sub(%q) failed: %v", dir, err)
+		}
+		if !Exists(sub, "lib/util.js") {
+			t.Errorf("Sub(%q): expected lib/util.js", dir)
+		}
+	}
+	if sub, err := Sub(fsys, "."); err != nil || sub != fsys {
+		t.Errorf("expected the root for ., got %v, %v", sub, err)
+	}
+
+	expectedCodes := map[string]string{
+		"../src":       types.ErrorCodeInvalidPath,
+		"src/../../x":  types.ErrorCodeInvalidPath,
+		"missing":      types.ErrorCodeSubdirNotFound,
+		"package.json": types.ErrorCodeSubdirNotFound,
+	}
+	for dir, code := range expectedCodes {
+		_, err := Sub(fsys, dir)
+		devBoxErr, ok := err.(*types.DevBoxPackError)
+		if !ok || devBoxErr.Code != code {
+			t.Errorf("Sub(%q): expected %s error, got %v", dir, code, err)
+		}
+	}
+}
+
+func TestNewGitCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	git("init", "-q")
+	for name, content := range testFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "v1")
+
+	// Changes after the tag must not be visible through the commit source
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "second")
+
+	fsys, err := NewGitCommit(context.Background(), dir, "v1")
+	if err != nil {
+		t.Fatalf("NewGitCommit failed: %v", err)
+	}
+	if err := fstest.TestFS(fsys, expectedPaths()...); err != nil {
+		t.Fatal(err)
+	}
+	if Exists(fsys, "go.mod") {
+		t.Error("expected go.mod from a later commit to be absent")
+	}
+
+	_, err = NewGitCommit(context.Background(), dir, "does-not-exist")
+	if devboxErr, ok := err.(*types.DevBoxPackError); !ok || devboxErr.Code != types.ErrorCodeGitCheckoutError {
+		t.Errorf("expected %s for unknown revision, got %v", types.ErrorCodeGitCheckoutError, err)
+	}
+	if _, err := NewGitCommit(context.Background(), t.TempDir(), "main"); err == nil {
+		t.Error("expected error outside a repository")
+	}
+}
+
+func TestReadJSONC(t *testing.T) {
+	fsys := NewMap(testFiles)
+
+	var config map[string]interface{}
+	if err := ReadJSONC(fsys, "config/app.jsonc", &config); err != nil {
+		t.Fatalf("ReadJSONC failed: %v", err)
+	}
+	if config["port"] != float64(3000) {
+		t.Errorf("expected port 3000, got %v", config["port"])
+	}
+
+	// Comment markers inside strings are text
+	fsys = NewMap(map[string]string{"tsconfig.json": `{"glob": "src/*.ts", "url": "http://a/*b", /* note */ "strict": true,}`})
+	config = nil
+	if err := ReadJSONC(fsys, "tsconfig.json", &config); err != nil {
+		t.Fatalf("ReadJSONC failed: %v", err)
+	}
+	if config["glob"] != "src/*.ts" || config["url"] != "http://a/*b" || config["strict"] != true {
+		t.Errorf("unexpected config %v", config)
+	}
+
+	if err := ReadJSON(NewMap(testFiles), "config/app.jsonc", &config); err == nil {
+		t.Error("expected plain JSON decoding to reject comments")
+	}
+	if _, err := ReadText(fsys, "missing.txt"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestScan(t *testing.T) {
+	fsys := NewMap(map[string]string{
+		"package.json":              "{}",
+		".nvmrc":                    "18",
+		".DS_Store":                 "",
+		"src/app.js":                "",
+		"src/deep/nested/file.js":   "",
+		"node_modules/pkg/index.js": "",
+	})
+
+	files, err := Scan(context.Background(), fsys, &types.ScanOptions{Depth: 1, MaxFiles: 100})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, file := range files {
+		found[file.Path] = true
+	}
+	for _, expected := range []string{"package.json", ".nvmrc", "src/app.js"} {
+		if !found[expected] {
+			t.Errorf("expected %s to be scanned", expected)
+		}
+	}
+	for _, unexpected := range []string{".DS_Store", "src/deep/nested/file.js", "node_modules/pkg/index.js"} {
+		if found[unexpected] {
+			t.Errorf("expected %s to be skipped", unexpected)
+		}
+	}
+}
@@ -2,7 +2,10 @@
 package types
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"time"
 )
 
@@ -26,14 +29,35 @@ type ExecutionPlan struct {
 	// Port configuration
 	Port int `json:"port"`
 
+	// HTTP path answering health checks on Port, e.g. "/healthz"
+	HealthCheck string `json:"healthCheck,omitempty"`
+
+	// Databases, caches and brokers the application connects to
+	BackingServices []BackingService `json:"backingServices,omitempty"`
+
 	// Detection evidence
 	Evidence Evidence `json:"evidence,omitempty"`
+
+	// Plan cache entry of the plan, nil without a plan cache
+	Cache *CacheInfo `json:"cache,omitempty"`
+}
+
+// CacheInfo identifies what a cached plan was computed from. A plan whose key
+// differs from the key of a later analysis is stale.
+type CacheInfo struct {
+	// Hash of the tool version, the analysis options and the commit, archive or
+	// file contents analysed; it changes whenever the plan may change
+	Key string `json:"key"`
+	// Commit the plan was computed from, empty for local directories and archives
+	Commit string `json:"commit,omitempty"`
 }
 
 // RuntimeConfig represents the simplified runtime configuration
 type RuntimeConfig struct {
 	// Base image name, e.g., "node:20-alpine"
 	Image string `json:"image"`
+	// Language runtime version the image was chosen for, e.g. "20" or "3.11"
+	Version string `json:"version,omitempty"`
 	// Framework name, e.g., "nextjs"
 	Framework *string `json:"framework,omitempty"`
 }
@@ -44,6 +68,26 @@ type Evidence struct {
 	Files []string `json:"files,omitempty"`
 	// Reason for match
 	Reason string `json:"reason,omitempty"`
+	// Fields taken from override, builder configuration and Dev Container files instead of detection
+	Overrides []OverrideEvidence `json:"overrides,omitempty"`
+}
+
+// BackingService represents a database, cache or broker inferred from the project
+type BackingService struct {
+	// Service kind, e.g. "postgres" or "redis"
+	Name string `json:"name"`
+	// Declared dependencies implying the service, e.g. "pg" or "github.com/lib/pq"
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Environment variables holding the service's connection URL, e.g. "DATABASE_URL"
+	Environment []string `json:"environment,omitempty"`
+}
+
+// OverrideEvidence records which plan fields an override or imported file replaced
+type OverrideEvidence struct {
+	// Override or imported file, relative to the project root
+	File string `json:"file"`
+	// Overridden fields, e.g. "commands.build" or "environment.PORT"
+	Fields []string `json:"fields"`
 }
 
 // DetectResult represents the result of project detection
@@ -68,6 +112,8 @@ type DetectResult struct {
 	BuildTools []string `json:"buildTools"`
 	// Additional metadata
 	Metadata map[string]interface{} `json:"metadata"`
+	// How the confidence was calculated, nil for providers that do not report it
+	Scoring *Scoring `json:"scoring,omitempty"`
 }
 
 // Provider interface defines the contract for all language providers
@@ -76,8 +122,8 @@ type Provider interface {
 	GetName() string
 	// Get provider priority (lower number = higher priority)
 	GetPriority() int
-	// Detect if project uses this provider
-	Detect(projectPath string, files []FileInfo, gitHandler interface{}) (*DetectResult, error)
+	// Detect if project uses this provider, reading project files from fsys
+	Detect(ctx context.Context, fsys fs.FS, files []FileInfo) (*DetectResult, error)
 }
 
 // CLIOptions represents command line interface options
@@ -95,6 +141,29 @@ type CLIOptions struct {
 	Version    bool    `json:"version,omitempty"`
 	Quiet      bool    `json:"quiet,omitempty"`
 	Pretty     bool    `json:"pretty,omitempty"`
+	Monorepo   bool    `json:"monorepo,omitempty"`
+	// Maximum duration of the whole analysis, zero disables the deadline
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Directories searched for provider plugin executables
+	PluginPaths []string `json:"pluginPaths,omitempty"`
+	// Directories of declarative provider rule files
+	RulesPaths []string `json:"rulesPaths,omitempty"`
+	// Record the scoring breakdown of every provider and the selection of the winner
+	Explain bool `json:"explain,omitempty"`
+	// Kubernetes namespace of generated manifests
+	Namespace *string `json:"namespace,omitempty"`
+	// Kubernetes Deployment replicas, nil uses the default of one
+	Replicas *int `json:"replicas,omitempty"`
+	// Directory receiving the files of bundle formats such as scripts, nil prints them
+	OutputDir *string `json:"outputDir,omitempty"`
+	// text/template file rendering the plan in the template format
+	Template *string `json:"template,omitempty"`
+	// Command CI pipelines run between the setup and build commands
+	TestCommand *string `json:"testCommand,omitempty"`
+	// Directory caching mirrors of remote repositories and plans between runs, empty disables both
+	CacheDir string `json:"cacheDir,omitempty"`
+	// Recompute the plan instead of reading it from the plan cache, replacing the cached plan
+	NoCache bool `json:"noCache,omitempty"`
 }
 
 // GitRepository represents a Git repository
@@ -152,15 +221,105 @@ type BaseCatalog map[string]map[string]string
 
 // DevBoxPackError represents a custom error for DevBox Pack
 type DevBoxPackError struct {
-	Message string
-	Code    string
-	Details interface{}
+	Message string      `json:"message"`
+	Code    string      `json:"code"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 // ConfidenceIndicator confidence indicator
 type ConfidenceIndicator struct {
-	Weight    int  `json:"weight"`
-	Satisfied bool `json:"satisfied"`
+	Description string `json:"description,omitempty"`
+	Weight      int    `json:"weight"`
+	Satisfied   bool   `json:"satisfied"`
+}
+
+// Scoring records how a provider calculated its confidence
+type Scoring struct {
+	// Weighted indicators the confidence is calculated from
+	Indicators []ConfidenceIndicator `json:"indicators"`
+	// Satisfied weight divided by total weight
+	RawConfidence float64 `json:"rawConfidence"`
+	// Adjustments applied to the raw confidence in order
+	Adjustments []ConfidenceAdjustment `json:"adjustments,omitempty"`
+	// Confidence after adjustments
+	Confidence float64 `json:"confidence"`
+	// Confidence a match must exceed, or reach when ThresholdInclusive is set
+	Threshold float64 `json:"threshold"`
+	// Whether a confidence equal to Threshold matches
+	ThresholdInclusive bool `json:"thresholdInclusive,omitempty"`
+}
+
+// ConfidenceAdjustment is a factor applied to the raw confidence
+type ConfidenceAdjustment struct {
+	Reason string  `json:"reason"`
+	Factor float64 `json:"factor"`
+}
+
+// Adjust multiplies the confidence by factor and records why
+func (s *Scoring) Adjust(reason string, factor float64) {
+	s.Adjustments = append(s.Adjustments, ConfidenceAdjustment{Reason: reason, Factor: factor})
+	s.Confidence *= factor
+}
+
+// Matched reports whether the confidence passes the threshold
+func (s *Scoring) Matched() bool {
+	if s.ThresholdInclusive {
+		return s.Confidence > 0 && s.Confidence >= s.Threshold
+	}
+	return s.Confidence > s.Threshold
+}
+
+// Explanation records how every provider scored and why the plan's provider was chosen
+type Explanation struct {
+	// Every provider run in priority order, including non-matching ones
+	Providers []ProviderExplanation `json:"providers"`
+	// How the winner was chosen among the matched providers
+	Selection *SelectionExplanation `json:"selection,omitempty"`
+}
+
+// ProviderExplanation is the outcome of a single provider
+type ProviderExplanation struct {
+	Provider   string   `json:"provider"`
+	Priority   int      `json:"priority"`
+	Matched    bool     `json:"matched"`
+	Confidence float64  `json:"confidence"`
+	Scoring    *Scoring `json:"scoring,omitempty"`
+	// Failure or invalid result message, empty on success
+	Error string `json:"error,omitempty"`
+}
+
+// Selection rules reported in SelectionExplanation
+const (
+	// The provider was forced with --provider, an override file or a builder configuration file
+	SelectionRuleForced = "forced"
+	// Only one provider matched
+	SelectionRuleOnlyMatch = "only-match"
+	// Backend results win over frontend results, then confidence decides
+	SelectionRuleBackendFirst = "backend-first"
+	// No backend result matched, the highest confidence wins
+	SelectionRuleConfidence = "highest-confidence"
+)
+
+// SelectionExplanation describes how the winning detection result was chosen
+type SelectionExplanation struct {
+	// Winning provider
+	Provider string `json:"provider"`
+	// Rule that decided, see the SelectionRule constants
+	Rule string `json:"rule"`
+	// Human-readable reason
+	Reason string `json:"reason"`
+	// Matched results that took part in the selection
+	Candidates []SelectionCandidate `json:"candidates"`
+}
+
+// SelectionCandidate is a matched result considered during selection
+type SelectionCandidate struct {
+	Provider   string  `json:"provider"`
+	Language   string  `json:"language"`
+	Framework  string  `json:"framework,omitempty"`
+	Confidence float64 `json:"confidence"`
+	// Whether the result counts as a backend for the backend-first rule
+	Backend bool `json:"backend"`
 }
 
 // Commands represents the command configuration (contains all build logic)
@@ -178,6 +337,27 @@ type ScanOptions struct {
 	MaxFiles int `json:"maxFiles"`
 }
 
+// Diagnostic represents a non-fatal problem found while analysing a project
+type Diagnostic struct {
+	// Severity level: "info", "warning" or "error"
+	Severity string `json:"severity"`
+	// Component that raised the diagnostic, e.g. a provider name
+	Source string `json:"source,omitempty"`
+	// Human readable message
+	Message string `json:"message"`
+	// Check that raised the diagnostic, e.g. "missing-lock-file"
+	Rule string `json:"rule,omitempty"`
+	// File the diagnostic is about, relative to the project root
+	File string `json:"file,omitempty"`
+}
+
+// Diagnostic severities
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
 // Error codes
 const (
 	ErrorCodeGitError          = "GIT_ERROR"
@@ -197,6 +377,13 @@ const (
 	ErrorCodeScanError         = "SCAN_ERROR"
 	ErrorCodeInvalidProvider   = "INVALID_PROVIDER"
 	ErrorCodeInvalidArgument   = "INVALID_ARGUMENT"
+	ErrorCodeInvalidOverride   = "INVALID_OVERRIDE"
+	ErrorCodeTimeout           = "TIMEOUT"
+	ErrorCodeArchiveError      = "ARCHIVE_ERROR"
+	ErrorCodePluginError       = "PLUGIN_ERROR"
+	ErrorCodeInvalidRule       = "INVALID_RULE"
+	ErrorCodeUnsupported       = "UNSUPPORTED_PROJECT"
+	ErrorCodeInternal          = "INTERNAL_ERROR"
 )
 
 func (e *DevBoxPackError) Error() string {
@@ -212,6 +399,17 @@ func NewDevBoxPackError(message, code string, details interface{}) *DevBoxPackEr
 	}
 }
 
+// ContextError converts a finished context into an error.
+// An expired deadline becomes a DevBoxPackError with ErrorCodeTimeout, cancellation
+// is returned as context.Canceled, and nil is returned while ctx is still active.
+func ContextError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewDevBoxPackError("operation timed out", ErrorCodeTimeout, nil)
+	}
+	return err
+}
+
 // SupportedLanguage represents supported programming languages
 type SupportedLanguage string
 
@@ -246,6 +444,32 @@ const (
 	OutputFormatJSON OutputFormat = "json"
 	// OutputFormatPretty represents human-readable pretty output format
 	OutputFormatPretty OutputFormat = "pretty"
+	// OutputFormatDockerfile represents multi-stage Dockerfile output format
+	OutputFormatDockerfile OutputFormat = "dockerfile"
+	// OutputFormatKubernetes represents Kubernetes Deployment and Service manifest output format
+	OutputFormatKubernetes OutputFormat = "k8s"
+	// OutputFormatCompose represents Docker Compose file output format
+	OutputFormatCompose OutputFormat = "compose"
+	// OutputFormatYAML represents YAML output format
+	OutputFormatYAML OutputFormat = "yaml"
+	// OutputFormatTOML represents TOML output format
+	OutputFormatTOML OutputFormat = "toml"
+	// OutputFormatScripts represents setup, build, dev and run shell script bundle output format
+	OutputFormatScripts OutputFormat = "scripts"
+	// OutputFormatTemplate represents output rendered through the --template file
+	OutputFormatTemplate OutputFormat = "template"
+	// OutputFormatGitHubActions represents GitHub Actions workflow output format
+	OutputFormatGitHubActions OutputFormat = "github-actions"
+	// OutputFormatGitLabCI represents GitLab CI pipeline output format
+	OutputFormatGitLabCI OutputFormat = "gitlab-ci"
+	// OutputFormatDevcontainer represents Dev Container configuration output format
+	OutputFormatDevcontainer OutputFormat = "devcontainer"
+	// OutputFormatRailpack represents Railpack build plan output format
+	OutputFormatRailpack OutputFormat = "railpack"
+	// OutputFormatMarkdown represents Markdown report output format
+	OutputFormatMarkdown OutputFormat = "markdown"
+	// OutputFormatSARIF represents SARIF report output format
+	OutputFormatSARIF OutputFormat = "sarif"
 )
 
 // Platform represents supported platforms
@@ -396,6 +396,8 @@ func TestErrorCodes(t *testing.T) {
 		"SCAN_ERROR":          ErrorCodeScanError,
 		"INVALID_PROVIDER":    ErrorCodeInvalidProvider,
 		"INVALID_ARGUMENT":    ErrorCodeInvalidArgument,
+		"UNSUPPORTED_PROJECT": ErrorCodeUnsupported,
+		"INTERNAL_ERROR":      ErrorCodeInternal,
 	}
 
 	for expectedValue, actualConstant := range expectedCodes {
@@ -428,8 +430,21 @@ func TestSupportedLanguages(t *testing.T) {
 
 func TestOutputFormats(t *testing.T) {
 	expectedFormats := map[OutputFormat]string{
-		OutputFormatJSON:   "json",
-		OutputFormatPretty: "pretty",
+		OutputFormatJSON:          "json",
+		OutputFormatPretty:        "pretty",
+		OutputFormatDockerfile:    "dockerfile",
+		OutputFormatKubernetes:    "k8s",
+		OutputFormatCompose:       "compose",
+		OutputFormatYAML:          "yaml",
+		OutputFormatTOML:          "toml",
+		OutputFormatScripts:       "scripts",
+		OutputFormatTemplate:      "template",
+		OutputFormatGitHubActions: "github-actions",
+		OutputFormatGitLabCI:      "gitlab-ci",
+		OutputFormatDevcontainer:  "devcontainer",
+		OutputFormatRailpack:      "railpack",
+		OutputFormatMarkdown:      "markdown",
+		OutputFormatSARIF:         "sarif",
 	}
 
 	for constant, expectedValue := range expectedFormats {
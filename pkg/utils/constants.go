@@ -8,6 +8,13 @@ import (
 	"github.com/labring/devbox-pack/pkg/types"
 )
 
+// Version of DevBox Pack, replaced by the version a release is built with
+var Version = "1.0.0"
+
+// Commit the binary was built from, set by release builds that do not embed
+// version control information
+var Commit = ""
+
 // BaseCatalog Base image catalog
 var BaseCatalog = map[types.SupportedLanguage]map[string]string{
 	types.LanguageNode: {
@@ -409,3 +409,26 @@ func TestDataConsistency(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildVersion(t *testing.T) {
+	stamp := func() string { return "stamp" }
+	tests := []struct {
+		revision string
+		modified bool
+		expected string
+	}{
+		{"abc123", false, "1.2.0+abc123"},
+		{"abc123", true, "1.2.0+abc123+stamp"},
+		{"", false, "1.2.0+stamp"},
+	}
+	for _, tt := range tests {
+		if actual := buildVersion("1.2.0", tt.revision, tt.modified, stamp); actual != tt.expected {
+			t.Errorf("buildVersion(%q, %t): expected %q, got %q", tt.revision, tt.modified, tt.expected, actual)
+		}
+	}
+
+	// Builds of different versions never share plan cache keys
+	if BuildVersion() == buildVersion("0.0.1", "", false, executableStamp) {
+		t.Error("expected the version to be part of the build version")
+	}
+}
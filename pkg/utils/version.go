@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// BuildVersion identifies the build of the running binary for the plan cache:
+// Version and the commit it was built from. Builds of modified or unknown
+// sources also include the size and time of the executable, so plans computed
+// by a different build are never served.
+func BuildVersion() string {
+	revision, modified := Commit, false
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && revision == "":
+				revision = setting.Value
+			case setting.Key == "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+	}
+	return buildVersion(Version, revision, modified, executableStamp)
+}
+
+// buildVersion combines the parts of BuildVersion, calling stamp for builds
+// that a version and revision do not identify
+func buildVersion(version, revision string, modified bool, stamp func() string) string {
+	if revision != "" {
+		version += "+" + revision
+	}
+	if revision == "" || modified {
+		version += "+" + stamp()
+	}
+	return version
+}
+
+// executableStamp returns the size and modification time of the running executable
+func executableStamp() string {
+	path, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d", info.Size(), info.ModTime().UnixNano())
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/labring/devbox-pack/pkg/providers"
 )
 
 // ExecutionPlan execution plan structure (current)
@@ -74,34 +76,19 @@ type PlanValidator struct {
 	knownCommands  map[string][]string
 }
 
-// NewPlanValidator creates a new validator
+// NewPlanValidator creates a new validator from the registered built-in Providers
 func NewPlanValidator() *PlanValidator {
-	return &PlanValidator{
-		knownProviders: map[string]bool{
-			"node":       true,
-			"python":     true,
-			"java":       true,
-			"go":         true,
-			"php":        true,
-			"ruby":       true,
-			"elixir":     true,
-			"deno":       true,
-			"rust":       true,
-			"staticfile": true,
-			"shell":      true,
-		},
-		knownCommands: map[string][]string{
-			"node":   {"npm", "yarn", "pnpm", "bun"},
-			"python": {"pip", "poetry", "pipenv", "uv", "pdm"},
-			"java":   {"mvn", "gradle"},
-			"go":     {"go"},
-			"php":    {"composer"},
-			"ruby":   {"bundle"},
-			"elixir": {"mix"},
-			"deno":   {"deno"},
-			"rust":   {"cargo"},
-		},
+	validator := &PlanValidator{
+		knownProviders: make(map[string]bool),
+		knownCommands:  make(map[string][]string),
+	}
+	for _, registration := range providers.Registered() {
+		validator.knownProviders[registration.Name] = true
+		if len(registration.PackageManagers) > 0 {
+			validator.knownCommands[registration.Name] = registration.PackageManagers
+		}
 	}
+	return validator
 }
 
 // ValidatePlan validates a single execution plan